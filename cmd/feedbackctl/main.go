@@ -0,0 +1,322 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	fdk_user_feedback_service "github.com/Informasjonsforvaltning/fdk-user-feedback-service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+const usage = `Usage: feedbackctl <command> [flags] <arguments>
+
+Commands:
+  thread <entityId>                   Print the thread id mapped to an entity
+  relink <entityId> <threadId>        Map an entity to another thread
+  unlink <entityId>                   Delete the thread mapping of an entity
+  posts [-page n] <entityId>          List the posts in the thread of an entity
+  delete-post [-uid n] <entityId> <postId>
+                                      Soft-delete a post, as the thread bot unless -uid is set
+  print [-page n] <entityId>          Print the thread of an entity with full post contents
+  index-posts <entityId>...           Add the posts of the entities to the statistics index
+
+feedbackctl is configured with the same environment variables as the service.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	fdk_user_feedback_service.Configure()
+
+	if err := run(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "feedbackctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	page := flags.String("page", "", "page of posts")
+	uid := flags.String("uid", env.EnvironmentVariables.ThreadBotUid, "user id performing the deletion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch command {
+	case "thread":
+		return lookupThread(flags.Args(), out)
+	case "relink":
+		return relinkThread(flags.Args(), out)
+	case "unlink":
+		return unlinkThread(flags.Args(), out)
+	case "posts":
+		return listPosts(flags.Args(), optional(*page), out)
+	case "delete-post":
+		return deletePost(flags.Args(), *uid, out)
+	case "print":
+		return printThread(flags.Args(), optional(*page), out)
+	case "index-posts":
+		return indexPosts(flags.Args(), out)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+}
+
+func lookupThread(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("thread expects <entityId>")
+	}
+
+	threadId, err := requireThreadId(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, *threadId)
+	return nil
+}
+
+func relinkThread(args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("relink expects <entityId> <threadId>")
+	}
+
+	entityId, threadId := args[0], args[1]
+	if _, statusCode := service.CurrentThreadService.GetThread(threadId, model.ThreadQuery{}); statusCode != http.StatusOK {
+		return fmt.Errorf("thread %s not found, status %d", threadId, statusCode)
+	}
+
+	previous, err := service.CurrentThreadIdService.GetThreadId(entityId)
+	if err != nil {
+		return err
+	}
+
+	if err := service.CurrentThreadIdService.CreateThreadId(entityId, threadId); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s: %s -> %s\n", entityId, valueOrNone(previous), threadId)
+	return nil
+}
+
+func unlinkThread(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("unlink expects <entityId>")
+	}
+
+	threadId, err := requireThreadId(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := service.CurrentThreadIdService.DeleteThreadId(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s: %s -> none\n", args[0], *threadId)
+	return nil
+}
+
+func listPosts(args []string, page *string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("posts expects <entityId>")
+	}
+
+	thread, err := getThread(args[0], page)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "PID\tUID\tINDEX\tTIMESTAMP\tDELETED\tCONTENT")
+	for _, post := range thread.Posts {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%t\t%s\n",
+			valueOrNone(post.PostId),
+			valueOrNone(post.UserId),
+			valueOrNone(post.Index),
+			formatTimestamp(post.Timestamp),
+			post.Deleted != nil && *post.Deleted,
+			summarize(post.Content, 60),
+		)
+	}
+	writer.Flush()
+	printPagination(thread, out)
+
+	return nil
+}
+
+func deletePost(args []string, uid string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("delete-post expects <entityId> <postId>")
+	}
+
+	threadId, err := requireThreadId(args[0])
+	if err != nil {
+		return err
+	}
+
+	postId := args[1]
+	if statusCode := service.CurrentThreadService.HideThreadPost(*threadId, postId, uid); statusCode != http.StatusOK {
+		return fmt.Errorf("could not delete post %s in thread %s, status %d", postId, *threadId, statusCode)
+	}
+
+	fmt.Fprintf(out, "deleted post %s in thread %s\n", postId, *threadId)
+	return nil
+}
+
+func printThread(args []string, page *string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("print expects <entityId>")
+	}
+
+	thread, err := getThread(args[0], page)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Thread %s: %s\n", valueOrNone(thread.ThreadId), valueOrNone(thread.Title))
+	for _, post := range thread.Posts {
+		author := valueOrNone(post.UserId)
+		if post.UserInfo != nil && post.UserInfo.Displayname != nil {
+			author = fmt.Sprintf("%s (%s)", *post.UserInfo.Displayname, author)
+		}
+
+		fmt.Fprintf(out, "\n#%s pid=%s by %s at %s", valueOrNone(post.Index), valueOrNone(post.PostId), author, formatTimestamp(post.Timestamp))
+		if post.ToPostId != nil && *post.ToPostId != "" {
+			fmt.Fprintf(out, " in reply to %s", *post.ToPostId)
+		}
+		if post.Deleted != nil && *post.Deleted {
+			fmt.Fprint(out, " [deleted]")
+		}
+		fmt.Fprintf(out, "\n%s\n", valueOrNone(post.Content))
+	}
+	printPagination(thread, out)
+
+	return nil
+}
+
+// indexPosts adds posts made before the statistics index was kept. Posts
+// already in the index are replaced.
+func indexPosts(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("index-posts expects <entityId>...")
+	}
+
+	pageSize := env.ConstantValues.MaxPageSize
+	for _, entityId := range args {
+		threadId, err := requireThreadId(entityId)
+		if err != nil {
+			return err
+		}
+
+		indexed := 0
+		for page, pageCount := 1, 1; page <= pageCount; page++ {
+			pageParam := strconv.Itoa(page)
+			thread, statusCode := service.CurrentThreadService.GetThreadByEntityId(entityId, model.ThreadQuery{
+				Page:     &pageParam,
+				Sort:     model.OldestFirst,
+				PageSize: &pageSize,
+			})
+			if statusCode != http.StatusOK || thread == nil {
+				return fmt.Errorf("could not read thread of entity %s, status %d", entityId, statusCode)
+			}
+
+			for _, post := range thread.Posts {
+				if post.Deleted == nil || !*post.Deleted {
+					service.CurrentStatisticsService.IndexPost(entityId, *threadId, *post)
+					indexed++
+				}
+			}
+
+			if thread.Pagination != nil && thread.Pagination.PageCount != nil {
+				pageCount = *thread.Pagination.PageCount
+			}
+		}
+
+		fmt.Fprintf(out, "%s: indexed %d posts\n", entityId, indexed)
+	}
+
+	return nil
+}
+
+func requireThreadId(entityId string) (*string, error) {
+	threadId, err := service.CurrentThreadIdService.GetThreadId(entityId)
+	if err != nil {
+		return nil, err
+	}
+	if threadId == nil {
+		return nil, fmt.Errorf("no thread mapped to entity %s", entityId)
+	}
+	return threadId, nil
+}
+
+// getThread reads the thread from the repository rather than ThreadService,
+// so deleted posts are listed too.
+func getThread(entityId string, page *string) (*model.Thread, error) {
+	threadId, err := requireThreadId(entityId)
+	if err != nil {
+		return nil, err
+	}
+
+	thread, err := repository.CurrentThreadRepository.GetThread(*threadId, model.ThreadQuery{Page: page})
+	if err != nil {
+		return nil, err
+	}
+	if thread == nil {
+		return nil, fmt.Errorf("thread %s not found", *threadId)
+	}
+	return thread, nil
+}
+
+func printPagination(thread *model.Thread, out io.Writer) {
+	if thread.Pagination == nil || thread.Pagination.CurrentPage == nil || thread.Pagination.PageCount == nil {
+		return
+	}
+	fmt.Fprintf(out, "\npage %d of %d\n", *thread.Pagination.CurrentPage, *thread.Pagination.PageCount)
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func valueOrNone(value *string) string {
+	if value == nil || *value == "" {
+		return "-"
+	}
+	return *value
+}
+
+func formatTimestamp(timestamp *int) string {
+	if timestamp == nil {
+		return "-"
+	}
+	return time.UnixMilli(int64(*timestamp)).UTC().Format(time.RFC3339)
+}
+
+func summarize(content *string, length int) string {
+	if content == nil {
+		return "-"
+	}
+	summary := strings.Join(strings.Fields(*content), " ")
+	runes := []rune(summary)
+	if len(runes) > length {
+		return string(runes[:length-1]) + "…"
+	}
+	return summary
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests/unit_tests"
+)
+
+func TestRun(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId := "1"
+	var runTests = []struct {
+		testName            string
+		command             string
+		args                []string
+		statusCode          int
+		expectedError       bool
+		expectedOutput      string
+		expectedCreatedIds  []string
+		expectedDeletedIds  []string
+		expectedHiddenPosts []string
+	}{
+		{"Thread", "thread", []string{"entity"}, http.StatusOK, false, "1\n", nil, nil, nil},
+		{"Relink", "relink", []string{"entity", "2"}, http.StatusOK, false, "entity: 1 -> 2\n", []string{"entity_2"}, nil, nil},
+		{"Relink to unknown thread", "relink", []string{"entity", "2"}, http.StatusNotFound, true, "", nil, nil, nil},
+		{"Unlink", "unlink", []string{"entity"}, http.StatusOK, false, "entity: 1 -> none\n", nil, []string{"entity"}, nil},
+		{"Delete post", "delete-post", []string{"-uid", "5", "entity", "10"}, http.StatusOK, false, "deleted post 10 in thread 1\n", nil, nil, []string{"10_5"}},
+		{"Delete post as thread bot", "delete-post", []string{"entity", "10"}, http.StatusOK, false, "deleted post 10 in thread 1\n", nil, nil, []string{"10_" + env.EnvironmentVariables.ThreadBotUid}},
+		{"Delete post fails", "delete-post", []string{"-uid", "5", "entity", "10"}, http.StatusInternalServerError, true, "", nil, nil, []string{"10_5"}},
+		{"Missing arguments", "unlink", nil, http.StatusOK, true, "", nil, nil, nil},
+		{"Unknown command", "rename", []string{"entity"}, http.StatusOK, true, "", nil, nil, nil},
+	}
+
+	for _, test := range runTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockThreadIdService := unit_tests.MockThreadIdService{MockThreadId: &threadId}
+			mockThreadService := unit_tests.MockThreadService{MockThread: &model.Thread{ThreadId: &threadId}, MockStatusCode: test.statusCode}
+			service.CurrentThreadIdService = &mockThreadIdService
+			service.CurrentThreadService = &mockThreadService
+
+			var out bytes.Buffer
+			err := run(test.command, test.args, &out)
+
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %t. Got: %v", test.expectedError, err)
+			}
+			if out.String() != test.expectedOutput {
+				t.Errorf("expected output %q. Got %q", test.expectedOutput, out.String())
+			}
+			if !reflect.DeepEqual(mockThreadIdService.CreatedThreadIds, test.expectedCreatedIds) {
+				t.Errorf("expected thread ids %v to be created. Got %v", test.expectedCreatedIds, mockThreadIdService.CreatedThreadIds)
+			}
+			if !reflect.DeepEqual(mockThreadIdService.DeletedIds, test.expectedDeletedIds) {
+				t.Errorf("expected thread ids of %v to be deleted. Got %v", test.expectedDeletedIds, mockThreadIdService.DeletedIds)
+			}
+			if !reflect.DeepEqual(mockThreadService.HiddenPosts, test.expectedHiddenPosts) {
+				t.Errorf("expected posts %v to be hidden. Got %v", test.expectedHiddenPosts, mockThreadService.HiddenPosts)
+			}
+		})
+	}
+}
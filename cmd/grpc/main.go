@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+
+	fdk_user_feedback_service "github.com/Informasjonsforvaltning/fdk-user-feedback-service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/feedbackpb"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/grpcserver"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	fdk_user_feedback_service.Configure()
+
+	// Use GRPC_PORT environment variable, or default to 9000.
+	port := "9000"
+	if envPort := os.Getenv("GRPC_PORT"); envPort != "" {
+		port = envPort
+	}
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("net.Listen: %v\n", err)
+	}
+
+	server := grpc.NewServer()
+	feedbackpb.RegisterFeedbackServiceServer(server, grpcserver.CurrentFeedbackServer)
+
+	log.Printf("Bringing user-feedback-service gRPC API up on :%s\n", port)
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("grpc.Serve: %v\n", err)
+	}
+}
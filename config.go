@@ -1,13 +1,61 @@
 package fdk_user_feedback_service
 
 import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	controller "github.com/Informasjonsforvaltning/fdk-user-feedback-service/controller"
 	env "github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	eventbus "github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
+	grpcserver "github.com/Informasjonsforvaltning/fdk-user-feedback-service/grpcserver"
+	model "github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	repository "github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	secret "github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
 	service "github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 )
 
+var configureSecretsOnce sync.Once
+var configureEventBusOnce sync.Once
+var configureGraphStoreOnce sync.Once
+var configureNotifierOnce sync.Once
+
+// notifier delivers the notifications of contact points and followers. It is
+// kept between requests, as it holds a queue of notifications.
+var notifier service.NotificationQueue
+
+func configureSecrets() {
+	secret.CurrentSecretProvider = secret.NewFileSecretProvider(
+		env.EnvironmentVariables.SecretsDirectory,
+		&secret.EnvSecretProvider{},
+	)
+}
+
+func configureEventBus() {
+	switch env.EnvironmentVariables.EventBus {
+	case "firestore":
+		retention, err := time.ParseDuration(env.EnvironmentVariables.EventRetention)
+		if err != nil || retention <= 0 {
+			log.Println("Invalid EVENT_RETENTION, using 24h.\n[ERROR] -", err)
+			retention = 24 * time.Hour
+		}
+		eventbus.CurrentEventBus = &eventbus.FirestoreEventBus{
+			FirestoreProjectId:           env.ConstantValues.FirestoreProjectId,
+			FirestoreCollectionId:        env.EnvironmentVariables.EventCollection,
+			FirestoreCounterCollectionId: env.EnvironmentVariables.EventCounters,
+			Retention:                    retention,
+		}
+	default:
+		eventbus.CurrentEventBus = eventbus.NewInMemoryEventBus(env.ConstantValues.EventBufferSize)
+	}
+}
+
 func Configure() {
+	configureSecretsOnce.Do(configureSecrets)
+	configureEventBusOnce.Do(configureEventBus)
+
 	repository.CurrentEntityRepository = &repository.EntityRepositoryImpl{
 		SparqlServiceUrl: env.EnvironmentVariables.SparqlServiceUrl,
 	}
@@ -16,25 +64,82 @@ func Configure() {
 		FirestoreCollectionId: env.EnvironmentVariables.FirestoreCollection,
 	}
 	repository.CurrentThreadRepository = &repository.ThreadRepositoryImpl{
-		WriteApiToken:       env.EnvironmentVariables.WriteApiToken,
-		ReadApiToken:        env.EnvironmentVariables.ReadApiToken,
+		SecretProvider:      secret.CurrentSecretProvider,
 		CommunityApiUrl:     env.EnvironmentVariables.CommunityApiUrl,
 		ThreadBotUid:        env.EnvironmentVariables.ThreadBotUid,
 		CommunityCategoryId: env.EnvironmentVariables.CommunityCategoryId,
 		TopicPath:           env.ConstantValues.TopicPath,
 		TopicsPath:          env.ConstantValues.TopicsPath,
-		ThreadSlugPath:      env.ConstantValues.ThreadSlugPath,
 		PostsPath:           env.ConstantValues.PostsPath,
+		UserPath:            env.ConstantValues.UserPath,
+	}
+	repository.CurrentRevisionRepository = &repository.RevisionRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.RevisionCollection,
+	}
+	repository.CurrentReportRepository = &repository.ReportRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.ReportCollection,
+	}
+	repository.CurrentPendingPostRepository = &repository.PendingPostRepositoryImpl{
+		FirestoreProjectId:            env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId:         env.EnvironmentVariables.PendingCollection,
+		FirestoreApprovedCollectionId: env.EnvironmentVariables.ApprovedCollection,
+	}
+	repository.CurrentContentHistoryRepository = &repository.ContentHistoryRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.HistoryCollection,
+	}
+	repository.CurrentErasureRepository = &repository.ErasureRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.ErasureCollection,
+	}
+	repository.CurrentIssueRepository = &repository.IssueRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.IssueCollection,
+	}
+	repository.CurrentRatingRepository = &repository.RatingRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.RatingCollection,
+	}
+	repository.CurrentUsefulnessRepository = &repository.UsefulnessRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.UsefulCollection,
+	}
+	repository.CurrentPostIndexRepository = &repository.PostIndexRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.PostIndexCollection,
+	}
+	repository.CurrentNotificationRepository = &repository.NotificationRepositoryImpl{
+		FirestoreProjectId:            env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId:         env.EnvironmentVariables.NotifyCollection,
+		FirestoreSettingsCollectionId: env.EnvironmentVariables.NotifySettings,
+	}
+	repository.CurrentMailRepository = nil
+	if env.EnvironmentVariables.SmtpHost != "" {
+		repository.CurrentMailRepository = &repository.MailRepositoryImpl{
+			SecretProvider: secret.CurrentSecretProvider,
+			SmtpHost:       env.EnvironmentVariables.SmtpHost,
+			SmtpPort:       env.EnvironmentVariables.SmtpPort,
+			Username:       env.EnvironmentVariables.SmtpUsername,
+			From:           env.EnvironmentVariables.NotifyFrom,
+			Timeout:        10 * time.Second,
+		}
+	}
+	repository.CurrentSubscriptionRepository = &repository.SubscriptionRepositoryImpl{
+		FirestoreProjectId:    env.ConstantValues.FirestoreProjectId,
+		FirestoreCollectionId: env.EnvironmentVariables.FollowCollection,
 	}
 	repository.CurrentUserRepository = &repository.UserRepositoryImpl{
-		ReadApiToken:     env.EnvironmentVariables.ReadApiToken,
+		SecretProvider:   secret.CurrentSecretProvider,
 		CommunityBaseUrl: env.EnvironmentVariables.CommunityApiUrl,
 		UserByEmailPath:  env.ConstantValues.UserByEmailPath,
 	}
 
 	service.CurrentAuthService = &service.AuthServiceImpl{
-		UserRepository: repository.CurrentUserRepository,
-		KeycloakHost:   env.EnvironmentVariables.KeycloakHost,
+		UserRepository:     repository.CurrentUserRepository,
+		KeycloakHost:       env.EnvironmentVariables.KeycloakHost,
+		ModeratorAuthority: env.EnvironmentVariables.ModeratorAuthority,
 	}
 	service.CurrentEntityService = &service.EntityServiceImpl{
 		EntityRepository: repository.CurrentEntityRepository,
@@ -42,16 +147,278 @@ func Configure() {
 	service.CurrentThreadIdService = &service.ThreadIdServiceImpl{
 		ThreadIdRepository: repository.CurrentThreadIdRepository,
 	}
+	configureGraphStoreOnce.Do(configureGraphStore)
+	service.CurrentStatisticsService = &service.StatisticsServiceImpl{
+		PostIndexRepository: repository.CurrentPostIndexRepository,
+		EntityService:       service.CurrentEntityService,
+		ThreadBotUid:        env.EnvironmentVariables.ThreadBotUid,
+	}
+	throttleWindow, err := time.ParseDuration(env.EnvironmentVariables.NotifyThrottle)
+	if err != nil {
+		log.Println("Invalid NOTIFICATION_THROTTLE, using 1h.\n[ERROR] -", err)
+		throttleWindow = time.Hour
+	}
+	configureNotifierOnce.Do(configureNotifier)
+	service.CurrentNotificationService = &service.NotificationServiceImpl{
+		NotificationRepository: repository.CurrentNotificationRepository,
+		Notifier:               notifier,
+		EntityService:          service.CurrentEntityService,
+		ThreadBotUid:           env.EnvironmentVariables.ThreadBotUid,
+		ThrottleWindow:         throttleWindow,
+	}
+	unsubscribeTtl, err := time.ParseDuration(env.EnvironmentVariables.UnsubscribeTtl)
+	if err != nil {
+		log.Println("Invalid UNSUBSCRIBE_TOKEN_TTL, using 720h.\n[ERROR] -", err)
+		unsubscribeTtl = 720 * time.Hour
+	}
+	service.CurrentSubscriptionService = &service.SubscriptionServiceImpl{
+		SubscriptionRepository: repository.CurrentSubscriptionRepository,
+		NotificationRepository: repository.CurrentNotificationRepository,
+		ThreadRepository:       repository.CurrentThreadRepository,
+		EntityService:          service.CurrentEntityService,
+		SecretProvider:         secret.CurrentSecretProvider,
+		Notifier:               notifier,
+		ThreadBotUid:           env.EnvironmentVariables.ThreadBotUid,
+		ThrottleWindow:         throttleWindow,
+		UnsubscribeUrl:         env.EnvironmentVariables.UnsubscribeUrl,
+		TokenTtl:               unsubscribeTtl,
+	}
+	premoderation, err := strconv.ParseBool(env.EnvironmentVariables.Premoderation)
+	if err != nil {
+		log.Println("Invalid PREMODERATION, posts are published without moderation.\n[ERROR] -", err)
+	}
+	personalDataAction, ok := model.ParsePersonalDataAction(env.EnvironmentVariables.PersonalDataAction)
+	if !ok {
+		log.Println("Invalid PERSONAL_DATA_ACTION, personal data is masked.")
+	}
 	service.CurrentThreadService = &service.ThreadServiceImpl{
-		ThreadRepository: repository.CurrentThreadRepository,
+		ThreadRepository:      repository.CurrentThreadRepository,
+		ThreadIdService:       service.CurrentThreadIdService,
+		EntityService:         service.CurrentEntityService,
+		EventBus:              eventbus.CurrentEventBus,
+		RevisionRepository:    repository.CurrentRevisionRepository,
+		PendingPostRepository: repository.CurrentPendingPostRepository,
+		IssueRepository:       repository.CurrentIssueRepository,
+		PostIndexRepository:   repository.CurrentPostIndexRepository,
+		GraphStoreService:     service.CurrentGraphStoreService,
+		StatisticsService:     service.CurrentStatisticsService,
+		NotificationService:   service.CurrentNotificationService,
+		SubscriptionService:   service.CurrentSubscriptionService,
+		Premoderation:         premoderation,
+		PersonalDataAction:    personalDataAction,
+	}
+	service.CurrentIssueService = &service.IssueServiceImpl{
+		IssueRepository:  repository.CurrentIssueRepository,
 		ThreadIdService:  service.CurrentThreadIdService,
 		EntityService:    service.CurrentEntityService,
+		MaxCommentLength: env.ConstantValues.MaxCommentLength,
+	}
+	service.CurrentAnnotationService = &service.AnnotationServiceImpl{
+		ThreadService:    service.CurrentThreadService,
+		EntityService:    service.CurrentEntityService,
+		CommunityBaseUrl: env.EnvironmentVariables.CommunityBaseUrl,
+		ThreadBotUid:     env.EnvironmentVariables.ThreadBotUid,
+		PageSize:         env.ConstantValues.MaxPageSize,
+	}
+	service.CurrentRatingService = &service.RatingServiceImpl{
+		RatingRepository: repository.CurrentRatingRepository,
+		EntityService:    service.CurrentEntityService,
+	}
+	nonceTtl, err := time.ParseDuration(env.EnvironmentVariables.UsefulNonceTtl)
+	if err != nil {
+		log.Println("Invalid USEFULNESS_NONCE_TTL, using 1h.\n[ERROR] -", err)
+		nonceTtl = time.Hour
+	}
+	ipWindow, err := time.ParseDuration(env.EnvironmentVariables.UsefulIpWindow)
+	if err != nil {
+		log.Println("Invalid USEFULNESS_IP_WINDOW, using 1h.\n[ERROR] -", err)
+		ipWindow = time.Hour
+	}
+	ipLimit, err := strconv.Atoi(env.EnvironmentVariables.UsefulIpLimit)
+	if err != nil || ipLimit < 1 {
+		log.Println("Invalid USEFULNESS_IP_LIMIT, using 20.\n[ERROR] -", err)
+		ipLimit = 20
+	}
+	service.CurrentUsefulnessService = &service.UsefulnessServiceImpl{
+		UsefulnessRepository: repository.CurrentUsefulnessRepository,
+		EntityService:        service.CurrentEntityService,
+		SecretProvider:       secret.CurrentSecretProvider,
+		NonceTtl:             nonceTtl,
+		IpLimit:              ipLimit,
+		IpWindow:             ipWindow,
+		MaxReasonLength:      env.ConstantValues.MaxReasonLength,
+	}
+	service.CurrentModerationService = &service.ModerationServiceImpl{
+		PendingPostRepository: repository.CurrentPendingPostRepository,
+		ThreadService:         service.CurrentThreadService,
+	}
+
+	hideThreshold, err := strconv.Atoi(env.EnvironmentVariables.ReportHideThreshold)
+	if err != nil || hideThreshold < 0 {
+		log.Println("Invalid REPORT_HIDE_THRESHOLD, reported posts are not hidden.\n[ERROR] -", err)
+		hideThreshold = 0
+	}
+	service.CurrentReportService = &service.ReportServiceImpl{
+		ReportRepository: repository.CurrentReportRepository,
+		ThreadRepository: repository.CurrentThreadRepository,
+		ThreadService:    service.CurrentThreadService,
+		EntityService:    service.CurrentEntityService,
+		HideThreshold:    hideThreshold,
+		ModeratorUid:     env.EnvironmentVariables.ThreadBotUid,
+		MaxTextLength:    env.ConstantValues.MaxReportLength,
+	}
+
+	repeatWindow, err := time.ParseDuration(env.EnvironmentVariables.RepeatWindow)
+	if err != nil {
+		log.Println("Invalid CONTENT_REPEAT_WINDOW, repeated content is allowed.\n[ERROR] -", err)
+	}
+	service.CurrentContentScreeningService = &service.ContentScreeningServiceImpl{
+		ContentHistoryRepository: repository.CurrentContentHistoryRepository,
+		MaxLength:                screeningLimit("CONTENT_MAX_LENGTH", env.EnvironmentVariables.MaxContentLength),
+		MaxRepeatedCharacters:    screeningLimit("CONTENT_MAX_REPEATED_CHARS", env.EnvironmentVariables.MaxRepeatedChars),
+		MaxLinks:                 screeningLimit("CONTENT_MAX_LINKS", env.EnvironmentVariables.MaxLinks),
+		BlockedDomains:           splitList(env.EnvironmentVariables.BlockedDomains),
+		BannedWords: map[string][]string{
+			"nb": splitList(env.EnvironmentVariables.BannedWordsNb),
+			"nn": splitList(env.EnvironmentVariables.BannedWordsNn),
+			"en": splitList(env.EnvironmentVariables.BannedWordsEn),
+		},
+		RepeatWindow: repeatWindow,
+	}
+
+	service.CurrentExportService = &service.ExportServiceImpl{
+		ThreadRepository:    repository.CurrentThreadRepository,
+		ThreadIdService:     service.CurrentThreadIdService,
+		ThreadService:       service.CurrentThreadService,
+		EntityService:       service.CurrentEntityService,
+		PostIndexRepository: repository.CurrentPostIndexRepository,
+		ThreadBotUid:        env.EnvironmentVariables.ThreadBotUid,
+		PageSize:            env.ConstantValues.MaxPageSize,
+	}
+
+	erasurePolicy, ok := model.ParseErasurePolicy(env.EnvironmentVariables.ErasurePolicy)
+	if !ok {
+		log.Println("Invalid ERASURE_POLICY, erased posts are purged.")
+	}
+	service.CurrentErasureService = &service.ErasureServiceImpl{
+		ThreadRepository:         repository.CurrentThreadRepository,
+		RevisionRepository:       repository.CurrentRevisionRepository,
+		PendingPostRepository:    repository.CurrentPendingPostRepository,
+		ContentHistoryRepository: repository.CurrentContentHistoryRepository,
+		ErasureRepository:        repository.CurrentErasureRepository,
+		RatingRepository:         repository.CurrentRatingRepository,
+		SubscriptionRepository:   repository.CurrentSubscriptionRepository,
+		IssueRepository:          repository.CurrentIssueRepository,
+		ReportRepository:         repository.CurrentReportRepository,
+		GraphStoreService:        service.CurrentGraphStoreService,
+		StatisticsService:        service.CurrentStatisticsService,
+		Policy:                   erasurePolicy,
+		AnonymousUid:             env.EnvironmentVariables.ErasureAnonymousUid,
+		ProgressInterval:         env.ConstantValues.ErasureProgress,
+		ChunkSize:                env.ConstantValues.ErasureChunk,
+		LeaseDuration:            env.ConstantValues.ErasureLease,
+	}
+
+	heartbeatInterval, err := time.ParseDuration(env.EnvironmentVariables.EventHeartbeat)
+	if err != nil {
+		log.Println("Invalid EVENT_HEARTBEAT, using default.\n[ERROR] -", err)
 	}
 
 	controller.CurrentController = &controller.ControllerImpl{
-		AuthService:     service.CurrentAuthService,
-		ThreadIdService: service.CurrentThreadIdService,
-		ThreadService:   service.CurrentThreadService,
+		AuthService:         service.CurrentAuthService,
+		ThreadIdService:     service.CurrentThreadIdService,
+		ThreadService:       service.CurrentThreadService,
+		ReportService:       service.CurrentReportService,
+		ModerationService:   service.CurrentModerationService,
+		ScreeningService:    service.CurrentContentScreeningService,
+		ExportService:       service.CurrentExportService,
+		ErasureService:      service.CurrentErasureService,
+		IssueService:        service.CurrentIssueService,
+		AnnotationService:   service.CurrentAnnotationService,
+		RatingService:       service.CurrentRatingService,
+		UsefulnessService:   service.CurrentUsefulnessService,
+		StatisticsService:   service.CurrentStatisticsService,
+		NotificationService: service.CurrentNotificationService,
+		SubscriptionService: service.CurrentSubscriptionService,
+		EventBus:            eventbus.CurrentEventBus,
+		HeartbeatInterval:   heartbeatInterval,
 	}
 
+	grpcserver.CurrentFeedbackServer = &grpcserver.FeedbackServerImpl{
+		AuthService:         service.CurrentAuthService,
+		ThreadIdService:     service.CurrentThreadIdService,
+		ThreadService:       service.CurrentThreadService,
+		ScreeningService:    service.CurrentContentScreeningService,
+		SubscriptionService: service.CurrentSubscriptionService,
+	}
+}
+
+// configureGraphStore sets up pushing of annotations to the graph store when
+// SPARQL_UPDATE_URL is set. It is only done once, as the service keeps a
+// queue of updates between requests.
+func configureGraphStore() {
+	if env.EnvironmentVariables.SparqlUpdateUrl == "" {
+		return
+	}
+
+	repository.CurrentGraphStoreRepository = &repository.GraphStoreRepositoryImpl{
+		SecretProvider:  secret.CurrentSecretProvider,
+		SparqlUpdateUrl: env.EnvironmentVariables.SparqlUpdateUrl,
+	}
+
+	maxAttempts, err := strconv.Atoi(env.EnvironmentVariables.SparqlUpdateTries)
+	if err != nil || maxAttempts < 1 {
+		log.Println("Invalid SPARQL_UPDATE_ATTEMPTS, updates are tried once.\n[ERROR] -", err)
+		maxAttempts = 1
+	}
+	retryDelay, err := time.ParseDuration(env.EnvironmentVariables.SparqlUpdateDelay)
+	if err != nil {
+		log.Println("Invalid SPARQL_UPDATE_RETRY_DELAY, updates are retried at once.\n[ERROR] -", err)
+	}
+
+	service.CurrentGraphStoreService = &service.GraphStoreServiceImpl{
+		GraphStoreRepository: repository.CurrentGraphStoreRepository,
+		ThreadIdService:      service.CurrentThreadIdService,
+		EntityService:        service.CurrentEntityService,
+		CommunityBaseUrl:     env.EnvironmentVariables.CommunityBaseUrl,
+		ThreadBotUid:         env.EnvironmentVariables.ThreadBotUid,
+		Graph:                env.EnvironmentVariables.FeedbackGraph,
+		MaxAttempts:          maxAttempts,
+		RetryDelay:           retryDelay,
+		QueueSize:            env.ConstantValues.GraphStoreQueue,
+	}
+}
+
+// configureNotifier sets up the queued delivery of notifications by email
+// when SMTP_HOST is set.
+func configureNotifier() {
+	if repository.CurrentMailRepository == nil {
+		return
+	}
+
+	notifier = &service.QueuedNotifier{
+		Notifier:  &service.MailNotifier{MailRepository: repository.CurrentMailRepository},
+		QueueSize: env.ConstantValues.NotificationQueue,
+	}
+}
+
+// screeningLimit parses a content screening limit, turning the rule off when
+// it is invalid.
+func screeningLimit(name string, value string) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Printf("Invalid %s, the rule is turned off.\n[ERROR] - %v\n", name, err)
+		return 0
+	}
+	return limit
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
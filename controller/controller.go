@@ -2,8 +2,14 @@ package controller
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
@@ -12,15 +18,62 @@ import (
 type Controller interface {
 	CreateComment(w http.ResponseWriter, r *http.Request)
 	GetComments(w http.ResponseWriter, r *http.Request)
+	GetComment(w http.ResponseWriter, r *http.Request)
 	UpdateComment(w http.ResponseWriter, r *http.Request)
 	DeleteComment(w http.ResponseWriter, r *http.Request)
 	CurrentUser(w http.ResponseWriter, r *http.Request)
+	StreamThreadEvents(w http.ResponseWriter, r *http.Request)
+	UpvoteComment(w http.ResponseWriter, r *http.Request)
+	UnvoteComment(w http.ResponseWriter, r *http.Request)
+	GetCommentRevisions(w http.ResponseWriter, r *http.Request)
+	ReportComment(w http.ResponseWriter, r *http.Request)
+	UpdateIssueStatus(w http.ResponseWriter, r *http.Request)
+	GetReportedComments(w http.ResponseWriter, r *http.Request)
+	ResolveReports(w http.ResponseWriter, r *http.Request)
+	GetPendingComments(w http.ResponseWriter, r *http.Request)
+	ApproveComment(w http.ResponseWriter, r *http.Request)
+	RejectComment(w http.ResponseWriter, r *http.Request)
+	ExportCurrentUser(w http.ResponseWriter, r *http.Request)
+	ExportUser(w http.ResponseWriter, r *http.Request)
+	ExportOrganization(w http.ResponseWriter, r *http.Request)
+	GetNotificationSettings(w http.ResponseWriter, r *http.Request)
+	UpdateNotificationSettings(w http.ResponseWriter, r *http.Request)
+	EraseCurrentUser(w http.ResponseWriter, r *http.Request)
+	EraseUser(w http.ResponseWriter, r *http.Request)
+	GetErasure(w http.ResponseWriter, r *http.Request)
+	ResumeErasure(w http.ResponseWriter, r *http.Request)
+	GetAnnotations(w http.ResponseWriter, r *http.Request)
+	GetRatings(w http.ResponseWriter, r *http.Request)
+	RateEntity(w http.ResponseWriter, r *http.Request)
+	WithdrawRating(w http.ResponseWriter, r *http.Request)
+	GetUsefulness(w http.ResponseWriter, r *http.Request)
+	VoteUsefulness(w http.ResponseWriter, r *http.Request)
+	GetStatistics(w http.ResponseWriter, r *http.Request)
+	GetSubscription(w http.ResponseWriter, r *http.Request)
+	FollowThread(w http.ResponseWriter, r *http.Request)
+	UnfollowThread(w http.ResponseWriter, r *http.Request)
+	GetUnsubscribe(w http.ResponseWriter, r *http.Request)
+	Unsubscribe(w http.ResponseWriter, r *http.Request)
 }
 
 type ControllerImpl struct {
-	AuthService     service.AuthService
-	ThreadIdService service.ThreadIdService
-	ThreadService   service.ThreadService
+	AuthService         service.AuthService
+	ThreadIdService     service.ThreadIdService
+	ThreadService       service.ThreadService
+	ReportService       service.ReportService
+	ModerationService   service.ModerationService
+	ScreeningService    service.ContentScreeningService
+	ExportService       service.ExportService
+	ErasureService      service.ErasureService
+	IssueService        service.IssueService
+	AnnotationService   service.AnnotationService
+	RatingService       service.RatingService
+	UsefulnessService   service.UsefulnessService
+	StatisticsService   service.StatisticsService
+	NotificationService service.NotificationService
+	SubscriptionService service.SubscriptionService
+	EventBus            eventbus.EventBus
+	HeartbeatInterval   time.Duration
 }
 
 func (controller *ControllerImpl) CreateComment(w http.ResponseWriter, r *http.Request) {
@@ -52,19 +105,45 @@ func (controller *ControllerImpl) CreateComment(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if post.OfficialAnswer {
+		publisher, statusCode := controller.IssueService.IsPublisher(*entityId, *user)
+		if statusCode != http.StatusOK {
+			w.WriteHeader(statusCode)
+			return
+		}
+		if !publisher {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	if controller.rejectScreenedContent(w, model.Post{UserId: user.UserId, Content: post.Content}) {
+		return
+	}
+
 	created, statusCode := controller.ThreadService.CreatePostForEntityId(model.Post{
-		PostId:   post.PostId,
-		UserId:   user.UserId,
-		ThreadId: post.ThreadId,
-		Content:  post.Content,
-		ToPostId: post.ToPostId,
+		UserId:         user.UserId,
+		ThreadId:       post.ThreadId,
+		Content:        post.Content,
+		ToPostId:       post.ToPostId,
+		Issue:          post.Issue,
+		OfficialAnswer: post.OfficialAnswer,
 	}, *entityId)
 	if !util.SuccsessfulStatus(statusCode) {
 		w.WriteHeader(statusCode)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	if created != nil {
+		controller.recordScreenedContent(model.Post{PostId: created.PostId, UserId: user.UserId, Content: post.Content})
+	}
+	controller.followThread(*entityId, *user)
+
+	// Posts held back for moderation are accepted rather than created.
+	if statusCode != http.StatusAccepted {
+		statusCode = http.StatusCreated
+	}
+	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(created)
 }
 
@@ -75,16 +154,58 @@ func (controller *ControllerImpl) GetComments(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	thread, statusCode := controller.ThreadService.GetThreadByEntityId(*entityId, util.GetPageQueryParam(r.URL.Query()))
+	query, err := util.GetThreadQueryParams(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Signed in readers also get their own votes, anyone else reads anonymously.
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if user, statusCode := controller.AuthService.AuthenticateAndGetUser(authorization); statusCode == http.StatusOK && user != nil {
+			query.ViewerUid = user.UserId
+		}
+	}
+
+	thread, statusCode := controller.ThreadService.GetThreadByEntityId(*entityId, *query)
 	if !util.SuccsessfulStatus(statusCode) || thread == nil {
 		w.WriteHeader(statusCode)
 		return
 	}
 
+	if links := util.PaginationLinks(r.URL, thread.Pagination); links != "" {
+		w.Header().Set("Link", links)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(thread)
 }
 
+func (controller *ControllerImpl) GetComment(w http.ResponseWriter, r *http.Request) {
+	_, entityId, postId := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil || postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// Signed in readers also get their own vote, anyone else reads anonymously.
+	var viewerUid *string
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if user, statusCode := controller.AuthService.AuthenticateAndGetUser(authorization); statusCode == http.StatusOK && user != nil {
+			viewerUid = user.UserId
+		}
+	}
+
+	post, statusCode := controller.ThreadService.GetThreadPostByEntityId(*entityId, *postId, viewerUid)
+	if !util.SuccsessfulStatus(statusCode) || post == nil {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(post)
+}
+
 func (controller *ControllerImpl) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
 	if statusCode != http.StatusOK {
@@ -120,19 +241,30 @@ func (controller *ControllerImpl) UpdateComment(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if controller.rejectScreenedContent(w, model.Post{PostId: postId, UserId: user.UserId, Content: post.Content}) {
+		return
+	}
+
 	created, statusCode := controller.ThreadService.UpdateThreadPost(model.Post{
 		PostId:   postId,
 		UserId:   user.UserId,
 		ThreadId: threadId,
 		Content:  post.Content,
 		ToPostId: post.ToPostId,
-	}, util.GetPostIndexQueryParam(r.URL.Query()))
+		Issue:    post.Issue,
+	})
 	if !util.SuccsessfulStatus(statusCode) {
 		w.WriteHeader(statusCode)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	controller.recordScreenedContent(model.Post{PostId: postId, UserId: user.UserId, Content: post.Content})
+
+	// Edits held back for moderation are accepted rather than applied.
+	if statusCode != http.StatusAccepted {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(created)
 }
 
@@ -164,12 +296,12 @@ func (controller *ControllerImpl) DeleteComment(w http.ResponseWriter, r *http.R
 		PostId:   postId,
 		UserId:   user.UserId,
 		ThreadId: threadId,
-	}, util.GetPostIndexQueryParam(r.URL.Query()))
+	})
 
 	w.WriteHeader(statusCode)
 }
 
-func (controller *ControllerImpl) CurrentUser(w http.ResponseWriter, r *http.Request) {
+func (controller *ControllerImpl) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
 	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
 	if statusCode != http.StatusOK {
 		w.WriteHeader(statusCode)
@@ -181,8 +313,1070 @@ func (controller *ControllerImpl) CurrentUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	_, entityId, postId := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil || postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	revisions, statusCode := controller.ThreadService.GetThreadPostRevisions(*entityId, *postId, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (controller *ControllerImpl) ReportComment(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, entityId, postId := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil || postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var report model.PostReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	created, statusCode := controller.ReportService.ReportPost(*entityId, *postId, model.PostReport{
+		ReporterUid: *user.UserId,
+		Reason:      report.Reason,
+		Text:        report.Text,
+	})
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (controller *ControllerImpl) UpdateIssueStatus(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, entityId, postId := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil || postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var transition model.IssueTransition
+	if err := json.NewDecoder(r.Body).Decode(&transition); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	issue, statusCode := controller.IssueService.UpdateIssueStatus(*entityId, *postId, transition, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(issue)
+}
+
+func (controller *ControllerImpl) GetReportedComments(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	reportedPosts, statusCode := controller.ReportService.GetReportedPosts()
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reportedPosts)
+}
+
+func (controller *ControllerImpl) ResolveReports(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	_, postId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	reports, statusCode := controller.ReportService.ResolveReports(*postId, *user.UserId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reports)
+}
+
+func (controller *ControllerImpl) GetPendingComments(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	pendingPosts, statusCode := controller.ModerationService.GetPendingPosts()
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pendingPosts)
+}
+
+func (controller *ControllerImpl) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	_, pendingId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if pendingId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	approved, statusCode := controller.ModerationService.ApprovePendingPost(*pendingId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(approved)
+}
+
+func (controller *ControllerImpl) RejectComment(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	_, pendingId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if pendingId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	statusCode = controller.ModerationService.RejectPendingPost(*pendingId)
+
+	w.WriteHeader(statusCode)
+}
+
+// GetRatings writes the rating summary of the entity in the path, with the
+// score of a signed in reader. Without an entity in the path, the summaries
+// of the entities in the entityId query parameters are written by entity id.
+func (controller *ControllerImpl) GetRatings(w http.ResponseWriter, r *http.Request) {
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil {
+		entityIds, err := util.GetEntityIdsQueryParam(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		summaries, statusCode := controller.RatingService.GetRatingSummaries(entityIds)
+		if !util.SuccsessfulStatus(statusCode) {
+			w.WriteHeader(statusCode)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(summaries)
+		return
+	}
+
+	var userId *string
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if user, statusCode := controller.AuthService.AuthenticateAndGetUser(authorization); statusCode == http.StatusOK && user != nil {
+			userId = user.UserId
+		}
+	}
+
+	summary, statusCode := controller.RatingService.GetRatingSummary(*entityId, userId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (controller *ControllerImpl) RateEntity(w http.ResponseWriter, r *http.Request) {
+	user, entityId, ok := controller.entityRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var rating model.Rating
+	if err := json.NewDecoder(r.Body).Decode(&rating); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	summary, statusCode := controller.RatingService.RateEntity(entityId, *user.UserId, rating.Score)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (controller *ControllerImpl) WithdrawRating(w http.ResponseWriter, r *http.Request) {
+	user, entityId, ok := controller.entityRequest(w, r)
+	if !ok {
+		return
+	}
+
+	summary, statusCode := controller.RatingService.WithdrawRating(entityId, *user.UserId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// entityRequest authenticates the user changing a rating or subscription of
+// the entity in the path, writing the error status if it fails.
+func (controller *ControllerImpl) entityRequest(w http.ResponseWriter, r *http.Request) (*model.User, string, bool) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return nil, "", false
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, "", false
+	}
+
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, "", false
+	}
+
+	return user, *entityId, true
+}
+
+// GetUsefulness writes the usefulness votes of the entity in the path, with a
+// nonce to vote with, or of every entity of the type in the entityType query
+// parameter.
+func (controller *ControllerImpl) GetUsefulness(w http.ResponseWriter, r *http.Request) {
+	var summary *model.UsefulnessSummary
+	var statusCode int
+
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId != nil {
+		summary, statusCode = controller.UsefulnessService.GetEntitySummary(*entityId)
+		w.Header().Set("Cache-Control", "no-store")
+	} else if entityType := r.URL.Query().Get("entityType"); entityType != "" {
+		summary, statusCode = controller.UsefulnessService.GetTypeSummary(model.ParseEntityType(&entityType))
+	} else {
+		statusCode = http.StatusBadRequest
+	}
+
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (controller *ControllerImpl) VoteUsefulness(w http.ResponseWriter, r *http.Request) {
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var request model.UsefulnessVoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	summary, statusCode := controller.UsefulnessService.Vote(*entityId, util.ClientIp(r), request)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (controller *ControllerImpl) GetStatistics(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	query, err := util.GetStatisticsQueryParams(r.URL.Query(), time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statistics, statusCode := controller.StatisticsService.GetStatistics(*query, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statistics)
+}
+
+func (controller *ControllerImpl) UpvoteComment(w http.ResponseWriter, r *http.Request) {
+	controller.voteComment(w, r, true)
+}
+
+func (controller *ControllerImpl) UnvoteComment(w http.ResponseWriter, r *http.Request) {
+	controller.voteComment(w, r, false)
+}
+
+func (controller *ControllerImpl) voteComment(w http.ResponseWriter, r *http.Request, upvote bool) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, entityId, postId := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil || postId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	voted, statusCode := controller.ThreadService.VotePostForEntityId(*entityId, *postId, *user.UserId, upvote)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(voted)
+}
+
+func (controller *ControllerImpl) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (controller *ControllerImpl) ExportCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	controller.writeUserExport(w, r, *user)
+}
+
+// ExportUser lets moderators export the posts of the user with the email in
+// the path, as in /users/{email}/export.
+func (controller *ControllerImpl) ExportUser(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	_, email, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if email == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	exportedUser, err := controller.AuthService.GetUser(*email)
+	if err != nil || exportedUser == nil || exportedUser.UserId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	controller.writeUserExport(w, r, *exportedUser)
+}
+
+// writeUserExport writes the export as JSON, or as CSV when asked for with
+// ?format=csv or an Accept header of text/csv.
+func (controller *ControllerImpl) writeUserExport(w http.ResponseWriter, r *http.Request, user model.User) {
+	export, statusCode := controller.ExportService.ExportUserPosts(user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	filename := "feedback-export-" + *user.UserId
+	if r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := util.WriteUserExportCsv(w, *export); err != nil {
+			log.Println("Could not write user export.\n[ERROR] -", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(export)
+}
+
+// ExportOrganization streams the feedback on the entities of the
+// organization in the path, as in /organizations/{orgId}/export, as CSV, or
+// as XLSX when asked for with ?format=xlsx or the Accept header.
+func (controller *ControllerImpl) ExportOrganization(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, organization, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if organization == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	entities, statusCode := controller.ExportService.GetExportedEntities(*organization, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	newWriter := util.NewFeedbackCsvWriter
+	contentType, extension := "text/csv; charset=utf-8", ".csv"
+	if r.URL.Query().Get("format") == "xlsx" || strings.Contains(r.Header.Get("Accept"), util.XlsxContentType) {
+		newWriter = util.NewFeedbackXlsxWriter
+		contentType, extension = util.XlsxContentType, ".xlsx"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="feedback-`+url.PathEscape(*organization)+extension+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := newWriter(w)
+	if err != nil {
+		log.Println("Could not start organization export.\n[ERROR] -", err)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for _, entity := range entities {
+		if err := controller.ExportService.ExportEntityFeedback(entity, writer.Write); err != nil {
+			log.Println("Could not export feedback of entity.\n[ERROR] -", err)
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			log.Println("Could not write organization export.\n[ERROR] -", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if err := writer.Close(); err != nil {
+		log.Println("Could not write organization export.\n[ERROR] -", err)
+	}
+}
+
+// GetNotificationSettings tells whether the contact points of the resources
+// of the organization in the path, as in /organizations/{orgId}/notifications,
+// are emailed about new feedback.
+func (controller *ControllerImpl) GetNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, organization, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if organization == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	settings, statusCode := controller.NotificationService.GetSettings(*organization, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+func (controller *ControllerImpl) UpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, organization, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if organization == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var request model.NotificationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	settings, statusCode := controller.NotificationService.UpdateSettings(model.NotificationSettings{
+		Organization: *organization,
+		Enabled:      *request.Enabled,
+	}, *user)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// GetAnnotations writes the posts of the thread of an entity as RDF, in the
+// format asked for with ?format= or the Accept header.
+func (controller *ControllerImpl) GetAnnotations(w http.ResponseWriter, r *http.Request) {
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	format, ok := util.NegotiateRdfFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if !ok {
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+
+	triples, statusCode := controller.AnnotationService.GetAnnotations(*entityId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType()+"; charset=utf-8")
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	if err := util.WriteRdf(w, format, triples); err != nil {
+		log.Println("Could not write annotations.\n[ERROR] -", err)
+	}
+}
+
+func (controller *ControllerImpl) EraseCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	controller.startErasure(w, *user, *user.UserId)
+}
+
+// EraseUser lets moderators erase the posts of the user with the email in
+// the path, as in /users/{email}/erasure.
+func (controller *ControllerImpl) EraseUser(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.Moderator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	_, email, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if email == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	erasedUser, err := controller.AuthService.GetUser(*email)
+	if err != nil || erasedUser == nil || erasedUser.UserId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	controller.startErasure(w, *erasedUser, *user.UserId)
+}
+
+// GetErasure reports the progress of an erasure to the erased user and to
+// moderators.
+func (controller *ControllerImpl) GetErasure(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, erasureId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if erasureId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tombstone, statusCode := controller.ErasureService.GetErasure(*erasureId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if !user.Moderator && tombstone.UserId != *user.UserId {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tombstone)
+}
+
+// ResumeErasure lets the erased user and moderators erase the next chunk of
+// posts of a running erasure, until it is done.
+func (controller *ControllerImpl) ResumeErasure(w http.ResponseWriter, r *http.Request) {
+	user, statusCode := controller.AuthService.AuthenticateAndGetUser(r.Header.Get("Authorization"))
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if user == nil || user.UserId == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	_, erasureId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if erasureId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tombstone, statusCode := controller.ErasureService.GetErasure(*erasureId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if !user.Moderator && tombstone.UserId != *user.UserId {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if tombstone.Status != model.ErasureRunning {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	controller.processErasure(w, tombstone.ErasureId)
+}
+
+// startErasure erases the first chunk of posts of the user, and answers
+// with the tombstone to follow the progress on. The erasure is continued on
+// the tombstone while it answers http.StatusAccepted.
+func (controller *ControllerImpl) startErasure(w http.ResponseWriter, user model.User, requestedBy string) {
+	tombstone, statusCode := controller.ErasureService.StartErasure(user, requestedBy)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.Header().Set("Location", "/"+env.ConstantValues.ErasuresPath+"/"+tombstone.ErasureId)
+	controller.processErasure(w, tombstone.ErasureId)
+}
+
+func (controller *ControllerImpl) processErasure(w http.ResponseWriter, erasureId string) {
+	tombstone, statusCode := controller.ErasureService.ProcessErasure(erasureId)
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(tombstone)
+}
+
+func (controller *ControllerImpl) StreamThreadEvents(w http.ResponseWriter, r *http.Request) {
+	_, entityId, _ := util.ParseRequestUrlPath(r.URL.Path)
+	if entityId == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || controller.EventBus == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var lastEventId *string
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		if _, ok := model.ParseEventSequence(value); !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lastEventId = &value
+	}
+
+	threadId, err := controller.ThreadIdService.GetThreadId(*entityId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	heartbeat := time.NewTicker(controller.heartbeatInterval())
+	defer heartbeat.Stop()
+
+	// Entities get their thread when the first post is created, so wait for
+	// it and replay everything retained for the new thread once it appears.
+	for threadId == nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			util.WriteServerSentComment(w, "heartbeat")
+			flusher.Flush()
+
+			threadId, err = controller.ThreadIdService.GetThreadId(*entityId)
+			if err != nil {
+				return
+			}
+			replayAll := ""
+			lastEventId = &replayAll
+		}
+	}
+
+	events, err := controller.EventBus.Subscribe(ctx, *threadId, lastEventId)
+	if err != nil {
+		log.Println("Could not subscribe to thread events.\n[ERROR] -", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			util.WriteServerSentComment(w, "heartbeat")
+			flusher.Flush()
+		case event, open := <-events:
+			if !open {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Println("Error on event marshal.\n[ERROR] -", err)
+				continue
+			}
+
+			util.WriteServerSentEvent(w, event.EventId, string(event.Type), data)
+			flusher.Flush()
+		}
+	}
+}
+
+// rejectScreenedContent responds with the screening rules the post breaks,
+// and reports whether it did.
+func (controller *ControllerImpl) rejectScreenedContent(w http.ResponseWriter, post model.Post) bool {
+	if controller.ScreeningService == nil || post.Content == nil {
+		return false
+	}
+
+	violations := controller.ScreeningService.ScreenPost(post)
+	if len(violations) == 0 {
+		return false
+	}
+
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(model.ContentViolationResponse{Errors: violations})
+	return true
+}
+
+func (controller *ControllerImpl) recordScreenedContent(post model.Post) {
+	if controller.ScreeningService != nil {
+		controller.ScreeningService.RecordPost(post)
+	}
+}
+
+// followThread lets the author of a post follow the thread of the entity.
+func (controller *ControllerImpl) followThread(entityId string, user model.User) {
+	if controller.SubscriptionService != nil {
+		controller.SubscriptionService.Follow(entityId, user)
+	}
+}
+
+func (controller *ControllerImpl) heartbeatInterval() time.Duration {
+	if controller.HeartbeatInterval <= 0 {
+		return 15 * time.Second
+	}
+	return controller.HeartbeatInterval
+}
+
+func (controller *ControllerImpl) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	user, entityId, ok := controller.entityRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, statusCode := controller.SubscriptionService.GetSubscription(entityId, *user)
+	writeSubscription(w, subscription, statusCode)
+}
+
+func (controller *ControllerImpl) FollowThread(w http.ResponseWriter, r *http.Request) {
+	user, entityId, ok := controller.entityRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, statusCode := controller.SubscriptionService.Follow(entityId, *user)
+	writeSubscription(w, subscription, statusCode)
+}
+
+func (controller *ControllerImpl) UnfollowThread(w http.ResponseWriter, r *http.Request) {
+	user, entityId, ok := controller.entityRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, statusCode := controller.SubscriptionService.Unfollow(entityId, *user)
+	writeSubscription(w, subscription, statusCode)
+}
+
+// GetUnsubscribe shows the subscription named by the token query parameter
+// from a notification without changing it, so following the link does not
+// unsubscribe before the user confirms.
+func (controller *ControllerImpl) GetUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	subscription, statusCode := controller.SubscriptionService.GetTokenSubscription(token)
+	writeSubscription(w, subscription, statusCode)
+}
+
+// Unsubscribe unfollows the thread named by the token query parameter from a
+// notification, without authentication. It is the one-click unsubscribe of
+// RFC 8058.
+func (controller *ControllerImpl) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	subscription, statusCode := controller.SubscriptionService.Unsubscribe(token)
+	writeSubscription(w, subscription, statusCode)
+}
+
+func writeSubscription(w http.ResponseWriter, subscription *model.SubscriptionStatus, statusCode int) {
+	if !util.SuccsessfulStatus(statusCode) {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(subscription)
 }
 
 var CurrentController Controller
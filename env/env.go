@@ -1,6 +1,9 @@
 package env
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -11,48 +14,206 @@ func getEnv(key, fallback string) string {
 
 type Environment struct {
 	CommunityApiUrl     string
+	CommunityBaseUrl    string
 	CommunityCategoryId string
 	ThreadBotUid        string
-	ReadApiToken        string
-	WriteApiToken       string
+	SecretsDirectory    string
 	SparqlServiceUrl    string
+	SparqlUpdateUrl     string
+	FeedbackGraph       string
+	SparqlUpdateTries   string
+	SparqlUpdateDelay   string
 	KeycloakHost        string
 	FdkBaseUri          string
 	FirestoreCollection string
+	EventBus            string
+	EventCollection     string
+	EventCounters       string
+	EventRetention      string
+	EventHeartbeat      string
+	RevisionCollection  string
+	ModeratorAuthority  string
+	ReportCollection    string
+	ReportHideThreshold string
+	Premoderation       string
+	PendingCollection   string
+	ApprovedCollection  string
+	HistoryCollection   string
+	MaxContentLength    string
+	MaxRepeatedChars    string
+	MaxLinks            string
+	RepeatWindow        string
+	BlockedDomains      string
+	BannedWordsNb       string
+	BannedWordsNn       string
+	BannedWordsEn       string
+	PersonalDataAction  string
+	ErasurePolicy       string
+	ErasureAnonymousUid string
+	ErasureCollection   string
+	IssueCollection     string
+	RatingCollection    string
+	UsefulCollection    string
+	UsefulNonceTtl      string
+	UsefulIpLimit       string
+	UsefulIpWindow      string
+	PostIndexCollection string
+	NotifyCollection    string
+	NotifySettings      string
+	NotifyThrottle      string
+	NotifyFrom          string
+	SmtpHost            string
+	SmtpPort            string
+	SmtpUsername        string
+	FollowCollection    string
+	UnsubscribeUrl      string
+	UnsubscribeTtl      string
 }
 
 type Constants struct {
 	PingPath           string
 	CurrentUserPath    string
 	ThreadPath         string
+	EventsPath         string
+	VotesPath          string
+	RevisionsPath      string
+	ReportPath         string
+	StatusPath         string
+	ReportsPath        string
+	PendingPath        string
+	ApprovePath        string
+	RejectPath         string
+	ResolvePath        string
+	ExportPath         string
+	UsersPath          string
+	ErasurePath        string
+	ErasuresPath       string
+	AnnotationsPath    string
+	RatingsPath        string
+	UsefulnessPath     string
+	StatisticsPath     string
+	OrganizationsPath  string
+	NotificationsPath  string
+	SubscriptionsPath  string
+	UnsubscribePath    string
+	MaxReportLength    int
+	MaxCommentLength   int
+	MaxReasonLength    int
 	UserByEmailPath    string
 	TopicPath          string
 	TopicsPath         string
-	ThreadSlugPath     string
 	PostsPath          string
+	UserPath           string
 	FirestoreProjectId string
+	EventBufferSize    int
+	MaxPageSize        int
+	DefaultPageSize    int
+	ErasureProgress    int
+	ErasureChunk       int
+	ErasureLease       time.Duration
+	GraphStoreQueue    int
+	NotificationQueue  int
 }
 
 var EnvironmentVariables = Environment{
 	CommunityApiUrl:     getEnv("COMMUNITY_API_URL", "https://community.staging.fellesdatakatalog.digdir.no/api/"),
+	CommunityBaseUrl:    getEnv("COMMUNITY_BASE_URL", "https://community.staging.fellesdatakatalog.digdir.no/"),
 	CommunityCategoryId: getEnv("COMMUNITY_CATEGORY_ID", "25"),
 	ThreadBotUid:        getEnv("TOPIC_BOT_UID", "1"),
-	ReadApiToken:        getEnv("READ_API_TOKEN", ""),
-	WriteApiToken:       getEnv("WRITE_API_TOKEN", ""),
+	SecretsDirectory:    getEnv("SECRETS_DIRECTORY", ""),
 	SparqlServiceUrl:    getEnv("SPARQL_SERVICE_URL", "https://sparql.staging.fellesdatakatalog.digdir.no"),
+	SparqlUpdateUrl:     getEnv("SPARQL_UPDATE_URL", ""),
+	FeedbackGraph:       getEnv("FEEDBACK_GRAPH", "https://data.norge.no/graphs/user-feedback"),
+	SparqlUpdateTries:   getEnv("SPARQL_UPDATE_ATTEMPTS", "5"),
+	SparqlUpdateDelay:   getEnv("SPARQL_UPDATE_RETRY_DELAY", "1s"),
 	KeycloakHost:        getEnv("KEYCLOAK_HOST", "https://sso.staging.fellesdatakatalog.digdir.no/"),
 	FdkBaseUri:          getEnv("FDK_BASE_URI", "https://www.staging.fellesdatakatalog.digdir.no/"),
 	FirestoreCollection: getEnv("FIRESTORE_COLLECTION", "threadIds_staging"),
+	EventBus:            getEnv("EVENT_BUS", "memory"),
+	EventCollection:     getEnv("FIRESTORE_EVENT_COLLECTION", "threadEvents_staging"),
+	EventCounters:       getEnv("FIRESTORE_EVENT_COUNTER_COLLECTION", "threadEventCounters_staging"),
+	EventRetention:      getEnv("EVENT_RETENTION", "24h"),
+	EventHeartbeat:      getEnv("EVENT_HEARTBEAT", "15s"),
+	RevisionCollection:  getEnv("FIRESTORE_REVISION_COLLECTION", "postRevisions_staging"),
+	ModeratorAuthority:  getEnv("MODERATOR_AUTHORITY", "system:root:admin"),
+	ReportCollection:    getEnv("FIRESTORE_REPORT_COLLECTION", "postReports_staging"),
+	ReportHideThreshold: getEnv("REPORT_HIDE_THRESHOLD", "3"),
+	Premoderation:       getEnv("PREMODERATION", "false"),
+	PendingCollection:   getEnv("FIRESTORE_PENDING_COLLECTION", "pendingPosts_staging"),
+	ApprovedCollection:  getEnv("FIRESTORE_APPROVED_COLLECTION", "approvedUsers_staging"),
+	HistoryCollection:   getEnv("FIRESTORE_CONTENT_HISTORY_COLLECTION", "contentHistory_staging"),
+	MaxContentLength:    getEnv("CONTENT_MAX_LENGTH", "5000"),
+	MaxRepeatedChars:    getEnv("CONTENT_MAX_REPEATED_CHARS", "20"),
+	MaxLinks:            getEnv("CONTENT_MAX_LINKS", "3"),
+	RepeatWindow:        getEnv("CONTENT_REPEAT_WINDOW", "24h"),
+	BlockedDomains:      getEnv("CONTENT_BLOCKED_DOMAINS", ""),
+	BannedWordsNb:       getEnv("BANNED_WORDS_NB", ""),
+	BannedWordsNn:       getEnv("BANNED_WORDS_NN", ""),
+	BannedWordsEn:       getEnv("BANNED_WORDS_EN", ""),
+	PersonalDataAction:  getEnv("PERSONAL_DATA_ACTION", "mask"),
+	ErasurePolicy:       getEnv("ERASURE_POLICY", "purge"),
+	ErasureAnonymousUid: getEnv("ERASURE_ANONYMOUS_UID", ""),
+	ErasureCollection:   getEnv("FIRESTORE_ERASURE_COLLECTION", "erasures_staging"),
+	IssueCollection:     getEnv("FIRESTORE_ISSUE_COLLECTION", "dataQualityIssues_staging"),
+	RatingCollection:    getEnv("FIRESTORE_RATING_COLLECTION", "ratings_staging"),
+	UsefulCollection:    getEnv("FIRESTORE_USEFULNESS_COLLECTION", "usefulnessVotes_staging"),
+	UsefulNonceTtl:      getEnv("USEFULNESS_NONCE_TTL", "1h"),
+	UsefulIpLimit:       getEnv("USEFULNESS_IP_LIMIT", "20"),
+	UsefulIpWindow:      getEnv("USEFULNESS_IP_WINDOW", "1h"),
+	PostIndexCollection: getEnv("FIRESTORE_POST_INDEX_COLLECTION", "postIndex_staging"),
+	NotifyCollection:    getEnv("FIRESTORE_NOTIFICATION_COLLECTION", "notifications_staging"),
+	NotifySettings:      getEnv("FIRESTORE_NOTIFICATION_SETTINGS_COLLECTION", "notificationSettings_staging"),
+	NotifyThrottle:      getEnv("NOTIFICATION_THROTTLE", "1h"),
+	NotifyFrom:          getEnv("NOTIFICATION_FROM", "noreply@fellesdatakatalog.digdir.no"),
+	SmtpHost:            getEnv("SMTP_HOST", ""),
+	SmtpPort:            getEnv("SMTP_PORT", "587"),
+	SmtpUsername:        getEnv("SMTP_USERNAME", ""),
+	FollowCollection:    getEnv("FIRESTORE_SUBSCRIPTION_COLLECTION", "threadSubscriptions_staging"),
+	UnsubscribeUrl:      getEnv("UNSUBSCRIBE_URL", "https://europe-west1-digdir-cloud-functions.cloudfunctions.net/user-feedback-service-staging/unsubscribe"),
+	UnsubscribeTtl:      getEnv("UNSUBSCRIBE_TOKEN_TTL", "720h"),
 }
 
 var ConstantValues = Constants{
 	PingPath:           "ping",
 	CurrentUserPath:    "current-user",
 	ThreadPath:         "thread",
+	EventsPath:         "events",
+	VotesPath:          "votes",
+	RevisionsPath:      "revisions",
+	ReportPath:         "report",
+	StatusPath:         "status",
+	ReportsPath:        "reports",
+	PendingPath:        "pending",
+	ApprovePath:        "approve",
+	RejectPath:         "reject",
+	ResolvePath:        "resolve",
+	ExportPath:         "export",
+	UsersPath:          "users",
+	ErasurePath:        "erasure",
+	ErasuresPath:       "erasures",
+	AnnotationsPath:    "annotations",
+	RatingsPath:        "ratings",
+	UsefulnessPath:     "usefulness",
+	StatisticsPath:     "statistics",
+	OrganizationsPath:  "organizations",
+	NotificationsPath:  "notifications",
+	SubscriptionsPath:  "subscriptions",
+	UnsubscribePath:    "unsubscribe",
+	MaxReportLength:    1000,
+	MaxCommentLength:   1000,
+	MaxReasonLength:    200,
 	UserByEmailPath:    "/user/email/",
 	TopicPath:          "/topic/",
 	TopicsPath:         "/v3/topics/",
-	ThreadSlugPath:     "/thread-slug/",
 	PostsPath:          "/v3/posts/",
+	UserPath:           "/user/",
 	FirestoreProjectId: "digdir-cloud-functions",
+	EventBufferSize:    100,
+	MaxPageSize:        100,
+	DefaultPageSize:    20,
+	ErasureProgress:    20,
+	ErasureChunk:       200,
+	ErasureLease:       5 * time.Minute,
+	GraphStoreQueue:    1000,
+	NotificationQueue:  1000,
 }
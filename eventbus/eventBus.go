@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+// EventBus fans post events out to every subscriber of a thread.
+//
+// Subscribe with a nil lastEventId only receives events published after the
+// subscription. A non-nil lastEventId replays retained events after that id
+// first; an empty id replays everything retained for the thread.
+type EventBus interface {
+	Publish(threadId string, event model.PostEvent) error
+	Subscribe(ctx context.Context, threadId string, lastEventId *string) (<-chan model.PostEvent, error)
+}
+
+// InMemoryEventBus delivers events within a single instance and keeps the
+// last BufferSize events per thread for resuming streams.
+type InMemoryEventBus struct {
+	BufferSize int
+
+	mutex        sync.Mutex
+	lastSequence int64
+	history      map[string][]model.PostEvent
+	subscribers  map[string]map[chan model.PostEvent]struct{}
+}
+
+func NewInMemoryEventBus(bufferSize int) *InMemoryEventBus {
+	return &InMemoryEventBus{
+		BufferSize:  bufferSize,
+		history:     map[string][]model.PostEvent{},
+		subscribers: map[string]map[chan model.PostEvent]struct{}{},
+	}
+}
+
+func (bus *InMemoryEventBus) Publish(threadId string, event model.PostEvent) error {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.lastSequence = nextSequence(bus.lastSequence)
+	event.Sequence = bus.lastSequence
+	event.EventId = model.EventIdFromSequence(event.Sequence)
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixMilli()
+	}
+
+	history := append(bus.history[threadId], event)
+	if len(history) > bus.BufferSize {
+		history = history[len(history)-bus.BufferSize:]
+	}
+	bus.history[threadId] = history
+
+	for subscriber := range bus.subscribers[threadId] {
+		select {
+		case subscriber <- event:
+		default:
+			log.Printf("Dropped event %s for slow subscriber on thread %s\n", event.EventId, threadId)
+		}
+	}
+
+	return nil
+}
+
+func (bus *InMemoryEventBus) Subscribe(ctx context.Context, threadId string, lastEventId *string) (<-chan model.PostEvent, error) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	subscriber := make(chan model.PostEvent, bus.BufferSize+1)
+	if lastEventId != nil {
+		after, _ := model.ParseEventSequence(*lastEventId)
+		for _, event := range bus.history[threadId] {
+			if event.Sequence > after {
+				subscriber <- event
+			}
+		}
+	}
+
+	if bus.subscribers[threadId] == nil {
+		bus.subscribers[threadId] = map[chan model.PostEvent]struct{}{}
+	}
+	bus.subscribers[threadId][subscriber] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		bus.mutex.Lock()
+		delete(bus.subscribers[threadId], subscriber)
+		if len(bus.subscribers[threadId]) == 0 {
+			delete(bus.subscribers, threadId)
+		}
+		close(subscriber)
+		bus.mutex.Unlock()
+	}()
+
+	return subscriber, nil
+}
+
+// nextSequence returns a sequence based on the current time in nanoseconds,
+// so ids stay comparable across restarts, while remaining strictly
+// increasing within this instance.
+func nextSequence(last int64) int64 {
+	now := time.Now().UnixNano()
+	if now <= last {
+		return last + 1
+	}
+	return now
+}
+
+var CurrentEventBus EventBus
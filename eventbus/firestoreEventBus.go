@@ -0,0 +1,185 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreEventBus shares events between instances by writing them to a
+// Firestore collection and listening to it with snapshot queries. Events are
+// numbered per thread by a counter in FirestoreCounterCollectionId, so event
+// ids are strictly increasing whichever instance publishes them. Events are
+// kept for Retention, by a TTL policy on their expireAt field, and are not
+// replayed once expired.
+type FirestoreEventBus struct {
+	FirestoreProjectId           string
+	FirestoreCollectionId        string
+	FirestoreCounterCollectionId string
+	Retention                    time.Duration
+}
+
+type firestoreEvent struct {
+	ThreadId  string    `firestore:"threadId"`
+	Sequence  int64     `firestore:"sequence"`
+	Type      string    `firestore:"type"`
+	Post      string    `firestore:"post"`
+	Timestamp int64     `firestore:"timestamp"`
+	ExpireAt  time.Time `firestore:"expireAt"`
+}
+
+type eventCounter struct {
+	Sequence int64 `firestore:"sequence"`
+}
+
+func (bus *FirestoreEventBus) Publish(threadId string, event model.PostEvent) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, bus.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	post, err := json.Marshal(event.Post)
+	if err != nil {
+		return err
+	}
+
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixMilli()
+	}
+
+	counter := firestoreClient.Collection(bus.FirestoreCounterCollectionId).Doc(threadId)
+	events := firestoreClient.Collection(bus.FirestoreCollectionId)
+	return firestoreClient.RunTransaction(ctx, func(ctx context.Context, transaction *firestore.Transaction) error {
+		sequence, err := counterSequence(transaction.Get(counter))
+		if err != nil {
+			return err
+		}
+		sequence++
+
+		if err := transaction.Set(counter, eventCounter{Sequence: sequence}); err != nil {
+			return err
+		}
+		return transaction.Create(events.Doc(threadId+"_"+model.EventIdFromSequence(sequence)), firestoreEvent{
+			ThreadId:  threadId,
+			Sequence:  sequence,
+			Type:      string(event.Type),
+			Post:      string(post),
+			Timestamp: event.Timestamp,
+			ExpireAt:  time.Now().Add(bus.Retention),
+		})
+	})
+}
+
+func (bus *FirestoreEventBus) Subscribe(ctx context.Context, threadId string, lastEventId *string) (<-chan model.PostEvent, error) {
+	firestoreClient, err := firestore.NewClient(ctx, bus.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	var after int64
+	if lastEventId != nil {
+		after, _ = model.ParseEventSequence(*lastEventId)
+	} else {
+		after, err = counterSequence(firestoreClient.Collection(bus.FirestoreCounterCollectionId).Doc(threadId).Get(ctx))
+		if err != nil {
+			firestoreClient.Close()
+			return nil, err
+		}
+	}
+
+	snapshots := firestoreClient.Collection(bus.FirestoreCollectionId).
+		Where("threadId", "==", threadId).
+		Where("sequence", ">", after).
+		OrderBy("sequence", firestore.Asc).
+		Snapshots(ctx)
+
+	subscriber := make(chan model.PostEvent)
+	go func() {
+		defer firestoreClient.Close()
+		defer snapshots.Stop()
+		defer close(subscriber)
+
+		for {
+			snapshot, err := snapshots.Next()
+			if err != nil {
+				if status.Code(err) != codes.Canceled && ctx.Err() == nil {
+					log.Println("Error on event snapshot.\n[ERROR] -", err)
+				}
+				return
+			}
+
+			for _, change := range snapshot.Changes {
+				if change.Kind != firestore.DocumentAdded {
+					continue
+				}
+
+				event, expired, err := toPostEvent(change.Doc)
+				if err != nil {
+					log.Println("Error on event unmarshal.\n[ERROR] -", err)
+					continue
+				}
+				if expired {
+					continue
+				}
+
+				select {
+				case subscriber <- *event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return subscriber, nil
+}
+
+// toPostEvent reads a stored event, and tells whether it has expired but is
+// not yet deleted by the TTL policy.
+func toPostEvent(doc *firestore.DocumentSnapshot) (*model.PostEvent, bool, error) {
+	var stored firestoreEvent
+	if err := doc.DataTo(&stored); err != nil {
+		return nil, false, err
+	}
+
+	var post model.Post
+	if err := json.Unmarshal([]byte(stored.Post), &post); err != nil {
+		return nil, false, err
+	}
+
+	threadId := stored.ThreadId
+	return &model.PostEvent{
+		EventId:   model.EventIdFromSequence(stored.Sequence),
+		Sequence:  stored.Sequence,
+		Type:      model.PostEventType(stored.Type),
+		ThreadId:  &threadId,
+		Post:      &post,
+		Timestamp: stored.Timestamp,
+	}, !stored.ExpireAt.IsZero() && stored.ExpireAt.Before(time.Now()), nil
+}
+
+// counterSequence returns the last sequence of a thread, which is 0 before
+// its first event.
+func counterSequence(snapshot *firestore.DocumentSnapshot, err error) (int64, error) {
+	if status.Code(err) == codes.NotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var counter eventCounter
+	if err := snapshot.DataTo(&counter); err != nil {
+		return 0, err
+	}
+	return counter.Sequence, nil
+}
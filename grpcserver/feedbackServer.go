@@ -0,0 +1,420 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/feedbackpb"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadataKey = "authorization"
+
+type FeedbackServerImpl struct {
+	feedbackpb.UnimplementedFeedbackServiceServer
+	AuthService     service.AuthService
+	ThreadIdService service.ThreadIdService
+	ThreadService   service.ThreadService
+	// ScreeningService is optional, content is not screened without it.
+	ScreeningService service.ContentScreeningService
+	// SubscriptionService is optional, authors follow the threads they post
+	// in with it.
+	SubscriptionService service.SubscriptionService
+}
+
+func (server *FeedbackServerImpl) GetThread(ctx context.Context, request *feedbackpb.GetThreadRequest) (*feedbackpb.Thread, error) {
+	if request.GetEntityId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity_id is required")
+	}
+
+	sort, ok := model.ParseThreadSort(request.GetSort())
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, model.ErrInvalidSort.Error())
+	}
+
+	query := model.ThreadQuery{
+		Page: optionalIndex(request.GetPage()),
+		Sort: sort,
+	}
+	if request.GetPageSize() != 0 {
+		if request.GetPageSize() < 1 || int(request.GetPageSize()) > env.ConstantValues.MaxPageSize {
+			return nil, status.Error(codes.InvalidArgument, model.ErrInvalidPageSize.Error())
+		}
+		pageSize := int(request.GetPageSize())
+		query.PageSize = &pageSize
+	}
+	if request.GetCursor() != "" {
+		cursor, err := model.ParseThreadCursor(request.GetCursor())
+		if err != nil || (request.GetSort() != "" && cursor.Sort != sort) {
+			return nil, status.Error(codes.InvalidArgument, model.ErrInvalidCursor.Error())
+		}
+		query.Page = nil
+		query.Sort = cursor.Sort
+		query.Cursor = cursor
+	}
+
+	if user, err := server.authenticate(ctx); err == nil {
+		query.ViewerUid = user.UserId
+	}
+
+	thread, statusCode := server.ThreadService.GetThreadByEntityId(request.GetEntityId(), query)
+	if !util.SuccsessfulStatus(statusCode) || thread == nil {
+		return nil, statusError(statusCode)
+	}
+
+	return toThreadMessage(thread), nil
+}
+
+func (server *FeedbackServerImpl) GetPost(ctx context.Context, request *feedbackpb.GetPostRequest) (*feedbackpb.Post, error) {
+	if request.GetEntityId() == "" || request.GetPostId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity_id and post_id are required")
+	}
+
+	var viewerUid *string
+	if user, err := server.authenticate(ctx); err == nil {
+		viewerUid = user.UserId
+	}
+
+	post, statusCode := server.ThreadService.GetThreadPostByEntityId(request.GetEntityId(), request.GetPostId(), viewerUid)
+	if !util.SuccsessfulStatus(statusCode) || post == nil {
+		return nil, statusError(statusCode)
+	}
+
+	return toPostMessage(post), nil
+}
+
+func (server *FeedbackServerImpl) CreatePost(ctx context.Context, request *feedbackpb.CreatePostRequest) (*feedbackpb.Post, error) {
+	user, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.GetEntityId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity_id is required")
+	}
+
+	if err := server.screenContent(model.Post{UserId: user.UserId, Content: optionalString(request.GetContent())}); err != nil {
+		return nil, err
+	}
+
+	created, statusCode := server.ThreadService.CreatePostForEntityId(model.Post{
+		UserId:   user.UserId,
+		Content:  optionalString(request.GetContent()),
+		ToPostId: optionalString(request.GetToPostId()),
+	}, request.GetEntityId())
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusError(statusCode)
+	}
+
+	if server.ScreeningService != nil && created != nil {
+		server.ScreeningService.RecordPost(model.Post{PostId: created.PostId, UserId: user.UserId, Content: optionalString(request.GetContent())})
+	}
+	if server.SubscriptionService != nil {
+		server.SubscriptionService.Follow(request.GetEntityId(), *user)
+	}
+
+	return toPostMessage(created), nil
+}
+
+func (server *FeedbackServerImpl) UpdatePost(ctx context.Context, request *feedbackpb.UpdatePostRequest) (*feedbackpb.Post, error) {
+	user, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	threadId, err := server.threadId(request.GetEntityId(), request.GetPostId())
+	if err != nil {
+		return nil, err
+	}
+
+	postId := request.GetPostId()
+	if err := server.screenContent(model.Post{PostId: &postId, UserId: user.UserId, Content: optionalString(request.GetContent())}); err != nil {
+		return nil, err
+	}
+
+	updated, statusCode := server.ThreadService.UpdateThreadPost(model.Post{
+		PostId:   &postId,
+		UserId:   user.UserId,
+		ThreadId: threadId,
+		Content:  optionalString(request.GetContent()),
+		ToPostId: optionalString(request.GetToPostId()),
+	})
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusError(statusCode)
+	}
+
+	if server.ScreeningService != nil {
+		server.ScreeningService.RecordPost(model.Post{PostId: &postId, UserId: user.UserId, Content: optionalString(request.GetContent())})
+	}
+
+	return toPostMessage(updated), nil
+}
+
+func (server *FeedbackServerImpl) DeletePost(ctx context.Context, request *feedbackpb.DeletePostRequest) (*feedbackpb.DeletePostResponse, error) {
+	user, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	threadId, err := server.threadId(request.GetEntityId(), request.GetPostId())
+	if err != nil {
+		return nil, err
+	}
+
+	postId := request.GetPostId()
+	statusCode := server.ThreadService.DeleteThreadPost(model.Post{
+		PostId:   &postId,
+		UserId:   user.UserId,
+		ThreadId: threadId,
+	})
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusError(statusCode)
+	}
+
+	return &feedbackpb.DeletePostResponse{}, nil
+}
+
+func (server *FeedbackServerImpl) VotePost(ctx context.Context, request *feedbackpb.VotePostRequest) (*feedbackpb.Post, error) {
+	user, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.GetEntityId() == "" || request.GetPostId() == "" || user.UserId == nil {
+		return nil, status.Error(codes.InvalidArgument, "entity_id and post_id are required")
+	}
+
+	voted, statusCode := server.ThreadService.VotePostForEntityId(request.GetEntityId(), request.GetPostId(), *user.UserId, request.GetUpvote())
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusError(statusCode)
+	}
+
+	return toPostMessage(voted), nil
+}
+
+func (server *FeedbackServerImpl) CurrentUser(ctx context.Context, request *feedbackpb.CurrentUserRequest) (*feedbackpb.User, error) {
+	user, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserMessage(user), nil
+}
+
+func (server *FeedbackServerImpl) BatchCountPosts(ctx context.Context, request *feedbackpb.BatchCountPostsRequest) (*feedbackpb.BatchCountPostsResponse, error) {
+	if len(request.GetEntityIds()) == 0 || len(request.GetEntityIds()) > env.ConstantValues.MaxPageSize {
+		return nil, status.Error(codes.InvalidArgument, model.ErrInvalidEntityIds.Error())
+	}
+
+	counts, statusCode := server.ThreadService.CountPostsByEntityIds(request.GetEntityIds())
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusError(statusCode)
+	}
+
+	response := &feedbackpb.BatchCountPostsResponse{Counts: make(map[string]int32, len(counts))}
+	for entityId, count := range counts {
+		response.Counts[entityId] = int32(count)
+	}
+
+	return response, nil
+}
+
+func (server *FeedbackServerImpl) authenticate(ctx context.Context) (*model.User, error) {
+	var jwt string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationMetadataKey); len(values) > 0 {
+			jwt = values[0]
+		}
+	}
+	if jwt == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	user, statusCode := server.AuthService.AuthenticateAndGetUser(jwt)
+	if statusCode != http.StatusOK {
+		return nil, statusError(statusCode)
+	}
+
+	if user == nil {
+		return nil, statusError(http.StatusUnauthorized)
+	}
+
+	return user, nil
+}
+
+func (server *FeedbackServerImpl) threadId(entityId string, postId string) (*string, error) {
+	if entityId == "" || postId == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity_id and post_id are required")
+	}
+
+	threadId, err := server.ThreadIdService.GetThreadId(entityId)
+	if err != nil || threadId == nil {
+		return nil, statusError(http.StatusNotFound)
+	}
+
+	return threadId, nil
+}
+
+func statusError(statusCode int) error {
+	var code codes.Code
+	switch statusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusUnprocessableEntity:
+		code = codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, http.StatusText(statusCode))
+}
+
+// screenContent returns a FailedPrecondition error with a bad request detail
+// per screening rule the post breaks, or nil if it may be published.
+func (server *FeedbackServerImpl) screenContent(post model.Post) error {
+	if server.ScreeningService == nil || post.Content == nil {
+		return nil
+	}
+
+	violations := server.ScreeningService.ScreenPost(post)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "content",
+			Description: string(violation.Rule) + ": " + violation.Message,
+		})
+	}
+
+	rejected := status.New(codes.FailedPrecondition, "content rejected by screening")
+	if detailed, err := rejected.WithDetails(badRequest); err == nil {
+		rejected = detailed
+	}
+	return rejected.Err()
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func optionalIndex(value int32) *string {
+	if value < 1 {
+		return nil
+	}
+	index := strconv.Itoa(int(value))
+	return &index
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func intValue(value *int) int64 {
+	if value == nil {
+		return 0
+	}
+	return int64(*value)
+}
+
+func toUserMessage(user *model.User) *feedbackpb.User {
+	if user == nil {
+		return nil
+	}
+
+	return &feedbackpb.User{
+		UserId:      stringValue(user.UserId),
+		Username:    stringValue(user.Username),
+		Displayname: stringValue(user.Displayname),
+		Userslug:    stringValue(user.Userslug),
+		Picture:     stringValue(user.Picture),
+		IconText:    stringValue(user.IconText),
+		IconBgColor: stringValue(user.IconBgColor),
+	}
+}
+
+func toPostMessage(post *model.Post) *feedbackpb.Post {
+	if post == nil {
+		return nil
+	}
+
+	return &feedbackpb.Post{
+		PostId:          stringValue(post.PostId),
+		UserId:          stringValue(post.UserId),
+		ThreadId:        stringValue(post.ThreadId),
+		Index:           stringValue(post.Index),
+		Content:         stringValue(post.Content),
+		ToPostId:        stringValue(post.ToPostId),
+		Timestamp:       intValue(post.Timestamp),
+		User:            toUserMessage(post.UserInfo),
+		Votes:           int32(intValue(post.Votes)),
+		Upvoted:         post.Upvoted != nil && *post.Upvoted,
+		Edited:          post.Edited != nil && *post.Edited,
+		EditedTimestamp: intValue(post.EditedAt),
+		PendingId:       stringValue(post.PendingId),
+	}
+}
+
+func toThreadMessage(thread *model.Thread) *feedbackpb.Thread {
+	if thread == nil {
+		return nil
+	}
+
+	posts := make([]*feedbackpb.Post, 0, len(thread.Posts))
+	for _, post := range thread.Posts {
+		posts = append(posts, toPostMessage(post))
+	}
+
+	message := &feedbackpb.Thread{
+		ThreadId:  stringValue(thread.ThreadId),
+		Title:     stringValue(thread.Title),
+		Posts:     posts,
+		Timestamp: intValue(thread.Timestamp),
+	}
+
+	for _, post := range thread.PendingPosts {
+		message.PendingPosts = append(message.PendingPosts, toPostMessage(post))
+	}
+
+	if thread.Pagination != nil {
+		message.Pagination = &feedbackpb.Pagination{
+			CurrentPage: int32(intValue(thread.Pagination.CurrentPage)),
+			PageCount:   int32(intValue(thread.Pagination.PageCount)),
+			TotalPosts:  int32(intValue(thread.Pagination.TotalPosts)),
+			Sort:        stringValue(thread.Pagination.Sort),
+			PageSize:    int32(intValue(thread.Pagination.PageSize)),
+			NextCursor:  stringValue(thread.Pagination.NextCursor),
+			PrevCursor:  stringValue(thread.Pagination.PrevCursor),
+		}
+	}
+
+	return message
+}
+
+var CurrentFeedbackServer feedbackpb.FeedbackServiceServer
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+)
+
+// ThreadCursor marks a post in a thread to continue reading from. Posts are
+// compared by timestamp and post id, so a cursor keeps pointing at the same
+// place when posts are added to the thread. The index is only a hint for
+// which page of the thread the post is on.
+type ThreadCursor struct {
+	Sort      ThreadSort
+	Backward  bool
+	Index     int
+	Timestamp int
+	PostId    int
+}
+
+type threadCursorDTO struct {
+	Sort      string `json:"s"`
+	Backward  bool   `json:"b,omitempty"`
+	Index     int    `json:"i"`
+	Timestamp int    `json:"t"`
+	PostId    int    `json:"p"`
+}
+
+// CursorForPost returns a cursor reading on from post in the given direction,
+// or nil if the post lacks the fields a cursor is built from.
+func CursorForPost(post *Post, sort ThreadSort, backward bool) *ThreadCursor {
+	if post == nil || post.PostId == nil || post.Timestamp == nil {
+		return nil
+	}
+
+	postId, err := strconv.Atoi(*post.PostId)
+	if err != nil {
+		return nil
+	}
+
+	var index int
+	if post.Index != nil {
+		index, _ = strconv.Atoi(*post.Index)
+	}
+
+	return &ThreadCursor{
+		Sort:      sort,
+		Backward:  backward,
+		Index:     index,
+		Timestamp: *post.Timestamp,
+		PostId:    postId,
+	}
+}
+
+func ParseThreadCursor(token string) (*ThreadCursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var dto threadCursorDTO
+	if err := json.Unmarshal(bytes, &dto); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	sort, ok := ParseThreadSort(dto.Sort)
+	if !ok || sort == MostVotes || dto.Index < 0 || dto.PostId < 1 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &ThreadCursor{
+		Sort:      sort,
+		Backward:  dto.Backward,
+		Index:     dto.Index,
+		Timestamp: dto.Timestamp,
+		PostId:    dto.PostId,
+	}, nil
+}
+
+func (cursor *ThreadCursor) String() string {
+	bytes, _ := json.Marshal(threadCursorDTO{
+		Sort:      cursor.Sort.String(),
+		Backward:  cursor.Backward,
+		Index:     cursor.Index,
+		Timestamp: cursor.Timestamp,
+		PostId:    cursor.PostId,
+	})
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// Precedes reports whether post comes before the cursor position in the
+// cursor's sort order. Posts without a timestamp or id are never before it.
+func (cursor *ThreadCursor) Precedes(post *Post) bool {
+	timestamp, postId, ok := postPosition(post)
+	if !ok {
+		return false
+	}
+
+	if cursor.Sort == NewestFirst {
+		return timestamp > cursor.Timestamp || (timestamp == cursor.Timestamp && postId > cursor.PostId)
+	}
+	return timestamp < cursor.Timestamp || (timestamp == cursor.Timestamp && postId < cursor.PostId)
+}
+
+// Follows reports whether post comes after the cursor position in the
+// cursor's sort order.
+func (cursor *ThreadCursor) Follows(post *Post) bool {
+	timestamp, postId, ok := postPosition(post)
+	if !ok {
+		return false
+	}
+	if timestamp == cursor.Timestamp && postId == cursor.PostId {
+		return false
+	}
+	return !cursor.Precedes(post)
+}
+
+// Before reports whether post a is listed before post b in the sort order.
+// Posts ordered by votes have no stable position and are never reordered.
+func (s ThreadSort) Before(a *Post, b *Post) bool {
+	if s == MostVotes {
+		return false
+	}
+
+	timestamp, postId, ok := postPosition(b)
+	if !ok {
+		return false
+	}
+
+	cursor := ThreadCursor{Sort: s, Timestamp: timestamp, PostId: postId}
+	return cursor.Precedes(a)
+}
+
+// WithCursors returns a copy of the pagination with cursors reading on from
+// the first and last of posts. Posts ordered by votes get no cursors.
+func (pagination *Pagination) WithCursors(posts []*Post, sort ThreadSort) *Pagination {
+	var applied Pagination
+	if pagination != nil {
+		applied = *pagination
+	}
+
+	if sort == MostVotes || len(posts) == 0 {
+		return &applied
+	}
+
+	if prev := CursorForPost(posts[0], sort, true); prev != nil {
+		token := prev.String()
+		applied.PrevCursor = &token
+	}
+	if next := CursorForPost(posts[len(posts)-1], sort, false); next != nil {
+		token := next.String()
+		applied.NextCursor = &token
+	}
+
+	return &applied
+}
+
+func postPosition(post *Post) (int, int, bool) {
+	if post == nil || post.Timestamp == nil || post.PostId == nil {
+		return 0, 0, false
+	}
+
+	postId, err := strconv.Atoi(*post.PostId)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return *post.Timestamp, postId, true
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"fmt"
 	"log"
 	"regexp"
+	"time"
 )
 
 type EntityType int
@@ -156,3 +158,311 @@ func (e EntityType) StringNbPlural() string {
 		return ""
 	}
 }
+
+// Key identifies the entity type in query parameters and stored aggregates.
+func (e EntityType) Key() string {
+	switch e {
+	case Dataset:
+		return "dataset"
+	case DataService:
+		return "dataservice"
+	case Concept:
+		return "concept"
+	case InformationModel:
+		return "informationmodel"
+	case PublicService:
+		return "publicservice"
+	case Event:
+		return "event"
+	default:
+		return ""
+	}
+}
+
+type ThreadSort int
+
+const (
+	NewestFirst ThreadSort = iota
+	OldestFirst
+	MostVotes
+)
+
+func ParseThreadSort(str string) (ThreadSort, bool) {
+	switch str {
+	case "", "newest":
+		return NewestFirst, true
+	case "oldest":
+		return OldestFirst, true
+	case "most_votes":
+		return MostVotes, true
+	default:
+		return NewestFirst, false
+	}
+}
+
+func (s ThreadSort) String() string {
+	switch s {
+	case OldestFirst:
+		return "oldest"
+	case MostVotes:
+		return "most_votes"
+	default:
+		return "newest"
+	}
+}
+
+func (s ThreadSort) ToNodeBBSort() string {
+	switch s {
+	case OldestFirst:
+		return "oldest_to_newest"
+	case MostVotes:
+		return "most_votes"
+	default:
+		return "newest_to_oldest"
+	}
+}
+
+type ReportReason string
+
+const (
+	ReportSpam         ReportReason = "spam"
+	ReportOffensive    ReportReason = "offensive"
+	ReportPersonalData ReportReason = "personal_data"
+	ReportOffTopic     ReportReason = "off_topic"
+	ReportOther        ReportReason = "other"
+)
+
+func ParseReportReason(str string) (ReportReason, bool) {
+	switch reason := ReportReason(str); reason {
+	case ReportSpam, ReportOffensive, ReportPersonalData, ReportOffTopic, ReportOther:
+		return reason, true
+	default:
+		return "", false
+	}
+}
+
+type ReportStatus string
+
+const (
+	ReportOpen     ReportStatus = "open"
+	ReportResolved ReportStatus = "resolved"
+)
+
+type ScreeningRule string
+
+const (
+	RuleEmptyContent       ScreeningRule = "empty_content"
+	RuleMaxLength          ScreeningRule = "max_length"
+	RuleRepeatedCharacters ScreeningRule = "repeated_characters"
+	RuleMaxLinks           ScreeningRule = "max_links"
+	RuleBlockedDomain      ScreeningRule = "blocked_domain"
+	RuleBannedWord         ScreeningRule = "banned_word"
+	RuleRepeatedContent    ScreeningRule = "repeated_content"
+)
+
+type PersonalDataKind string
+
+const (
+	BirthNumber  PersonalDataKind = "birth_number"
+	DNumber      PersonalDataKind = "d_number"
+	PhoneNumber  PersonalDataKind = "phone_number"
+	EmailAddress PersonalDataKind = "email"
+	BankAccount  PersonalDataKind = "bank_account"
+)
+
+// PersonalDataAction is what happens to posts containing personal data.
+type PersonalDataAction string
+
+const (
+	AllowPersonalData    PersonalDataAction = "off"
+	RejectPersonalData   PersonalDataAction = "reject"
+	MaskPersonalData     PersonalDataAction = "mask"
+	ModeratePersonalData PersonalDataAction = "moderate"
+)
+
+func ParsePersonalDataAction(str string) (PersonalDataAction, bool) {
+	switch action := PersonalDataAction(str); action {
+	case AllowPersonalData, RejectPersonalData, MaskPersonalData, ModeratePersonalData:
+		return action, true
+	default:
+		return MaskPersonalData, false
+	}
+}
+
+// ErasurePolicy is what happens to the posts of a user whose feedback is
+// erased.
+type ErasurePolicy string
+
+const (
+	SoftDeleteErasure ErasurePolicy = "delete"
+	PurgeErasure      ErasurePolicy = "purge"
+	AnonymizeErasure  ErasurePolicy = "anonymize"
+)
+
+func ParseErasurePolicy(str string) (ErasurePolicy, bool) {
+	switch policy := ErasurePolicy(str); policy {
+	case SoftDeleteErasure, PurgeErasure, AnonymizeErasure:
+		return policy, true
+	default:
+		return PurgeErasure, false
+	}
+}
+
+type ErasureStatus string
+
+const (
+	ErasureRunning   ErasureStatus = "running"
+	ErasureCompleted ErasureStatus = "completed"
+	ErasureFailed    ErasureStatus = "failed"
+)
+
+// IssueCategory is the kind of data quality problem a structured post
+// reports.
+type IssueCategory string
+
+const (
+	BrokenLinkIssue        IssueCategory = "broken_link"
+	WrongLicenseIssue      IssueCategory = "wrong_license"
+	OutdatedDataIssue      IssueCategory = "outdated_data"
+	IncorrectMetadataIssue IssueCategory = "incorrect_metadata"
+	MissingDataIssue       IssueCategory = "missing_data"
+	AccessProblemIssue     IssueCategory = "access_problem"
+	OtherIssue             IssueCategory = "other"
+)
+
+func ParseIssueCategory(str string) (IssueCategory, bool) {
+	switch category := IssueCategory(str); category {
+	case BrokenLinkIssue, WrongLicenseIssue, OutdatedDataIssue, IncorrectMetadataIssue,
+		MissingDataIssue, AccessProblemIssue, OtherIssue:
+		return category, true
+	default:
+		return "", false
+	}
+}
+
+func (c IssueCategory) StringNb() string {
+	switch c {
+	case BrokenLinkIssue:
+		return "Lenke virker ikke"
+	case WrongLicenseIssue:
+		return "Feil lisens"
+	case OutdatedDataIssue:
+		return "Utdaterte data"
+	case IncorrectMetadataIssue:
+		return "Feil i metadata"
+	case MissingDataIssue:
+		return "Manglende data"
+	case AccessProblemIssue:
+		return "Problemer med tilgang"
+	default:
+		return "Annet"
+	}
+}
+
+type IssueSeverity string
+
+const (
+	LowSeverity    IssueSeverity = "low"
+	MediumSeverity IssueSeverity = "medium"
+	HighSeverity   IssueSeverity = "high"
+)
+
+// ParseIssueSeverity defaults to medium.
+func ParseIssueSeverity(str string) (IssueSeverity, bool) {
+	switch severity := IssueSeverity(str); severity {
+	case "":
+		return MediumSeverity, true
+	case LowSeverity, MediumSeverity, HighSeverity:
+		return severity, true
+	default:
+		return "", false
+	}
+}
+
+func (s IssueSeverity) StringNb() string {
+	switch s {
+	case LowSeverity:
+		return "Lav"
+	case HighSeverity:
+		return "Høy"
+	default:
+		return "Middels"
+	}
+}
+
+// IssueStatus is how far the publisher has come with an issue.
+type IssueStatus string
+
+const (
+	IssueOpen         IssueStatus = "open"
+	IssueAcknowledged IssueStatus = "acknowledged"
+	IssueResolved     IssueStatus = "resolved"
+	IssueWontFix      IssueStatus = "wont_fix"
+)
+
+func ParseIssueStatus(str string) (IssueStatus, bool) {
+	switch status := IssueStatus(str); status {
+	case IssueOpen, IssueAcknowledged, IssueResolved, IssueWontFix:
+		return status, true
+	default:
+		return "", false
+	}
+}
+
+// RdfFormat is a serialization of RDF that feedback is published in.
+type RdfFormat string
+
+const (
+	Turtle RdfFormat = "turtle"
+	JsonLd RdfFormat = "jsonld"
+	RdfXml RdfFormat = "rdfxml"
+)
+
+func ParseRdfFormat(str string) (RdfFormat, bool) {
+	switch format := RdfFormat(str); format {
+	case Turtle, JsonLd, RdfXml:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
+func (f RdfFormat) ContentType() string {
+	switch f {
+	case JsonLd:
+		return "application/ld+json"
+	case RdfXml:
+		return "application/rdf+xml"
+	default:
+		return "text/turtle"
+	}
+}
+
+type StatisticsPeriod string
+
+const (
+	WeekPeriod  StatisticsPeriod = "week"
+	MonthPeriod StatisticsPeriod = "month"
+)
+
+func ParseStatisticsPeriod(str string) (StatisticsPeriod, bool) {
+	switch period := StatisticsPeriod(str); period {
+	case "":
+		return MonthPeriod, true
+	case WeekPeriod, MonthPeriod:
+		return period, true
+	default:
+		return MonthPeriod, false
+	}
+}
+
+// Key names the period the time falls in, as an ISO week such as 2026-W42 or
+// a month such as 2026-10, in UTC.
+func (p StatisticsPeriod) Key(t time.Time) string {
+	t = t.UTC()
+	if p == WeekPeriod {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01")
+}
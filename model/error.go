@@ -4,3 +4,14 @@ import "errors"
 
 var ErrNoBytes = errors.New("no bytes received")
 var ErrBadResponse = errors.New("bad response code received")
+var ErrInvalidSort = errors.New("invalid sort, expected newest, oldest or most_votes")
+var ErrInvalidPageSize = errors.New("invalid pageSize")
+var ErrDuplicateReport = errors.New("post already reported by user")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidIssue = errors.New("invalid issue, expected a known category and severity")
+var ErrInvalidCategory = errors.New("invalid category")
+var ErrInvalidIssueStatus = errors.New("invalid status, expected open, acknowledged, resolved or wont_fix")
+var ErrInvalidEntityIds = errors.New("invalid entityId, expected 1 to 100 entity ids")
+var ErrInvalidNonce = errors.New("invalid or expired nonce")
+var ErrUsedNonce = errors.New("nonce already used")
+var ErrInvalidStatisticsQuery = errors.New("invalid statistics query, expected period week or month, dates as YYYY-MM-DD and a known entityType")
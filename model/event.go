@@ -0,0 +1,34 @@
+package model
+
+import "strconv"
+
+type PostEventType string
+
+const (
+	PostCreated PostEventType = "post_created"
+	PostUpdated PostEventType = "post_updated"
+	PostDeleted PostEventType = "post_deleted"
+)
+
+type PostEvent struct {
+	EventId   string        `json:"id"`
+	Sequence  int64         `json:"-"`
+	Type      PostEventType `json:"type"`
+	ThreadId  *string       `json:"tid"`
+	Post      *Post         `json:"post"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+// ParseEventSequence returns the sequence encoded in an event id, as sent back
+// by clients in the Last-Event-ID header.
+func ParseEventSequence(eventId string) (int64, bool) {
+	sequence, err := strconv.ParseInt(eventId, 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, false
+	}
+	return sequence, true
+}
+
+func EventIdFromSequence(sequence int64) string {
+	return strconv.FormatInt(sequence, 10)
+}
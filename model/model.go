@@ -1,12 +1,21 @@
 package model
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Entity struct {
 	EntityId     string `json:"id"`
 	Title        string `json:"title"`
 	Organization string
-	Type         EntityType
+	// PublisherId is the organization number of the responsible organization.
+	PublisherId string
+	// Uri is the URI of the resource in the catalog.
+	Uri  string
+	Type EntityType
+	// ContactEmail is the email of the contact point of the resource.
+	ContactEmail string
 }
 
 type User struct {
@@ -17,6 +26,13 @@ type User struct {
 	Picture     *string `json:"picture"`
 	IconText    *string `json:"icon:text"`
 	IconBgColor *string `json:"icon:bgColor"`
+	Moderator   bool    `json:"moderator"`
+	// Organizations are the organization numbers the user may answer for.
+	Organizations []string `json:"-"`
+	// AdminOrganizations are the organization numbers the user is admin for.
+	AdminOrganizations []string `json:"-"`
+	// Email is the address of the user in the access token.
+	Email string `json:"-"`
 }
 
 type Thread struct {
@@ -26,6 +42,8 @@ type Thread struct {
 	Timestamp  *int    `json:"timestamp"`
 	Content    *string
 	Pagination *Pagination `json:"pagination"`
+	// PendingPosts holds the reader's own posts awaiting moderation.
+	PendingPosts []*Post `json:"pendingPosts,omitempty"`
 }
 
 type Post struct {
@@ -38,6 +56,301 @@ type Post struct {
 	Timestamp *int    `json:"timestamp"`
 	Deleted   *bool   `json:"deleted"`
 	UserInfo  *User   `json:"user"`
+	Votes     *int    `json:"votes"`
+	Upvoted   *bool   `json:"upvoted,omitempty"`
+	Edited    *bool   `json:"edited"`
+	EditedAt  *int    `json:"editedTimestamp,omitempty"`
+	PendingId *string `json:"pendingId,omitempty"`
+	// Issue is set on posts reporting a data quality problem.
+	Issue *DataQualityIssue `json:"issue,omitempty"`
+	// OfficialAnswer is set on answers to an issue from the publisher of
+	// the entity.
+	OfficialAnswer bool `json:"officialAnswer,omitempty"`
+}
+
+// DataQualityIssue is the machine-readable part of a post reporting a data
+// quality problem with an entity. Field names the affected property, if any.
+// Every change of status is kept in Transitions, and the official answers
+// of the publisher in AnswerPostIds.
+type DataQualityIssue struct {
+	PostId        string            `json:"-" firestore:"pid"`
+	ThreadId      string            `json:"-" firestore:"tid"`
+	Category      IssueCategory     `json:"category" firestore:"category"`
+	Field         string            `json:"field,omitempty" firestore:"field"`
+	Severity      IssueSeverity     `json:"severity" firestore:"severity"`
+	Status        IssueStatus       `json:"status" firestore:"status"`
+	Transitions   []IssueTransition `json:"transitions,omitempty" firestore:"transitions"`
+	AnswerPostIds []string          `json:"answerPids,omitempty" firestore:"answerPids"`
+	Timestamp     int64             `json:"-" firestore:"timestamp"`
+}
+
+// IssueTransition is a change of the status of an issue, from From to Status.
+type IssueTransition struct {
+	From      IssueStatus `json:"from,omitempty" firestore:"from"`
+	Status    IssueStatus `json:"status" firestore:"status"`
+	ChangedBy string      `json:"uid" firestore:"uid"`
+	Comment   string      `json:"comment,omitempty" firestore:"comment"`
+	Timestamp int64       `json:"timestamp" firestore:"timestamp"`
+}
+
+// Rating is the score from 1 to 5 a user gives an entity.
+type Rating struct {
+	EntityId  string `json:"entityId" firestore:"entityId"`
+	UserId    string `json:"uid" firestore:"uid"`
+	Score     int    `json:"score" firestore:"score"`
+	Timestamp int64  `json:"timestamp" firestore:"timestamp"`
+}
+
+// RatingSummary aggregates the ratings of an entity. Distribution counts the
+// ratings of every score, and UserScore is the score of the reader, if any.
+type RatingSummary struct {
+	EntityId     string      `json:"entityId"`
+	Count        int         `json:"count"`
+	Mean         float64     `json:"mean"`
+	Distribution map[int]int `json:"distribution"`
+	UserScore    *int        `json:"userScore,omitempty"`
+}
+
+// UsefulnessVote is an anonymous answer to whether the page of an entity was
+// useful. VoteId is the id of the nonce the vote was cast with, and IpWindow
+// a pseudonym of the voter's IP address within the rate limit window.
+type UsefulnessVote struct {
+	VoteId     string `json:"-" firestore:"-"`
+	EntityId   string `json:"entityId" firestore:"entityId"`
+	EntityType string `json:"entityType" firestore:"entityType"`
+	Helpful    bool   `json:"helpful" firestore:"helpful"`
+	Reason     string `json:"reason,omitempty" firestore:"reason,omitempty"`
+	IpWindow   string `json:"-" firestore:"ipWindow"`
+	Timestamp  int64  `json:"timestamp" firestore:"timestamp"`
+}
+
+type UsefulnessVoteRequest struct {
+	Helpful *bool  `json:"helpful"`
+	Reason  string `json:"reason"`
+	Nonce   string `json:"nonce"`
+}
+
+// UsefulnessSummary counts the usefulness votes of an entity or of every
+// entity of a type. Nonce is handed out with the summary of an entity, to be
+// sent with a vote on it.
+type UsefulnessSummary struct {
+	EntityId   string `json:"entityId,omitempty"`
+	EntityType string `json:"entityType,omitempty"`
+	Helpful    int    `json:"helpful"`
+	NotHelpful int    `json:"notHelpful"`
+	Nonce      string `json:"nonce,omitempty"`
+}
+
+// IndexedPost is the copy of a post kept for statistics, without its author
+// or content. Organization is the organization number of the publisher of
+// the entity.
+type IndexedPost struct {
+	PostId         string `json:"pid" firestore:"pid"`
+	ThreadId       string `json:"tid" firestore:"tid"`
+	EntityId       string `json:"entityId" firestore:"entityId"`
+	Organization   string `json:"organization" firestore:"organization"`
+	EntityType     string `json:"entityType" firestore:"entityType"`
+	ToPostId       string `json:"toPid,omitempty" firestore:"toPid"`
+	OfficialAnswer bool   `json:"officialAnswer" firestore:"officialAnswer"`
+	Timestamp      int64  `json:"timestamp" firestore:"timestamp"`
+}
+
+// StatisticsQuery selects the posts from From to To, both included, of one
+// organization or entity type if set, grouped by Period.
+type StatisticsQuery struct {
+	Organization string
+	EntityType   string
+	Period       StatisticsPeriod
+	From         time.Time
+	To           time.Time
+}
+
+// FeedbackStatistics counts the feedback on the entities of an organization
+// and type within a period. Official answers are counted in the period they
+// were given, and the feedback they answer in the period it was posted.
+// Response times are the milliseconds from feedback to its first official
+// answer, and are left out when no feedback was answered.
+type FeedbackStatistics struct {
+	Organization       string `json:"organization"`
+	EntityType         string `json:"entityType"`
+	Period             string `json:"period"`
+	Posts              int    `json:"posts"`
+	Threads            int    `json:"threads"`
+	OfficialAnswers    int    `json:"officialAnswers"`
+	Answered           int    `json:"answered"`
+	MeanResponseTime   *int64 `json:"meanResponseTime,omitempty"`
+	MedianResponseTime *int64 `json:"medianResponseTime,omitempty"`
+}
+
+type PostRevision struct {
+	PostId    string `json:"pid" firestore:"pid"`
+	ThreadId  string `json:"tid" firestore:"tid"`
+	Content   string `json:"content" firestore:"content"`
+	EditorUid string `json:"editorUid" firestore:"editorUid"`
+	Timestamp int64  `json:"timestamp" firestore:"timestamp"`
+}
+
+// PendingPost is a post held back until a moderator approves it. Pending
+// edits of published posts have the id of the post.
+type PendingPost struct {
+	PendingId string `json:"id" firestore:"-"`
+	EntityId  string `json:"entityId" firestore:"entityId"`
+	PostId    string `json:"pid,omitempty" firestore:"pid"`
+	ThreadId  string `json:"tid" firestore:"tid"`
+	UserId    string `json:"uid" firestore:"uid"`
+	Content   string `json:"content" firestore:"content"`
+	ToPostId  string `json:"toPid,omitempty" firestore:"toPid"`
+	Timestamp int64  `json:"timestamp" firestore:"timestamp"`
+
+	Issue          *DataQualityIssue `json:"issue,omitempty" firestore:"issue,omitempty"`
+	OfficialAnswer bool              `json:"officialAnswer,omitempty" firestore:"officialAnswer,omitempty"`
+}
+
+type PostReport struct {
+	PostId      string       `json:"pid" firestore:"pid"`
+	ThreadId    string       `json:"tid" firestore:"tid"`
+	EntityId    string       `json:"entityId" firestore:"entityId"`
+	ReporterUid string       `json:"reporterUid" firestore:"reporterUid"`
+	Reason      ReportReason `json:"reason" firestore:"reason"`
+	Text        string       `json:"text" firestore:"text"`
+	Status      ReportStatus `json:"status" firestore:"status"`
+	Timestamp   int64        `json:"timestamp" firestore:"timestamp"`
+	ResolverUid string       `json:"resolverUid,omitempty" firestore:"resolverUid,omitempty"`
+	ResolvedAt  int64        `json:"resolvedAt,omitempty" firestore:"resolvedAt,omitempty"`
+}
+
+// ReportedPost groups the open reports of a post for moderators.
+type ReportedPost struct {
+	EntityId string       `json:"entityId"`
+	Entity   *Entity      `json:"entity"`
+	Post     *Post        `json:"post"`
+	Reports  []PostReport `json:"reports"`
+}
+
+// UserExport is everything a user has written in feedback threads, as
+// handed out on data subject access requests.
+type UserExport struct {
+	User       *User          `json:"user"`
+	ExportedAt int64          `json:"exportedAt"`
+	Posts      []ExportedPost `json:"posts"`
+}
+
+// ExportedPost is a post of a UserExport with the entity of its thread.
+type ExportedPost struct {
+	EntityId  *string `json:"entityId"`
+	ThreadId  *string `json:"tid"`
+	PostId    *string `json:"pid"`
+	ToPostId  *string `json:"toPid,omitempty"`
+	Content   *string `json:"content"`
+	Timestamp *int    `json:"timestamp"`
+	EditedAt  *int    `json:"editedTimestamp,omitempty"`
+}
+
+// FeedbackExportRow is a post on an entity of an organization, as exported
+// to its publisher. Status is set on posts reporting an issue.
+type FeedbackExportRow struct {
+	EntityId    string
+	EntityTitle string
+	EntityType  string
+	EntityLink  string
+	ThreadId    string
+	PostId      string
+	ToPostId    string
+	Author      string
+	Timestamp   *int
+	Content     string
+	Status      IssueStatus
+}
+
+// NotificationSettings are the choices of an organization about emails to
+// the contact points of its resources.
+type NotificationSettings struct {
+	Organization string `json:"organization" firestore:"-"`
+	Enabled      bool   `json:"enabled" firestore:"enabled"`
+	UpdatedBy    string `json:"-" firestore:"updatedBy"`
+	Timestamp    int64  `json:"timestamp,omitempty" firestore:"timestamp"`
+}
+
+type NotificationSettingsRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// MailMessage is a plain text email. UnsubscribeUrl is set for mail a user
+// can unsubscribe from.
+type MailMessage struct {
+	To             string
+	Subject        string
+	Body           string
+	UnsubscribeUrl string
+}
+
+// ThreadSubscription is a user following the thread of an entity, with the
+// address notifications about it are sent to.
+type ThreadSubscription struct {
+	EntityId  string `json:"entityId" firestore:"entityId"`
+	UserId    string `json:"uid" firestore:"uid"`
+	Email     string `json:"-" firestore:"email"`
+	Timestamp int64  `json:"timestamp" firestore:"timestamp"`
+}
+
+type SubscriptionStatus struct {
+	EntityId  string `json:"entityId"`
+	Following bool   `json:"following"`
+}
+
+// UserNotification tells a user about a thread the user follows.
+type UserNotification struct {
+	UserId         string
+	Email          string
+	Subject        string
+	Body           string
+	UnsubscribeUrl string
+}
+
+// ErasureTombstone records the erasure of a user's feedback for audit, and
+// the progress of an erasure that is still running. It keeps no content.
+type ErasureTombstone struct {
+	ErasureId     string        `json:"id" firestore:"-"`
+	UserId        string        `json:"uid" firestore:"uid"`
+	RequestedBy   string        `json:"requestedBy" firestore:"requestedBy"`
+	Policy        ErasurePolicy `json:"policy" firestore:"policy"`
+	Status        ErasureStatus `json:"status" firestore:"status"`
+	PostIds       []string      `json:"pids" firestore:"pids"`
+	Total         int           `json:"total" firestore:"total"`
+	Processed     int           `json:"processed" firestore:"processed"`
+	FailedPostIds []string      `json:"failedPids,omitempty" firestore:"failedPids"`
+	StartedAt     int64         `json:"startedAt" firestore:"startedAt"`
+	FinishedAt    int64         `json:"finishedAt,omitempty" firestore:"finishedAt"`
+	LeaseUntil    int64         `json:"-" firestore:"leaseUntil"`
+}
+
+// ContentViolation explains which screening rule a post broke. Limit and
+// Actual are set for counted rules, Match and Language for blocked words and
+// domains.
+type ContentViolation struct {
+	Rule     ScreeningRule `json:"rule"`
+	Message  string        `json:"message"`
+	Limit    *int          `json:"limit,omitempty"`
+	Actual   *int          `json:"actual,omitempty"`
+	Match    *string       `json:"match,omitempty"`
+	Language *string       `json:"language,omitempty"`
+}
+
+type ContentViolationResponse struct {
+	Errors []ContentViolation `json:"errors"`
+}
+
+// PersonalDataMatch is the byte range of personal data found in content.
+type PersonalDataMatch struct {
+	Kind  PersonalDataKind
+	Start int
+	End   int
+}
+
+// ContentFingerprint records when a user last posted some content.
+type ContentFingerprint struct {
+	PostId    string `firestore:"pid"`
+	Timestamp int64  `firestore:"timestamp"`
 }
 
 type StatusDTO struct {
@@ -46,8 +359,8 @@ type StatusDTO struct {
 }
 
 type PostResponseDTO struct {
-	Status   *StatusDTO `json:"code"`
-	Response *PostDTO 	`json:"response"`
+	Status   *StatusDTO `json:"status"`
+	Response *PostDTO   `json:"response"`
 }
 
 type ThreadResponseDTO struct {
@@ -83,6 +396,24 @@ type PostDTO struct {
 	Timestamp *json.Number `json:"timestamp"`
 	Deleted   *json.Number `json:"deleted"`
 	UserInfo  *UserDTO     `json:"user"`
+	Votes     *json.Number `json:"votes"`
+	Upvoted   *bool        `json:"upvoted"`
+	Edited    *json.Number `json:"edited"`
+}
+
+// UserPostsDTO is a page of the posts on a NodeBB user profile.
+type UserPostsDTO struct {
+	Posts      []*UserPostDTO `json:"posts"`
+	Pagination *PaginationDTO `json:"pagination"`
+}
+
+type UserPostDTO struct {
+	PostDTO
+	Category *CategoryDTO `json:"category"`
+}
+
+type CategoryDTO struct {
+	CategoryId *json.Number `json:"cid"`
 }
 
 type PaginationDTO struct {
@@ -91,7 +422,23 @@ type PaginationDTO struct {
 }
 
 type Pagination struct {
-	CurrentPage *int `json:"currentPage"`
-	PageCount   *int `json:"pageCount"`
-	TotalPosts  *int `json:"totalPosts"`
+	CurrentPage *int    `json:"currentPage"`
+	PageCount   *int    `json:"pageCount"`
+	TotalPosts  *int    `json:"totalPosts"`
+	Sort        *string `json:"sort,omitempty"`
+	PageSize    *int    `json:"pageSize,omitempty"`
+	NextCursor  *string `json:"nextCursor,omitempty"`
+	PrevCursor  *string `json:"prevCursor,omitempty"`
+}
+
+type ThreadQuery struct {
+	Page      *string
+	Sort      ThreadSort
+	PageSize  *int
+	Cursor    *ThreadCursor
+	ViewerUid *string
+	// Category and IssueStatus limit the thread to posts reporting issues
+	// of that kind and status.
+	Category    *IssueCategory
+	IssueStatus *IssueStatus
 }
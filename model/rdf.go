@@ -0,0 +1,96 @@
+package model
+
+import "strings"
+
+const (
+	RdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	XsdNamespace = "http://www.w3.org/2001/XMLSchema#"
+	DctNamespace = "http://purl.org/dc/terms/"
+	DcNamespace  = "http://purl.org/dc/elements/1.1/"
+	OaNamespace  = "http://www.w3.org/ns/oa#"
+	DqvNamespace = "http://www.w3.org/ns/dqv#"
+	AsNamespace  = "https://www.w3.org/ns/activitystreams#"
+	RdfType      = RdfNamespace + "type"
+	XsdDateTime  = XsdNamespace + "dateTime"
+)
+
+// RdfPrefixes are the namespaces feedback is published with, in the order
+// they are declared.
+var RdfPrefixes = []RdfPrefix{
+	{"rdf", RdfNamespace},
+	{"xsd", XsdNamespace},
+	{"dct", DctNamespace},
+	{"dc", DcNamespace},
+	{"oa", OaNamespace},
+	{"dqv", DqvNamespace},
+	{"as", AsNamespace},
+}
+
+type RdfPrefix struct {
+	Prefix    string
+	Namespace string
+}
+
+type RdfTermKind int
+
+const (
+	IriTerm RdfTermKind = iota
+	BlankTerm
+	LiteralTerm
+)
+
+// RdfTerm is an IRI, a blank node labelled by Value, or a literal with an
+// optional Datatype IRI.
+type RdfTerm struct {
+	Kind     RdfTermKind
+	Value    string
+	Datatype string
+}
+
+type RdfTriple struct {
+	Subject   RdfTerm
+	Predicate string
+	Object    RdfTerm
+}
+
+func Iri(value string) RdfTerm {
+	return RdfTerm{Kind: IriTerm, Value: value}
+}
+
+func BlankNode(label string) RdfTerm {
+	return RdfTerm{Kind: BlankTerm, Value: label}
+}
+
+func Literal(value string) RdfTerm {
+	return RdfTerm{Kind: LiteralTerm, Value: value}
+}
+
+func TypedLiteral(value string, datatype string) RdfTerm {
+	return RdfTerm{Kind: LiteralTerm, Value: value, Datatype: datatype}
+}
+
+// CompactIri shortens the IRI with the first of RdfPrefixes it starts with,
+// as in oa:hasTarget. The IRI is returned as is, and false, if no prefix
+// gives a simple local name.
+func CompactIri(iri string) (string, bool) {
+	for _, prefix := range RdfPrefixes {
+		local, found := strings.CutPrefix(iri, prefix.Namespace)
+		if found && isLocalName(local) {
+			return prefix.Prefix + ":" + local, true
+		}
+	}
+	return iri, false
+}
+
+func isLocalName(local string) bool {
+	if local == "" {
+		return false
+	}
+	for i, r := range local {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		if !isLetter && (i == 0 || ((r < '0' || r > '9') && r != '-')) {
+			return false
+		}
+	}
+	return true
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
 )
@@ -13,6 +15,26 @@ const threadTitleTemplate = "%s %s, %s"
 const threadLinkedContentTemplate = "Dette er en automatisk opprettet kommentartråd for %s [%s](%s)."
 const threadContentTemplate = "Dette er en automatisk opprettet kommentartråd for %s %s."
 
+const issueHeaderPrefix = "> **Datakvalitet:**"
+const issueCategoryTemplate = issueHeaderPrefix + " %s"
+const issueFieldTemplate = " · **Felt:** `%s`"
+const issueSeverityTemplate = " · **Alvorlighetsgrad:** %s"
+const maxIssueFieldLength = 100
+const officialAnswerHeader = "> **Offisielt svar fra utgiver**"
+
+const notificationSubjectTemplate = "Ny tilbakemelding på %s"
+const notificationBodyTemplate = "Det har kommet en ny tilbakemelding på %s i Datalandsbyen. Les og svar på den her:\n\n%s\n\n" +
+	"Du får denne e-posten fordi adressen er kontaktpunkt for %s. Administratorer for utgiveren kan slå av varslene.\n"
+
+const threadNotificationSubjectTemplate = "Nytt innlegg om %s"
+const threadNotificationBodyTemplate = "Det har kommet et nytt innlegg om %s i Datalandsbyen. Les det her:\n\n%s\n\n"
+const threadReplySubjectTemplate = "Nytt svar på innlegget ditt om %s"
+const threadReplyBodyTemplate = "Noen har svart på innlegget ditt om %s i Datalandsbyen. Les svaret her:\n\n%s\n\n"
+const threadUnsubscribeTemplate = "Du får denne e-posten fordi du følger %s. Slutt å følge her:\n\n%s\n"
+
+const MinRatingScore = 1
+const MaxRatingScore = 5
+
 func fdkLink(entityType EntityType, entityId string) *string {
 	var path string
 	entityBasePath := entityType.ToPath()
@@ -66,6 +88,21 @@ func (thread *Thread) FilterDeletedPosts() *Thread {
 	}
 }
 
+// WithQuery returns a copy of the pagination with the sort order and page
+// size the thread was read with.
+func (pagination *Pagination) WithQuery(query ThreadQuery) *Pagination {
+	var applied Pagination
+	if pagination != nil {
+		applied = *pagination
+	}
+
+	sort := query.Sort.String()
+	applied.Sort = &sort
+	applied.PageSize = query.PageSize
+
+	return &applied
+}
+
 func (threadDto *ThreadDTO) ToThread() *Thread {
 	if threadDto == nil {
 		return nil
@@ -136,6 +173,33 @@ func (entity *Entity) ToThread() (*Thread, error) {
 	}, nil
 }
 
+// FeedbackNotification is the email to the contact point of the entity
+// about new feedback.
+func (entity *Entity) FeedbackNotification() UserNotification {
+	name := strings.TrimSpace(entity.Type.StringNbPlural() + " " + entity.Title)
+	return UserNotification{
+		Email:   entity.ContactEmail,
+		Subject: fmt.Sprintf(notificationSubjectTemplate, entity.Title),
+		Body:    fmt.Sprintf(notificationBodyTemplate, name, entity.PortalLink(), name),
+	}
+}
+
+// ThreadNotification is the notification to a follower of the entity about a
+// new post, or about a reply to a post of the follower.
+func (entity *Entity) ThreadNotification(reply bool, unsubscribeUrl string) UserNotification {
+	name := strings.TrimSpace(entity.Type.StringNbPlural() + " " + entity.Title)
+	subjectTemplate, bodyTemplate := threadNotificationSubjectTemplate, threadNotificationBodyTemplate
+	if reply {
+		subjectTemplate, bodyTemplate = threadReplySubjectTemplate, threadReplyBodyTemplate
+	}
+	return UserNotification{
+		Subject: fmt.Sprintf(subjectTemplate, entity.Title),
+		Body: fmt.Sprintf(bodyTemplate, name, entity.PortalLink()) +
+			fmt.Sprintf(threadUnsubscribeTemplate, name, unsubscribeUrl),
+		UnsubscribeUrl: unsubscribeUrl,
+	}
+}
+
 func (postDto *PostDTO) ToPost() *Post {
 	if postDto == nil {
 		return nil
@@ -149,6 +213,11 @@ func (postDto *PostDTO) ToPost() *Post {
 	deletedVal := NumberPointerToStringPointer(postDto.Deleted)
 	deleted := deletedVal != nil && *deletedVal == "1"
 	toPostId := postDto.ToPostId
+	editedAt := NumberPointerToIntPointer(postDto.Edited)
+	edited := editedAt != nil && *editedAt > 0
+	if !edited {
+		editedAt = nil
+	}
 	return &Post{
 		PostId:    postId,
 		UserId:    userId,
@@ -159,9 +228,200 @@ func (postDto *PostDTO) ToPost() *Post {
 		Timestamp: timestamp,
 		Deleted:   &deleted,
 		UserInfo:  postDto.UserInfo.ToUser(),
+		Votes:     NumberPointerToIntPointer(postDto.Votes),
+		Upvoted:   postDto.Upvoted,
+		Edited:    &edited,
+		EditedAt:  editedAt,
 	}
 }
 
+// ToPosts returns the posts of the page made in the given category.
+func (userPostsDto *UserPostsDTO) ToPosts(categoryId string) []*Post {
+	if userPostsDto == nil {
+		return nil
+	}
+
+	var posts []*Post
+	for _, userPostDto := range userPostsDto.Posts {
+		if userPostDto == nil || userPostDto.Category == nil || userPostDto.Category.CategoryId == nil {
+			continue
+		}
+		if userPostDto.Category.CategoryId.String() == categoryId {
+			posts = append(posts, userPostDto.ToPost())
+		}
+	}
+
+	return posts
+}
+
+func (userPostsDto *UserPostsDTO) PageCount() int {
+	if userPostsDto == nil || userPostsDto.Pagination == nil {
+		return 0
+	}
+
+	pageCount := NumberPointerToIntPointer(userPostsDto.Pagination.PageCount)
+	if pageCount == nil {
+		return 0
+	}
+	return *pageCount
+}
+
+func (post *Post) ToExportedPost(entityId *string) ExportedPost {
+	return ExportedPost{
+		EntityId:  entityId,
+		ThreadId:  post.ThreadId,
+		PostId:    post.PostId,
+		ToPostId:  post.ToPostId,
+		Content:   post.Content,
+		Timestamp: post.Timestamp,
+		EditedAt:  post.EditedAt,
+	}
+}
+
+// ToFeedbackExportRow returns the post as exported to the publisher of the
+// entity, with the display name of its author.
+func (post *Post) ToFeedbackExportRow(entity Entity) FeedbackExportRow {
+	row := FeedbackExportRow{
+		EntityId:    entity.EntityId,
+		EntityTitle: entity.Title,
+		EntityType:  entity.Type.Key(),
+		EntityLink:  entity.PortalLink(),
+		ThreadId:    valueOrEmpty(post.ThreadId),
+		PostId:      valueOrEmpty(post.PostId),
+		ToPostId:    valueOrEmpty(post.ToPostId),
+		Timestamp:   post.Timestamp,
+		Content:     valueOrEmpty(post.Content),
+	}
+	if post.UserInfo != nil {
+		if post.UserInfo.Displayname != nil {
+			row.Author = *post.UserInfo.Displayname
+		} else if post.UserInfo.Username != nil {
+			row.Author = *post.UserInfo.Username
+		}
+	}
+	if post.Issue != nil {
+		row.Status = post.Issue.Status
+	}
+
+	return row
+}
+
+// ToPost returns the pending post as shown to its author. Only pending edits
+// have a post id.
+func (pending *PendingPost) ToPost() *Post {
+	if pending == nil {
+		return nil
+	}
+
+	pendingId := pending.PendingId
+	userId := pending.UserId
+	threadId := pending.ThreadId
+	content := pending.Content
+	timestamp := int(pending.Timestamp)
+	var toPostId *string
+	if pending.ToPostId != "" {
+		toPostId = &pending.ToPostId
+	}
+	var postId *string
+	if pending.PostId != "" {
+		postId = &pending.PostId
+	}
+	return &Post{
+		PostId:         postId,
+		UserId:         &userId,
+		ThreadId:       &threadId,
+		Content:        &content,
+		ToPostId:       toPostId,
+		Timestamp:      &timestamp,
+		PendingId:      &pendingId,
+		Issue:          pending.Issue,
+		OfficialAnswer: pending.OfficialAnswer,
+	}
+}
+
+// Validate checks the category and severity of the issue, trims the field
+// and fills in the default severity.
+func (issue *DataQualityIssue) Validate() error {
+	category, ok := ParseIssueCategory(string(issue.Category))
+	if !ok {
+		return ErrInvalidIssue
+	}
+	severity, ok := ParseIssueSeverity(string(issue.Severity))
+	if !ok {
+		return ErrInvalidIssue
+	}
+
+	field := strings.TrimSpace(issue.Field)
+	if len([]rune(field)) > maxIssueFieldLength || strings.ContainsAny(field, "`\r\n") {
+		return ErrInvalidIssue
+	}
+
+	issue.Category = category
+	issue.Severity = severity
+	issue.Field = field
+	return nil
+}
+
+// RenderContent puts a summary of the issue in front of the content, in
+// place of any summary already there, so readers in the forum see it too.
+func (issue *DataQualityIssue) RenderContent(content string) string {
+	header := fmt.Sprintf(issueCategoryTemplate, issue.Category.StringNb())
+	if issue.Field != "" {
+		header += fmt.Sprintf(issueFieldTemplate, issue.Field)
+	}
+	header += fmt.Sprintf(issueSeverityTemplate, issue.Severity.StringNb())
+
+	return header + "\n\n" + StripRenderedHeaders(content)
+}
+
+// StripRenderedHeaders removes every line that looks like an issue summary or
+// an official answer mark, so only the service can put them in a post.
+func StripRenderedHeaders(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := make([]string, 0, len(lines))
+	stripped := false
+	for _, line := range lines {
+		if isRenderedHeader(line) {
+			stripped = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if !stripped {
+		return content
+	}
+
+	return strings.TrimLeft(strings.Join(kept, "\n"), " \t\r\n")
+}
+
+// RenderOfficialAnswer marks the content as an official answer from the
+// publisher, in place of any mark already there.
+func RenderOfficialAnswer(content string) string {
+	return officialAnswerHeader + "\n\n" + StripRenderedHeaders(content)
+}
+
+// FiltersIssues tells whether the query only asks for posts reporting issues.
+func (query ThreadQuery) FiltersIssues() bool {
+	return query.Category != nil || query.IssueStatus != nil
+}
+
+// Matches tells whether the issue is of the category and status the query
+// asks for.
+func (issue *DataQualityIssue) Matches(query ThreadQuery) bool {
+	return (query.Category == nil || issue.Category == *query.Category) &&
+		(query.IssueStatus == nil || issue.Status == *query.IssueStatus)
+}
+
+func isRenderedHeader(line string) bool {
+	marker := strings.ToLower(strings.TrimLeft(line, "> \t"))
+	for _, header := range []string{issueHeaderPrefix, officialAnswerHeader} {
+		if strings.HasPrefix(marker, strings.ToLower(strings.TrimPrefix(header, "> "))) {
+			return true
+		}
+	}
+	return false
+}
+
 func (userDto *UserDTO) ToUser() *User {
 	if userDto == nil {
 		return nil
@@ -178,6 +438,13 @@ func (userDto *UserDTO) ToUser() *User {
 	}
 }
 
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func StringPointerToIntPointer(s *string) (*int, error) {
 	if s == nil {
 		return nil, nil
@@ -215,3 +482,48 @@ func NumberPointerToIntPointer(num *json.Number) *int {
 
 	return &intVal
 }
+
+// SummarizeRatings aggregates the ratings of the entity, leaving out ratings
+// of other entities. The mean is rounded to two decimals.
+func SummarizeRatings(entityId string, ratings []Rating) RatingSummary {
+	summary := RatingSummary{EntityId: entityId, Distribution: map[int]int{}}
+	for score := MinRatingScore; score <= MaxRatingScore; score++ {
+		summary.Distribution[score] = 0
+	}
+
+	total := 0
+	for _, rating := range ratings {
+		if rating.EntityId != entityId {
+			continue
+		}
+		summary.Count++
+		summary.Distribution[rating.Score]++
+		total += rating.Score
+	}
+	if summary.Count > 0 {
+		summary.Mean = math.Round(float64(total)/float64(summary.Count)*100) / 100
+	}
+
+	return summary
+}
+
+// ResourceUri is the URI of the entity in the catalog, or its page in the
+// portal if the URI is not known.
+func (entity *Entity) ResourceUri() string {
+	if entity.Uri != "" {
+		return entity.Uri
+	}
+	if link := fdkLink(entity.Type, entity.EntityId); link != nil {
+		return *link
+	}
+	return ""
+}
+
+// PortalLink is the page of the entity in the portal, or its URI in the
+// catalog if it has no page.
+func (entity *Entity) PortalLink() string {
+	if link := fdkLink(entity.Type, entity.EntityId); link != nil {
+		return *link
+	}
+	return entity.Uri
+}
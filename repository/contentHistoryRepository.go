@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ContentHistoryRepository interface {
+	GetFingerprint(userId string, hash string) (*model.ContentFingerprint, error)
+	SaveFingerprint(userId string, hash string, fingerprint model.ContentFingerprint) error
+	DeleteHistory(userId string) error
+}
+
+// ContentHistoryRepositoryImpl keeps the fingerprints of the content each
+// user has posted in a subcollection of a document named by the user id.
+type ContentHistoryRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+const fingerprintSubcollection = "fingerprints"
+
+func (contentHistoryRepository *ContentHistoryRepositoryImpl) GetFingerprint(userId string, hash string) (*model.ContentFingerprint, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, contentHistoryRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, err := firestoreClient.Collection(contentHistoryRepository.FirestoreCollectionId).
+		Doc(userId).
+		Collection(fingerprintSubcollection).
+		Doc(hash).
+		Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fingerprint model.ContentFingerprint
+	if err := document.DataTo(&fingerprint); err != nil {
+		return nil, err
+	}
+
+	return &fingerprint, nil
+}
+
+func (contentHistoryRepository *ContentHistoryRepositoryImpl) SaveFingerprint(userId string, hash string, fingerprint model.ContentFingerprint) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, contentHistoryRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(contentHistoryRepository.FirestoreCollectionId).
+		Doc(userId).
+		Collection(fingerprintSubcollection).
+		Doc(hash).
+		Set(ctx, fingerprint)
+
+	return err
+}
+
+func (contentHistoryRepository *ContentHistoryRepositoryImpl) DeleteHistory(userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, contentHistoryRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	return deleteDocuments(ctx, firestoreClient.Collection(contentHistoryRepository.FirestoreCollectionId).
+		Doc(userId).
+		Collection(fingerprintSubcollection).
+		Documents(ctx))
+}
+
+var CurrentContentHistoryRepository ContentHistoryRepository
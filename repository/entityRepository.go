@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
@@ -13,6 +14,7 @@ import (
 
 type EntityRepository interface {
 	GetEntityById(entityID string) (*model.Entity, error)
+	GetOrganizationEntityIds(organization string) ([]string, error)
 }
 
 type EntityRepositoryImpl struct {
@@ -33,6 +35,9 @@ type binding struct {
 	Title         field `json:"title"`
 	TitleLanguage field `json:"titleLanguage"`
 	Organization  field `json:"responsibleOrganization"`
+	PublisherId   field `json:"responsibleOrganizationId"`
+	Uri           field `json:"entity"`
+	ContactEmail  field `json:"contactEmail"`
 }
 
 func (b *binding) toEntity(entityId string) *model.Entity {
@@ -45,6 +50,9 @@ func (b *binding) toEntity(entityId string) *model.Entity {
 		Type:         model.ParseEntityType(&b.Type.Value),
 		Title:        b.Title.Value,
 		Organization: b.Organization.Value,
+		PublisherId:  b.PublisherId.Value,
+		Uri:          b.Uri.Value,
+		ContactEmail: strings.TrimPrefix(b.ContactEmail.Value, "mailto:"),
 	}
 }
 
@@ -52,6 +60,14 @@ type field struct {
 	Value string `json:"value"`
 }
 
+type entityIdsResponse struct {
+	Results struct {
+		Bindings []struct {
+			EntityId field `json:"entityId"`
+		} `json:"bindings"`
+	} `json:"results"`
+}
+
 const sparqlFormatQuery = `
 PREFIX dcat: <http://www.w3.org/ns/dcat#>
 PREFIX modelldcatno: <https://data.norge.no/vocabulary/modelldcatno#>
@@ -62,8 +78,9 @@ PREFIX skos:  <http://www.w3.org/2004/02/skos/core#>
 PREFIX rov:   <http://www.w3.org/ns/regorg#>
 PREFIX cpsv: <http://purl.org/vocab/cpsv#>
 PREFIX cv: <http://data.europa.eu/m8g/>
+PREFIX vcard: <http://www.w3.org/2006/vcard/ns#>
 
-SELECT DISTINCT  ?type ?title ?responsibleOrganization ?titleLanguage
+SELECT DISTINCT  ?entity ?type ?title ?responsibleOrganization ?responsibleOrganizationId ?titleLanguage ?contactEmail
 WHERE {
     ?record dct:identifier "%s" .
     ?record foaf:primaryTopic ?entity .
@@ -81,9 +98,11 @@ WHERE {
     OPTIONAL {
         ?entity cv:hasCompetentAuthority ?authnode.
         ?authnode rov:legalName ?authtitle.
+        OPTIONAL { ?authnode dct:identifier ?authidentification . }
         }
 
     bind( IF(?type = cpsv:PublicService, ?authtitle, ?publishertitle) as ?responsibleOrganization )
+    bind( IF(?type = cpsv:PublicService, ?authidentification, ?publisheridentification) as ?responsibleOrganizationId )
 
 
     # Get title
@@ -97,6 +116,31 @@ WHERE {
 
     bind( IF(?type = skos:Concept, ?skostitle, ?dcttitle) as ?title )
     bind(lang(?title) as ?titleLanguage)
+
+    # Get email of contact point
+    OPTIONAL {
+        ?entity dcat:contactPoint ?contactPoint .
+        ?contactPoint vcard:hasEmail ?contactEmailNode .
+        bind( STR(?contactEmailNode) as ?contactEmail )
+        }
+}
+`
+
+const sparqlOrganizationQuery = `
+PREFIX dct: <http://purl.org/dc/terms/>
+PREFIX foaf: <http://xmlns.com/foaf/0.1/>
+PREFIX cv: <http://data.europa.eu/m8g/>
+
+SELECT DISTINCT ?entityId
+WHERE {
+    ?record foaf:primaryTopic ?entity .
+    ?record dct:identifier ?entityId .
+
+    # Published by the organization, or a public service it is competent authority for
+    { ?entity dct:publisher ?publisher . }
+    UNION
+    { ?entity cv:hasCompetentAuthority ?publisher . }
+    ?publisher dct:identifier %s .
 }
 `
 
@@ -133,6 +177,42 @@ func (entityRepository *EntityRepositoryImpl) GetEntityById(entityID string) (*m
 	}
 }
 
+// GetOrganizationEntityIds lists the ids of the entities the organization is
+// responsible for in the catalog.
+func (entityRepository *EntityRepositoryImpl) GetOrganizationEntityIds(organization string) ([]string, error) {
+	var parsedResponse entityIdsResponse
+	params := map[string]string{
+		"query": fmt.Sprintf(sparqlOrganizationQuery, sparqlString(organization)),
+	}
+
+	rawResponse, err := util.Request(util.RequestOptions{
+		Method:          http.MethodGet,
+		EndpointUrl:     entityRepository.SparqlServiceUrl,
+		QueryParameters: &params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(*rawResponse, &parsedResponse); err != nil {
+		log.Println("Error on entityIdsResponse unmarshal.\n[ERROR] -", err)
+		return nil, err
+	}
+
+	entityIds := make([]string, 0, len(parsedResponse.Results.Bindings))
+	for _, binding := range parsedResponse.Results.Bindings {
+		entityIds = append(entityIds, binding.EntityId.Value)
+	}
+
+	return entityIds, nil
+}
+
+// sparqlString quotes the value as a string literal of a query.
+func sparqlString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
 func getPreferredEntity(bindings []binding) (*binding, error) {
 	var nb, nn, en, any *binding
 
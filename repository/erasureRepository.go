@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ErasureRepository interface {
+	CreateErasure(tombstone model.ErasureTombstone) (*model.ErasureTombstone, error)
+	UpdateErasure(tombstone model.ErasureTombstone) error
+	GetErasure(erasureId string) (*model.ErasureTombstone, error)
+	LeaseErasure(erasureId string, now time.Time, lease time.Duration) (*model.ErasureTombstone, bool, error)
+}
+
+// ErasureRepositoryImpl keeps the tombstones of erased users. Tombstones
+// are never deleted.
+type ErasureRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (erasureRepository *ErasureRepositoryImpl) CreateErasure(tombstone model.ErasureTombstone) (*model.ErasureTombstone, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, erasureRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, _, err := firestoreClient.Collection(erasureRepository.FirestoreCollectionId).Add(ctx, tombstone)
+	if err != nil {
+		return nil, err
+	}
+
+	tombstone.ErasureId = document.ID
+	return &tombstone, nil
+}
+
+func (erasureRepository *ErasureRepositoryImpl) UpdateErasure(tombstone model.ErasureTombstone) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, erasureRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(erasureRepository.FirestoreCollectionId).
+		Doc(tombstone.ErasureId).
+		Set(ctx, tombstone)
+
+	return err
+}
+
+func (erasureRepository *ErasureRepositoryImpl) GetErasure(erasureId string) (*model.ErasureTombstone, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, erasureRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, err := firestoreClient.Collection(erasureRepository.FirestoreCollectionId).Doc(erasureId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstone model.ErasureTombstone
+	if err := document.DataTo(&tombstone); err != nil {
+		return nil, err
+	}
+
+	tombstone.ErasureId = document.Ref.ID
+	return &tombstone, nil
+}
+
+// LeaseErasure lets one worker at a time process a running erasure, until
+// the lease runs out or the tombstone is saved without it. The tombstone is
+// returned whether or not it was leased, or nil if there is none.
+func (erasureRepository *ErasureRepositoryImpl) LeaseErasure(erasureId string, now time.Time, lease time.Duration) (*model.ErasureTombstone, bool, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, erasureRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, false, err
+	}
+	defer firestoreClient.Close()
+
+	document := firestoreClient.Collection(erasureRepository.FirestoreCollectionId).Doc(erasureId)
+	var tombstone *model.ErasureTombstone
+	leased := false
+	err = firestoreClient.RunTransaction(ctx, func(ctx context.Context, transaction *firestore.Transaction) error {
+		tombstone, leased = nil, false
+		snapshot, err := transaction.Get(document)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tombstone = &model.ErasureTombstone{}
+		if err := snapshot.DataTo(tombstone); err != nil {
+			return err
+		}
+		tombstone.ErasureId = erasureId
+		if tombstone.Status != model.ErasureRunning || tombstone.LeaseUntil > now.UnixMilli() {
+			return nil
+		}
+
+		leased = true
+		tombstone.LeaseUntil = now.Add(lease).UnixMilli()
+		return transaction.Update(document, []firestore.Update{{Path: "leaseUntil", Value: tombstone.LeaseUntil}})
+	})
+
+	return tombstone, leased, err
+}
+
+// deleteDocuments deletes every document of the iterator, for erasing the
+// data of a user.
+func deleteDocuments(ctx context.Context, documents *firestore.DocumentIterator) error {
+	defer documents.Stop()
+
+	for {
+		document, err := documents.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := document.Ref.Delete(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+var CurrentErasureRepository ErasureRepository
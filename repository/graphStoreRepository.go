@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type GraphStoreRepository interface {
+	Update(update string) error
+}
+
+// GraphStoreRepositoryImpl sends SPARQL Updates to the update endpoint of a
+// graph store, with the SPARQL_UPDATE_TOKEN secret as bearer token if set.
+type GraphStoreRepositoryImpl struct {
+	SecretProvider  secret.SecretProvider
+	SparqlUpdateUrl string
+}
+
+func (graphStoreRepository *GraphStoreRepositoryImpl) Update(update string) error {
+	request, err := http.NewRequest(http.MethodPost, graphStoreRepository.SparqlUpdateUrl, strings.NewReader(update))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/sparql-update")
+
+	if graphStoreRepository.SecretProvider != nil {
+		if token, err := graphStoreRepository.SecretProvider.GetSecret(secret.SparqlUpdateToken); err == nil && token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if !util.SuccsessfulStatus(response.StatusCode) {
+		body, _ := io.ReadAll(response.Body)
+		log.Print(string(body))
+		return errors.New(response.Status)
+	}
+
+	return nil
+}
+
+var CurrentGraphStoreRepository GraphStoreRepository
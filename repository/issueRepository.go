@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type IssueRepository interface {
+	SaveIssue(issue model.DataQualityIssue) error
+	GetIssue(postId string) (*model.DataQualityIssue, error)
+	GetIssues(threadId string) ([]model.DataQualityIssue, error)
+	DeleteIssue(postId string) error
+}
+
+// IssueRepositoryImpl stores the data quality issue of a post in a document
+// named by the post id.
+type IssueRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (issueRepository *IssueRepositoryImpl) SaveIssue(issue model.DataQualityIssue) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, issueRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(issueRepository.FirestoreCollectionId).
+		Doc(issue.PostId).
+		Set(ctx, issue)
+
+	return err
+}
+
+func (issueRepository *IssueRepositoryImpl) GetIssue(postId string) (*model.DataQualityIssue, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, issueRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, err := firestoreClient.Collection(issueRepository.FirestoreCollectionId).Doc(postId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issue model.DataQualityIssue
+	if err := document.DataTo(&issue); err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+func (issueRepository *IssueRepositoryImpl) GetIssues(threadId string) ([]model.DataQualityIssue, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, issueRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(issueRepository.FirestoreCollectionId).
+		Where("tid", "==", threadId).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]model.DataQualityIssue, 0, len(documents))
+	for _, document := range documents {
+		var issue model.DataQualityIssue
+		if err := document.DataTo(&issue); err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+func (issueRepository *IssueRepositoryImpl) DeleteIssue(postId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, issueRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(issueRepository.FirestoreCollectionId).
+		Doc(postId).
+		Delete(ctx)
+
+	return err
+}
+
+var CurrentIssueRepository IssueRepository
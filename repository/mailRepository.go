@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type MailRepository interface {
+	SendMail(message model.MailMessage) error
+}
+
+// MailRepositoryImpl sends mail through an SMTP server, with STARTTLS when
+// the server offers it. It logs in with Username and the SMTP_PASSWORD
+// secret if Username is set.
+type MailRepositoryImpl struct {
+	SecretProvider secret.SecretProvider
+	SmtpHost       string
+	SmtpPort       string
+	Username       string
+	From           string
+	Timeout        time.Duration
+}
+
+func (mailRepository *MailRepositoryImpl) SendMail(message model.MailMessage) error {
+	to, err := util.ParseMailAddress(message.To)
+	if err != nil {
+		return err
+	}
+	message.To = to
+
+	content, err := util.FormatMail(mailRepository.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(mailRepository.SmtpHost, mailRepository.SmtpPort), mailRepository.Timeout)
+	if err != nil {
+		return err
+	}
+	if mailRepository.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(mailRepository.Timeout))
+	}
+
+	client, err := smtp.NewClient(conn, mailRepository.SmtpHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: mailRepository.SmtpHost}); err != nil {
+			return err
+		}
+	}
+	if mailRepository.Username != "" {
+		password, err := mailRepository.SecretProvider.GetSecret(secret.SmtpPassword)
+		if err != nil {
+			return err
+		}
+		if err := client.Auth(smtp.PlainAuth("", mailRepository.Username, password, mailRepository.SmtpHost)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(mailRepository.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+var CurrentMailRepository MailRepository
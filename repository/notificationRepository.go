@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type NotificationRepository interface {
+	ClaimNotification(key string, now time.Time, window time.Duration) (bool, error)
+	ReleaseNotification(key string, claimedAt time.Time) error
+	GetSettings(organization string) (*model.NotificationSettings, error)
+	SaveSettings(settings model.NotificationSettings) error
+}
+
+// NotificationRepositoryImpl keeps when each notification was last sent in
+// FirestoreCollectionId, and the settings of each organization, named by its
+// organization number, in FirestoreSettingsCollectionId.
+type NotificationRepositoryImpl struct {
+	FirestoreProjectId            string
+	FirestoreCollectionId         string
+	FirestoreSettingsCollectionId string
+}
+
+type sentNotification struct {
+	Timestamp int64 `firestore:"timestamp"`
+}
+
+// ClaimNotification records that the notification with the key is sent now,
+// unless it was already sent within the window.
+func (notificationRepository *NotificationRepositoryImpl) ClaimNotification(key string, now time.Time, window time.Duration) (bool, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, notificationRepository.FirestoreProjectId)
+	if err != nil {
+		return false, err
+	}
+	defer firestoreClient.Close()
+
+	document := firestoreClient.Collection(notificationRepository.FirestoreCollectionId).Doc(key)
+	claimed := false
+	err = firestoreClient.RunTransaction(ctx, func(ctx context.Context, transaction *firestore.Transaction) error {
+		claimed = false
+		snapshot, err := transaction.Get(document)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			var sent sentNotification
+			if err := snapshot.DataTo(&sent); err != nil {
+				return err
+			}
+			if now.Sub(time.UnixMilli(sent.Timestamp)) < window {
+				return nil
+			}
+		}
+
+		claimed = true
+		return transaction.Set(document, sentNotification{Timestamp: now.UnixMilli()})
+	})
+
+	return claimed, err
+}
+
+// ReleaseNotification takes back the claim made at claimedAt when the
+// notification could not be sent, unless it has been claimed again since.
+func (notificationRepository *NotificationRepositoryImpl) ReleaseNotification(key string, claimedAt time.Time) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, notificationRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	document := firestoreClient.Collection(notificationRepository.FirestoreCollectionId).Doc(key)
+	return firestoreClient.RunTransaction(ctx, func(ctx context.Context, transaction *firestore.Transaction) error {
+		snapshot, err := transaction.Get(document)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var sent sentNotification
+		if err := snapshot.DataTo(&sent); err != nil {
+			return err
+		}
+		if sent.Timestamp != claimedAt.UnixMilli() {
+			return nil
+		}
+
+		return transaction.Delete(document)
+	})
+}
+
+// GetSettings reads the settings of the organization, or nil if it has none.
+func (notificationRepository *NotificationRepositoryImpl) GetSettings(organization string) (*model.NotificationSettings, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, notificationRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	snapshot, err := firestoreClient.Collection(notificationRepository.FirestoreSettingsCollectionId).
+		Doc(organization).
+		Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var settings model.NotificationSettings
+	if err := snapshot.DataTo(&settings); err != nil {
+		return nil, err
+	}
+	settings.Organization = organization
+
+	return &settings, nil
+}
+
+func (notificationRepository *NotificationRepositoryImpl) SaveSettings(settings model.NotificationSettings) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, notificationRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(notificationRepository.FirestoreSettingsCollectionId).
+		Doc(settings.Organization).
+		Set(ctx, settings)
+
+	return err
+}
+
+var CurrentNotificationRepository NotificationRepository
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PendingPostRepository interface {
+	CreatePendingPost(post model.PendingPost) (*model.PendingPost, error)
+	GetPendingPost(pendingId string) (*model.PendingPost, error)
+	GetPendingPosts() ([]model.PendingPost, error)
+	GetPendingPostsByUser(threadId string, userId string) ([]model.PendingPost, error)
+	DeletePendingPost(pendingId string) error
+	IsApprovedUser(userId string) (bool, error)
+	ApproveUser(userId string) error
+	DeleteUser(userId string) error
+}
+
+// PendingPostRepositoryImpl keeps posts awaiting moderation in one
+// collection, and the users who have had a post approved in another.
+type PendingPostRepositoryImpl struct {
+	FirestoreProjectId            string
+	FirestoreCollectionId         string
+	FirestoreApprovedCollectionId string
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) CreatePendingPost(post model.PendingPost) (*model.PendingPost, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, _, err := firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).Add(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+
+	post.PendingId = document.ID
+	return &post, nil
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) GetPendingPost(pendingId string) (*model.PendingPost, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	document, err := firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).Doc(pendingId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toPendingPost(document)
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) GetPendingPosts() ([]model.PendingPost, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).
+		OrderBy("timestamp", firestore.Asc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return toPendingPosts(documents)
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) GetPendingPostsByUser(threadId string, userId string) ([]model.PendingPost, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).
+		Where("tid", "==", threadId).
+		Where("uid", "==", userId).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := toPendingPosts(documents)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sorted here rather than in the query, which would need a composite index.
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Timestamp < posts[j].Timestamp })
+	return posts, nil
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) DeletePendingPost(pendingId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).Doc(pendingId).Delete(ctx)
+	return err
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) IsApprovedUser(userId string) (bool, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return false, err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(pendingPostRepository.FirestoreApprovedCollectionId).Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (pendingPostRepository *PendingPostRepositoryImpl) ApproveUser(userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(pendingPostRepository.FirestoreApprovedCollectionId).
+		Doc(userId).
+		Set(ctx, map[string]interface{}{"approvedAt": time.Now().UnixMilli()})
+
+	return err
+}
+
+// DeleteUser deletes the pending posts of the user and the record of the user
+// being approved.
+func (pendingPostRepository *PendingPostRepositoryImpl) DeleteUser(userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, pendingPostRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	err = deleteDocuments(ctx, firestoreClient.Collection(pendingPostRepository.FirestoreCollectionId).
+		Where("uid", "==", userId).
+		Documents(ctx))
+	if err != nil {
+		return err
+	}
+
+	_, err = firestoreClient.Collection(pendingPostRepository.FirestoreApprovedCollectionId).Doc(userId).Delete(ctx)
+	return err
+}
+
+func toPendingPost(document *firestore.DocumentSnapshot) (*model.PendingPost, error) {
+	var post model.PendingPost
+	if err := document.DataTo(&post); err != nil {
+		return nil, err
+	}
+
+	post.PendingId = document.Ref.ID
+	return &post, nil
+}
+
+func toPendingPosts(documents []*firestore.DocumentSnapshot) ([]model.PendingPost, error) {
+	posts := make([]model.PendingPost, 0, len(documents))
+	for _, document := range documents {
+		post, err := toPendingPost(document)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+
+	return posts, nil
+}
+
+var CurrentPendingPostRepository PendingPostRepository
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PostIndexRepository interface {
+	SavePost(post model.IndexedPost) error
+	DeletePost(postId string) error
+	GetPostsSince(timestamp int64, organization string, entityType string) ([]model.IndexedPost, error)
+	GetOrganizationEntityIds(organization string) ([]string, error)
+	CountEntityPosts(entityIds []string) (map[string]int, error)
+}
+
+// PostCountBatchSize is the most entities counted at once, as Firestore
+// allows at most 30 values in an "in" filter.
+const PostCountBatchSize = 30
+
+// PostIndexRepositoryImpl stores one document per post, named by its id.
+type PostIndexRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (postIndexRepository *PostIndexRepositoryImpl) SavePost(post model.IndexedPost) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, postIndexRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(postIndexRepository.FirestoreCollectionId).
+		Doc(post.PostId).
+		Set(ctx, post)
+
+	return err
+}
+
+func (postIndexRepository *PostIndexRepositoryImpl) DeletePost(postId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, postIndexRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(postIndexRepository.FirestoreCollectionId).
+		Doc(postId).
+		Delete(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+
+	return err
+}
+
+// GetPostsSince reads the posts made at or after timestamp, oldest first, on
+// the entities of the organization and type unless they are empty.
+func (postIndexRepository *PostIndexRepositoryImpl) GetPostsSince(timestamp int64, organization string, entityType string) ([]model.IndexedPost, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, postIndexRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	query := firestoreClient.Collection(postIndexRepository.FirestoreCollectionId).Query
+	if organization != "" {
+		query = query.Where("organization", "==", organization)
+	}
+	if entityType != "" {
+		query = query.Where("entityType", "==", entityType)
+	}
+	documents, err := query.
+		Where("timestamp", ">=", timestamp).
+		OrderBy("timestamp", firestore.Asc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]model.IndexedPost, 0, len(documents))
+	for _, document := range documents {
+		var post model.IndexedPost
+		if err := document.DataTo(&post); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// GetOrganizationEntityIds lists the entities of the organization with
+// posts, each once.
+func (postIndexRepository *PostIndexRepositoryImpl) GetOrganizationEntityIds(organization string) ([]string, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, postIndexRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(postIndexRepository.FirestoreCollectionId).
+		Where("organization", "==", organization).
+		Select("entityId").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var entityIds []string
+	for _, document := range documents {
+		var post model.IndexedPost
+		if err := document.DataTo(&post); err != nil {
+			return nil, err
+		}
+		if !seen[post.EntityId] {
+			seen[post.EntityId] = true
+			entityIds = append(entityIds, post.EntityId)
+		}
+	}
+
+	return entityIds, nil
+}
+
+// CountEntityPosts counts the posts of each of at most PostCountBatchSize
+// entities. Entities without posts are left out.
+func (postIndexRepository *PostIndexRepositoryImpl) CountEntityPosts(entityIds []string) (map[string]int, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, postIndexRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(postIndexRepository.FirestoreCollectionId).
+		Where("entityId", "in", entityIds).
+		Select("entityId").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, document := range documents {
+		var post model.IndexedPost
+		if err := document.DataTo(&post); err != nil {
+			return nil, err
+		}
+		counts[post.EntityId]++
+	}
+
+	return counts, nil
+}
+
+var CurrentPostIndexRepository PostIndexRepository
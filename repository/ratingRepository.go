@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+// firestoreInLimit is the most values Firestore compares with in an "in"
+// query.
+const firestoreInLimit = 30
+
+type RatingRepository interface {
+	SaveRating(rating model.Rating) error
+	DeleteRating(entityId string, userId string) error
+	GetRatings(entityIds []string) ([]model.Rating, error)
+	DeleteUserRatings(userId string) error
+}
+
+// RatingRepositoryImpl stores the rating of a user for an entity in a
+// document named by both, so a user has at most one rating per entity.
+type RatingRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (ratingRepository *RatingRepositoryImpl) SaveRating(rating model.Rating) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, ratingRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(ratingRepository.FirestoreCollectionId).
+		Doc(ratingDocumentId(rating.EntityId, rating.UserId)).
+		Set(ctx, rating)
+
+	return err
+}
+
+func (ratingRepository *RatingRepositoryImpl) DeleteRating(entityId string, userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, ratingRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(ratingRepository.FirestoreCollectionId).
+		Doc(ratingDocumentId(entityId, userId)).
+		Delete(ctx)
+
+	return err
+}
+
+// GetRatings reads the ratings of the entities, querying at most
+// firestoreInLimit entities at a time.
+func (ratingRepository *RatingRepositoryImpl) GetRatings(entityIds []string) ([]model.Rating, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, ratingRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	ratings := []model.Rating{}
+	for start := 0; start < len(entityIds); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(entityIds))
+		documents, err := firestoreClient.Collection(ratingRepository.FirestoreCollectionId).
+			Where("entityId", "in", entityIds[start:end]).
+			Documents(ctx).
+			GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documents {
+			var rating model.Rating
+			if err := document.DataTo(&rating); err != nil {
+				return nil, err
+			}
+			ratings = append(ratings, rating)
+		}
+	}
+
+	return ratings, nil
+}
+
+func (ratingRepository *RatingRepositoryImpl) DeleteUserRatings(userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, ratingRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	return deleteDocuments(ctx, firestoreClient.Collection(ratingRepository.FirestoreCollectionId).
+		Where("uid", "==", userId).
+		Documents(ctx))
+}
+
+func ratingDocumentId(entityId string, userId string) string {
+	return entityId + "_" + userId
+}
+
+var CurrentRatingRepository RatingRepository
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ReportRepository interface {
+	CreateReport(report model.PostReport) error
+	CountReports(postId string) (int, error)
+	GetOpenReports() ([]model.PostReport, error)
+	ResolveReports(postId string, resolverUid string, resolvedAt int64) ([]model.PostReport, error)
+	DeleteUserReports(reporterUid string) error
+}
+
+// ReportRepositoryImpl stores one document per post and reporter, so a user
+// reporting the same post again is rejected with model.ErrDuplicateReport,
+// also after the report is resolved.
+type ReportRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (reportRepository *ReportRepositoryImpl) CreateReport(report model.PostReport) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, reportRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(reportRepository.FirestoreCollectionId).
+		Doc(report.PostId+"_"+report.ReporterUid).
+		Create(ctx, report)
+	if status.Code(err) == codes.AlreadyExists {
+		return model.ErrDuplicateReport
+	}
+
+	return err
+}
+
+// CountReports counts the open reports of the post.
+func (reportRepository *ReportRepositoryImpl) CountReports(postId string) (int, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, reportRepository.FirestoreProjectId)
+	if err != nil {
+		return 0, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(reportRepository.FirestoreCollectionId).
+		Where("pid", "==", postId).
+		Where("status", "==", string(model.ReportOpen)).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(documents), nil
+}
+
+func (reportRepository *ReportRepositoryImpl) GetOpenReports() ([]model.PostReport, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, reportRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(reportRepository.FirestoreCollectionId).
+		Where("status", "==", string(model.ReportOpen)).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]model.PostReport, 0, len(documents))
+	for _, document := range documents {
+		var report model.PostReport
+		if err := document.DataTo(&report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// ResolveReports marks the open reports of the post as resolved by the
+// moderator and returns them.
+func (reportRepository *ReportRepositoryImpl) ResolveReports(postId string, resolverUid string, resolvedAt int64) ([]model.PostReport, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, reportRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(reportRepository.FirestoreCollectionId).
+		Where("pid", "==", postId).
+		Where("status", "==", string(model.ReportOpen)).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]model.PostReport, 0, len(documents))
+	for _, document := range documents {
+		var report model.PostReport
+		if err := document.DataTo(&report); err != nil {
+			return nil, err
+		}
+
+		_, err = document.Ref.Update(ctx, []firestore.Update{
+			{Path: "status", Value: string(model.ReportResolved)},
+			{Path: "resolverUid", Value: resolverUid},
+			{Path: "resolvedAt", Value: resolvedAt},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		report.Status = model.ReportResolved
+		report.ResolverUid = resolverUid
+		report.ResolvedAt = resolvedAt
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// DeleteUserReports deletes the reports filed by the user, with their texts.
+func (reportRepository *ReportRepositoryImpl) DeleteUserReports(reporterUid string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, reportRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	return deleteDocuments(ctx, firestoreClient.Collection(reportRepository.FirestoreCollectionId).
+		Where("reporterUid", "==", reporterUid).
+		Documents(ctx))
+}
+
+var CurrentReportRepository ReportRepository
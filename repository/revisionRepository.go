@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/api/iterator"
+)
+
+type RevisionRepository interface {
+	CreateRevision(revision model.PostRevision) (string, error)
+	GetRevisions(postId string) ([]model.PostRevision, error)
+	DeleteRevision(postId string, revisionId string) error
+	DeleteRevisions(postId string) error
+}
+
+// RevisionRepositoryImpl keeps the revisions of each post in a subcollection
+// of a document named by the post id.
+type RevisionRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+const revisionSubcollection = "revisions"
+
+// CreateRevision stores the revision and returns its id.
+func (revisionRepository *RevisionRepositoryImpl) CreateRevision(revision model.PostRevision) (string, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, revisionRepository.FirestoreProjectId)
+	if err != nil {
+		return "", err
+	}
+	defer firestoreClient.Close()
+
+	document, _, err := firestoreClient.Collection(revisionRepository.FirestoreCollectionId).
+		Doc(revision.PostId).
+		Collection(revisionSubcollection).
+		Add(ctx, revision)
+	if err != nil {
+		return "", err
+	}
+
+	return document.ID, nil
+}
+
+func (revisionRepository *RevisionRepositoryImpl) DeleteRevision(postId string, revisionId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, revisionRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(revisionRepository.FirestoreCollectionId).
+		Doc(postId).
+		Collection(revisionSubcollection).
+		Doc(revisionId).
+		Delete(ctx)
+
+	return err
+}
+
+func (revisionRepository *RevisionRepositoryImpl) GetRevisions(postId string) ([]model.PostRevision, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, revisionRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents := firestoreClient.Collection(revisionRepository.FirestoreCollectionId).
+		Doc(postId).
+		Collection(revisionSubcollection).
+		OrderBy("timestamp", firestore.Asc).
+		Documents(ctx)
+	defer documents.Stop()
+
+	revisions := []model.PostRevision{}
+	for {
+		document, err := documents.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var revision model.PostRevision
+		if err := document.DataTo(&revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (revisionRepository *RevisionRepositoryImpl) DeleteRevisions(postId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, revisionRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	return deleteDocuments(ctx, firestoreClient.Collection(revisionRepository.FirestoreCollectionId).
+		Doc(postId).
+		Collection(revisionSubcollection).
+		Documents(ctx))
+}
+
+var CurrentRevisionRepository RevisionRepository
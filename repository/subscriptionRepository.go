@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type SubscriptionRepository interface {
+	SaveSubscription(subscription model.ThreadSubscription) error
+	DeleteSubscription(entityId string, userId string) error
+	GetSubscription(entityId string, userId string) (*model.ThreadSubscription, error)
+	GetEntitySubscriptions(entityId string) ([]model.ThreadSubscription, error)
+	DeleteUserSubscriptions(userId string) error
+}
+
+// SubscriptionRepositoryImpl stores the subscription of a user to the thread
+// of an entity in a document named by both, so a user follows a thread at
+// most once.
+type SubscriptionRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (subscriptionRepository *SubscriptionRepositoryImpl) SaveSubscription(subscription model.ThreadSubscription) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, subscriptionRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(subscriptionRepository.FirestoreCollectionId).
+		Doc(subscriptionDocumentId(subscription.EntityId, subscription.UserId)).
+		Set(ctx, subscription)
+
+	return err
+}
+
+func (subscriptionRepository *SubscriptionRepositoryImpl) DeleteSubscription(entityId string, userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, subscriptionRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(subscriptionRepository.FirestoreCollectionId).
+		Doc(subscriptionDocumentId(entityId, userId)).
+		Delete(ctx)
+
+	return err
+}
+
+// GetSubscription reads the subscription of the user to the thread of the
+// entity, or nil if the user does not follow it.
+func (subscriptionRepository *SubscriptionRepositoryImpl) GetSubscription(entityId string, userId string) (*model.ThreadSubscription, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, subscriptionRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	snapshot, err := firestoreClient.Collection(subscriptionRepository.FirestoreCollectionId).
+		Doc(subscriptionDocumentId(entityId, userId)).
+		Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription model.ThreadSubscription
+	if err := snapshot.DataTo(&subscription); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (subscriptionRepository *SubscriptionRepositoryImpl) GetEntitySubscriptions(entityId string) ([]model.ThreadSubscription, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, subscriptionRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(subscriptionRepository.FirestoreCollectionId).
+		Where("entityId", "==", entityId).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := []model.ThreadSubscription{}
+	for _, document := range documents {
+		var subscription model.ThreadSubscription
+		if err := document.DataTo(&subscription); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (subscriptionRepository *SubscriptionRepositoryImpl) DeleteUserSubscriptions(userId string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, subscriptionRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	return deleteDocuments(ctx, firestoreClient.Collection(subscriptionRepository.FirestoreCollectionId).
+		Where("uid", "==", userId).
+		Documents(ctx))
+}
+
+func subscriptionDocumentId(entityId string, userId string) string {
+	return entityId + "_" + userId
+}
+
+var CurrentSubscriptionRepository SubscriptionRepository
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
@@ -11,9 +12,14 @@ import (
 
 type ThreadIdRepository interface {
 	GetThreadId(id string) (*string, error)
+	GetThreadIds(ids []string) (map[string]string, error)
 	CreateThreadId(id string, threadId string) error
+	DeleteThreadId(id string) error
+	GetEntityId(threadId string) (*string, error)
 }
 
+const threadIdBatchSize = 100
+
 type ThreadIdRepositoryImpl struct {
 	FirestoreProjectId    string
 	FirestoreCollectionId string
@@ -46,6 +52,43 @@ func (threadRepository *ThreadIdRepositoryImpl) GetThreadId(id string) (*string,
 	return &threadIdString, err
 }
 
+// GetThreadIds returns the threads of the entities that have one, by entity
+// id, reading the documents in batches.
+func (threadRepository *ThreadIdRepositoryImpl) GetThreadIds(ids []string) (map[string]string, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, threadRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	threadIds := map[string]string{}
+	for start := 0; start < len(ids); start += threadIdBatchSize {
+		var documents []*firestore.DocumentRef
+		for _, id := range ids[start:min(start+threadIdBatchSize, len(ids))] {
+			documents = append(documents, firestoreClient.Collection(threadRepository.FirestoreCollectionId).Doc(id))
+		}
+
+		dataSnapshots, err := firestoreClient.GetAll(ctx, documents)
+		if err != nil {
+			return nil, err
+		}
+		for _, dataSnapshot := range dataSnapshots {
+			if !dataSnapshot.Exists() {
+				continue
+			}
+			threadId, err := dataSnapshot.DataAt("topicId")
+			if err != nil {
+				return nil, err
+			}
+			threadIds[dataSnapshot.Ref.ID] = fmt.Sprint(threadId)
+		}
+	}
+
+	return threadIds, nil
+}
+
 func (threadRepository *ThreadIdRepositoryImpl) CreateThreadId(id string, threadId string) error {
 	ctx := context.Background()
 
@@ -62,4 +105,56 @@ func (threadRepository *ThreadIdRepositoryImpl) CreateThreadId(id string, thread
 	return err
 }
 
+func (threadRepository *ThreadIdRepositoryImpl) DeleteThreadId(id string) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, threadRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(threadRepository.FirestoreCollectionId).Doc(id).Delete(ctx)
+
+	return err
+}
+
+// GetEntityId returns the id of the entity mapped to the thread, or nil if
+// the thread belongs to no entity. Older mappings store the thread id as a
+// number, so both forms are looked up.
+func (threadRepository *ThreadIdRepositoryImpl) GetEntityId(threadId string) (*string, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, threadRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	dataSnapshots, err := firestoreClient.Collection(threadRepository.FirestoreCollectionId).
+		Where("topicId", "in", threadIdValues(threadId)).
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(dataSnapshots) == 0 {
+		return nil, nil
+	}
+
+	entityId := dataSnapshots[0].Ref.ID
+
+	return &entityId, nil
+}
+
+// threadIdValues lists the forms a thread id may be stored in.
+func threadIdValues(threadId string) []interface{} {
+	values := []interface{}{threadId}
+	if number, err := strconv.ParseInt(threadId, 10, 64); err == nil {
+		values = append(values, number)
+	}
+	return values
+}
+
 var CurrentThreadIdRepository ThreadIdRepository
@@ -4,49 +4,69 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
 
 type ThreadRepository interface {
-	GetThread(threadId string, page *string, postIndex *string) (*model.Thread, error)
+	GetThread(threadId string, query model.ThreadQuery) (*model.Thread, error)
+	GetThreadPost(postId string, viewerUid *string) (*model.Post, error)
 	CreateThread(thread model.Thread) (*model.Thread, error)
 	CreateThreadPost(post model.Post) (*model.Post, error)
 	UpdateThreadPost(post model.Post) error
 	DeleteThreadPost(post model.Post) error
+	VoteThreadPost(post model.Post) error
+	UnvoteThreadPost(post model.Post) error
+	GetUserPosts(userslug string, page int) ([]*model.Post, int, error)
+	PurgeThreadPost(post model.Post) error
+	ChangePostOwner(post model.Post, userId string) error
 }
 
 type ThreadRepositoryImpl struct {
-	WriteApiToken       string
-	ReadApiToken        string
+	SecretProvider      secret.SecretProvider
 	CommunityApiUrl     string
 	TopicPath           string
 	TopicsPath          string
 	PostsPath           string
-	ThreadSlugPath      string
+	UserPath            string
 	ThreadBotUid        string
 	CommunityCategoryId string
 }
 
-func (threadRepository *ThreadRepositoryImpl) GetThread(threadId string, page *string, postIndex *string) (*model.Thread, error) {
-	bearerToken := threadRepository.ReadApiToken
+func (threadRepository *ThreadRepositoryImpl) GetThread(threadId string, query model.ThreadQuery) (*model.Thread, error) {
+	// Reading as a viewer needs the write token, which may act as any user.
+	tokenKey := secret.ReadApiToken
+	if query.ViewerUid != nil {
+		tokenKey = secret.WriteApiToken
+	}
+
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(tokenKey)
+	if err != nil {
+		log.Println("Could not get api token.\n[ERROR] -", err)
+		return nil, err
+	}
 	method := http.MethodGet
 	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.TopicPath + threadId
-	sortParam := "?sort=newest_to_oldest"
 
-	if page != nil {
-		endpointUrl = endpointUrl + sortParam + "&page=" + *page
-	} else if postIndex != nil {
-		endpointUrl = endpointUrl + threadRepository.ThreadSlugPath + *postIndex + sortParam
-	} else {
-		endpointUrl = endpointUrl + sortParam
+	params := map[string]string{
+		"sort": query.Sort.ToNodeBBSort(),
+	}
+	if query.Page != nil {
+		params["page"] = *query.Page
+	}
+	if query.ViewerUid != nil {
+		params["_uid"] = *query.ViewerUid
 	}
 
 	response, err := util.Request(util.RequestOptions{
-		Method:      method,
-		EndpointUrl: endpointUrl,
-		AccessToken: &bearerToken,
+		Method:          method,
+		EndpointUrl:     endpointUrl,
+		AccessToken:     &bearerToken,
+		QueryParameters: &params,
 	})
 	if err != nil {
 		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
@@ -57,12 +77,83 @@ func (threadRepository *ThreadRepositoryImpl) GetThread(threadId string, page *s
 	return thread, err
 }
 
+func (threadRepository *ThreadRepositoryImpl) GetThreadPost(postId string, viewerUid *string) (*model.Post, error) {
+	// Reading as a viewer needs the write token, which may act as any user.
+	tokenKey := secret.ReadApiToken
+	params := map[string]string{}
+	if viewerUid != nil {
+		tokenKey = secret.WriteApiToken
+		params["_uid"] = *viewerUid
+	}
+
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(tokenKey)
+	if err != nil {
+		log.Println("Could not get api token.\n[ERROR] -", err)
+		return nil, err
+	}
+	method := http.MethodGet
+	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + postId
+
+	response, err := util.Request(util.RequestOptions{
+		Method:          method,
+		EndpointUrl:     endpointUrl,
+		AccessToken:     &bearerToken,
+		QueryParameters: &params,
+	})
+	if err != nil {
+		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
+		return nil, err
+	}
+
+	post, err := util.UnmarshalPostResponse(response)
+
+	return post, err
+}
+
+// GetUserPosts returns a page of the posts a user has made in the feedback
+// category, and the number of pages of posts the user has in all categories.
+func (threadRepository *ThreadRepositoryImpl) GetUserPosts(userslug string, page int) ([]*model.Post, int, error) {
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.ReadApiToken)
+	if err != nil {
+		log.Println("Could not get read token.\n[ERROR] -", err)
+		return nil, 0, err
+	}
+	method := http.MethodGet
+	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.UserPath + url.PathEscape(userslug) + "/posts"
+
+	params := map[string]string{
+		"page": strconv.Itoa(page),
+	}
+
+	response, err := util.Request(util.RequestOptions{
+		Method:          method,
+		EndpointUrl:     endpointUrl,
+		AccessToken:     &bearerToken,
+		QueryParameters: &params,
+	})
+	if err != nil {
+		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
+		return nil, 0, err
+	}
+
+	userPosts, err := util.UnmarshalUserPosts(response)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userPosts.ToPosts(threadRepository.CommunityCategoryId), userPosts.PageCount(), nil
+}
+
 func (threadRepository *ThreadRepositoryImpl) CreateThread(thread model.Thread) (*model.Thread, error) {
 	if thread.Title == nil || thread.Content == nil {
 		return nil, fmt.Errorf("cannot create thread without title and content")
 	}
 
-	bearerToken := threadRepository.WriteApiToken
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return nil, err
+	}
 	method := http.MethodPost
 	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.TopicsPath
 
@@ -99,7 +190,11 @@ func (threadRepository *ThreadRepositoryImpl) CreateThreadPost(post model.Post)
 		toPid = *post.ToPostId
 	}
 
-	bearerToken := threadRepository.WriteApiToken
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return nil, err
+	}
 	method := http.MethodPost
 	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.TopicsPath + *post.ThreadId
 
@@ -133,7 +228,11 @@ func (threadRepository *ThreadRepositoryImpl) UpdateThreadPost(post model.Post)
 		return fmt.Errorf("cannot update post without threadid, postid, and content")
 	}
 
-	bearerToken := threadRepository.WriteApiToken
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return err
+	}
 	method := http.MethodPut
 	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + *post.PostId
 
@@ -141,7 +240,7 @@ func (threadRepository *ThreadRepositoryImpl) UpdateThreadPost(post model.Post)
 		"_uid":    *post.UserId,
 		"content": *post.Content,
 	}
-	_, err := util.Request(util.RequestOptions{
+	_, err = util.Request(util.RequestOptions{
 		Method:      method,
 		EndpointUrl: endpointUrl,
 		AccessToken: &bearerToken,
@@ -159,7 +258,11 @@ func (threadRepository *ThreadRepositoryImpl) DeleteThreadPost(post model.Post)
 		return fmt.Errorf("cannot update post without postId and userId")
 	}
 
-	bearerToken := threadRepository.WriteApiToken
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return err
+	}
 	method := http.MethodDelete
 	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + *post.PostId + "/state"
 
@@ -167,7 +270,7 @@ func (threadRepository *ThreadRepositoryImpl) DeleteThreadPost(post model.Post)
 		"_uid": *post.UserId,
 	}
 
-	_, err := util.Request(util.RequestOptions{
+	_, err = util.Request(util.RequestOptions{
 		Method:      method,
 		EndpointUrl: endpointUrl,
 		AccessToken: &bearerToken,
@@ -180,4 +283,106 @@ func (threadRepository *ThreadRepositoryImpl) DeleteThreadPost(post model.Post)
 	return err
 }
 
+// PurgeThreadPost deletes the post for good, as the user of the post.
+func (threadRepository *ThreadRepositoryImpl) PurgeThreadPost(post model.Post) error {
+	if post.PostId == nil || post.UserId == nil {
+		return fmt.Errorf("cannot purge post without postId and userId")
+	}
+
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return err
+	}
+	method := http.MethodDelete
+	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + *post.PostId
+
+	purgeBody := map[string]string{
+		"_uid": *post.UserId,
+	}
+
+	_, err = util.Request(util.RequestOptions{
+		Method:      method,
+		EndpointUrl: endpointUrl,
+		AccessToken: &bearerToken,
+		RequestBody: &purgeBody,
+	})
+	if err != nil {
+		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
+	}
+
+	return err
+}
+
+// ChangePostOwner reassigns the post to another user, as the thread bot.
+func (threadRepository *ThreadRepositoryImpl) ChangePostOwner(post model.Post, userId string) error {
+	if post.PostId == nil {
+		return fmt.Errorf("cannot change owner of post without postId")
+	}
+
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return err
+	}
+	method := http.MethodPut
+	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + "owner"
+
+	_, err = util.Request(util.RequestOptions{
+		Method:      method,
+		EndpointUrl: endpointUrl,
+		AccessToken: &bearerToken,
+		JsonBody: map[string]interface{}{
+			"_uid": threadRepository.ThreadBotUid,
+			"pids": []string{*post.PostId},
+			"uid":  userId,
+		},
+	})
+	if err != nil {
+		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
+	}
+
+	return err
+}
+
+func (threadRepository *ThreadRepositoryImpl) VoteThreadPost(post model.Post) error {
+	return threadRepository.vote(http.MethodPut, post)
+}
+
+func (threadRepository *ThreadRepositoryImpl) UnvoteThreadPost(post model.Post) error {
+	return threadRepository.vote(http.MethodDelete, post)
+}
+
+func (threadRepository *ThreadRepositoryImpl) vote(method string, post model.Post) error {
+	if post.PostId == nil || post.UserId == nil {
+		return fmt.Errorf("cannot vote on post without postId and userId")
+	}
+
+	bearerToken, err := threadRepository.SecretProvider.GetSecret(secret.WriteApiToken)
+	if err != nil {
+		log.Println("Could not get write token.\n[ERROR] -", err)
+		return err
+	}
+	endpointUrl := threadRepository.CommunityApiUrl + threadRepository.PostsPath + *post.PostId + "/vote"
+
+	voteBody := map[string]string{
+		"_uid": *post.UserId,
+	}
+	if method == http.MethodPut {
+		voteBody["delta"] = "1"
+	}
+
+	_, err = util.Request(util.RequestOptions{
+		Method:      method,
+		EndpointUrl: endpointUrl,
+		AccessToken: &bearerToken,
+		RequestBody: &voteBody,
+	})
+	if err != nil {
+		log.Println("Error on request.\n[ERROR] -", err, method, endpointUrl)
+	}
+
+	return err
+}
+
 var CurrentThreadRepository ThreadRepository
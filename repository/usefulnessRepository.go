@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type UsefulnessRepository interface {
+	CreateVote(vote model.UsefulnessVote) error
+	GetIpVotes(ipWindow string) ([]model.UsefulnessVote, error)
+	CountVotes(field string, value string) (helpful int, notHelpful int, err error)
+}
+
+// UsefulnessRepositoryImpl stores each vote in a document named by its
+// VoteId, so a nonce used again is rejected with model.ErrUsedNonce.
+type UsefulnessRepositoryImpl struct {
+	FirestoreProjectId    string
+	FirestoreCollectionId string
+}
+
+func (usefulnessRepository *UsefulnessRepositoryImpl) CreateVote(vote model.UsefulnessVote) error {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, usefulnessRepository.FirestoreProjectId)
+	if err != nil {
+		return err
+	}
+	defer firestoreClient.Close()
+
+	_, err = firestoreClient.Collection(usefulnessRepository.FirestoreCollectionId).
+		Doc(vote.VoteId).
+		Create(ctx, vote)
+	if status.Code(err) == codes.AlreadyExists {
+		return model.ErrUsedNonce
+	}
+
+	return err
+}
+
+func (usefulnessRepository *UsefulnessRepositoryImpl) GetIpVotes(ipWindow string) ([]model.UsefulnessVote, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, usefulnessRepository.FirestoreProjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer firestoreClient.Close()
+
+	documents, err := firestoreClient.Collection(usefulnessRepository.FirestoreCollectionId).
+		Where("ipWindow", "==", ipWindow).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]model.UsefulnessVote, 0, len(documents))
+	for _, document := range documents {
+		var vote model.UsefulnessVote
+		if err := document.DataTo(&vote); err != nil {
+			return nil, err
+		}
+		vote.VoteId = document.Ref.ID
+		votes = append(votes, vote)
+	}
+
+	return votes, nil
+}
+
+// CountVotes counts the helpful and not helpful votes where field, entityId
+// or entityType, has value, without reading the votes.
+func (usefulnessRepository *UsefulnessRepositoryImpl) CountVotes(field string, value string) (int, int, error) {
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, usefulnessRepository.FirestoreProjectId)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer firestoreClient.Close()
+
+	counts := [2]int{}
+	for i, helpful := range []bool{true, false} {
+		query := firestoreClient.Collection(usefulnessRepository.FirestoreCollectionId).
+			Where(field, "==", value).
+			Where("helpful", "==", helpful)
+		result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		if count, ok := result["count"].(*firestorepb.Value); ok {
+			counts[i] = int(count.GetIntegerValue())
+		}
+	}
+
+	return counts[0], counts[1], nil
+}
+
+var CurrentUsefulnessRepository UsefulnessRepository
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
 
@@ -13,13 +14,17 @@ type UserRepository interface {
 }
 
 type UserRepositoryImpl struct {
-	ReadApiToken     string
+	SecretProvider   secret.SecretProvider
 	CommunityBaseUrl string
 	UserByEmailPath  string
 }
 
 func (userRepository *UserRepositoryImpl) GetByEmail(email string) (*model.User, error) {
-	bearerToken := userRepository.ReadApiToken
+	bearerToken, err := userRepository.SecretProvider.GetSecret(secret.ReadApiToken)
+	if err != nil {
+		log.Println("Could not get read token.\n[ERROR] -", err)
+		return nil, err
+	}
 	method := http.MethodGet
 	endpointUrl := userRepository.CommunityBaseUrl + userRepository.UserByEmailPath + email
 
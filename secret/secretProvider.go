@@ -0,0 +1,126 @@
+package secret
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ReadApiToken         = "READ_API_TOKEN"
+	WriteApiToken        = "WRITE_API_TOKEN"
+	SparqlUpdateToken    = "SPARQL_UPDATE_TOKEN"
+	UsefulnessNonceKey   = "USEFULNESS_NONCE_KEY"
+	SmtpPassword         = "SMTP_PASSWORD"
+	SubscriptionTokenKey = "SUBSCRIPTION_TOKEN_KEY"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+type SecretProvider interface {
+	GetSecret(key string) (string, error)
+}
+
+// EnvSecretProvider reads secrets from environment variables on every lookup.
+type EnvSecretProvider struct{}
+
+func (provider *EnvSecretProvider) GetSecret(key string) (string, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+type fileSecret struct {
+	value   string
+	modTime time.Time
+	size    int64
+}
+
+// FileSecretProvider reads secrets from files named after the secret key in
+// Directory, as mounted by Kubernetes secrets or Secret Manager volumes.
+// Files are reloaded when their modification time or size changes, and keys
+// without a file are resolved through Fallback.
+type FileSecretProvider struct {
+	Directory string
+	Fallback  SecretProvider
+
+	mutex   sync.RWMutex
+	secrets map[string]fileSecret
+}
+
+func NewFileSecretProvider(directory string, fallback SecretProvider) *FileSecretProvider {
+	return &FileSecretProvider{
+		Directory: directory,
+		Fallback:  fallback,
+		secrets:   map[string]fileSecret{},
+	}
+}
+
+func (provider *FileSecretProvider) GetSecret(key string) (string, error) {
+	value, err := provider.load(key)
+	if err == nil {
+		return value, nil
+	}
+
+	if provider.Fallback != nil {
+		return provider.Fallback.GetSecret(key)
+	}
+	return "", err
+}
+
+func (provider *FileSecretProvider) load(key string) (string, error) {
+	if provider.Directory == "" {
+		return "", ErrSecretNotFound
+	}
+
+	path := filepath.Join(provider.Directory, key)
+	info, err := os.Stat(path)
+	if err != nil {
+		provider.forget(key)
+		return "", ErrSecretNotFound
+	}
+
+	provider.mutex.RLock()
+	cached, present := provider.secrets[key]
+	provider.mutex.RUnlock()
+	if present && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.value, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(content))
+
+	provider.mutex.Lock()
+	if provider.secrets == nil {
+		provider.secrets = map[string]fileSecret{}
+	}
+	provider.secrets[key] = fileSecret{
+		value:   value,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+	provider.mutex.Unlock()
+
+	if present {
+		log.Printf("Reloaded secret %s from %s\n", key, path)
+	}
+
+	return value, nil
+}
+
+func (provider *FileSecretProvider) forget(key string) {
+	provider.mutex.Lock()
+	delete(provider.secrets, key)
+	provider.mutex.Unlock()
+}
+
+var CurrentSecretProvider SecretProvider
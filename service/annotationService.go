@@ -0,0 +1,130 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type AnnotationService interface {
+	GetAnnotations(entityId string) ([]model.RdfTriple, int)
+}
+
+// AnnotationServiceImpl describes the posts of a thread as W3C Web
+// Annotations and DQV user quality feedback on the entity. Posts and users
+// are identified by their pages in the community at CommunityBaseUrl.
+type AnnotationServiceImpl struct {
+	ThreadService    ThreadService
+	EntityService    EntityService
+	CommunityBaseUrl string
+	ThreadBotUid     string
+	PageSize         int
+}
+
+// GetAnnotations reads every page of the thread of the entity, leaving out
+// the opening post of the thread bot.
+func (annotationService *AnnotationServiceImpl) GetAnnotations(entityId string) ([]model.RdfTriple, int) {
+	entity, err := annotationService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return nil, http.StatusNotFound
+	}
+	target := entity.ResourceUri()
+	if target == "" {
+		return nil, http.StatusNotFound
+	}
+
+	triples := []model.RdfTriple{}
+	for page, pageCount := 1, 1; page <= pageCount; page++ {
+		pageParam := strconv.Itoa(page)
+		thread, statusCode := annotationService.ThreadService.GetThreadByEntityId(entityId, model.ThreadQuery{
+			Page:     &pageParam,
+			Sort:     model.OldestFirst,
+			PageSize: &annotationService.PageSize,
+		})
+		if !util.SuccsessfulStatus(statusCode) || thread == nil {
+			log.Println("Could not read thread for annotations, status:", statusCode)
+			return nil, statusCode
+		}
+
+		for _, post := range thread.Posts {
+			if post.PostId == nil || (post.UserId != nil && *post.UserId == annotationService.ThreadBotUid) {
+				continue
+			}
+			triples = append(triples, annotationTriples(post, target, annotationService.CommunityBaseUrl)...)
+		}
+
+		if thread.Pagination != nil && thread.Pagination.PageCount != nil {
+			pageCount = *thread.Pagination.PageCount
+		}
+	}
+
+	return triples, http.StatusOK
+}
+
+// annotationTriples describes a post as an annotation of the target with a
+// textual body. Issues are quality assessments classified by their category,
+// and replies are linked to the post they answer.
+func annotationTriples(post *model.Post, target string, communityBaseUrl string) []model.RdfTriple {
+	subject := model.Iri(communityBaseUrl + "post/" + *post.PostId)
+	body := model.BlankNode("body" + *post.PostId)
+
+	motivation := model.OaNamespace + "commenting"
+	if post.Issue != nil {
+		motivation = model.DqvNamespace + "qualityAssessment"
+	} else if post.ToPostId != nil && *post.ToPostId != "" {
+		motivation = model.OaNamespace + "replying"
+	}
+
+	triples := []model.RdfTriple{
+		{Subject: subject, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "Annotation")},
+		{Subject: subject, Predicate: model.RdfType, Object: model.Iri(model.DqvNamespace + "UserQualityFeedback")},
+		{Subject: subject, Predicate: model.OaNamespace + "hasTarget", Object: model.Iri(target)},
+		{Subject: subject, Predicate: model.OaNamespace + "motivatedBy", Object: model.Iri(motivation)},
+		{Subject: subject, Predicate: model.OaNamespace + "hasBody", Object: body},
+		{Subject: body, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "TextualBody")},
+		{Subject: body, Predicate: model.RdfNamespace + "value", Object: model.Literal(stringOrEmpty(post.Content))},
+		{Subject: body, Predicate: model.DcNamespace + "format", Object: model.Literal("text/html")},
+	}
+
+	if post.ToPostId != nil && *post.ToPostId != "" {
+		triples = append(triples, model.RdfTriple{
+			Subject: subject, Predicate: model.AsNamespace + "inReplyTo", Object: model.Iri(communityBaseUrl + "post/" + *post.ToPostId),
+		})
+	}
+	if post.Issue != nil {
+		category := model.BlankNode("category" + *post.PostId)
+		triples = append(triples,
+			model.RdfTriple{Subject: subject, Predicate: model.OaNamespace + "hasBody", Object: category},
+			model.RdfTriple{Subject: category, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "TextualBody")},
+			model.RdfTriple{Subject: category, Predicate: model.OaNamespace + "purpose", Object: model.Iri(model.OaNamespace + "classifying")},
+			model.RdfTriple{Subject: category, Predicate: model.RdfNamespace + "value", Object: model.Literal(string(post.Issue.Category))},
+		)
+	}
+	if post.UserId != nil {
+		triples = append(triples, model.RdfTriple{
+			Subject: subject, Predicate: model.DctNamespace + "creator", Object: model.Iri(communityBaseUrl + "uid/" + *post.UserId),
+		})
+	}
+	if post.Timestamp != nil {
+		triples = append(triples, model.RdfTriple{
+			Subject: subject, Predicate: model.DctNamespace + "created", Object: dateTime(*post.Timestamp),
+		})
+	}
+	if post.EditedAt != nil && *post.EditedAt > 0 {
+		triples = append(triples, model.RdfTriple{
+			Subject: subject, Predicate: model.DctNamespace + "modified", Object: dateTime(*post.EditedAt),
+		})
+	}
+
+	return triples
+}
+
+func dateTime(millis int) model.RdfTerm {
+	return model.TypedLiteral(time.UnixMilli(int64(millis)).UTC().Format(time.RFC3339), model.XsdDateTime)
+}
+
+var CurrentAnnotationService AnnotationService
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
@@ -19,8 +21,9 @@ type AuthService interface {
 }
 
 type AuthServiceImpl struct {
-	UserRepository repository.UserRepository
-	KeycloakHost   string
+	UserRepository     repository.UserRepository
+	KeycloakHost       string
+	ModeratorAuthority string
 }
 
 func (authService *AuthServiceImpl) AuthenticateAndGetUser(jwt string) (*model.User, int) {
@@ -34,9 +37,58 @@ func (authService *AuthServiceImpl) AuthenticateAndGetUser(jwt string) (*model.U
 		return nil, http.StatusUnauthorized
 	}
 
+	if user != nil {
+		user.Moderator = authService.hasModeratorAuthority(claims)
+		user.Organizations = organizationsWithRoles(claims, "admin", "write")
+		user.AdminOrganizations = organizationsWithRoles(claims, "admin")
+		user.Email = fmt.Sprint((*claims)["email"])
+	}
+
 	return user, http.StatusOK
 }
 
+// hasModeratorAuthority looks for the moderator authority in the comma
+// separated authorities claim of FDK access tokens.
+func (authService *AuthServiceImpl) hasModeratorAuthority(claims *jwt.MapClaims) bool {
+	if authService.ModeratorAuthority == "" {
+		return false
+	}
+
+	for _, authority := range authorities(claims) {
+		if authority == authService.ModeratorAuthority {
+			return true
+		}
+	}
+
+	return false
+}
+
+// organizationsWithRoles lists the organizations the user has one of the
+// roles for, from authorities like organization:910244132:admin.
+func organizationsWithRoles(claims *jwt.MapClaims, roles ...string) []string {
+	var organizations []string
+	for _, authority := range authorities(claims) {
+		parts := strings.Split(authority, ":")
+		if len(parts) == 3 && parts[0] == "organization" && parts[1] != "" && slices.Contains(roles, parts[2]) {
+			organizations = append(organizations, parts[1])
+		}
+	}
+	return organizations
+}
+
+func authorities(claims *jwt.MapClaims) []string {
+	authorities, ok := (*claims)["authorities"].(string)
+	if !ok {
+		return nil
+	}
+
+	var trimmed []string
+	for _, authority := range strings.Split(authorities, ",") {
+		trimmed = append(trimmed, strings.TrimSpace(authority))
+	}
+	return trimmed
+}
+
 func (authService *AuthServiceImpl) AuthenticateJwt(jwt string) (*jwt.MapClaims, int) {
 	client := gocloak.NewClient(authService.KeycloakHost)
 
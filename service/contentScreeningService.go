@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type ContentScreeningService interface {
+	ScreenPost(post model.Post) []model.ContentViolation
+	RecordPost(post model.Post)
+}
+
+// ContentScreeningServiceImpl checks posts against its rules before they are
+// created or updated. Limits of 0 and empty lists turn a rule off. Banned
+// words are keyed by language (nb, nn, en) and may be phrases. Personal data
+// is left to the PersonalDataAction of ThreadServiceImpl.
+type ContentScreeningServiceImpl struct {
+	ContentHistoryRepository repository.ContentHistoryRepository
+	MaxLength                int
+	MaxRepeatedCharacters    int
+	MaxLinks                 int
+	BlockedDomains           []string
+	BannedWords              map[string][]string
+	RepeatWindow             time.Duration
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()\[\]"']+`)
+
+// ScreenPost lists every rule the content of the post breaks, or nothing if
+// the post may be published.
+func (screeningService *ContentScreeningServiceImpl) ScreenPost(post model.Post) []model.ContentViolation {
+	content := stringOrEmpty(post.Content)
+	if strings.TrimSpace(content) == "" {
+		return []model.ContentViolation{{Rule: model.RuleEmptyContent, Message: "content is empty"}}
+	}
+
+	var violations []model.ContentViolation
+
+	if length := utf8.RuneCountInString(content); screeningService.MaxLength > 0 && length > screeningService.MaxLength {
+		violations = append(violations, countViolation(model.RuleMaxLength,
+			"content is longer than the allowed number of characters", screeningService.MaxLength, length))
+	}
+
+	if run := longestRepeatedRun(content); screeningService.MaxRepeatedCharacters > 0 && run > screeningService.MaxRepeatedCharacters {
+		violations = append(violations, countViolation(model.RuleRepeatedCharacters,
+			"content repeats the same character too many times in a row", screeningService.MaxRepeatedCharacters, run))
+	}
+
+	links := linkPattern.FindAllString(content, -1)
+	if screeningService.MaxLinks > 0 && len(links) > screeningService.MaxLinks {
+		violations = append(violations, countViolation(model.RuleMaxLinks,
+			"content has more links than allowed", screeningService.MaxLinks, len(links)))
+	}
+
+	for _, domain := range screeningService.blockedDomains(links) {
+		violations = append(violations, model.ContentViolation{
+			Rule:    model.RuleBlockedDomain,
+			Message: fmt.Sprintf("links to %s are not allowed", domain),
+			Match:   &domain,
+		})
+	}
+
+	violations = append(violations, screeningService.bannedWords(content)...)
+
+	if violation := screeningService.repeatedContent(post); violation != nil {
+		violations = append(violations, *violation)
+	}
+
+	return violations
+}
+
+// RecordPost remembers the content of a published post, so the same user
+// posting it again within RepeatWindow is stopped.
+func (screeningService *ContentScreeningServiceImpl) RecordPost(post model.Post) {
+	if screeningService.ContentHistoryRepository == nil || post.UserId == nil || post.Content == nil {
+		return
+	}
+
+	err := screeningService.ContentHistoryRepository.SaveFingerprint(*post.UserId, contentHash(*post.Content), model.ContentFingerprint{
+		PostId:    stringOrEmpty(post.PostId),
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Println("Could not record content fingerprint.\n[ERROR] -", err)
+	}
+}
+
+func (screeningService *ContentScreeningServiceImpl) blockedDomains(links []string) []string {
+	blocked := map[string]bool{}
+	for _, link := range links {
+		host := linkHost(link)
+		for _, domain := range screeningService.BlockedDomains {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				blocked[domain] = true
+			}
+		}
+	}
+
+	domains := make([]string, 0, len(blocked))
+	for domain := range blocked {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// bannedWords matches whole words and phrases, ignoring case and punctuation.
+func (screeningService *ContentScreeningServiceImpl) bannedWords(content string) []model.ContentViolation {
+	normalized := " " + strings.Join(words(content), " ") + " "
+
+	languages := make([]string, 0, len(screeningService.BannedWords))
+	for language := range screeningService.BannedWords {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var violations []model.ContentViolation
+	for _, language := range languages {
+		for _, banned := range screeningService.BannedWords[language] {
+			phrase := strings.Join(words(banned), " ")
+			if phrase == "" || !strings.Contains(normalized, " "+phrase+" ") {
+				continue
+			}
+
+			language := language
+			violations = append(violations, model.ContentViolation{
+				Rule:     model.RuleBannedWord,
+				Message:  fmt.Sprintf("content contains a banned word (%s)", language),
+				Match:    &phrase,
+				Language: &language,
+			})
+		}
+	}
+
+	return violations
+}
+
+// repeatedContent finds the same content posted by the user within
+// RepeatWindow. Editing a post without changing it is not a repeat. Posts
+// are let through if the history cannot be read.
+func (screeningService *ContentScreeningServiceImpl) repeatedContent(post model.Post) *model.ContentViolation {
+	if screeningService.ContentHistoryRepository == nil || screeningService.RepeatWindow <= 0 || post.UserId == nil {
+		return nil
+	}
+
+	fingerprint, err := screeningService.ContentHistoryRepository.GetFingerprint(*post.UserId, contentHash(*post.Content))
+	if err != nil {
+		log.Println("Could not get content fingerprint.\n[ERROR] -", err)
+		return nil
+	}
+
+	if fingerprint == nil || time.Since(time.UnixMilli(fingerprint.Timestamp)) > screeningService.RepeatWindow {
+		return nil
+	}
+	if post.PostId != nil && fingerprint.PostId == *post.PostId {
+		return nil
+	}
+
+	return &model.ContentViolation{
+		Rule:    model.RuleRepeatedContent,
+		Message: fmt.Sprintf("the same content was posted within the last %s", screeningService.RepeatWindow),
+	}
+}
+
+func countViolation(rule model.ScreeningRule, message string, limit int, actual int) model.ContentViolation {
+	return model.ContentViolation{Rule: rule, Message: message, Limit: &limit, Actual: &actual}
+}
+
+func longestRepeatedRun(content string) int {
+	longest, run := 0, 0
+	var previous rune
+	for _, character := range content {
+		if unicode.IsSpace(character) {
+			run = 0
+			continue
+		}
+		if run > 0 && character == previous {
+			run++
+		} else {
+			run = 1
+		}
+		previous = character
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+func linkHost(link string) string {
+	host := strings.ToLower(link)
+	if index := strings.Index(host, "://"); index >= 0 {
+		host = host[index+3:]
+	}
+	if index := strings.IndexAny(host, "/?#:"); index >= 0 {
+		host = host[:index]
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func words(content string) []string {
+	return strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(strings.ToLower(content)), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+var CurrentContentScreeningService ContentScreeningService
@@ -7,6 +7,7 @@ import (
 
 type EntityService interface {
 	GetEntity(id string) (*model.Entity, error)
+	GetOrganizationEntityIds(organization string) ([]string, error)
 }
 
 type EntityServiceImpl struct {
@@ -22,4 +23,8 @@ func (entityService *EntityServiceImpl) GetEntity(id string) (*model.Entity, err
 	return entity, nil
 }
 
+func (entityService *EntityServiceImpl) GetOrganizationEntityIds(organization string) ([]string, error) {
+	return entityService.EntityRepository.GetOrganizationEntityIds(organization)
+}
+
 var CurrentEntityService EntityService
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type ErasureService interface {
+	StartErasure(user model.User, requestedBy string) (*model.ErasureTombstone, int)
+	ProcessErasure(erasureId string) (*model.ErasureTombstone, int)
+	GetErasure(erasureId string) (*model.ErasureTombstone, int)
+}
+
+// ErasureServiceImpl erases every post of a user by Policy, along with the
+// revisions, pending posts, content history, ratings, thread subscriptions
+// and abuse reports of the user. Posts are reassigned to AnonymousUid when
+// anonymizing. Their annotations in GraphStoreService, if set, are
+// reassigned or removed along with them, and removed posts are left out of
+// the statistics of StatisticsService and lose their data quality issue.
+// ProcessErasure erases at most ChunkSize posts per call, under a lease of
+// LeaseDuration on the tombstone, so only one worker processes an erasure
+// at a time. Progress is saved to the tombstone every ProgressInterval posts.
+type ErasureServiceImpl struct {
+	ThreadRepository         repository.ThreadRepository
+	RevisionRepository       repository.RevisionRepository
+	PendingPostRepository    repository.PendingPostRepository
+	ContentHistoryRepository repository.ContentHistoryRepository
+	ErasureRepository        repository.ErasureRepository
+	RatingRepository         repository.RatingRepository
+	SubscriptionRepository   repository.SubscriptionRepository
+	IssueRepository          repository.IssueRepository
+	ReportRepository         repository.ReportRepository
+	GraphStoreService        GraphStoreService
+	StatisticsService        StatisticsService
+	Policy                   model.ErasurePolicy
+	AnonymousUid             string
+	ProgressInterval         int
+	ChunkSize                int
+	LeaseDuration            time.Duration
+}
+
+// StartErasure records a tombstone listing every post the user has made in
+// feedback threads. The posts are erased by ProcessErasure.
+func (erasureService *ErasureServiceImpl) StartErasure(user model.User, requestedBy string) (*model.ErasureTombstone, int) {
+	if user.UserId == nil || user.Userslug == nil {
+		return nil, http.StatusNotFound
+	}
+
+	if erasureService.Policy == model.AnonymizeErasure {
+		if erasureService.AnonymousUid == "" {
+			log.Println("Cannot anonymize posts without an anonymous user.")
+			return nil, http.StatusInternalServerError
+		}
+		if *user.UserId == erasureService.AnonymousUid {
+			return nil, http.StatusConflict
+		}
+	}
+
+	posts, err := getUserPosts(erasureService.ThreadRepository, *user.Userslug)
+	if err != nil {
+		log.Println("Could not get posts of user.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	postIds := make([]string, 0, len(posts))
+	for _, post := range posts {
+		if post.PostId != nil {
+			postIds = append(postIds, *post.PostId)
+		}
+	}
+
+	tombstone, err := erasureService.ErasureRepository.CreateErasure(model.ErasureTombstone{
+		UserId:      *user.UserId,
+		RequestedBy: requestedBy,
+		Policy:      erasureService.Policy,
+		Status:      model.ErasureRunning,
+		PostIds:     postIds,
+		Total:       len(postIds),
+		StartedAt:   time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Println("Could not create erasure.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return tombstone, http.StatusAccepted
+}
+
+// ProcessErasure erases the next ChunkSize posts of a running erasure,
+// continuing from the last saved progress, and answers http.StatusAccepted
+// while posts remain. An erasure leased by another worker is answered with
+// http.StatusConflict. Posts that could not be erased are listed in the
+// tombstone, and fail the erasure.
+func (erasureService *ErasureServiceImpl) ProcessErasure(erasureId string) (*model.ErasureTombstone, int) {
+	tombstone, leased, err := erasureService.ErasureRepository.LeaseErasure(erasureId, time.Now(), erasureService.LeaseDuration)
+	if err != nil {
+		log.Println("Could not lease erasure.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if tombstone == nil {
+		return nil, http.StatusNotFound
+	}
+	if tombstone.Status != model.ErasureRunning {
+		return tombstone, http.StatusOK
+	}
+	if !leased {
+		return tombstone, http.StatusConflict
+	}
+
+	chunkEnd := len(tombstone.PostIds)
+	if erasureService.ChunkSize > 0 {
+		chunkEnd = min(tombstone.Processed+erasureService.ChunkSize, chunkEnd)
+	}
+
+	interval := max(erasureService.ProgressInterval, 1)
+	for tombstone.Processed < chunkEnd {
+		postId := tombstone.PostIds[tombstone.Processed]
+		if err := erasureService.erasePost(*tombstone, postId); err != nil {
+			log.Println("Could not erase post.\n[ERROR] -", err, postId)
+			tombstone.FailedPostIds = append(tombstone.FailedPostIds, postId)
+		}
+		tombstone.Processed++
+
+		if tombstone.Processed%interval == 0 && tombstone.Processed < chunkEnd {
+			if err := erasureService.ErasureRepository.UpdateErasure(*tombstone); err != nil {
+				log.Println("Could not save erasure progress.\n[ERROR] -", err)
+			}
+		}
+	}
+
+	// The lease is given up with the saved progress, for the next chunk.
+	tombstone.LeaseUntil = 0
+	if tombstone.Processed < len(tombstone.PostIds) {
+		if err := erasureService.ErasureRepository.UpdateErasure(*tombstone); err != nil {
+			log.Println("Could not save erasure progress.\n[ERROR] -", err)
+			return nil, http.StatusInternalServerError
+		}
+		return tombstone, http.StatusAccepted
+	}
+
+	tombstone.Status = model.ErasureCompleted
+	if !erasureService.eraseUserData(tombstone.UserId) || len(tombstone.FailedPostIds) > 0 {
+		tombstone.Status = model.ErasureFailed
+	}
+	tombstone.FinishedAt = time.Now().UnixMilli()
+
+	if err := erasureService.ErasureRepository.UpdateErasure(*tombstone); err != nil {
+		log.Println("Could not save erasure.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return tombstone, http.StatusOK
+}
+
+// eraseUserData erases what the user has besides posts, and tells whether
+// all of it was erased.
+func (erasureService *ErasureServiceImpl) eraseUserData(userId string) bool {
+	erased := true
+	if err := erasureService.PendingPostRepository.DeleteUser(userId); err != nil {
+		log.Println("Could not delete pending posts of user.\n[ERROR] -", err)
+		erased = false
+	}
+	if err := erasureService.ContentHistoryRepository.DeleteHistory(userId); err != nil {
+		log.Println("Could not delete content history of user.\n[ERROR] -", err)
+		erased = false
+	}
+	if erasureService.RatingRepository != nil {
+		if err := erasureService.RatingRepository.DeleteUserRatings(userId); err != nil {
+			log.Println("Could not delete ratings of user.\n[ERROR] -", err)
+			erased = false
+		}
+	}
+	if erasureService.SubscriptionRepository != nil {
+		if err := erasureService.SubscriptionRepository.DeleteUserSubscriptions(userId); err != nil {
+			log.Println("Could not delete subscriptions of user.\n[ERROR] -", err)
+			erased = false
+		}
+	}
+	if erasureService.ReportRepository != nil {
+		if err := erasureService.ReportRepository.DeleteUserReports(userId); err != nil {
+			log.Println("Could not delete reports of user.\n[ERROR] -", err)
+			erased = false
+		}
+	}
+
+	return erased
+}
+
+func (erasureService *ErasureServiceImpl) GetErasure(erasureId string) (*model.ErasureTombstone, int) {
+	tombstone, err := erasureService.ErasureRepository.GetErasure(erasureId)
+	if err != nil {
+		log.Println("Could not get erasure.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if tombstone == nil {
+		return nil, http.StatusNotFound
+	}
+
+	return tombstone, http.StatusOK
+}
+
+// erasePost erases the post by the policy the erasure was started with.
+func (erasureService *ErasureServiceImpl) erasePost(tombstone model.ErasureTombstone, postId string) error {
+	post := model.Post{PostId: &postId, UserId: &tombstone.UserId}
+
+	var err error
+	switch tombstone.Policy {
+	case model.SoftDeleteErasure:
+		err = erasureService.ThreadRepository.DeleteThreadPost(post)
+	case model.AnonymizeErasure:
+		err = erasureService.ThreadRepository.ChangePostOwner(post, erasureService.AnonymousUid)
+	default:
+		err = erasureService.ThreadRepository.PurgeThreadPost(post)
+	}
+	if err != nil {
+		return err
+	}
+
+	if erasureService.GraphStoreService != nil {
+		if tombstone.Policy == model.AnonymizeErasure {
+			erasureService.GraphStoreService.ChangePostOwner(postId, erasureService.AnonymousUid)
+		} else {
+			erasureService.GraphStoreService.DeletePost(postId)
+		}
+	}
+	if erasureService.StatisticsService != nil && tombstone.Policy != model.AnonymizeErasure {
+		erasureService.StatisticsService.RemovePost(postId)
+	}
+	if erasureService.IssueRepository != nil && tombstone.Policy != model.AnonymizeErasure {
+		if err := erasureService.IssueRepository.DeleteIssue(postId); err != nil {
+			return err
+		}
+	}
+
+	return erasureService.RevisionRepository.DeleteRevisions(postId)
+}
+
+var CurrentErasureService ErasureService
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type ExportService interface {
+	ExportUserPosts(user model.User) (*model.UserExport, int)
+	GetExportedEntities(organization string, user model.User) ([]model.Entity, int)
+	ExportEntityFeedback(entity model.Entity, write func(model.FeedbackExportRow) error) error
+}
+
+// ExportServiceImpl exports the posts of a user, and the feedback on the
+// entities of an organization. The entities of an organization are those it
+// is responsible for in the catalog, along with those in the statistics index
+// of posts, as entities no longer in the catalog are only found there.
+type ExportServiceImpl struct {
+	ThreadRepository    repository.ThreadRepository
+	ThreadIdService     ThreadIdService
+	ThreadService       ThreadService
+	EntityService       EntityService
+	PostIndexRepository repository.PostIndexRepository
+	ThreadBotUid        string
+	PageSize            int
+}
+
+// ExportUserPosts gathers every post the user has made in feedback threads,
+// with the entity each thread belongs to.
+func (exportService *ExportServiceImpl) ExportUserPosts(user model.User) (*model.UserExport, int) {
+	if user.Userslug == nil {
+		return nil, http.StatusNotFound
+	}
+
+	userPosts, err := getUserPosts(exportService.ThreadRepository, *user.Userslug)
+	if err != nil {
+		log.Println("Could not get posts of user.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	posts := []model.ExportedPost{}
+	entityIds := map[string]*string{}
+	for _, post := range userPosts {
+		threadId := stringOrEmpty(post.ThreadId)
+		entityId, found := entityIds[threadId]
+		if !found {
+			entityId, err = exportService.ThreadIdService.GetEntityId(threadId)
+			if err != nil {
+				return nil, http.StatusInternalServerError
+			}
+			entityIds[threadId] = entityId
+		}
+
+		posts = append(posts, post.ToExportedPost(entityId))
+	}
+
+	return &model.UserExport{
+		User:       &user,
+		ExportedAt: time.Now().UnixMilli(),
+		Posts:      posts,
+	}, http.StatusOK
+}
+
+// GetExportedEntities lists the entities of the organization with a feedback
+// thread, for moderators and admins of the organization. Entities no longer
+// in the catalog are listed with their id only.
+func (exportService *ExportServiceImpl) GetExportedEntities(organization string, user model.User) ([]model.Entity, int) {
+	if !user.Moderator && !slices.Contains(user.AdminOrganizations, organization) {
+		return nil, http.StatusForbidden
+	}
+
+	entityIds, err := exportService.EntityService.GetOrganizationEntityIds(organization)
+	if err != nil {
+		log.Println("Could not get entities of organization.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if exportService.PostIndexRepository != nil {
+		indexedEntityIds, err := exportService.PostIndexRepository.GetOrganizationEntityIds(organization)
+		if err != nil {
+			log.Println("Could not get indexed entities of organization.\n[ERROR] -", err)
+		}
+		entityIds = append(entityIds, indexedEntityIds...)
+	}
+	slices.Sort(entityIds)
+	entityIds = slices.Compact(entityIds)
+
+	threadIds, err := exportService.ThreadIdService.GetThreadIds(entityIds)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+
+	entities := []model.Entity{}
+	for _, entityId := range entityIds {
+		if _, ok := threadIds[entityId]; !ok {
+			continue
+		}
+
+		entity, err := exportService.EntityService.GetEntity(entityId)
+		if err != nil || entity == nil {
+			log.Println("Could not get exported entity", entityId, "\n[ERROR] -", err)
+			entity = &model.Entity{}
+		}
+		entity.EntityId = entityId
+		entities = append(entities, *entity)
+	}
+
+	return entities, http.StatusOK
+}
+
+// ExportEntityFeedback writes every post in the thread of the entity, oldest
+// first and leaving out the opening post of the thread bot. Entities without
+// a thread have nothing to export.
+func (exportService *ExportServiceImpl) ExportEntityFeedback(entity model.Entity, write func(model.FeedbackExportRow) error) error {
+	for page, pageCount := 1, 1; page <= pageCount; page++ {
+		pageParam := strconv.Itoa(page)
+		thread, statusCode := exportService.ThreadService.GetThreadByEntityId(entity.EntityId, model.ThreadQuery{
+			Page:     &pageParam,
+			Sort:     model.OldestFirst,
+			PageSize: &exportService.PageSize,
+		})
+		if statusCode == http.StatusNotFound && page == 1 {
+			return nil
+		}
+		if !util.SuccsessfulStatus(statusCode) || thread == nil {
+			return fmt.Errorf("could not read thread of entity %s, status %d", entity.EntityId, statusCode)
+		}
+
+		for _, post := range thread.Posts {
+			if post.PostId == nil || (post.UserId != nil && *post.UserId == exportService.ThreadBotUid) {
+				continue
+			}
+			if err := write(post.ToFeedbackExportRow(entity)); err != nil {
+				return err
+			}
+		}
+
+		if thread.Pagination != nil && thread.Pagination.PageCount != nil {
+			pageCount = *thread.Pagination.PageCount
+		}
+	}
+
+	return nil
+}
+
+// getUserPosts reads every page of the posts the user has made in feedback
+// threads.
+func getUserPosts(threadRepository repository.ThreadRepository, userslug string) ([]*model.Post, error) {
+	var posts []*model.Post
+	for page, pageCount := 1, 1; page <= pageCount; page++ {
+		userPosts, count, err := threadRepository.GetUserPosts(userslug, page)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, userPosts...)
+		pageCount = count
+	}
+
+	return posts, nil
+}
+
+var CurrentExportService ExportService
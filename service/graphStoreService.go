@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type GraphStoreService interface {
+	PushPost(threadId string, post model.Post)
+	DeletePost(postId string)
+	ChangePostOwner(postId string, userId string)
+}
+
+// GraphStoreServiceImpl keeps the annotations of posts in Graph of the graph
+// store up to date. Updates are sent one at a time in the background, in the
+// order they are pushed, and are tried MaxAttempts times with a delay that
+// doubles from RetryDelay. At most QueueSize updates wait to be sent, later
+// ones are dropped.
+type GraphStoreServiceImpl struct {
+	GraphStoreRepository repository.GraphStoreRepository
+	ThreadIdService      ThreadIdService
+	EntityService        EntityService
+	CommunityBaseUrl     string
+	ThreadBotUid         string
+	Graph                string
+	MaxAttempts          int
+	RetryDelay           time.Duration
+	QueueSize            int
+
+	startOnce sync.Once
+	queue     chan graphStoreUpdate
+}
+
+// graphStoreUpdate builds the SPARQL Update to send. It is built when sent,
+// so failed lookups are retried along with the update.
+type graphStoreUpdate func() (string, error)
+
+// PushPost inserts or replaces the annotation of the post. Posts of the
+// thread bot are not annotations.
+func (graphStoreService *GraphStoreServiceImpl) PushPost(threadId string, post model.Post) {
+	if post.PostId == nil || (post.UserId != nil && *post.UserId == graphStoreService.ThreadBotUid) {
+		return
+	}
+
+	graphStoreService.enqueue(func() (string, error) {
+		entityId, err := graphStoreService.ThreadIdService.GetEntityId(threadId)
+		if err != nil {
+			return "", err
+		}
+		if entityId == nil {
+			return "", errors.New("no entity for thread " + threadId)
+		}
+
+		entity, err := graphStoreService.EntityService.GetEntity(*entityId)
+		if err != nil {
+			return "", err
+		}
+		if entity == nil || entity.ResourceUri() == "" {
+			return "", errors.New("no URI for entity " + *entityId)
+		}
+
+		triples := annotationTriples(&post, entity.ResourceUri(), graphStoreService.CommunityBaseUrl)
+		return util.SparqlReplaceAnnotation(graphStoreService.Graph, graphStoreService.postIri(*post.PostId), triples), nil
+	})
+}
+
+func (graphStoreService *GraphStoreServiceImpl) DeletePost(postId string) {
+	graphStoreService.enqueue(func() (string, error) {
+		return util.SparqlDeleteAnnotation(graphStoreService.Graph, graphStoreService.postIri(postId)), nil
+	})
+}
+
+func (graphStoreService *GraphStoreServiceImpl) ChangePostOwner(postId string, userId string) {
+	graphStoreService.enqueue(func() (string, error) {
+		return util.SparqlReplaceCreator(
+			graphStoreService.Graph,
+			graphStoreService.postIri(postId),
+			graphStoreService.CommunityBaseUrl+"uid/"+userId,
+		), nil
+	})
+}
+
+func (graphStoreService *GraphStoreServiceImpl) enqueue(update graphStoreUpdate) {
+	graphStoreService.startOnce.Do(func() {
+		graphStoreService.queue = make(chan graphStoreUpdate, max(graphStoreService.QueueSize, 1))
+		go graphStoreService.send()
+	})
+
+	select {
+	case graphStoreService.queue <- update:
+	default:
+		log.Println("Graph store queue is full, update dropped.")
+	}
+}
+
+func (graphStoreService *GraphStoreServiceImpl) send() {
+	for update := range graphStoreService.queue {
+		delay := graphStoreService.RetryDelay
+		for attempt := 1; ; attempt++ {
+			err := graphStoreService.trySend(update)
+			if err == nil {
+				break
+			}
+			if attempt >= graphStoreService.MaxAttempts {
+				log.Println("Could not update graph store, giving up.\n[ERROR] -", err)
+				break
+			}
+			log.Printf("Could not update graph store, attempt %d of %d.\n[ERROR] - %v\n", attempt, graphStoreService.MaxAttempts, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+func (graphStoreService *GraphStoreServiceImpl) trySend(update graphStoreUpdate) error {
+	sparqlUpdate, err := update()
+	if err != nil {
+		return err
+	}
+	return graphStoreService.GraphStoreRepository.Update(sparqlUpdate)
+}
+
+func (graphStoreService *GraphStoreServiceImpl) postIri(postId string) string {
+	return graphStoreService.CommunityBaseUrl + "post/" + postId
+}
+
+var CurrentGraphStoreService GraphStoreService
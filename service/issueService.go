@@ -0,0 +1,104 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type IssueService interface {
+	IsPublisher(entityId string, user model.User) (bool, int)
+	UpdateIssueStatus(entityId string, postId string, transition model.IssueTransition, user model.User) (*model.DataQualityIssue, int)
+}
+
+// IssueServiceImpl lets the publisher of an entity, and moderators, answer
+// and change the status of the issues reported on it.
+type IssueServiceImpl struct {
+	IssueRepository  repository.IssueRepository
+	ThreadIdService  ThreadIdService
+	EntityService    EntityService
+	MaxCommentLength int
+}
+
+// IsPublisher tells whether the user may act for the organization
+// responsible for the entity. Moderators may act for any organization.
+func (issueService *IssueServiceImpl) IsPublisher(entityId string, user model.User) (bool, int) {
+	if user.Moderator {
+		return true, http.StatusOK
+	}
+	if len(user.Organizations) == 0 {
+		return false, http.StatusOK
+	}
+
+	entity, err := issueService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return false, http.StatusNotFound
+	}
+
+	return entity.PublisherId != "" && slices.Contains(user.Organizations, entity.PublisherId), http.StatusOK
+}
+
+// UpdateIssueStatus moves the issue of a post to the status of the
+// transition, and records the transition. Moving an issue to the status it
+// already has changes nothing.
+func (issueService *IssueServiceImpl) UpdateIssueStatus(entityId string, postId string, transition model.IssueTransition, user model.User) (*model.DataQualityIssue, int) {
+	status, ok := model.ParseIssueStatus(string(transition.Status))
+	if !ok {
+		return nil, http.StatusBadRequest
+	}
+	if issueService.MaxCommentLength > 0 && utf8.RuneCountInString(transition.Comment) > issueService.MaxCommentLength {
+		return nil, http.StatusBadRequest
+	}
+	if user.UserId == nil {
+		return nil, http.StatusUnauthorized
+	}
+
+	threadId, err := issueService.ThreadIdService.GetThreadId(entityId)
+	if err != nil || threadId == nil {
+		return nil, http.StatusNotFound
+	}
+
+	issue, err := issueService.IssueRepository.GetIssue(postId)
+	if err != nil {
+		log.Println("Could not get issue.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if issue == nil || issue.ThreadId != *threadId {
+		return nil, http.StatusNotFound
+	}
+
+	publisher, statusCode := issueService.IsPublisher(entityId, user)
+	if statusCode != http.StatusOK {
+		return nil, statusCode
+	}
+	if !publisher {
+		return nil, http.StatusForbidden
+	}
+
+	if issue.Status == status {
+		return issue, http.StatusOK
+	}
+
+	issue.Transitions = append(issue.Transitions, model.IssueTransition{
+		From:      issue.Status,
+		Status:    status,
+		ChangedBy: *user.UserId,
+		Comment:   transition.Comment,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	issue.Status = status
+
+	if err := issueService.IssueRepository.SaveIssue(*issue); err != nil {
+		log.Println("Could not save issue.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return issue, http.StatusOK
+}
+
+var CurrentIssueService IssueService
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type ModerationService interface {
+	GetPendingPosts() ([]model.PendingPost, int)
+	ApprovePendingPost(pendingId string) (*model.Post, int)
+	RejectPendingPost(pendingId string) int
+}
+
+type ModerationServiceImpl struct {
+	PendingPostRepository repository.PendingPostRepository
+	ThreadService         ThreadService
+}
+
+func (moderationService *ModerationServiceImpl) GetPendingPosts() ([]model.PendingPost, int) {
+	pendingPosts, err := moderationService.PendingPostRepository.GetPendingPosts()
+	if err != nil {
+		log.Println("Could not get pending posts.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return pendingPosts, http.StatusOK
+}
+
+// ApprovePendingPost publishes a pending post or edit in its thread. Once a
+// new post is approved, later posts from the user skip premoderation.
+func (moderationService *ModerationServiceImpl) ApprovePendingPost(pendingId string) (*model.Post, int) {
+	pending, statusCode := moderationService.getPendingPost(pendingId)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+
+	published, statusCode := moderationService.ThreadService.PublishPendingPost(*pending)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+
+	if pending.PostId == "" {
+		if err := moderationService.PendingPostRepository.ApproveUser(pending.UserId); err != nil {
+			log.Println("Could not approve user", pending.UserId, "\n[ERROR] -", err)
+		}
+	}
+
+	if err := moderationService.PendingPostRepository.DeletePendingPost(pendingId); err != nil {
+		log.Println("Could not delete approved pending post", pendingId, "\n[ERROR] -", err)
+	}
+
+	return published, http.StatusCreated
+}
+
+func (moderationService *ModerationServiceImpl) RejectPendingPost(pendingId string) int {
+	_, statusCode := moderationService.getPendingPost(pendingId)
+	if !util.SuccsessfulStatus(statusCode) {
+		return statusCode
+	}
+
+	if err := moderationService.PendingPostRepository.DeletePendingPost(pendingId); err != nil {
+		log.Println("Could not delete rejected pending post.\n[ERROR] -", err)
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusOK
+}
+
+func (moderationService *ModerationServiceImpl) getPendingPost(pendingId string) (*model.PendingPost, int) {
+	pending, err := moderationService.PendingPostRepository.GetPendingPost(pendingId)
+	if err != nil {
+		log.Println("Could not get pending post.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if pending == nil {
+		return nil, http.StatusNotFound
+	}
+
+	return pending, http.StatusOK
+}
+
+var CurrentModerationService ModerationService
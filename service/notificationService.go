@@ -0,0 +1,132 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type NotificationService interface {
+	NotifyPost(entityId string, post model.Post)
+	GetSettings(organization string, user model.User) (*model.NotificationSettings, int)
+	UpdateSettings(settings model.NotificationSettings, user model.User) (*model.NotificationSettings, int)
+}
+
+// NotificationServiceImpl emails the contact point of an entity when
+// feedback is posted on it, at most once per ThrottleWindow for each entity
+// and contact point, unless the publisher has turned notifications off.
+// The notification is worked out and sent in the background through
+// Notifier, and nothing is sent without one.
+type NotificationServiceImpl struct {
+	NotificationRepository repository.NotificationRepository
+	Notifier               NotificationQueue
+	EntityService          EntityService
+	ThreadBotUid           string
+	ThrottleWindow         time.Duration
+}
+
+// NotifyPost notifies about posts from users, leaving out posts of the
+// thread bot and official answers from the publisher.
+func (notificationService *NotificationServiceImpl) NotifyPost(entityId string, post model.Post) {
+	if notificationService.Notifier == nil || post.OfficialAnswer ||
+		(post.UserId != nil && *post.UserId == notificationService.ThreadBotUid) {
+		return
+	}
+
+	err := notificationService.Notifier.Run(func(notifier Notifier) error {
+		if err := notificationService.notifyPost(entityId, notifier); err != nil {
+			log.Println("Could not notify contact point.\n[ERROR] -", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Could not queue notification of contact point.\n[ERROR] -", err)
+	}
+}
+
+func (notificationService *NotificationServiceImpl) notifyPost(entityId string, notifier Notifier) error {
+	entity, err := notificationService.EntityService.GetEntity(entityId)
+	if err != nil {
+		return err
+	}
+	if entity == nil || entity.ContactEmail == "" {
+		return nil
+	}
+
+	if entity.PublisherId != "" {
+		settings, err := notificationService.NotificationRepository.GetSettings(entity.PublisherId)
+		if err != nil {
+			return err
+		}
+		if settings != nil && !settings.Enabled {
+			return nil
+		}
+	}
+
+	key := notificationKey(entityId, entity.ContactEmail)
+	now := time.Now()
+	claimed, err := notificationService.NotificationRepository.ClaimNotification(key, now, notificationService.ThrottleWindow)
+	if err != nil || !claimed {
+		return err
+	}
+
+	// A failed mail gives the claim back, so the next post is notified.
+	err = notifier.Notify(entity.FeedbackNotification())
+	if err != nil {
+		if releaseErr := notificationService.NotificationRepository.ReleaseNotification(key, now); releaseErr != nil {
+			log.Println("Could not release notification.\n[ERROR] -", releaseErr)
+		}
+	}
+
+	return err
+}
+
+// notificationKey names the notifications about an entity to a contact
+// point without storing the address.
+func notificationKey(entityId string, email string) string {
+	hash := sha256.Sum256([]byte(entityId + "\n" + strings.ToLower(email)))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetSettings reads the notification settings of the organization for its
+// admins and moderators. Notifications are on unless turned off.
+func (notificationService *NotificationServiceImpl) GetSettings(organization string, user model.User) (*model.NotificationSettings, int) {
+	if !user.Moderator && !slices.Contains(user.AdminOrganizations, organization) {
+		return nil, http.StatusForbidden
+	}
+
+	settings, err := notificationService.NotificationRepository.GetSettings(organization)
+	if err != nil {
+		log.Println("Could not get notification settings.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if settings == nil {
+		settings = &model.NotificationSettings{Organization: organization, Enabled: true}
+	}
+
+	return settings, http.StatusOK
+}
+
+func (notificationService *NotificationServiceImpl) UpdateSettings(settings model.NotificationSettings, user model.User) (*model.NotificationSettings, int) {
+	if !user.Moderator && !slices.Contains(user.AdminOrganizations, settings.Organization) {
+		return nil, http.StatusForbidden
+	}
+
+	settings.UpdatedBy = stringOrEmpty(user.UserId)
+	settings.Timestamp = time.Now().UnixMilli()
+	if err := notificationService.NotificationRepository.SaveSettings(settings); err != nil {
+		log.Println("Could not save notification settings.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &settings, http.StatusOK
+}
+
+var CurrentNotificationService NotificationService
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+var ErrNotificationQueueFull = errors.New("notification queue is full")
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(notification model.UserNotification) error
+}
+
+// MailNotifier delivers notifications by email to the address of the user.
+type MailNotifier struct {
+	MailRepository repository.MailRepository
+}
+
+func (notifier *MailNotifier) Notify(notification model.UserNotification) error {
+	return notifier.MailRepository.SendMail(model.MailMessage{
+		To:             notification.Email,
+		Subject:        notification.Subject,
+		Body:           notification.Body,
+		UnsubscribeUrl: notification.UnsubscribeUrl,
+	})
+}
+
+// NotificationQueue delivers notifications in the background, and runs the
+// work of finding out whom to notify there too.
+type NotificationQueue interface {
+	Notifier
+	// Run queues a task that notifies through the given Notifier, which
+	// delivers right away as the task already runs in the background.
+	Run(task func(notifier Notifier) error) error
+}
+
+// QueuedNotifier delivers notifications through Notifier one at a time in
+// the background, so requests do not wait for them. At most QueueSize
+// notifications and tasks wait their turn, later ones are refused.
+type QueuedNotifier struct {
+	Notifier  Notifier
+	QueueSize int
+
+	startOnce sync.Once
+	queue     chan func(notifier Notifier) error
+}
+
+func (notifier *QueuedNotifier) Notify(notification model.UserNotification) error {
+	return notifier.Run(func(delivery Notifier) error {
+		return delivery.Notify(notification)
+	})
+}
+
+func (notifier *QueuedNotifier) Run(task func(notifier Notifier) error) error {
+	notifier.startOnce.Do(func() {
+		notifier.queue = make(chan func(notifier Notifier) error, max(notifier.QueueSize, 1))
+		go notifier.deliver()
+	})
+
+	select {
+	case notifier.queue <- task:
+		return nil
+	default:
+		return ErrNotificationQueueFull
+	}
+}
+
+func (notifier *QueuedNotifier) deliver() {
+	for task := range notifier.queue {
+		if err := task(notifier.Notifier); err != nil {
+			log.Println("Could not deliver notification.\n[ERROR] -", err)
+		}
+	}
+}
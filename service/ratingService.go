@@ -0,0 +1,101 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type RatingService interface {
+	GetRatingSummary(entityId string, userId *string) (*model.RatingSummary, int)
+	GetRatingSummaries(entityIds []string) (map[string]model.RatingSummary, int)
+	RateEntity(entityId string, userId string, score int) (*model.RatingSummary, int)
+	WithdrawRating(entityId string, userId string) (*model.RatingSummary, int)
+}
+
+// RatingServiceImpl lets users rate entities from 1 to 5, once per entity.
+// Only entities found by EntityService can be rated.
+type RatingServiceImpl struct {
+	RatingRepository repository.RatingRepository
+	EntityService    EntityService
+}
+
+// GetRatingSummary aggregates the ratings of the entity, with the score of
+// the user if given.
+func (ratingService *RatingServiceImpl) GetRatingSummary(entityId string, userId *string) (*model.RatingSummary, int) {
+	ratings, err := ratingService.RatingRepository.GetRatings([]string{entityId})
+	if err != nil {
+		log.Println("Could not get ratings.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	summary := model.SummarizeRatings(entityId, ratings)
+	if userId != nil {
+		for _, rating := range ratings {
+			if rating.EntityId == entityId && rating.UserId == *userId {
+				score := rating.Score
+				summary.UserScore = &score
+			}
+		}
+	}
+
+	return &summary, http.StatusOK
+}
+
+// GetRatingSummaries aggregates the ratings of each entity. Entities without
+// ratings have an empty summary.
+func (ratingService *RatingServiceImpl) GetRatingSummaries(entityIds []string) (map[string]model.RatingSummary, int) {
+	ratings, err := ratingService.RatingRepository.GetRatings(entityIds)
+	if err != nil {
+		log.Println("Could not get ratings.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	summaries := make(map[string]model.RatingSummary, len(entityIds))
+	for _, entityId := range entityIds {
+		summaries[entityId] = model.SummarizeRatings(entityId, ratings)
+	}
+
+	return summaries, http.StatusOK
+}
+
+// RateEntity sets the rating of the user for the entity, replacing any
+// earlier rating.
+func (ratingService *RatingServiceImpl) RateEntity(entityId string, userId string, score int) (*model.RatingSummary, int) {
+	if score < model.MinRatingScore || score > model.MaxRatingScore {
+		return nil, http.StatusBadRequest
+	}
+
+	entity, err := ratingService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return nil, http.StatusNotFound
+	}
+
+	err = ratingService.RatingRepository.SaveRating(model.Rating{
+		EntityId:  entityId,
+		UserId:    userId,
+		Score:     score,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Println("Could not save rating.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return ratingService.GetRatingSummary(entityId, &userId)
+}
+
+// WithdrawRating removes the rating of the user for the entity, if any.
+func (ratingService *RatingServiceImpl) WithdrawRating(entityId string, userId string) (*model.RatingSummary, int) {
+	if err := ratingService.RatingRepository.DeleteRating(entityId, userId); err != nil {
+		log.Println("Could not delete rating.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return ratingService.GetRatingSummary(entityId, &userId)
+}
+
+var CurrentRatingService RatingService
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+type ReportService interface {
+	ReportPost(entityId string, postId string, report model.PostReport) (*model.PostReport, int)
+	GetReportedPosts() ([]model.ReportedPost, int)
+	ResolveReports(postId string, moderatorUid string) ([]model.PostReport, int)
+}
+
+// ReportServiceImpl hides a post through ThreadService, as ModeratorUid, once
+// it has HideThreshold open reports. A threshold of 0 never hides posts.
+// Moderators resolve the reports of a post to take it off the list.
+type ReportServiceImpl struct {
+	ReportRepository repository.ReportRepository
+	ThreadRepository repository.ThreadRepository
+	ThreadService    ThreadService
+	EntityService    EntityService
+	HideThreshold    int
+	ModeratorUid     string
+	MaxTextLength    int
+}
+
+func (reportService *ReportServiceImpl) ReportPost(entityId string, postId string, report model.PostReport) (*model.PostReport, int) {
+	if _, ok := model.ParseReportReason(string(report.Reason)); !ok || report.ReporterUid == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	if reportService.MaxTextLength > 0 && utf8.RuneCountInString(report.Text) > reportService.MaxTextLength {
+		return nil, http.StatusBadRequest
+	}
+
+	post, statusCode := reportService.ThreadService.GetThreadPostByEntityId(entityId, postId, nil)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+	if post == nil || post.ThreadId == nil {
+		return nil, http.StatusNotFound
+	}
+
+	stored := model.PostReport{
+		PostId:      postId,
+		ThreadId:    *post.ThreadId,
+		EntityId:    entityId,
+		ReporterUid: report.ReporterUid,
+		Reason:      report.Reason,
+		Text:        report.Text,
+		Status:      model.ReportOpen,
+		Timestamp:   time.Now().UnixMilli(),
+	}
+
+	err := reportService.ReportRepository.CreateReport(stored)
+	if err == model.ErrDuplicateReport {
+		return nil, http.StatusConflict
+	}
+	if err != nil {
+		log.Println("Could not create report.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	reportService.hideIfReportedTooOften(stored)
+
+	return &stored, http.StatusCreated
+}
+
+func (reportService *ReportServiceImpl) hideIfReportedTooOften(report model.PostReport) {
+	if reportService.HideThreshold < 1 {
+		return
+	}
+
+	count, err := reportService.ReportRepository.CountReports(report.PostId)
+	if err != nil {
+		log.Println("Could not count reports.\n[ERROR] -", err)
+		return
+	}
+	if count < reportService.HideThreshold {
+		return
+	}
+
+	statusCode := reportService.ThreadService.HideThreadPost(report.ThreadId, report.PostId, reportService.ModeratorUid)
+	if !util.SuccsessfulStatus(statusCode) {
+		log.Println("Could not hide reported post", report.PostId, "\n[ERROR] - status", statusCode)
+		return
+	}
+
+	log.Printf("Hid post %s in thread %s after %d reports\n", report.PostId, report.ThreadId, count)
+}
+
+// GetReportedPosts lists posts with open reports, most reported first, with
+// the entity each thread belongs to. Hidden posts are included.
+func (reportService *ReportServiceImpl) GetReportedPosts() ([]model.ReportedPost, int) {
+	reports, err := reportService.ReportRepository.GetOpenReports()
+	if err != nil {
+		log.Println("Could not get reports.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	var reportedPosts []*model.ReportedPost
+	byPostId := map[string]*model.ReportedPost{}
+	for _, report := range reports {
+		reportedPost, ok := byPostId[report.PostId]
+		if !ok {
+			reportedPost = &model.ReportedPost{EntityId: report.EntityId}
+			byPostId[report.PostId] = reportedPost
+			reportedPosts = append(reportedPosts, reportedPost)
+		}
+		reportedPost.Reports = append(reportedPost.Reports, report)
+	}
+
+	sort.SliceStable(reportedPosts, func(i, j int) bool {
+		return len(reportedPosts[i].Reports) > len(reportedPosts[j].Reports)
+	})
+
+	entities := map[string]*model.Entity{}
+	result := make([]model.ReportedPost, 0, len(reportedPosts))
+	for _, reportedPost := range reportedPosts {
+		postId := reportedPost.Reports[0].PostId
+		post, err := reportService.ThreadRepository.GetThreadPost(postId, nil)
+		if err != nil {
+			log.Println("Could not get reported post", postId, "\n[ERROR] -", err)
+		}
+		reportedPost.Post = post
+
+		entity, ok := entities[reportedPost.EntityId]
+		if !ok {
+			entity, err = reportService.EntityService.GetEntity(reportedPost.EntityId)
+			if err != nil {
+				log.Println("Could not get entity", reportedPost.EntityId, "\n[ERROR] -", err)
+			}
+			entities[reportedPost.EntityId] = entity
+		}
+		reportedPost.Entity = entity
+
+		result = append(result, *reportedPost)
+	}
+
+	return result, http.StatusOK
+}
+
+// ResolveReports resolves the open reports of the post, whether or not the
+// post is kept.
+func (reportService *ReportServiceImpl) ResolveReports(postId string, moderatorUid string) ([]model.PostReport, int) {
+	reports, err := reportService.ReportRepository.ResolveReports(postId, moderatorUid, time.Now().UnixMilli())
+	if err != nil {
+		log.Println("Could not resolve reports.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if len(reports) == 0 {
+		return nil, http.StatusNotFound
+	}
+
+	return reports, http.StatusOK
+}
+
+var CurrentReportService ReportService
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+)
+
+type StatisticsService interface {
+	IndexPost(entityId string, threadId string, post model.Post)
+	RemovePost(postId string)
+	GetStatistics(query model.StatisticsQuery, user model.User) ([]model.FeedbackStatistics, int)
+}
+
+// StatisticsServiceImpl keeps a copy of every post, except those of the
+// thread bot, with the organization and type of its entity, and computes
+// statistics from it instead of from the community.
+type StatisticsServiceImpl struct {
+	PostIndexRepository repository.PostIndexRepository
+	EntityService       EntityService
+	ThreadBotUid        string
+}
+
+type statisticsGroup struct {
+	statistics    model.FeedbackStatistics
+	threadIds     map[string]bool
+	responseTimes []int64
+}
+
+func (statisticsService *StatisticsServiceImpl) IndexPost(entityId string, threadId string, post model.Post) {
+	if post.PostId == nil || (post.UserId != nil && *post.UserId == statisticsService.ThreadBotUid) {
+		return
+	}
+
+	if err := statisticsService.indexPost(entityId, threadId, post); err != nil {
+		log.Println("Could not index post.\n[ERROR] -", err)
+	}
+}
+
+func (statisticsService *StatisticsServiceImpl) indexPost(entityId string, threadId string, post model.Post) error {
+	entity, err := statisticsService.EntityService.GetEntity(entityId)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return errors.New("entity not found " + entityId)
+	}
+
+	timestamp := time.Now().UnixMilli()
+	if post.Timestamp != nil {
+		timestamp = int64(*post.Timestamp)
+	}
+
+	return statisticsService.PostIndexRepository.SavePost(model.IndexedPost{
+		PostId:         *post.PostId,
+		ThreadId:       threadId,
+		EntityId:       entityId,
+		Organization:   entity.PublisherId,
+		EntityType:     entity.Type.Key(),
+		ToPostId:       stringOrEmpty(post.ToPostId),
+		OfficialAnswer: post.OfficialAnswer,
+		Timestamp:      timestamp,
+	})
+}
+
+func (statisticsService *StatisticsServiceImpl) RemovePost(postId string) {
+	if err := statisticsService.PostIndexRepository.DeletePost(postId); err != nil {
+		log.Println("Could not remove post from index.\n[ERROR] -", err)
+	}
+}
+
+// GetStatistics groups the indexed posts of the query by organization, entity
+// type and period. Moderators see every organization, publishers only their
+// own.
+func (statisticsService *StatisticsServiceImpl) GetStatistics(query model.StatisticsQuery, user model.User) ([]model.FeedbackStatistics, int) {
+	if !user.Moderator && (query.Organization == "" || !slices.Contains(user.Organizations, query.Organization)) {
+		return nil, http.StatusForbidden
+	}
+
+	posts, err := statisticsService.PostIndexRepository.GetPostsSince(query.From.UnixMilli(), query.Organization, query.EntityType)
+	if err != nil {
+		log.Println("Could not get indexed posts.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	// Answers may come after the end of the query, so every answer is read.
+	firstAnswers := map[string]int64{}
+	for _, post := range posts {
+		if post.OfficialAnswer && post.ToPostId != "" {
+			if answeredAt, present := firstAnswers[post.ToPostId]; !present || post.Timestamp < answeredAt {
+				firstAnswers[post.ToPostId] = post.Timestamp
+			}
+		}
+	}
+
+	groups := map[string]*statisticsGroup{}
+	to := query.To.UnixMilli()
+	for _, post := range posts {
+		if post.Timestamp > to {
+			continue
+		}
+
+		group := statisticsGroupOf(groups, post, query.Period)
+		if post.OfficialAnswer {
+			group.statistics.OfficialAnswers++
+			continue
+		}
+
+		group.statistics.Posts++
+		group.threadIds[post.ThreadId] = true
+		if answeredAt, present := firstAnswers[post.PostId]; present && answeredAt >= post.Timestamp {
+			group.statistics.Answered++
+			group.responseTimes = append(group.responseTimes, answeredAt-post.Timestamp)
+		}
+	}
+
+	statistics := make([]model.FeedbackStatistics, 0, len(groups))
+	for _, group := range groups {
+		group.statistics.Threads = len(group.threadIds)
+		if len(group.responseTimes) > 0 {
+			mean, median := responseTimes(group.responseTimes)
+			group.statistics.MeanResponseTime = &mean
+			group.statistics.MedianResponseTime = &median
+		}
+		statistics = append(statistics, group.statistics)
+	}
+	slices.SortFunc(statistics, func(a, b model.FeedbackStatistics) int {
+		return cmp.Or(
+			cmp.Compare(a.Period, b.Period),
+			cmp.Compare(a.Organization, b.Organization),
+			cmp.Compare(a.EntityType, b.EntityType),
+		)
+	})
+
+	return statistics, http.StatusOK
+}
+
+func statisticsGroupOf(groups map[string]*statisticsGroup, post model.IndexedPost, period model.StatisticsPeriod) *statisticsGroup {
+	periodKey := period.Key(time.UnixMilli(post.Timestamp))
+	key := post.Organization + "|" + post.EntityType + "|" + periodKey
+	if groups[key] == nil {
+		groups[key] = &statisticsGroup{
+			statistics: model.FeedbackStatistics{
+				Organization: post.Organization,
+				EntityType:   post.EntityType,
+				Period:       periodKey,
+			},
+			threadIds: map[string]bool{},
+		}
+	}
+	return groups[key]
+}
+
+// responseTimes returns the mean and median of times, sorting them in place.
+func responseTimes(times []int64) (int64, int64) {
+	slices.Sort(times)
+
+	var total int64
+	for _, time := range times {
+		total += time
+	}
+
+	median := times[len(times)/2]
+	if len(times)%2 == 0 {
+		median = (times[len(times)/2-1] + median) / 2
+	}
+
+	return total / int64(len(times)), median
+}
+
+var CurrentStatisticsService StatisticsService
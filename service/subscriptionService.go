@@ -0,0 +1,273 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+const unsubscribeTokenPurpose = "unsubscribe"
+
+type SubscriptionService interface {
+	GetSubscription(entityId string, user model.User) (*model.SubscriptionStatus, int)
+	Follow(entityId string, user model.User) (*model.SubscriptionStatus, int)
+	Unfollow(entityId string, user model.User) (*model.SubscriptionStatus, int)
+	GetTokenSubscription(token string) (*model.SubscriptionStatus, int)
+	Unsubscribe(token string) (*model.SubscriptionStatus, int)
+	NotifyPost(entityId string, post model.Post)
+}
+
+// SubscriptionServiceImpl lets users follow the threads of entities. New
+// posts are sent to followers through Notifier, at most once per
+// ThrottleWindow for each thread and follower, while replies to a post of a
+// follower are always sent. Followers are looked up and notified in the
+// background. Every notification links to UnsubscribeUrl with
+// a token signed with the SUBSCRIPTION_TOKEN_KEY secret, which is valid for
+// TokenTtl. Nothing is sent without a Notifier.
+type SubscriptionServiceImpl struct {
+	SubscriptionRepository repository.SubscriptionRepository
+	NotificationRepository repository.NotificationRepository
+	ThreadRepository       repository.ThreadRepository
+	EntityService          EntityService
+	SecretProvider         secret.SecretProvider
+	Notifier               NotificationQueue
+	ThreadBotUid           string
+	ThrottleWindow         time.Duration
+	UnsubscribeUrl         string
+	TokenTtl               time.Duration
+}
+
+func (subscriptionService *SubscriptionServiceImpl) GetSubscription(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	if user.UserId == nil {
+		return nil, http.StatusUnauthorized
+	}
+
+	subscription, err := subscriptionService.SubscriptionRepository.GetSubscription(entityId, *user.UserId)
+	if err != nil {
+		log.Println("Could not get subscription.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &model.SubscriptionStatus{EntityId: entityId, Following: subscription != nil}, http.StatusOK
+}
+
+// Follow subscribes the user to the thread of the entity, with the address
+// of the user at the time.
+func (subscriptionService *SubscriptionServiceImpl) Follow(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	if user.UserId == nil {
+		return nil, http.StatusUnauthorized
+	}
+
+	email, err := util.ParseMailAddress(user.Email)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+
+	entity, err := subscriptionService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return nil, http.StatusNotFound
+	}
+
+	err = subscriptionService.SubscriptionRepository.SaveSubscription(model.ThreadSubscription{
+		EntityId:  entityId,
+		UserId:    *user.UserId,
+		Email:     email,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Println("Could not save subscription.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &model.SubscriptionStatus{EntityId: entityId, Following: true}, http.StatusOK
+}
+
+func (subscriptionService *SubscriptionServiceImpl) Unfollow(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	if user.UserId == nil {
+		return nil, http.StatusUnauthorized
+	}
+
+	return subscriptionService.unfollow(entityId, *user.UserId)
+}
+
+// GetTokenSubscription tells whether the user of a token from a
+// notification follows the thread named by it, so the user can confirm
+// before unsubscribing.
+func (subscriptionService *SubscriptionServiceImpl) GetTokenSubscription(token string) (*model.SubscriptionStatus, int) {
+	entityId, userId, statusCode := subscriptionService.verifyToken(token)
+	if statusCode != http.StatusOK {
+		return nil, statusCode
+	}
+
+	subscription, err := subscriptionService.SubscriptionRepository.GetSubscription(entityId, userId)
+	if err != nil {
+		log.Println("Could not get subscription.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &model.SubscriptionStatus{EntityId: entityId, Following: subscription != nil}, http.StatusOK
+}
+
+// Unsubscribe unfollows the thread named by a token from a notification,
+// without signing in.
+func (subscriptionService *SubscriptionServiceImpl) Unsubscribe(token string) (*model.SubscriptionStatus, int) {
+	entityId, userId, statusCode := subscriptionService.verifyToken(token)
+	if statusCode != http.StatusOK {
+		return nil, statusCode
+	}
+
+	return subscriptionService.unfollow(entityId, userId)
+}
+
+// verifyToken returns the entity and user of an unsubscribe token issued
+// less than TokenTtl ago, allowing for a minute of clock skew between
+// instances.
+func (subscriptionService *SubscriptionServiceImpl) verifyToken(token string) (string, string, int) {
+	key, err := subscriptionService.tokenKey()
+	if err != nil {
+		log.Println("Could not get subscription token key.\n[ERROR] -", err)
+		return "", "", http.StatusServiceUnavailable
+	}
+
+	fields, ok := util.VerifyToken(key, token)
+	if !ok || len(fields) != 4 || fields[0] != unsubscribeTokenPurpose {
+		return "", "", http.StatusBadRequest
+	}
+
+	issuedAt, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return "", "", http.StatusBadRequest
+	}
+	age := time.Since(time.UnixMilli(issuedAt))
+	if age < -time.Minute || age > subscriptionService.TokenTtl {
+		return "", "", http.StatusBadRequest
+	}
+
+	return fields[1], fields[2], http.StatusOK
+}
+
+func (subscriptionService *SubscriptionServiceImpl) unfollow(entityId string, userId string) (*model.SubscriptionStatus, int) {
+	if err := subscriptionService.SubscriptionRepository.DeleteSubscription(entityId, userId); err != nil {
+		log.Println("Could not delete subscription.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &model.SubscriptionStatus{EntityId: entityId, Following: false}, http.StatusOK
+}
+
+// NotifyPost notifies the followers of the entity about a post, leaving out
+// its author and posts of the thread bot.
+func (subscriptionService *SubscriptionServiceImpl) NotifyPost(entityId string, post model.Post) {
+	if subscriptionService.Notifier == nil || post.UserId == nil || *post.UserId == subscriptionService.ThreadBotUid {
+		return
+	}
+
+	err := subscriptionService.Notifier.Run(func(notifier Notifier) error {
+		if err := subscriptionService.notifyPost(entityId, post, notifier); err != nil {
+			log.Println("Could not notify followers.\n[ERROR] -", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Could not queue notification of followers.\n[ERROR] -", err)
+	}
+}
+
+func (subscriptionService *SubscriptionServiceImpl) notifyPost(entityId string, post model.Post, notifier Notifier) error {
+	subscriptions, err := subscriptionService.SubscriptionRepository.GetEntitySubscriptions(entityId)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	entity, err := subscriptionService.EntityService.GetEntity(entityId)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return errors.New("no entity " + entityId)
+	}
+
+	key, err := subscriptionService.tokenKey()
+	if err != nil {
+		return err
+	}
+
+	issuedAt := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	repliedTo := subscriptionService.repliedToUserId(post)
+	for _, subscription := range subscriptions {
+		if subscription.UserId == *post.UserId {
+			continue
+		}
+
+		reply := subscription.UserId == repliedTo
+		if !reply && !subscriptionService.claimNotification(entityId, subscription.UserId) {
+			continue
+		}
+
+		token := util.SignToken(key, unsubscribeTokenPurpose, entityId, subscription.UserId, issuedAt)
+		notification := entity.ThreadNotification(reply, subscriptionService.UnsubscribeUrl+"?token="+url.QueryEscape(token))
+		notification.UserId = subscription.UserId
+		notification.Email = subscription.Email
+		if err := notifier.Notify(notification); err != nil {
+			log.Println("Could not notify follower.\n[ERROR] -", err)
+		}
+	}
+
+	return nil
+}
+
+// repliedToUserId is the author of the post the post replies to, if any.
+func (subscriptionService *SubscriptionServiceImpl) repliedToUserId(post model.Post) string {
+	if post.ToPostId == nil || *post.ToPostId == "" {
+		return ""
+	}
+
+	repliedTo, err := subscriptionService.ThreadRepository.GetThreadPost(*post.ToPostId, nil)
+	if err != nil || repliedTo == nil || repliedTo.UserId == nil {
+		log.Println("Could not get replied to post.\n[ERROR] -", err)
+		return ""
+	}
+
+	return *repliedTo.UserId
+}
+
+// claimNotification tells whether a notification about a new post in the
+// thread of the entity may be sent to the user now.
+func (subscriptionService *SubscriptionServiceImpl) claimNotification(entityId string, userId string) bool {
+	if subscriptionService.NotificationRepository == nil || subscriptionService.ThrottleWindow <= 0 {
+		return true
+	}
+
+	hash := sha256.Sum256([]byte("subscription\n" + entityId + "\n" + userId))
+	claimed, err := subscriptionService.NotificationRepository.ClaimNotification(hex.EncodeToString(hash[:]), time.Now(), subscriptionService.ThrottleWindow)
+	if err != nil {
+		log.Println("Could not claim notification.\n[ERROR] -", err)
+		return false
+	}
+
+	return claimed
+}
+
+func (subscriptionService *SubscriptionServiceImpl) tokenKey() ([]byte, error) {
+	key, err := subscriptionService.SecretProvider.GetSecret(secret.SubscriptionTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, secret.ErrSecretNotFound
+	}
+
+	return []byte(key), nil
+}
+
+var CurrentSubscriptionService SubscriptionService
@@ -8,7 +8,10 @@ import (
 
 type ThreadIdService interface {
 	GetThreadId(id string) (*string, error)
+	GetThreadIds(ids []string) (map[string]string, error)
 	CreateThreadId(id string, threadId string) error
+	DeleteThreadId(id string) error
+	GetEntityId(threadId string) (*string, error)
 }
 
 type ThreadIdServiceImpl struct {
@@ -25,6 +28,16 @@ func (threadIdService *ThreadIdServiceImpl) GetThreadId(id string) (*string, err
 	return threadId, err
 }
 
+func (threadIdService *ThreadIdServiceImpl) GetThreadIds(ids []string) (map[string]string, error) {
+	threadIds, err := threadIdService.ThreadIdRepository.GetThreadIds(ids)
+	if err != nil {
+		log.Println("GetThreadIds error.\n[ERROR] -", err)
+		return nil, err
+	}
+
+	return threadIds, nil
+}
+
 func (threadIdService *ThreadIdServiceImpl) CreateThreadId(id string, threadId string) error {
 	err := threadIdService.ThreadIdRepository.CreateThreadId(id, threadId)
 	if err != nil {
@@ -34,4 +47,23 @@ func (threadIdService *ThreadIdServiceImpl) CreateThreadId(id string, threadId s
 	return nil
 }
 
+func (threadIdService *ThreadIdServiceImpl) DeleteThreadId(id string) error {
+	err := threadIdService.ThreadIdRepository.DeleteThreadId(id)
+	if err != nil {
+		log.Println("DeleteThreadId error.\n[ERROR] -", err)
+		return err
+	}
+	return nil
+}
+
+func (threadIdService *ThreadIdServiceImpl) GetEntityId(threadId string) (*string, error) {
+	entityId, err := threadIdService.ThreadIdRepository.GetEntityId(threadId)
+	if err != nil {
+		log.Println("GetEntityId error.\n[ERROR] -", err)
+		return nil, err
+	}
+
+	return entityId, nil
+}
+
 var CurrentThreadIdService ThreadIdService
@@ -3,29 +3,74 @@ package service
 import (
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
 
 type ThreadService interface {
-	CreateThreadPost(postRequest model.Post) (*model.Post, int)
+	CreateThreadPost(postRequest model.Post, entityId string) (*model.Post, int)
 	CreateThread(forEntityId string) (*model.Thread, int)
-	GetThread(id string, page *string, postIndex *string) (*model.Thread, int)
-	UpdateThreadPost(updatedPost model.Post, postIndex *string) (*model.Post, int)
-	DeleteThreadPost(postToDelete model.Post, postIndex *string) int
+	GetThread(id string, query model.ThreadQuery) (*model.Thread, int)
+	GetThreadPost(threadId string, postId string) (*model.Post, int)
+	UpdateThreadPost(updatedPost model.Post) (*model.Post, int)
+	DeleteThreadPost(postToDelete model.Post) int
+	HideThreadPost(threadId string, postId string, moderatorUid string) int
 	CreatePostForEntityId(postRequest model.Post, entityId string) (*model.Post, int)
-	GetThreadByEntityId(entityId string, page *string) (*model.Thread, int)
+	GetThreadByEntityId(entityId string, query model.ThreadQuery) (*model.Thread, int)
+	GetThreadPostByEntityId(entityId string, postId string, viewerUid *string) (*model.Post, int)
+	CountPostsByEntityIds(entityIds []string) (map[string]int, int)
+	VotePostForEntityId(entityId string, postId string, userId string, upvote bool) (*model.Post, int)
+	GetThreadPostRevisions(entityId string, postId string, user model.User) ([]model.PostRevision, int)
+	PublishPendingPost(pending model.PendingPost) (*model.Post, int)
 }
 
+// ThreadServiceImpl holds back posts from users without an approved post
+// when Premoderation is set, until a moderator approves them. Posts and
+// edits containing personal data are handled by PersonalDataAction. Data
+// quality issues reported by posts are kept in IssueRepository. Annotations
+// of new, edited and deleted posts are pushed to GraphStoreService, new and
+// deleted posts indexed by StatisticsService, and contact points and
+// followers notified of new posts by NotificationService and
+// SubscriptionService, if set.
 type ThreadServiceImpl struct {
-	ThreadRepository repository.ThreadRepository
-	ThreadIdService  ThreadIdService
-	EntityService    EntityService
+	ThreadRepository      repository.ThreadRepository
+	ThreadIdService       ThreadIdService
+	EntityService         EntityService
+	EventBus              eventbus.EventBus
+	RevisionRepository    repository.RevisionRepository
+	PendingPostRepository repository.PendingPostRepository
+	IssueRepository       repository.IssueRepository
+	PostIndexRepository   repository.PostIndexRepository
+	GraphStoreService     GraphStoreService
+	StatisticsService     StatisticsService
+	NotificationService   NotificationService
+	SubscriptionService   SubscriptionService
+	Premoderation         bool
+	PersonalDataAction    model.PersonalDataAction
 }
 
 func (threadService *ThreadServiceImpl) CreatePostForEntityId(postRequest model.Post, entityId string) (*model.Post, int) {
+	// A new post has no id yet, and a pending post with an id is an edit.
+	postRequest.PostId = nil
+	if postRequest.Issue != nil {
+		if postRequest.OfficialAnswer || postRequest.Issue.Validate() != nil {
+			return nil, http.StatusBadRequest
+		}
+		postRequest.Issue.Status = model.IssueOpen
+		postRequest.Issue.Transitions = nil
+		postRequest.Issue.AnswerPostIds = nil
+	}
+	if postRequest.OfficialAnswer && postRequest.ToPostId == nil {
+		return nil, http.StatusBadRequest
+	}
+
 	threadId, err := threadService.ThreadIdService.GetThreadId(entityId)
 	if err != nil {
 		return nil, http.StatusInternalServerError
@@ -46,13 +91,37 @@ func (threadService *ThreadServiceImpl) CreatePostForEntityId(postRequest model.
 
 	}
 
+	// Official answers are replies to issues, from publishers who are not
+	// held for premoderation.
+	if postRequest.OfficialAnswer {
+		answered := threadService.getIssue(*postRequest.ToPostId)
+		if answered == nil || answered.ThreadId != *threadId {
+			return nil, http.StatusBadRequest
+		}
+	}
+
+	switch threadService.applyPersonalDataPolicy(&postRequest) {
+	case model.RejectPersonalData:
+		return nil, http.StatusUnprocessableEntity
+	case model.ModeratePersonalData:
+		return threadService.createPendingPost(postRequest, entityId, *threadId)
+	}
+
+	if threadService.Premoderation && !postRequest.OfficialAnswer {
+		pending, statusCode := threadService.holdForModeration(postRequest, entityId, *threadId)
+		if statusCode != http.StatusContinue {
+			return pending, statusCode
+		}
+	}
+
 	created, statusCode := threadService.CreateThreadPost(model.Post{
-		PostId:   postRequest.PostId,
-		UserId:   postRequest.UserId,
-		ThreadId: threadId,
-		Content:  postRequest.Content,
-		ToPostId: postRequest.ToPostId,
-	})
+		UserId:         postRequest.UserId,
+		ThreadId:       threadId,
+		Content:        postRequest.Content,
+		ToPostId:       postRequest.ToPostId,
+		Issue:          postRequest.Issue,
+		OfficialAnswer: postRequest.OfficialAnswer,
+	}, entityId)
 
 	if !util.SuccsessfulStatus(statusCode) {
 		return nil, statusCode
@@ -61,30 +130,373 @@ func (threadService *ThreadServiceImpl) CreatePostForEntityId(postRequest model.
 	return created, http.StatusCreated
 }
 
-func (threadService *ThreadServiceImpl) GetThreadByEntityId(entityId string, page *string) (*model.Thread, int) {
+// holdForModeration stores the post as pending when its author has no
+// approved post, and returns http.StatusContinue when it can be published.
+func (threadService *ThreadServiceImpl) holdForModeration(postRequest model.Post, entityId string, threadId string) (*model.Post, int) {
+	if postRequest.Content == nil || postRequest.UserId == nil {
+		return nil, http.StatusBadRequest
+	}
+
+	approved, err := threadService.PendingPostRepository.IsApprovedUser(*postRequest.UserId)
+	if err != nil {
+		log.Println("Could not look up approved user.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if approved {
+		return nil, http.StatusContinue
+	}
+
+	return threadService.createPendingPost(postRequest, entityId, threadId)
+}
+
+// createPendingPost stores a post, or an edit of the post with the post id,
+// until a moderator approves it.
+func (threadService *ThreadServiceImpl) createPendingPost(postRequest model.Post, entityId string, threadId string) (*model.Post, int) {
+	if postRequest.Content == nil || postRequest.UserId == nil {
+		return nil, http.StatusBadRequest
+	}
+
+	pending, err := threadService.PendingPostRepository.CreatePendingPost(model.PendingPost{
+		EntityId:       entityId,
+		PostId:         stringOrEmpty(postRequest.PostId),
+		ThreadId:       threadId,
+		UserId:         *postRequest.UserId,
+		Content:        *postRequest.Content,
+		ToPostId:       stringOrEmpty(postRequest.ToPostId),
+		Timestamp:      time.Now().UnixMilli(),
+		Issue:          postRequest.Issue,
+		OfficialAnswer: postRequest.OfficialAnswer,
+	})
+	if err != nil || pending == nil {
+		log.Println("Could not create pending post.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return pending.ToPost(), http.StatusAccepted
+}
+
+// applyPersonalDataPolicy masks personal data in the content of the post,
+// and returns the action taken, or "" if there was nothing to act on.
+func (threadService *ThreadServiceImpl) applyPersonalDataPolicy(post *model.Post) model.PersonalDataAction {
+	if post.Content == nil || threadService.PersonalDataAction == "" || threadService.PersonalDataAction == model.AllowPersonalData {
+		return ""
+	}
+
+	matches := util.FindPersonalData(*post.Content)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	if threadService.PersonalDataAction == model.MaskPersonalData {
+		masked := util.MaskPersonalData(*post.Content, matches)
+		post.Content = &masked
+	}
+	return threadService.PersonalDataAction
+}
+
+func (threadService *ThreadServiceImpl) GetThreadByEntityId(entityId string, query model.ThreadQuery) (*model.Thread, int) {
 	threadId, err := threadService.ThreadIdService.GetThreadId(entityId)
 	if err != nil || threadId == nil {
 		return nil, http.StatusNotFound
 	}
 
-	thread, statusCode := threadService.GetThread(*threadId, page, nil)
+	var thread *model.Thread
+	var statusCode int
+	if query.FiltersIssues() {
+		thread, statusCode = threadService.getIssueThread(*threadId, query)
+	} else {
+		thread, statusCode = threadService.GetThread(*threadId, query)
+	}
 	if !util.SuccsessfulStatus(statusCode) || thread == nil {
 		return nil, statusCode
 	}
 
+	if !query.FiltersIssues() {
+		threadService.attachIssues(*threadId, thread.Posts)
+	}
+
+	if query.ViewerUid != nil && threadService.PendingPostRepository != nil {
+		thread.PendingPosts = threadService.getPendingPosts(*threadId, *query.ViewerUid)
+	}
+
 	return thread, http.StatusOK
 }
 
-func (threadService *ThreadServiceImpl) CreateThreadPost(postRequest model.Post) (*model.Post, int) {
+// getPendingPosts lists the posts of a user awaiting moderation in a thread.
+// The thread is still readable if they cannot be looked up.
+func (threadService *ThreadServiceImpl) getPendingPosts(threadId string, userId string) []*model.Post {
+	pendingPosts, err := threadService.PendingPostRepository.GetPendingPostsByUser(threadId, userId)
+	if err != nil {
+		log.Println("Could not get pending posts.\n[ERROR] -", err)
+		return nil
+	}
+
+	var posts []*model.Post
+	for i := range pendingPosts {
+		posts = append(posts, pendingPosts[i].ToPost())
+	}
+	return posts
+}
+
+// getIssueThread lists the posts of a thread reporting issues of the query
+// category and status, sorted and paged like the rest of the thread.
+func (threadService *ThreadServiceImpl) getIssueThread(threadId string, query model.ThreadQuery) (*model.Thread, int) {
+	issues, err := threadService.IssueRepository.GetIssues(threadId)
+	if err != nil {
+		log.Println("Could not get issues.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	wanted := map[string]bool{}
+	for i := range issues {
+		if issues[i].Matches(query) {
+			wanted[issues[i].PostId] = true
+		}
+	}
+
+	// Read whole pages of the thread rather than each post of an issue, and
+	// stop once every post is found.
+	var thread *model.Thread
+	found := map[string]*model.Post{}
+	for page, pageCount := 1, 1; page <= pageCount && (page == 1 || len(found) < len(wanted)); page++ {
+		pageParam := strconv.Itoa(page)
+		threadPage, err := threadService.ThreadRepository.GetThread(threadId, model.ThreadQuery{
+			Page: &pageParam,
+			Sort: model.OldestFirst,
+		})
+		if err != nil || threadPage == nil {
+			log.Println("GetThread error.\n[ERROR] -", err)
+			return nil, http.StatusNotFound
+		}
+		if thread == nil {
+			thread = threadPage
+		}
+
+		for _, post := range threadPage.Posts {
+			if post.PostId != nil && wanted[*post.PostId] && (post.Deleted == nil || !*post.Deleted) {
+				found[*post.PostId] = post
+			}
+		}
+
+		if threadPage.Pagination != nil && threadPage.Pagination.PageCount != nil {
+			pageCount = *threadPage.Pagination.PageCount
+		}
+	}
+
+	posts := []*model.Post{}
+	for i := range issues {
+		post, ok := found[issues[i].PostId]
+		if !ok {
+			continue
+		}
+		post.Issue = &issues[i]
+		posts = append(posts, post)
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if query.Sort == model.MostVotes {
+			return intOrZero(posts[i].Votes) > intOrZero(posts[j].Votes)
+		}
+		return query.Sort.Before(posts[i], posts[j])
+	})
+
+	pageSize := env.ConstantValues.DefaultPageSize
+	if query.PageSize != nil {
+		pageSize = *query.PageSize
+	}
+	page := 1
+	if query.Page != nil {
+		page, _ = strconv.Atoi(*query.Page)
+	}
+	totalPosts := len(posts)
+	pageCount := max((totalPosts+pageSize-1)/pageSize, 1)
+	page = min(max(page, 1), pageCount)
+
+	start := min((page-1)*pageSize, totalPosts)
+	end := min(start+pageSize, totalPosts)
+
+	return &model.Thread{
+		ThreadId:  thread.ThreadId,
+		Title:     thread.Title,
+		Posts:     posts[start:end],
+		Timestamp: thread.Timestamp,
+		Pagination: (&model.Pagination{
+			CurrentPage: &page,
+			PageCount:   &pageCount,
+			TotalPosts:  &totalPosts,
+		}).WithQuery(query),
+	}, http.StatusOK
+}
+
+// attachIssues sets the data quality issue of the posts reporting one, and
+// marks the official answers to them. The posts are still readable if the
+// issues cannot be looked up.
+func (threadService *ThreadServiceImpl) attachIssues(threadId string, posts []*model.Post) {
+	if threadService.IssueRepository == nil || len(posts) == 0 {
+		return
+	}
+
+	issues, err := threadService.IssueRepository.GetIssues(threadId)
+	if err != nil {
+		log.Println("Could not get issues.\n[ERROR] -", err)
+		return
+	}
+
+	issuesByPostId := make(map[string]*model.DataQualityIssue, len(issues))
+	answerPostIds := map[string]bool{}
+	for i := range issues {
+		issuesByPostId[issues[i].PostId] = &issues[i]
+		for _, answerPostId := range issues[i].AnswerPostIds {
+			answerPostIds[answerPostId] = true
+		}
+	}
+	for _, post := range posts {
+		if post.PostId != nil {
+			post.Issue = issuesByPostId[*post.PostId]
+			post.OfficialAnswer = answerPostIds[*post.PostId]
+		}
+	}
+}
+
+// getIssue looks up the data quality issue of a post, or nil if it reports
+// none or the issue cannot be looked up.
+func (threadService *ThreadServiceImpl) getIssue(postId string) *model.DataQualityIssue {
+	if threadService.IssueRepository == nil {
+		return nil
+	}
+
+	issue, err := threadService.IssueRepository.GetIssue(postId)
+	if err != nil {
+		log.Println("Could not get issue.\n[ERROR] -", err)
+		return nil
+	}
+	return issue
+}
+
+// saveIssue stores the issue reported by a post. The post is kept if the
+// issue cannot be stored, since its content already describes the issue.
+func (threadService *ThreadServiceImpl) saveIssue(issue model.DataQualityIssue, postId string, threadId string) {
+	issue.PostId = postId
+	issue.ThreadId = threadId
+	if issue.Status == "" {
+		issue.Status = model.IssueOpen
+	}
+	if issue.Timestamp == 0 {
+		issue.Timestamp = time.Now().UnixMilli()
+	}
+
+	if err := threadService.IssueRepository.SaveIssue(issue); err != nil {
+		log.Println("Could not save issue.\n[ERROR] -", err, postId)
+	}
+}
+
+// recordOfficialAnswer adds the answer to the issue it answers.
+func (threadService *ThreadServiceImpl) recordOfficialAnswer(issuePostId string, answerPostId string) {
+	issue := threadService.getIssue(issuePostId)
+	if issue == nil {
+		log.Println("Could not record official answer of missing issue", issuePostId)
+		return
+	}
+
+	issue.AnswerPostIds = append(issue.AnswerPostIds, answerPostId)
+	if err := threadService.IssueRepository.SaveIssue(*issue); err != nil {
+		log.Println("Could not record official answer.\n[ERROR] -", err, answerPostId)
+	}
+}
+
+// isOfficialAnswer tells whether the post is an official answer to an issue
+// of the thread.
+func (threadService *ThreadServiceImpl) isOfficialAnswer(threadId string, postId string) bool {
+	post := &model.Post{PostId: &postId}
+	threadService.attachIssues(threadId, []*model.Post{post})
+	return post.OfficialAnswer
+}
+
+// GetThreadPostByEntityId reads a post of the entity's thread. A viewerUid
+// reads it as that user, so the post tells whether they upvoted it.
+func (threadService *ThreadServiceImpl) GetThreadPostByEntityId(entityId string, postId string, viewerUid *string) (*model.Post, int) {
+	threadId, err := threadService.ThreadIdService.GetThreadId(entityId)
+	if err != nil || threadId == nil {
+		return nil, http.StatusNotFound
+	}
+
+	post, statusCode := threadService.getThreadPost(*threadId, postId, viewerUid)
+	if post != nil {
+		threadService.attachIssues(*threadId, []*model.Post{post})
+	}
+	return post, statusCode
+}
+
+// CountPostsByEntityIds counts the posts of users on at most MaxPageSize
+// entities from the post index, which leaves out the opening post of the
+// thread bot and deleted posts. Entities whose posts could not be counted are
+// left out, rather than failing the others.
+func (threadService *ThreadServiceImpl) CountPostsByEntityIds(entityIds []string) (map[string]int, int) {
+	if len(entityIds) > env.ConstantValues.MaxPageSize {
+		return nil, http.StatusBadRequest
+	}
+
+	counts := make(map[string]int, len(entityIds))
+	for start := 0; start < len(entityIds); start += repository.PostCountBatchSize {
+		batch := entityIds[start:min(start+repository.PostCountBatchSize, len(entityIds))]
+		batchCounts, err := threadService.PostIndexRepository.CountEntityPosts(batch)
+		if err != nil {
+			log.Println("Could not count posts.\n[ERROR] -", err)
+			continue
+		}
+
+		for _, entityId := range batch {
+			counts[entityId] = batchCounts[entityId]
+		}
+	}
+
+	return counts, http.StatusOK
+}
+
+// CreateThreadPost posts in the thread of the entity, and indexes and
+// notifies about the post for the entity.
+func (threadService *ThreadServiceImpl) CreateThreadPost(postRequest model.Post, entityId string) (*model.Post, int) {
 	if postRequest.Content == nil || postRequest.UserId == nil || postRequest.ThreadId == nil {
 		return nil, http.StatusBadRequest
 	}
 
+	// Headers typed by the user are dropped, so only issues and official
+	// answers carry them.
+	content := model.StripRenderedHeaders(*postRequest.Content)
+	if postRequest.Issue != nil {
+		content = postRequest.Issue.RenderContent(content)
+	}
+	if postRequest.OfficialAnswer {
+		content = model.RenderOfficialAnswer(content)
+	}
+	postRequest.Content = &content
+
 	post, err := threadService.ThreadRepository.CreateThreadPost(postRequest)
 	if err != nil {
 		return nil, http.StatusInternalServerError
 	}
 
+	if postRequest.Issue != nil && post != nil && post.PostId != nil {
+		threadService.saveIssue(*postRequest.Issue, *post.PostId, *postRequest.ThreadId)
+		post.Issue = postRequest.Issue
+	}
+	if postRequest.OfficialAnswer && post != nil && post.PostId != nil {
+		threadService.recordOfficialAnswer(*postRequest.ToPostId, *post.PostId)
+		post.OfficialAnswer = true
+	}
+
+	threadService.publishPostEvent(model.PostCreated, postRequest.ThreadId, post)
+	threadService.pushAnnotation(postRequest.ThreadId, post)
+	if threadService.StatisticsService != nil && post != nil {
+		threadService.StatisticsService.IndexPost(entityId, *postRequest.ThreadId, *post)
+	}
+	if threadService.NotificationService != nil && post != nil {
+		threadService.NotificationService.NotifyPost(entityId, *post)
+	}
+	if threadService.SubscriptionService != nil && post != nil {
+		threadService.SubscriptionService.NotifyPost(entityId, *post)
+	}
+
 	return post, http.StatusCreated
 }
 
@@ -114,68 +526,593 @@ func (threadService *ThreadServiceImpl) CreateThread(forEntityId string) (*model
 	return createdThread, http.StatusCreated
 }
 
-func (threadService *ThreadServiceImpl) GetThread(id string, page *string, postIndex *string) (*model.Thread, int) {
-	thread, err := threadService.ThreadRepository.GetThread(id, page, postIndex)
-	if err != nil || thread == nil {
-		log.Println("GetThread error.\n[ERROR] -", err)
+func (threadService *ThreadServiceImpl) GetThread(id string, query model.ThreadQuery) (*model.Thread, int) {
+	if query.Cursor != nil {
+		return threadService.getThreadFromCursor(id, query)
+	}
+
+	var thread *model.Thread
+	if query.PageSize != nil {
+		thread = threadService.getThreadPage(id, query)
+	} else {
+		var err error
+		thread, err = threadService.ThreadRepository.GetThread(id, query)
+		if err != nil {
+			log.Println("GetThread error.\n[ERROR] -", err)
+		}
+	}
+	if thread == nil {
 		return nil, http.StatusNotFound
 	}
 
 	filteredThread := thread.FilterDeletedPosts()
+	filteredThread.Pagination = filteredThread.Pagination.WithQuery(query).WithCursors(filteredThread.Posts, query.Sort)
 
 	return filteredThread, http.StatusOK
 }
 
-func (threadService *ThreadServiceImpl) UpdateThreadPost(updatedPost model.Post, postIndex *string) (*model.Post, int) {
-	thread, statusCode := threadService.GetThread(*updatedPost.ThreadId, nil, postIndex)
+// getThreadPage cuts a page of the asked page size out of the pages NodeBB
+// returns, as NodeBB pages posts by the postsPerPage setting of the forum.
+func (threadService *ThreadServiceImpl) getThreadPage(id string, query model.ThreadQuery) *model.Thread {
+	pages := threadService.threadPages(id, query)
+	first := pages.page(1)
+	if first == nil {
+		return nil
+	}
+
+	page := 1
+	if query.Page != nil {
+		if parsedPage, err := strconv.Atoi(*query.Page); err == nil && parsedPage > 1 {
+			page = parsedPage
+		}
+	}
+	pageSize := *query.PageSize
+
+	posts, ok := pages.posts((page-1)*pageSize, pageSize)
+	if !ok {
+		return nil
+	}
+
+	pageCount := max((pages.totalPosts()+pageSize-1)/pageSize, 1)
+	var totalPosts *int
+	if first.Pagination != nil {
+		totalPosts = first.Pagination.TotalPosts
+	}
+
+	return &model.Thread{
+		ThreadId:  first.ThreadId,
+		Title:     first.Title,
+		Posts:     posts,
+		Timestamp: first.Timestamp,
+		Content:   first.Content,
+		Pagination: &model.Pagination{
+			CurrentPage: &page,
+			PageCount:   &pageCount,
+			TotalPosts:  totalPosts,
+		},
+	}
+}
+
+// threadPagesReader reads the pages of a thread from NodeBB once each.
+type threadPagesReader struct {
+	threadRepository repository.ThreadRepository
+	threadId         string
+	query            model.ThreadQuery
+	pages            map[int]*model.Thread
+}
+
+func (threadService *ThreadServiceImpl) threadPages(id string, query model.ThreadQuery) *threadPagesReader {
+	return &threadPagesReader{
+		threadRepository: threadService.ThreadRepository,
+		threadId:         id,
+		query:            model.ThreadQuery{Sort: query.Sort, ViewerUid: query.ViewerUid},
+		pages:            map[int]*model.Thread{},
+	}
+}
+
+func (reader *threadPagesReader) page(page int) *model.Thread {
+	if thread, ok := reader.pages[page]; ok {
+		return thread
+	}
+
+	pageString := strconv.Itoa(page)
+	reader.query.Page = &pageString
+	thread, err := reader.threadRepository.GetThread(reader.threadId, reader.query)
+	if err != nil || thread == nil {
+		log.Println("GetThread error.\n[ERROR] -", err)
+		return nil
+	}
+
+	reader.pages[page] = thread
+	return thread
+}
+
+// pageCount is the number of pages of the thread in NodeBB.
+func (reader *threadPagesReader) pageCount() int {
+	first := reader.page(1)
+	if first == nil || first.Pagination == nil || first.Pagination.PageCount == nil {
+		return 1
+	}
+	return max(*first.Pagination.PageCount, 1)
+}
+
+// pageSize is the number of posts on a page in NodeBB, which only a full first
+// page tells.
+func (reader *threadPagesReader) pageSize() int {
+	first := reader.page(1)
+	if first == nil || len(first.Posts) == 0 {
+		return 1
+	}
+	return len(first.Posts)
+}
+
+// totalPosts is the number of posts in the thread, deleted ones included.
+func (reader *threadPagesReader) totalPosts() int {
+	first := reader.page(1)
+	if first != nil && first.Pagination != nil && first.Pagination.TotalPosts != nil {
+		return *first.Pagination.TotalPosts
+	}
+	if reader.pageCount() == 1 {
+		return reader.pageSize()
+	}
+	return reader.pageCount() * reader.pageSize()
+}
+
+// posts returns at most count posts from the offset in the thread, reading the
+// NodeBB pages they are on. It reports false if a page could not be read.
+func (reader *threadPagesReader) posts(offset int, count int) ([]*model.Post, bool) {
+	pageSize := reader.pageSize()
+	firstPage := offset/pageSize + 1
+	skip := offset - (firstPage-1)*pageSize
+
+	var posts []*model.Post
+	for page := firstPage; page <= reader.pageCount() && len(posts) < skip+count; page++ {
+		thread := reader.page(page)
+		if thread == nil {
+			return nil, false
+		}
+		posts = append(posts, thread.Posts...)
+	}
+
+	if skip >= len(posts) {
+		return []*model.Post{}, true
+	}
+	return posts[skip:min(skip+count, len(posts))], true
+}
+
+// getThreadFromCursor reads a page of posts following the cursor, or
+// preceding it for backward cursors. The cursor index picks the page to start
+// from, and pages are walked until the page size is filled, so posts added
+// since the cursor was handed out neither repeat nor skip posts.
+func (threadService *ThreadServiceImpl) getThreadFromCursor(id string, query model.ThreadQuery) (*model.Thread, int) {
+	cursor := query.Cursor
+	pageSize := env.ConstantValues.DefaultPageSize
+	if query.PageSize != nil {
+		pageSize = *query.PageSize
+	}
+	pageQuery := model.ThreadQuery{Sort: query.Sort, PageSize: &pageSize, ViewerUid: query.ViewerUid}
+
+	pages := threadService.threadPages(id, query)
+	first := pages.page(1)
+	if first == nil {
+		return nil, http.StatusNotFound
+	}
+
+	pageCount := pages.pageCount()
+	var totalPosts *int
+	if first.Pagination != nil {
+		totalPosts = first.Pagination.TotalPosts
+	}
+
+	// The cursor index is a position in the thread, and NodeBB pages it by
+	// its own page size rather than the asked one.
+	position := cursor.Index
+	if cursor.Sort == model.NewestFirst && totalPosts != nil {
+		position = *totalPosts - 1 - cursor.Index
+	}
+	page := min(max(position/pages.pageSize()+1, 1), pageCount)
+
+	var posts []*model.Post
+	if !cursor.Backward {
+		// Step back while the page starts after the cursor, in case posts
+		// before it were removed since the cursor was handed out.
+		for ; page > 1; page-- {
+			thread := pages.page(page)
+			if thread == nil {
+				return nil, http.StatusNotFound
+			}
+			if len(thread.Posts) == 0 || !cursor.Follows(thread.Posts[0]) {
+				break
+			}
+		}
+
+		for ; page <= pageCount && len(posts) < pageSize; page++ {
+			thread := pages.page(page)
+			if thread == nil {
+				return nil, http.StatusNotFound
+			}
+			for _, post := range thread.Posts {
+				if cursor.Follows(post) && (post.Deleted == nil || !*post.Deleted) {
+					posts = append(posts, post)
+				}
+			}
+		}
+
+		sort.SliceStable(posts, func(i, j int) bool { return cursor.Sort.Before(posts[i], posts[j]) })
+		if len(posts) > pageSize {
+			posts = posts[:pageSize]
+		}
+	} else {
+		// Step forward while the page ends before the cursor, in case posts
+		// were added in front of it since the cursor was handed out.
+		for ; page < pageCount; page++ {
+			thread := pages.page(page)
+			if thread == nil {
+				return nil, http.StatusNotFound
+			}
+			if len(thread.Posts) == 0 || !cursor.Precedes(thread.Posts[len(thread.Posts)-1]) {
+				break
+			}
+		}
+
+		for ; page >= 1 && len(posts) < pageSize; page-- {
+			thread := pages.page(page)
+			if thread == nil {
+				return nil, http.StatusNotFound
+			}
+			var preceding []*model.Post
+			for _, post := range thread.Posts {
+				if cursor.Precedes(post) && (post.Deleted == nil || !*post.Deleted) {
+					preceding = append(preceding, post)
+				}
+			}
+			posts = append(preceding, posts...)
+		}
+
+		sort.SliceStable(posts, func(i, j int) bool { return cursor.Sort.Before(posts[i], posts[j]) })
+		if len(posts) > pageSize {
+			posts = posts[len(posts)-pageSize:]
+		}
+	}
+
+	pagination := (&model.Pagination{TotalPosts: totalPosts}).WithQuery(pageQuery).WithCursors(posts, cursor.Sort)
+	if len(posts) == 0 {
+		// Nothing more in this direction yet; hand the cursor back so the
+		// client can poll it for posts added later.
+		token := cursor.String()
+		if cursor.Backward {
+			pagination.PrevCursor = &token
+		} else {
+			pagination.NextCursor = &token
+		}
+	}
+
+	return &model.Thread{
+		ThreadId:   first.ThreadId,
+		Title:      first.Title,
+		Posts:      posts,
+		Timestamp:  first.Timestamp,
+		Pagination: pagination,
+	}, http.StatusOK
+}
+
+func (threadService *ThreadServiceImpl) GetThreadPost(threadId string, postId string) (*model.Post, int) {
+	return threadService.getThreadPost(threadId, postId, nil)
+}
+
+func (threadService *ThreadServiceImpl) getThreadPost(threadId string, postId string, viewerUid *string) (*model.Post, int) {
+	post, err := threadService.ThreadRepository.GetThreadPost(postId, viewerUid)
+	if err != nil || post == nil {
+		log.Println("GetThreadPost error.\n[ERROR] -", err)
+		return nil, http.StatusNotFound
+	}
+
+	if post.ThreadId == nil || *post.ThreadId != threadId {
+		log.Printf("Post %s is not in thread %s\n", postId, threadId)
+		return nil, http.StatusNotFound
+	}
+
+	if post.Deleted != nil && *post.Deleted {
+		return nil, http.StatusNotFound
+	}
+
+	return post, http.StatusOK
+}
+
+func (threadService *ThreadServiceImpl) UpdateThreadPost(updatedPost model.Post) (*model.Post, int) {
+	if updatedPost.ThreadId == nil || updatedPost.PostId == nil {
+		return nil, http.StatusBadRequest
+	}
+	if updatedPost.Issue != nil && updatedPost.Issue.Validate() != nil {
+		return nil, http.StatusBadRequest
+	}
+
+	postToUpdate, statusCode := threadService.GetThreadPost(*updatedPost.ThreadId, *updatedPost.PostId)
 	if !util.SuccsessfulStatus(statusCode) {
 		return nil, statusCode
 	}
 
-	postToUpdate, err := thread.FindThreadPostById(updatedPost.PostId)
-	if err != nil {
-		log.Println("FindThreadPostById error.\n[ERROR] -", err)
-		return postToUpdate, http.StatusNotFound
+	if postToUpdate.UserId != nil && updatedPost.UserId != nil && *postToUpdate.UserId != *updatedPost.UserId {
+		log.Println("Unauthorized error.\n[ERROR] - post", *updatedPost.PostId, "not written by user", *updatedPost.UserId)
+		return nil, http.StatusUnauthorized
 	}
 
-	if postToUpdate.UserId != nil && updatedPost.UserId != nil && *postToUpdate.UserId != *updatedPost.UserId {
-		log.Println("Unauthorized error.\n[ERROR] -", err)
+	switch threadService.applyPersonalDataPolicy(&updatedPost) {
+	case model.RejectPersonalData:
+		return nil, http.StatusUnprocessableEntity
+	case model.ModeratePersonalData:
+		entityId, err := threadService.ThreadIdService.GetEntityId(*updatedPost.ThreadId)
+		if err != nil || entityId == nil {
+			log.Println("Could not get entity of thread.\n[ERROR] -", err)
+			return nil, http.StatusInternalServerError
+		}
+		return threadService.createPendingPost(updatedPost, *entityId, *updatedPost.ThreadId)
+	}
+
+	return threadService.updateThreadPost(updatedPost, postToUpdate)
+}
+
+// PublishPendingPost publishes an approved pending post, or applies an
+// approved pending edit, without holding it back again.
+func (threadService *ThreadServiceImpl) PublishPendingPost(pending model.PendingPost) (*model.Post, int) {
+	post := model.Post{
+		UserId:         &pending.UserId,
+		ThreadId:       &pending.ThreadId,
+		Content:        &pending.Content,
+		Issue:          pending.Issue,
+		OfficialAnswer: pending.OfficialAnswer,
+	}
+	if pending.ToPostId != "" {
+		post.ToPostId = &pending.ToPostId
+	}
+
+	if pending.PostId == "" {
+		return threadService.CreateThreadPost(post, pending.EntityId)
+	}
+
+	post.PostId = &pending.PostId
+	postToUpdate, statusCode := threadService.GetThreadPost(pending.ThreadId, pending.PostId)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+
+	if postToUpdate.UserId != nil && *postToUpdate.UserId != pending.UserId {
+		log.Println("Unauthorized error.\n[ERROR] - post", pending.PostId, "not written by user", pending.UserId)
 		return nil, http.StatusUnauthorized
 	}
 
+	return threadService.updateThreadPost(post, postToUpdate)
+}
+
+// updateThreadPost records the previous content as a revision before
+// updating the post. The summary of the issue the post reports, or the mark
+// of an official answer, is kept in front of the new content, and any such
+// header typed by the user is dropped.
+func (threadService *ThreadServiceImpl) updateThreadPost(updatedPost model.Post, postToUpdate *model.Post) (*model.Post, int) {
+	issue := threadService.getIssue(*updatedPost.PostId)
+	if updatedPost.Issue != nil {
+		// Only the publisher changes the status of an issue.
+		if issue != nil {
+			updatedPost.Issue.Status = issue.Status
+			updatedPost.Issue.Transitions = issue.Transitions
+			updatedPost.Issue.AnswerPostIds = issue.AnswerPostIds
+			updatedPost.Issue.Timestamp = issue.Timestamp
+		} else {
+			updatedPost.Issue.Status = model.IssueOpen
+			updatedPost.Issue.Transitions = nil
+			updatedPost.Issue.AnswerPostIds = nil
+		}
+		issue = updatedPost.Issue
+	}
+	if issue == nil {
+		updatedPost.OfficialAnswer = threadService.isOfficialAnswer(*updatedPost.ThreadId, *updatedPost.PostId)
+	}
+	if updatedPost.Content != nil {
+		content := model.StripRenderedHeaders(*updatedPost.Content)
+		if issue != nil {
+			content = issue.RenderContent(content)
+		} else if updatedPost.OfficialAnswer {
+			content = model.RenderOfficialAnswer(content)
+		}
+		updatedPost.Content = &content
+	}
+
+	// The previous content is recorded first, so no edit goes untraced, and
+	// removed again when the edit fails.
+	editedAt := time.Now().UnixMilli()
+	revisionId, err := threadService.RevisionRepository.CreateRevision(model.PostRevision{
+		PostId:    *updatedPost.PostId,
+		ThreadId:  *updatedPost.ThreadId,
+		Content:   stringOrEmpty(postToUpdate.Content),
+		EditorUid: stringOrEmpty(updatedPost.UserId),
+		Timestamp: editedAt,
+	})
+	if err != nil {
+		log.Println("Could not record post revision.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
 	err = threadService.ThreadRepository.UpdateThreadPost(updatedPost)
 	if err != nil {
 		log.Println("Could not update post.\n[ERROR] -", err)
+		if err := threadService.RevisionRepository.DeleteRevision(*updatedPost.PostId, revisionId); err != nil {
+			log.Println("Could not delete revision of failed edit.\n[ERROR] -", err)
+		}
 		return nil, http.StatusInternalServerError
 	}
 
+	if updatedPost.Issue != nil {
+		threadService.saveIssue(*updatedPost.Issue, *updatedPost.PostId, *updatedPost.ThreadId)
+	}
+
+	edited, editedTimestamp := true, int(editedAt)
+	updatedPost.Edited = &edited
+	updatedPost.EditedAt = &editedTimestamp
+	updatedPost.Issue = issue
+
+	threadService.publishPostEvent(model.PostUpdated, updatedPost.ThreadId, &updatedPost)
+
+	annotatedPost := updatedPost
+	annotatedPost.Timestamp = postToUpdate.Timestamp
+	annotatedPost.ToPostId = postToUpdate.ToPostId
+	threadService.pushAnnotation(updatedPost.ThreadId, &annotatedPost)
+
 	return &updatedPost, http.StatusOK
 }
 
-func (threadService *ThreadServiceImpl) DeleteThreadPost(postToDelete model.Post, postIndex *string) int {
-	thread, statusCode := threadService.GetThread(*postToDelete.ThreadId, nil, postIndex)
-	if !util.SuccsessfulStatus(statusCode) {
-		return statusCode
+func (threadService *ThreadServiceImpl) DeleteThreadPost(postToDelete model.Post) int {
+	if postToDelete.ThreadId == nil || postToDelete.PostId == nil {
+		return http.StatusBadRequest
 	}
 
-	currentPost, err := thread.FindThreadPostById(postToDelete.PostId)
-	if err != nil {
-		log.Println("FindThreadPostById error.\n[ERROR] -", err)
-		return http.StatusNotFound
+	currentPost, statusCode := threadService.GetThreadPost(*postToDelete.ThreadId, *postToDelete.PostId)
+	if !util.SuccsessfulStatus(statusCode) {
+		return statusCode
 	}
 
 	if currentPost.UserId != nil && postToDelete.UserId != nil && *currentPost.UserId != *postToDelete.UserId {
-		log.Println("Unauthorized error.\n[ERROR] -", err)
+		log.Println("Unauthorized error.\n[ERROR] - post", *postToDelete.PostId, "not written by user", *postToDelete.UserId)
 		return http.StatusUnauthorized
 	}
 
-	err = threadService.ThreadRepository.DeleteThreadPost(postToDelete)
+	return threadService.deletePost(postToDelete)
+}
+
+// HideThreadPost soft-deletes the post as the moderator, whoever wrote it.
+func (threadService *ThreadServiceImpl) HideThreadPost(threadId string, postId string, moderatorUid string) int {
+	return threadService.deletePost(model.Post{
+		PostId:   &postId,
+		UserId:   &moderatorUid,
+		ThreadId: &threadId,
+	})
+}
+
+// deletePost soft-deletes the post and removes it from readers, the graph
+// store and the statistics.
+func (threadService *ThreadServiceImpl) deletePost(postToDelete model.Post) int {
+	err := threadService.ThreadRepository.DeleteThreadPost(postToDelete)
 	if err != nil {
 		log.Println("Could not update post.\n[ERROR] -", err)
 		return http.StatusInternalServerError
 	}
 
+	threadService.publishPostEvent(model.PostDeleted, postToDelete.ThreadId, &model.Post{
+		PostId:   postToDelete.PostId,
+		ThreadId: postToDelete.ThreadId,
+	})
+	if threadService.GraphStoreService != nil {
+		threadService.GraphStoreService.DeletePost(*postToDelete.PostId)
+	}
+	if threadService.StatisticsService != nil {
+		threadService.StatisticsService.RemovePost(*postToDelete.PostId)
+	}
+
 	return http.StatusOK
 }
 
+// VotePostForEntityId upvotes a post, or withdraws the vote when upvote is
+// false, and returns the post with its new vote count.
+func (threadService *ThreadServiceImpl) VotePostForEntityId(entityId string, postId string, userId string, upvote bool) (*model.Post, int) {
+	threadId, err := threadService.ThreadIdService.GetThreadId(entityId)
+	if err != nil || threadId == nil {
+		return nil, http.StatusNotFound
+	}
+
+	post, statusCode := threadService.GetThreadPost(*threadId, postId)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+
+	if post.UserId != nil && *post.UserId == userId {
+		log.Println("Forbidden error.\n[ERROR] - user", userId, "cannot vote on own post", postId)
+		return nil, http.StatusForbidden
+	}
+
+	vote := model.Post{
+		PostId:   &postId,
+		UserId:   &userId,
+		ThreadId: threadId,
+	}
+	if upvote {
+		err = threadService.ThreadRepository.VoteThreadPost(vote)
+	} else {
+		err = threadService.ThreadRepository.UnvoteThreadPost(vote)
+	}
+	if err != nil {
+		log.Println("Could not vote on post.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	if voted, statusCode := threadService.GetThreadPost(*threadId, postId); util.SuccsessfulStatus(statusCode) {
+		post = voted
+	}
+
+	threadService.publishPostEvent(model.PostUpdated, threadId, post)
+
+	votedPost := *post
+	votedPost.Upvoted = &upvote
+
+	return &votedPost, http.StatusOK
+}
+
+// GetThreadPostRevisions lists the earlier versions of a post, oldest first.
+// Only the author of the post and moderators may see them.
+func (threadService *ThreadServiceImpl) GetThreadPostRevisions(entityId string, postId string, user model.User) ([]model.PostRevision, int) {
+	post, statusCode := threadService.GetThreadPostByEntityId(entityId, postId, nil)
+	if !util.SuccsessfulStatus(statusCode) {
+		return nil, statusCode
+	}
+
+	isAuthor := post.UserId != nil && user.UserId != nil && *post.UserId == *user.UserId
+	if !isAuthor && !user.Moderator {
+		return nil, http.StatusForbidden
+	}
+
+	revisions, err := threadService.RevisionRepository.GetRevisions(postId)
+	if err != nil {
+		log.Println("Could not get post revisions.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return revisions, http.StatusOK
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func intOrZero(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func (threadService *ThreadServiceImpl) publishPostEvent(eventType model.PostEventType, threadId *string, post *model.Post) {
+	if threadService.EventBus == nil || threadId == nil || post == nil {
+		return
+	}
+
+	err := threadService.EventBus.Publish(*threadId, model.PostEvent{
+		Type:     eventType,
+		ThreadId: threadId,
+		Post:     post,
+	})
+	if err != nil {
+		log.Println("Could not publish post event.\n[ERROR] -", err)
+	}
+}
+
+// pushAnnotation sends the annotation of the post to the graph store, if
+// there is one.
+func (threadService *ThreadServiceImpl) pushAnnotation(threadId *string, post *model.Post) {
+	if threadService.GraphStoreService == nil || threadId == nil || post == nil {
+		return
+	}
+	threadService.GraphStoreService.PushPost(*threadId, *post)
+}
+
 var CurrentThreadService ThreadService
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+const usefulnessNoncePurpose = "usefulness"
+
+type UsefulnessService interface {
+	GetEntitySummary(entityId string) (*model.UsefulnessSummary, int)
+	GetTypeSummary(entityType model.EntityType) (*model.UsefulnessSummary, int)
+	Vote(entityId string, clientIp string, request model.UsefulnessVoteRequest) (*model.UsefulnessSummary, int)
+}
+
+// UsefulnessServiceImpl collects anonymous votes on whether entity pages were
+// useful. A vote needs a nonce handed out with the summary of the entity and
+// signed with the USEFULNESS_NONCE_KEY secret, which is valid for NonceTtl and
+// used once. An IP address may vote IpLimit times within IpWindow, once per
+// entity.
+type UsefulnessServiceImpl struct {
+	UsefulnessRepository repository.UsefulnessRepository
+	EntityService        EntityService
+	SecretProvider       secret.SecretProvider
+	NonceTtl             time.Duration
+	IpLimit              int
+	IpWindow             time.Duration
+	MaxReasonLength      int
+}
+
+// GetEntitySummary counts the votes of the entity, with a new nonce to vote
+// with.
+func (usefulnessService *UsefulnessServiceImpl) GetEntitySummary(entityId string) (*model.UsefulnessSummary, int) {
+	entity, err := usefulnessService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return nil, http.StatusNotFound
+	}
+
+	key, statusCode := usefulnessService.nonceKey()
+	if key == nil {
+		return nil, statusCode
+	}
+
+	summary, statusCode := usefulnessService.countVotes("entityId", entityId)
+	if summary == nil {
+		return nil, statusCode
+	}
+	summary.EntityId = entityId
+	summary.EntityType = entity.Type.Key()
+	summary.Nonce = util.SignToken(key, usefulnessNoncePurpose, entityId, randomId(), strconv.FormatInt(time.Now().UnixMilli(), 10))
+
+	return summary, http.StatusOK
+}
+
+func (usefulnessService *UsefulnessServiceImpl) GetTypeSummary(entityType model.EntityType) (*model.UsefulnessSummary, int) {
+	if entityType.Key() == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	summary, statusCode := usefulnessService.countVotes("entityType", entityType.Key())
+	if summary == nil {
+		return nil, statusCode
+	}
+	summary.EntityType = entityType.Key()
+
+	return summary, http.StatusOK
+}
+
+// Vote saves the vote if its nonce is valid for the entity and the IP address
+// has votes left, and returns the updated summary.
+func (usefulnessService *UsefulnessServiceImpl) Vote(entityId string, clientIp string, request model.UsefulnessVoteRequest) (*model.UsefulnessSummary, int) {
+	reason := strings.TrimSpace(request.Reason)
+	if request.Helpful == nil || utf8.RuneCountInString(reason) > usefulnessService.MaxReasonLength {
+		return nil, http.StatusBadRequest
+	}
+
+	key, statusCode := usefulnessService.nonceKey()
+	if key == nil {
+		return nil, statusCode
+	}
+
+	voteId, err := usefulnessService.verifyNonce(key, request.Nonce, entityId)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+
+	entity, err := usefulnessService.EntityService.GetEntity(entityId)
+	if err != nil || entity == nil {
+		return nil, http.StatusNotFound
+	}
+
+	now := time.Now()
+	ipWindow := util.Pseudonym(key, clientIp) + "_" + strconv.FormatInt(now.UnixMilli()/max(usefulnessService.IpWindow.Milliseconds(), 1), 10)
+	ipVotes, err := usefulnessService.UsefulnessRepository.GetIpVotes(ipWindow)
+	if err != nil {
+		log.Println("Could not get votes of IP address.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+	if len(ipVotes) >= usefulnessService.IpLimit {
+		return nil, http.StatusTooManyRequests
+	}
+	for _, vote := range ipVotes {
+		if vote.EntityId == entityId {
+			return nil, http.StatusTooManyRequests
+		}
+	}
+
+	err = usefulnessService.UsefulnessRepository.CreateVote(model.UsefulnessVote{
+		VoteId:     voteId,
+		EntityId:   entityId,
+		EntityType: entity.Type.Key(),
+		Helpful:    *request.Helpful,
+		Reason:     reason,
+		IpWindow:   ipWindow,
+		Timestamp:  now.UnixMilli(),
+	})
+	if err == model.ErrUsedNonce {
+		return nil, http.StatusConflict
+	}
+	if err != nil {
+		log.Println("Could not save usefulness vote.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	summary, statusCode := usefulnessService.countVotes("entityId", entityId)
+	if summary == nil {
+		return nil, statusCode
+	}
+	summary.EntityId = entityId
+	summary.EntityType = entity.Type.Key()
+
+	return summary, http.StatusCreated
+}
+
+func (usefulnessService *UsefulnessServiceImpl) countVotes(field string, value string) (*model.UsefulnessSummary, int) {
+	helpful, notHelpful, err := usefulnessService.UsefulnessRepository.CountVotes(field, value)
+	if err != nil {
+		log.Println("Could not count usefulness votes.\n[ERROR] -", err)
+		return nil, http.StatusInternalServerError
+	}
+
+	return &model.UsefulnessSummary{Helpful: helpful, NotHelpful: notHelpful}, http.StatusOK
+}
+
+func (usefulnessService *UsefulnessServiceImpl) nonceKey() ([]byte, int) {
+	key, err := usefulnessService.SecretProvider.GetSecret(secret.UsefulnessNonceKey)
+	if err != nil || key == "" {
+		log.Println("Could not get usefulness nonce key.\n[ERROR] -", err)
+		return nil, http.StatusServiceUnavailable
+	}
+
+	return []byte(key), http.StatusOK
+}
+
+// verifyNonce returns the id of a nonce handed out for the entity less than
+// NonceTtl ago, allowing for a minute of clock skew between instances.
+func (usefulnessService *UsefulnessServiceImpl) verifyNonce(key []byte, nonce string, entityId string) (string, error) {
+	fields, ok := util.VerifyToken(key, nonce)
+	if !ok || len(fields) != 4 || fields[0] != usefulnessNoncePurpose || fields[1] != entityId {
+		return "", model.ErrInvalidNonce
+	}
+
+	issuedAt, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return "", model.ErrInvalidNonce
+	}
+	age := time.Since(time.UnixMilli(issuedAt))
+	if age < -time.Minute || age > usefulnessService.NonceTtl {
+		return "", model.ErrInvalidNonce
+	}
+
+	return fields[2], nil
+}
+
+func randomId() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+var CurrentUsefulnessService UsefulnessService
@@ -16,7 +16,7 @@ func EntryPoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "Application/JSON")
 	if r.Method == http.MethodOptions {
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, access-control-allow-origin")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Last-Event-ID, access-control-allow-origin")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, OPTIONS, DELETE")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 		w.WriteHeader(http.StatusNoContent)
@@ -36,6 +36,32 @@ func EntryPoint(w http.ResponseWriter, r *http.Request) {
 		thread(w, r)
 	case env.ConstantValues.CurrentUserPath:
 		currentUser(w, r)
+	case env.ConstantValues.EventsPath:
+		events(w, r)
+	case env.ConstantValues.VotesPath:
+		votes(w, r)
+	case env.ConstantValues.ReportsPath:
+		reports(w, r)
+	case env.ConstantValues.PendingPath:
+		pending(w, r)
+	case env.ConstantValues.UsersPath:
+		users(w, r)
+	case env.ConstantValues.ErasuresPath:
+		erasures(w, r)
+	case env.ConstantValues.AnnotationsPath:
+		annotations(w, r)
+	case env.ConstantValues.RatingsPath:
+		ratings(w, r)
+	case env.ConstantValues.UsefulnessPath:
+		usefulness(w, r)
+	case env.ConstantValues.StatisticsPath:
+		statistics(w, r)
+	case env.ConstantValues.OrganizationsPath:
+		organizations(w, r)
+	case env.ConstantValues.SubscriptionsPath:
+		subscriptions(w, r)
+	case env.ConstantValues.UnsubscribePath:
+		unsubscribe(w, r)
 	default:
 		w.WriteHeader(http.StatusNotFound)
 	}
@@ -48,11 +74,37 @@ func ping(w http.ResponseWriter, r *http.Request) {
 func thread(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
-		controller.CurrentController.CreateComment(w, r)
+		if subresource := util.ParseRequestUrlSubresource(r.URL.Path); subresource != nil {
+			if *subresource == env.ConstantValues.ReportPath {
+				controller.CurrentController.ReportComment(w, r)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		} else {
+			controller.CurrentController.CreateComment(w, r)
+		}
 	case http.MethodGet:
-		controller.CurrentController.GetComments(w, r)
+		if subresource := util.ParseRequestUrlSubresource(r.URL.Path); subresource != nil {
+			if *subresource == env.ConstantValues.RevisionsPath {
+				controller.CurrentController.GetCommentRevisions(w, r)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		} else if _, _, postId := util.ParseRequestUrlPath(r.URL.Path); postId != nil {
+			controller.CurrentController.GetComment(w, r)
+		} else {
+			controller.CurrentController.GetComments(w, r)
+		}
 	case http.MethodPut:
-		controller.CurrentController.UpdateComment(w, r)
+		if subresource := util.ParseRequestUrlSubresource(r.URL.Path); subresource != nil {
+			if *subresource == env.ConstantValues.StatusPath {
+				controller.CurrentController.UpdateIssueStatus(w, r)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		} else {
+			controller.CurrentController.UpdateComment(w, r)
+		}
 	case http.MethodDelete:
 		controller.CurrentController.DeleteComment(w, r)
 	default:
@@ -63,7 +115,200 @@ func thread(w http.ResponseWriter, r *http.Request) {
 func currentUser(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		controller.CurrentController.CurrentUser(w, r)
+		_, subresource, _ := util.ParseRequestUrlPath(r.URL.Path)
+		if subresource == nil {
+			controller.CurrentController.CurrentUser(w, r)
+		} else if *subresource == env.ConstantValues.ExportPath {
+			controller.CurrentController.ExportCurrentUser(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPost:
+		_, subresource, _ := util.ParseRequestUrlPath(r.URL.Path)
+		if subresource != nil && *subresource == env.ConstantValues.ErasurePath {
+			controller.CurrentController.EraseCurrentUser(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func events(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.StreamThreadEvents(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func votes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		controller.CurrentController.UpvoteComment(w, r)
+	case http.MethodDelete:
+		controller.CurrentController.UnvoteComment(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func reports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetReportedComments(w, r)
+	case http.MethodPost:
+		_, _, action := util.ParseRequestUrlPath(r.URL.Path)
+		if action != nil && *action == env.ConstantValues.ResolvePath {
+			controller.CurrentController.ResolveReports(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func pending(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetPendingComments(w, r)
+	case http.MethodPost:
+		_, _, action := util.ParseRequestUrlPath(r.URL.Path)
+		if action != nil && *action == env.ConstantValues.ApprovePath {
+			controller.CurrentController.ApproveComment(w, r)
+		} else if action != nil && *action == env.ConstantValues.RejectPath {
+			controller.CurrentController.RejectComment(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func users(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		_, _, subresource := util.ParseRequestUrlPath(r.URL.Path)
+		if subresource != nil && *subresource == env.ConstantValues.ExportPath {
+			controller.CurrentController.ExportUser(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPost:
+		_, _, subresource := util.ParseRequestUrlPath(r.URL.Path)
+		if subresource != nil && *subresource == env.ConstantValues.ErasurePath {
+			controller.CurrentController.EraseUser(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func erasures(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetErasure(w, r)
+	case http.MethodPost:
+		controller.CurrentController.ResumeErasure(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func annotations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetAnnotations(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func ratings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetRatings(w, r)
+	case http.MethodPut:
+		controller.CurrentController.RateEntity(w, r)
+	case http.MethodDelete:
+		controller.CurrentController.WithdrawRating(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func usefulness(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetUsefulness(w, r)
+	case http.MethodPost:
+		controller.CurrentController.VoteUsefulness(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func statistics(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetStatistics(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func organizations(w http.ResponseWriter, r *http.Request) {
+	_, _, subresource := util.ParseRequestUrlPath(r.URL.Path)
+	if subresource == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if *subresource == env.ConstantValues.ExportPath {
+			controller.CurrentController.ExportOrganization(w, r)
+		} else if *subresource == env.ConstantValues.NotificationsPath {
+			controller.CurrentController.GetNotificationSettings(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPut:
+		if *subresource == env.ConstantValues.NotificationsPath {
+			controller.CurrentController.UpdateNotificationSettings(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func subscriptions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetSubscription(w, r)
+	case http.MethodPut:
+		controller.CurrentController.FollowThread(w, r)
+	case http.MethodDelete:
+		controller.CurrentController.UnfollowThread(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func unsubscribe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		controller.CurrentController.GetUnsubscribe(w, r)
+	case http.MethodPost:
+		controller.CurrentController.Unsubscribe(w, r)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
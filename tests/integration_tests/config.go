@@ -2,10 +2,12 @@ package integration_tests
 
 import (
 	"net/http/httptest"
+	"time"
 
 	controller "github.com/Informasjonsforvaltning/fdk-user-feedback-service/controller"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	repository "github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
 	service "github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests"
 )
@@ -21,7 +23,11 @@ func ConfigureIntegrationTests() ([]string, []string, map[string]*model.Thread,
 
 	entityMap := map[string]model.Entity{
 		entityIds[0]: {
-			Title: "Stort testdatasett",
+			Title:        "Stort testdatasett",
+			PublisherId:  "910244132",
+			Uri:          "https://data.example.com/datasets/1",
+			Type:         model.Dataset,
+			ContactEmail: "post@example.com",
 		},
 		entityIds[1]: {
 			Title: "Åpne Data fra Enhetsregisteret - API Dokumentasjon",
@@ -60,13 +66,47 @@ func ConfigureIntegrationTests() ([]string, []string, map[string]*model.Thread,
 	repository.CurrentThreadRepository = &MockThreadRepository{
 		ThreadMap: threadMap,
 	}
+	repository.CurrentRevisionRepository = &MockRevisionRepository{
+		RevisionMap: map[string][]model.PostRevision{},
+	}
+	repository.CurrentReportRepository = &MockReportRepository{}
+	repository.CurrentPendingPostRepository = &MockPendingPostRepository{
+		PendingPosts:  map[string]model.PendingPost{},
+		ApprovedUsers: map[string]bool{},
+	}
+	repository.CurrentContentHistoryRepository = &MockContentHistoryRepository{
+		Fingerprints: map[string]model.ContentFingerprint{},
+	}
+	repository.CurrentErasureRepository = &MockErasureRepository{
+		Erasures: map[string]model.ErasureTombstone{},
+	}
+	repository.CurrentIssueRepository = &MockIssueRepository{
+		Issues: map[string]model.DataQualityIssue{},
+	}
+	repository.CurrentRatingRepository = &MockRatingRepository{
+		Ratings: map[string]model.Rating{},
+	}
+	repository.CurrentUsefulnessRepository = &MockUsefulnessRepository{
+		Votes: map[string]model.UsefulnessVote{},
+	}
+	repository.CurrentPostIndexRepository = &MockPostIndexRepository{
+		Posts: map[string]model.IndexedPost{},
+	}
+	repository.CurrentNotificationRepository = &MockNotificationRepository{
+		Claims:   map[string]time.Time{},
+		Settings: map[string]model.NotificationSettings{},
+	}
+	repository.CurrentSubscriptionRepository = &MockSubscriptionRepository{
+		Subscriptions: map[string]model.ThreadSubscription{},
+	}
 	repository.CurrentUserRepository = &MockUserRepository{
 		UserIdMap: userIdMap,
 	}
 
 	service.CurrentAuthService = &service.AuthServiceImpl{
-		UserRepository: repository.CurrentUserRepository,
-		KeycloakHost:   mockJwkStore.URL,
+		UserRepository:     repository.CurrentUserRepository,
+		KeycloakHost:       mockJwkStore.URL,
+		ModeratorAuthority: "system:root:admin",
 	}
 
 	service.CurrentEntityService = &service.EntityServiceImpl{
@@ -75,16 +115,131 @@ func ConfigureIntegrationTests() ([]string, []string, map[string]*model.Thread,
 	service.CurrentThreadIdService = &service.ThreadIdServiceImpl{
 		ThreadIdRepository: repository.CurrentThreadIdRepository,
 	}
+	service.CurrentStatisticsService = &service.StatisticsServiceImpl{
+		PostIndexRepository: repository.CurrentPostIndexRepository,
+		EntityService:       service.CurrentEntityService,
+		ThreadBotUid:        "22",
+	}
+	service.CurrentNotificationService = &service.NotificationServiceImpl{
+		NotificationRepository: repository.CurrentNotificationRepository,
+		EntityService:          service.CurrentEntityService,
+		ThreadBotUid:           "22",
+		ThrottleWindow:         time.Hour,
+	}
+	service.CurrentSubscriptionService = &service.SubscriptionServiceImpl{
+		SubscriptionRepository: repository.CurrentSubscriptionRepository,
+		NotificationRepository: repository.CurrentNotificationRepository,
+		ThreadRepository:       repository.CurrentThreadRepository,
+		EntityService:          service.CurrentEntityService,
+		SecretProvider:         &MockSecretProvider{Secrets: map[string]string{secret.SubscriptionTokenKey: "subscription-key"}},
+		ThreadBotUid:           "22",
+		ThrottleWindow:         time.Hour,
+		UnsubscribeUrl:         "https://feedback.example.com/unsubscribe",
+		TokenTtl:               time.Hour,
+	}
 	service.CurrentThreadService = &service.ThreadServiceImpl{
-		ThreadRepository: repository.CurrentThreadRepository,
+		ThreadRepository:      repository.CurrentThreadRepository,
+		ThreadIdService:       service.CurrentThreadIdService,
+		EntityService:         service.CurrentEntityService,
+		RevisionRepository:    repository.CurrentRevisionRepository,
+		PendingPostRepository: repository.CurrentPendingPostRepository,
+		IssueRepository:       repository.CurrentIssueRepository,
+		PostIndexRepository:   repository.CurrentPostIndexRepository,
+		StatisticsService:     service.CurrentStatisticsService,
+		NotificationService:   service.CurrentNotificationService,
+		SubscriptionService:   service.CurrentSubscriptionService,
+	}
+	service.CurrentIssueService = &service.IssueServiceImpl{
+		IssueRepository:  repository.CurrentIssueRepository,
 		ThreadIdService:  service.CurrentThreadIdService,
 		EntityService:    service.CurrentEntityService,
+		MaxCommentLength: 1000,
+	}
+	service.CurrentAnnotationService = &service.AnnotationServiceImpl{
+		ThreadService:    service.CurrentThreadService,
+		EntityService:    service.CurrentEntityService,
+		CommunityBaseUrl: "https://community.example.com/",
+		ThreadBotUid:     "22",
+		PageSize:         100,
+	}
+	service.CurrentRatingService = &service.RatingServiceImpl{
+		RatingRepository: repository.CurrentRatingRepository,
+		EntityService:    service.CurrentEntityService,
+	}
+	service.CurrentUsefulnessService = &service.UsefulnessServiceImpl{
+		UsefulnessRepository: repository.CurrentUsefulnessRepository,
+		EntityService:        service.CurrentEntityService,
+		SecretProvider:       &MockSecretProvider{Secrets: map[string]string{secret.UsefulnessNonceKey: "nonce-key"}},
+		NonceTtl:             time.Hour,
+		IpLimit:              2,
+		IpWindow:             time.Hour,
+		MaxReasonLength:      200,
+	}
+	service.CurrentModerationService = &service.ModerationServiceImpl{
+		PendingPostRepository: repository.CurrentPendingPostRepository,
+		ThreadService:         service.CurrentThreadService,
+	}
+
+	service.CurrentReportService = &service.ReportServiceImpl{
+		ReportRepository: repository.CurrentReportRepository,
+		ThreadRepository: repository.CurrentThreadRepository,
+		ThreadService:    service.CurrentThreadService,
+		EntityService:    service.CurrentEntityService,
+		HideThreshold:    2,
+		ModeratorUid:     "22",
+		MaxTextLength:    1000,
+	}
+
+	service.CurrentContentScreeningService = &service.ContentScreeningServiceImpl{
+		ContentHistoryRepository: repository.CurrentContentHistoryRepository,
+		MaxLength:                5000,
+		MaxRepeatedCharacters:    20,
+		MaxLinks:                 3,
+		BlockedDomains:           []string{"spam.example"},
+		BannedWords:              map[string][]string{"nb": {"dust"}},
+		RepeatWindow:             time.Hour,
+	}
+
+	service.CurrentExportService = &service.ExportServiceImpl{
+		ThreadRepository:    repository.CurrentThreadRepository,
+		ThreadIdService:     service.CurrentThreadIdService,
+		ThreadService:       service.CurrentThreadService,
+		EntityService:       service.CurrentEntityService,
+		PostIndexRepository: repository.CurrentPostIndexRepository,
+		ThreadBotUid:        "22",
+		PageSize:            100,
+	}
+
+	service.CurrentErasureService = &service.ErasureServiceImpl{
+		ThreadRepository:         repository.CurrentThreadRepository,
+		RevisionRepository:       repository.CurrentRevisionRepository,
+		PendingPostRepository:    repository.CurrentPendingPostRepository,
+		ContentHistoryRepository: repository.CurrentContentHistoryRepository,
+		ErasureRepository:        repository.CurrentErasureRepository,
+		RatingRepository:         repository.CurrentRatingRepository,
+		SubscriptionRepository:   repository.CurrentSubscriptionRepository,
+		StatisticsService:        service.CurrentStatisticsService,
+		Policy:                   model.AnonymizeErasure,
+		AnonymousUid:             "0",
+		ProgressInterval:         20,
 	}
 
 	controller.CurrentController = &controller.ControllerImpl{
-		AuthService:     service.CurrentAuthService,
-		ThreadIdService: service.CurrentThreadIdService,
-		ThreadService:   service.CurrentThreadService,
+		AuthService:         service.CurrentAuthService,
+		ThreadIdService:     service.CurrentThreadIdService,
+		ThreadService:       service.CurrentThreadService,
+		ReportService:       service.CurrentReportService,
+		ModerationService:   service.CurrentModerationService,
+		ScreeningService:    service.CurrentContentScreeningService,
+		ExportService:       service.CurrentExportService,
+		ErasureService:      service.CurrentErasureService,
+		IssueService:        service.CurrentIssueService,
+		AnnotationService:   service.CurrentAnnotationService,
+		RatingService:       service.CurrentRatingService,
+		UsefulnessService:   service.CurrentUsefulnessService,
+		StatisticsService:   service.CurrentStatisticsService,
+		NotificationService: service.CurrentNotificationService,
+		SubscriptionService: service.CurrentSubscriptionService,
 	}
 
 	return entityIds, emails, threadMap, mockJwkStore
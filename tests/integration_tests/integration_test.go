@@ -1,15 +1,21 @@
 package integration_tests
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/controller"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
@@ -134,6 +140,58 @@ func TestUserFeedbackService(t *testing.T) {
 		}
 	})
 
+	t.Run("Get single post", func(t *testing.T) {
+		entityIds, _, threadMap, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		expectedStatusCode := http.StatusOK
+		expectedResponse := threadMap["1"].Posts[1]
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(
+			http.MethodGet,
+			fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/"+*expectedResponse.PostId),
+			nil,
+		)
+		controller.CurrentController.GetComment(&w, r)
+
+		if w.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected statuscode %d, got %d", expectedStatusCode, w.CurrentStatusCode)
+		}
+
+		var actualResponse model.Post
+		err := json.Unmarshal(w.CurrentWriteOutput, &actualResponse)
+
+		if err != nil {
+			t.Fatal("error decoding response")
+		}
+
+		if !tests.DeepEqualsPost(&actualResponse, expectedResponse) {
+			t.Fatalf("Expected: %#v\nGot: %#v", expectedResponse, actualResponse)
+		}
+	})
+
+	t.Run("Get post from other thread", func(t *testing.T) {
+		entityIds, _, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		repository.CurrentThreadIdRepository.CreateThreadId(entityIds[1], "99")
+		expectedStatusCode := http.StatusNotFound
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(
+			http.MethodGet,
+			fmt.Sprint(endpointUrl+routePath+"/"+entityIds[1]+"/1"),
+			nil,
+		)
+		controller.CurrentController.GetComment(&w, r)
+
+		if w.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected statuscode %d, got %d", expectedStatusCode, w.CurrentStatusCode)
+		}
+	})
+
 	t.Run("Create post", func(t *testing.T) {
 		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
 		defer mockJwkStore.Close()
@@ -183,6 +241,482 @@ func TestUserFeedbackService(t *testing.T) {
 		}
 	})
 
+	t.Run("Create post pushes annotation to graph store", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		updates := make(chan string, 10)
+		failures := 1
+		graphStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/sparql-update" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			updates <- string(body)
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer graphStore.Close()
+
+		service.CurrentThreadService.(*service.ThreadServiceImpl).GraphStoreService = &service.GraphStoreServiceImpl{
+			GraphStoreRepository: &repository.GraphStoreRepositoryImpl{SparqlUpdateUrl: graphStore.URL},
+			ThreadIdService:      service.CurrentThreadIdService,
+			EntityService:        service.CurrentEntityService,
+			CommunityBaseUrl:     "https://community.example.com/",
+			ThreadBotUid:         "22",
+			Graph:                "https://data.example.com/graphs/feedback",
+			MaxAttempts:          3,
+			RetryDelay:           time.Millisecond,
+			QueueSize:            10,
+		}
+
+		requestBody, _ := util.ProcessRequestBody(&map[string]string{"pid": "101", "content": "Lenken er død"})
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(http.MethodPost, endpointUrl+routePath+"/"+entityIds[0], requestBody)
+		audience := []string{"fdk-feedback-service"}
+		r.Header.Set("Authorization", *tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience))
+		controller.CurrentController.CreateComment(&w, r)
+
+		if w.CurrentStatusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, w.CurrentStatusCode)
+		}
+
+		var received []string
+		for len(received) < 2 {
+			select {
+			case update := <-updates:
+				received = append(received, update)
+			case <-time.After(time.Second):
+				t.Fatalf("expected update to be retried, got %d updates", len(received))
+			}
+		}
+		if received[0] != received[1] {
+			t.Errorf("expected the same update to be retried")
+		}
+		if !strings.Contains(received[1], "INSERT DATA") || !strings.Contains(received[1], "oa:hasTarget <https://data.example.com/datasets/1>") {
+			t.Errorf("expected annotation of the entity to be inserted, got %s", received[1])
+		}
+	})
+
+	t.Run("Report data quality issue and filter by category", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		requestBody := strings.NewReader(`{"pid": "100", "content": "Lenken gir 404", "issue": {"category": "broken_link", "field": "accessURL", "severity": "high"}}`)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), requestBody)
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.CreateComment(w, r)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var created model.Post
+		json.Unmarshal(w.Body.Bytes(), &created)
+		if created.Issue == nil || created.Issue.Severity != model.HighSeverity || !strings.HasPrefix(*created.Content, "> **Datakvalitet:** Lenke virker ikke") {
+			t.Fatalf("expected post with rendered issue, got %s", w.Body.String())
+		}
+
+		for category, expectedPosts := range map[string]int{"broken_link": 1, "other": 0} {
+			w = httptest.NewRecorder()
+			r, _ = http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"?category="+category), nil)
+			controller.CurrentController.GetComments(w, r)
+
+			var thread model.Thread
+			json.Unmarshal(w.Body.Bytes(), &thread)
+			if w.Code != http.StatusOK || len(thread.Posts) != expectedPosts {
+				t.Fatalf("expected %d posts for %s, got %d %s", expectedPosts, category, w.Code, w.Body.String())
+			}
+			if expectedPosts > 0 && (thread.Posts[0].Issue == nil || thread.Posts[0].Issue.Field != "accessURL") {
+				t.Errorf("expected issue of post, got %s", w.Body.String())
+			}
+		}
+
+		w = httptest.NewRecorder()
+		r, _ = http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"?category=typo"), nil)
+		controller.CurrentController.GetComments(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected statuscode %d for unknown category, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Publisher answers and resolves issue", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		authorities := "organization:910244132:admin"
+		reporterJwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		publisherJwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+
+		post := func(jwt *string, body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), strings.NewReader(body))
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.CreateComment(w, r)
+			return w
+		}
+		changeStatus := func(jwt *string, body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPut, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/100/status"), strings.NewReader(body))
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.UpdateIssueStatus(w, r)
+			return w
+		}
+
+		if w := post(reporterJwt, `{"pid": "100", "content": "Lisensen mangler", "issue": {"category": "wrong_license"}}`); w.Code != http.StatusCreated {
+			t.Fatalf("expected statuscode %d for issue, got %d", http.StatusCreated, w.Code)
+		}
+		if w := post(reporterJwt, `{"pid": "101", "content": "Svar", "toPid": "100", "officialAnswer": true}`); w.Code != http.StatusForbidden {
+			t.Fatalf("expected statuscode %d for answer from reporter, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := post(publisherJwt, `{"pid": "101", "content": "Lisensen er lagt til", "toPid": "100", "officialAnswer": true}`); w.Code != http.StatusCreated {
+			t.Fatalf("expected statuscode %d for official answer, got %d", http.StatusCreated, w.Code)
+		}
+
+		if w := changeStatus(reporterJwt, `{"status": "resolved"}`); w.Code != http.StatusForbidden {
+			t.Fatalf("expected statuscode %d for status change by reporter, got %d", http.StatusForbidden, w.Code)
+		}
+		w := changeStatus(publisherJwt, `{"status": "resolved", "comment": "Rettet"}`)
+		var issue model.DataQualityIssue
+		json.Unmarshal(w.Body.Bytes(), &issue)
+		if w.Code != http.StatusOK || issue.Status != model.IssueResolved || len(issue.Transitions) != 1 || len(issue.AnswerPostIds) != 1 {
+			t.Fatalf("expected resolved issue with answer, got %d %s", w.Code, w.Body.String())
+		}
+
+		for status, expectedPosts := range map[string]int{"open": 0, "resolved": 1} {
+			w = httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"?status="+status), nil)
+			controller.CurrentController.GetComments(w, r)
+
+			var thread model.Thread
+			json.Unmarshal(w.Body.Bytes(), &thread)
+			if w.Code != http.StatusOK || len(thread.Posts) != expectedPosts {
+				t.Fatalf("expected %d %s issues, got %d %s", expectedPosts, status, w.Code, w.Body.String())
+			}
+		}
+
+		w = httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/101"), nil)
+		controller.CurrentController.GetComment(w, r)
+		var answer model.Post
+		json.Unmarshal(w.Body.Bytes(), &answer)
+		if !answer.OfficialAnswer || !strings.HasPrefix(*answer.Content, "> **Offisielt svar fra utgiver**") {
+			t.Errorf("expected official answer, got %s", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		r, _ = http.NewRequest(http.MethodGet, endpointUrl+"/statistics?organization=910244132", nil)
+		r.Header.Set("Authorization", *publisherJwt)
+		controller.CurrentController.GetStatistics(w, r)
+		var statistics []model.FeedbackStatistics
+		json.Unmarshal(w.Body.Bytes(), &statistics)
+		if w.Code != http.StatusOK || len(statistics) != 1 || statistics[0].EntityType != "dataset" ||
+			statistics[0].Posts != 1 || statistics[0].OfficialAnswers != 1 || statistics[0].Answered != 1 || statistics[0].MedianResponseTime == nil {
+			t.Errorf("expected statistics of the answered issue, got %d %s", w.Code, w.Body.String())
+		}
+
+		for _, jwt := range []*string{reporterJwt, publisherJwt} {
+			w = httptest.NewRecorder()
+			r, _ = http.NewRequest(http.MethodGet, endpointUrl+"/organizations/910244132/export", nil)
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.ExportOrganization(w, r)
+			if jwt == reporterJwt {
+				if w.Code != http.StatusForbidden {
+					t.Errorf("expected statuscode %d for export by reporter, got %d", http.StatusForbidden, w.Code)
+				}
+				continue
+			}
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if w.Code != http.StatusOK || err != nil || len(records) != 4 {
+				t.Fatalf("expected export of the thread, got %d %v %v", w.Code, err, records)
+			}
+			if records[2][0] != currentEntity || records[2][2] != "dataset" || records[2][5] != "100" || records[2][10] != "resolved" || records[3][6] != "100" {
+				t.Errorf("expected issue and answer on %s, got %v", currentEntity, records)
+			}
+		}
+	})
+
+	t.Run("Notify contact point of new feedback", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+		smtpServer := tests.NewMockSmtpServer()
+		defer smtpServer.Close()
+		service.CurrentNotificationService.(*service.NotificationServiceImpl).Notifier = &MockNotificationQueue{
+			Notifier: &service.MailNotifier{
+				MailRepository: &repository.MailRepositoryImpl{
+					SmtpHost: smtpServer.Host,
+					SmtpPort: smtpServer.Port,
+					From:     "noreply@example.com",
+					Timeout:  time.Second,
+				},
+			},
+		}
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		authorities := "organization:910244132:admin"
+		userJwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		adminJwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+
+		post := func(content string) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), strings.NewReader(`{"content": "`+content+`"}`))
+			r.Header.Set("Authorization", *userJwt)
+			controller.CurrentController.CreateComment(w, r)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected statuscode %d for post, got %d", http.StatusCreated, w.Code)
+			}
+		}
+		setEnabled := func(jwt *string, enabled string) int {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPut, endpointUrl+"/organizations/910244132/notifications", strings.NewReader(`{"enabled": `+enabled+`}`))
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.UpdateNotificationSettings(w, r)
+			return w.Code
+		}
+
+		if statusCode := setEnabled(userJwt, "false"); statusCode != http.StatusForbidden {
+			t.Fatalf("expected statuscode %d for settings by user, got %d", http.StatusForbidden, statusCode)
+		}
+		if statusCode := setEnabled(adminJwt, "false"); statusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d for settings by admin, got %d", http.StatusOK, statusCode)
+		}
+		post("Ingen varsel")
+		if mails := smtpServer.Mails(); len(mails) != 0 {
+			t.Fatalf("expected no mail while turned off, got %v", mails)
+		}
+
+		setEnabled(adminJwt, "true")
+		post("Første varsel")
+		post("Ingen nytt varsel")
+		mails := smtpServer.Mails()
+		if len(mails) != 1 || mails[0].To[0] != "post@example.com" || !strings.Contains(mails[0].Data, "Stort testdatasett") {
+			t.Fatalf("expected one mail to the contact point, got %v", mails)
+		}
+	})
+
+	t.Run("Notify followers of new posts and replies", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+		smtpServer := tests.NewMockSmtpServer()
+		defer smtpServer.Close()
+		service.CurrentSubscriptionService.(*service.SubscriptionServiceImpl).Notifier = &MockNotificationQueue{
+			Notifier: &service.MailNotifier{
+				MailRepository: &repository.MailRepositoryImpl{
+					SmtpHost: smtpServer.Host,
+					SmtpPort: smtpServer.Port,
+					From:     "noreply@example.com",
+					Timeout:  time.Second,
+				},
+			},
+		}
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		authorJwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		followerJwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[1], &audience)
+
+		subscription := func(method string, jwt *string) model.SubscriptionStatus {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(method, endpointUrl+"/subscriptions/"+currentEntity, nil)
+			r.Header.Set("Authorization", *jwt)
+			if method == http.MethodPut {
+				controller.CurrentController.FollowThread(w, r)
+			} else {
+				controller.CurrentController.GetSubscription(w, r)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected statuscode %d for subscription, got %d", http.StatusOK, w.Code)
+			}
+			var status model.SubscriptionStatus
+			json.Unmarshal(w.Body.Bytes(), &status)
+			return status
+		}
+		post := func(jwt *string, body string) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), strings.NewReader(body))
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.CreateComment(w, r)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected statuscode %d for post, got %d", http.StatusCreated, w.Code)
+			}
+		}
+
+		if status := subscription(http.MethodPut, followerJwt); !status.Following {
+			t.Fatalf("expected thread to be followed, got %v", status)
+		}
+		post(authorJwt, `{"content": "Nytt innlegg"}`)
+		mails := smtpServer.Mails()
+		if len(mails) != 1 || mails[0].To[0] != emails[1] || !strings.Contains(mails[0].Data, "Subject: Nytt innlegg om Stort testdatasett") {
+			t.Fatalf("expected one mail to the follower, got %v", mails)
+		}
+		if status := subscription(http.MethodGet, authorJwt); !status.Following {
+			t.Fatalf("expected author to follow thread, got %v", status)
+		}
+
+		// The fixture post 2 is written by the author.
+		post(followerJwt, `{"content": "Svar", "toPid": "2"}`)
+		mails = smtpServer.Mails()
+		if len(mails) != 2 || mails[1].To[0] != emails[0] || !strings.Contains(mails[1].Data, "Nytt_svar") {
+			t.Fatalf("expected reply mail to the author, got %v", mails)
+		}
+
+		_, unsubscribeHeader, _ := strings.Cut(mails[0].Data, "List-Unsubscribe: <")
+		unsubscribeUrl, _, _ := strings.Cut(unsubscribeHeader, ">")
+		w := httptest.NewRecorder()
+		controller.CurrentController.GetUnsubscribe(w, httptest.NewRequest(http.MethodGet, unsubscribeUrl, nil))
+		if status := subscription(http.MethodGet, followerJwt); w.Code != http.StatusOK || !status.Following {
+			t.Fatalf("expected follower to still follow thread after opening the link, got %d %v", w.Code, status)
+		}
+		w = httptest.NewRecorder()
+		controller.CurrentController.Unsubscribe(w, httptest.NewRequest(http.MethodPost, unsubscribeUrl, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d for unsubscribe, got %d", http.StatusOK, w.Code)
+		}
+		if status := subscription(http.MethodGet, followerJwt); status.Following {
+			t.Errorf("expected follower to be unsubscribed, got %v", status)
+		}
+	})
+
+	t.Run("Get posts as annotations", func(t *testing.T) {
+		entityIds, _, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, endpointUrl+"/annotations/"+entityIds[0], nil)
+		r.Header.Set("Accept", "application/ld+json")
+		controller.CurrentController.GetAnnotations(recorder, r)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var document struct {
+			Graph []map[string]any `json:"@graph"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+			t.Fatal("error decoding response")
+		}
+		if len(document.Graph) != 2 || document.Graph[0]["@id"] != "https://community.example.com/post/2" {
+			t.Fatalf("expected annotation of post 2 and its body, got %s", recorder.Body.String())
+		}
+		target, _ := document.Graph[0]["oa:hasTarget"].(map[string]any)
+		if target["@id"] != "https://data.example.com/datasets/1" {
+			t.Errorf("expected annotation of the entity, got %v", document.Graph[0]["oa:hasTarget"])
+		}
+		reply, _ := document.Graph[0]["as:inReplyTo"].(map[string]any)
+		if reply["@id"] != "https://community.example.com/post/1" {
+			t.Errorf("expected reply to post 1, got %v", document.Graph[0]["as:inReplyTo"])
+		}
+	})
+
+	t.Run("Rate entity and get aggregated ratings", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		rate := func(score string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, endpointUrl+"/ratings/"+entityIds[0], strings.NewReader(`{"score":`+score+`}`))
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.RateEntity(recorder, r)
+			return recorder
+		}
+
+		if recorder := rate("2"); recorder.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, recorder.Code)
+		}
+		recorder := rate("5")
+		var summary model.RatingSummary
+		if err := json.Unmarshal(recorder.Body.Bytes(), &summary); err != nil {
+			t.Fatal("error decoding response")
+		}
+		if summary.Count != 1 || summary.Mean != 5 || summary.UserScore == nil || *summary.UserScore != 5 {
+			t.Fatalf("expected the changed rating to replace the first, got %s", recorder.Body.String())
+		}
+		if recorder := rate("6"); recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected statuscode %d, got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		recorder = httptest.NewRecorder()
+		controller.CurrentController.GetRatings(recorder, httptest.NewRequest(http.MethodGet, endpointUrl+"/ratings?entityId="+entityIds[0]+","+entityIds[1], nil))
+		var summaries map[string]model.RatingSummary
+		if err := json.Unmarshal(recorder.Body.Bytes(), &summaries); err != nil {
+			t.Fatal("error decoding response")
+		}
+		if summaries[entityIds[0]].Count != 1 || summaries[entityIds[1]].Count != 0 || summaries[entityIds[0]].UserScore != nil {
+			t.Fatalf("expected summaries of both entities, got %s", recorder.Body.String())
+		}
+
+		recorder = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, endpointUrl+"/ratings/"+entityIds[0], nil)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.WithdrawRating(recorder, r)
+		summary = model.RatingSummary{}
+		json.Unmarshal(recorder.Body.Bytes(), &summary)
+		if recorder.Code != http.StatusOK || summary.Count != 0 || summary.UserScore != nil {
+			t.Fatalf("expected the rating to be withdrawn, got %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("Vote on usefulness without login", func(t *testing.T) {
+		entityIds, _, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		getSummary := func(url string) model.UsefulnessSummary {
+			recorder := httptest.NewRecorder()
+			controller.CurrentController.GetUsefulness(recorder, httptest.NewRequest(http.MethodGet, endpointUrl+url, nil))
+			var summary model.UsefulnessSummary
+			if err := json.Unmarshal(recorder.Body.Bytes(), &summary); err != nil {
+				t.Fatal("error decoding response")
+			}
+			return summary
+		}
+		vote := func(entityId string, body string) int {
+			recorder := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, endpointUrl+"/usefulness/"+entityId, strings.NewReader(body))
+			r.Header.Set("X-Forwarded-For", "203.0.113.9")
+			controller.CurrentController.VoteUsefulness(recorder, r)
+			return recorder.Code
+		}
+
+		nonce := getSummary("/usefulness/" + entityIds[0]).Nonce
+		if statusCode := vote(entityIds[0], `{"helpful":false,"reason":"Mangler lisens","nonce":"`+nonce+`"}`); statusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, statusCode)
+		}
+		if statusCode := vote(entityIds[0], `{"helpful":true,"nonce":"`+nonce+`"}`); statusCode != http.StatusTooManyRequests {
+			t.Errorf("expected second vote from the same IP to be rejected, got %d", statusCode)
+		}
+		if statusCode := vote(entityIds[1], `{"helpful":true,"nonce":"`+nonce+`"}`); statusCode != http.StatusBadRequest {
+			t.Errorf("expected nonce of other entity to be rejected, got %d", statusCode)
+		}
+		nonce = getSummary("/usefulness/" + entityIds[1]).Nonce
+		if statusCode := vote(entityIds[1], `{"helpful":true,"nonce":"`+nonce+`"}`); statusCode != http.StatusCreated {
+			t.Errorf("expected vote on other entity, got %d", statusCode)
+		}
+
+		if summary := getSummary("/usefulness/" + entityIds[0]); summary.Helpful != 0 || summary.NotHelpful != 1 || summary.EntityType != "dataset" {
+			t.Errorf("expected one not helpful vote on the dataset, got %#v", summary)
+		}
+		if summary := getSummary("/usefulness?entityType=dataset"); summary.Helpful != 0 || summary.NotHelpful != 1 {
+			t.Errorf("expected one not helpful vote on datasets, got %#v", summary)
+		}
+	})
+
 	t.Run("Create new thread and post", func(t *testing.T) {
 		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
 		defer mockJwkStore.Close()
@@ -284,6 +818,73 @@ func TestUserFeedbackService(t *testing.T) {
 		}
 	})
 
+	t.Run("Edit history of own post", func(t *testing.T) {
+		entityIds, emails, threadMap, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		postId := "2"
+		currentEntity := entityIds[0]
+		previousContent := *threadMap["1"].Posts[1].Content
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+
+		requestBody, _ := util.ProcessRequestBody(&map[string]string{"content": "edited post"})
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(
+			http.MethodPut,
+			fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/"+postId),
+			requestBody,
+		)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.UpdateComment(&w, r)
+
+		if w.CurrentStatusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.CurrentStatusCode)
+		}
+
+		w = tests.MockResponseWriter{}
+		r, _ = http.NewRequest(
+			http.MethodGet,
+			fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/"+postId+"/revisions"),
+			nil,
+		)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.GetCommentRevisions(&w, r)
+
+		if w.CurrentStatusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.CurrentStatusCode)
+		}
+
+		var revisions []model.PostRevision
+		json.Unmarshal(w.CurrentWriteOutput, &revisions)
+		if len(revisions) != 1 || revisions[0].Content != previousContent || revisions[0].EditorUid != "1" {
+			t.Fatalf("expected one revision with previous content %q, got %#v", previousContent, revisions)
+		}
+	})
+
+	t.Run("Edit history of other users post", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		expectedStatusCode := http.StatusForbidden
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(
+			http.MethodGet,
+			fmt.Sprint(endpointUrl+routePath+"/"+entityIds[0]+"/1/revisions"),
+			nil,
+		)
+
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.GetCommentRevisions(&w, r)
+
+		if w.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected statuscode %d, got %d", expectedStatusCode, w.CurrentStatusCode)
+		}
+	})
+
 	t.Run("Edit other users post", func(t *testing.T) {
 		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
 		defer mockJwkStore.Close()
@@ -367,6 +968,400 @@ func TestUserFeedbackService(t *testing.T) {
 		}
 	})
 
+	t.Run("Upvote and unvote post", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+
+		for _, step := range []struct {
+			upvote        bool
+			expectedVotes int
+		}{{true, 1}, {false, 0}} {
+			method := http.MethodPut
+			if !step.upvote {
+				method = http.MethodDelete
+			}
+
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(
+				method,
+				fmt.Sprint(endpointUrl+"/votes/"+currentEntity+"/1"),
+				nil,
+			)
+			r.Header.Set("Authorization", *jwt)
+			if step.upvote {
+				controller.CurrentController.UpvoteComment(&w, r)
+			} else {
+				controller.CurrentController.UnvoteComment(&w, r)
+			}
+
+			if w.CurrentStatusCode != http.StatusOK {
+				t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.CurrentStatusCode)
+			}
+
+			var actualResponse model.Post
+			json.Unmarshal(w.CurrentWriteOutput, &actualResponse)
+			if actualResponse.Votes == nil || *actualResponse.Votes != step.expectedVotes || actualResponse.Upvoted == nil || *actualResponse.Upvoted != step.upvote {
+				t.Fatalf("expected %d votes and upvoted %t, got %#v", step.expectedVotes, step.upvote, actualResponse)
+			}
+		}
+	})
+
+	t.Run("Upvote own post", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		expectedStatusCode := http.StatusForbidden
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(
+			http.MethodPut,
+			fmt.Sprint(endpointUrl+"/votes/"+currentEntity+"/2"),
+			nil,
+		)
+
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.UpvoteComment(&w, r)
+
+		if w.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected statuscode %d, got %d", expectedStatusCode, w.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Report post until hidden", func(t *testing.T) {
+		entityIds, emails, threadMap, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		report := func(email string) int {
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(
+				http.MethodPost,
+				fmt.Sprint(endpointUrl+routePath+"/"+currentEntity+"/1/report"),
+				strings.NewReader(`{"reason":"offensive","text":"rude"}`),
+			)
+			jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &email, &audience)
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.ReportComment(&w, r)
+			return w.CurrentStatusCode
+		}
+
+		if statusCode := report(emails[0]); statusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, statusCode)
+		}
+		if statusCode := report(emails[0]); statusCode != http.StatusConflict {
+			t.Fatalf("expected duplicate report statuscode %d, got %d", http.StatusConflict, statusCode)
+		}
+		if deleted := threadMap["1"].Posts[0].Deleted; deleted != nil && *deleted {
+			t.Fatal("expected post to stay visible below the report threshold")
+		}
+
+		if statusCode := report(emails[1]); statusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, statusCode)
+		}
+		if deleted := threadMap["1"].Posts[0].Deleted; deleted == nil || !*deleted {
+			t.Fatal("expected post to be hidden at the report threshold")
+		}
+	})
+
+	t.Run("List reports without moderator authority", func(t *testing.T) {
+		_, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		expectedStatusCode := http.StatusForbidden
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+"/reports"), nil)
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.GetReportedComments(&w, r)
+
+		if w.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected statuscode %d, got %d", expectedStatusCode, w.CurrentStatusCode)
+		}
+	})
+
+	t.Run("List reports as moderator", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		repository.CurrentReportRepository.CreateReport(model.PostReport{PostId: "1", EntityId: entityIds[0], ReporterUid: "2", Reason: model.ReportSpam, Status: model.ReportOpen})
+
+		w := tests.MockResponseWriter{}
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+"/reports"), nil)
+		audience := []string{"fdk-feedback-service"}
+		authorities := "system:root:admin"
+		jwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.GetReportedComments(&w, r)
+
+		if w.CurrentStatusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.CurrentStatusCode)
+		}
+
+		var reportedPosts []model.ReportedPost
+		json.Unmarshal(w.CurrentWriteOutput, &reportedPosts)
+		if len(reportedPosts) != 1 || reportedPosts[0].Post == nil || reportedPosts[0].Entity == nil || reportedPosts[0].Entity.Title != "Stort testdatasett" {
+			t.Fatalf("expected reported post with entity context, got %s", w.CurrentWriteOutput)
+		}
+	})
+
+	t.Run("Resolve reports as moderator", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		repository.CurrentReportRepository.CreateReport(model.PostReport{PostId: "1", EntityId: entityIds[0], ReporterUid: "2", Reason: model.ReportSpam, Status: model.ReportOpen})
+
+		audience := []string{"fdk-feedback-service"}
+		authorities := "system:root:admin"
+		jwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+		resolve := func() int {
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+"/reports/1/resolve"), nil)
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.ResolveReports(&w, r)
+			return w.CurrentStatusCode
+		}
+
+		if statusCode := resolve(); statusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, statusCode)
+		}
+		if statusCode := resolve(); statusCode != http.StatusNotFound {
+			t.Fatalf("expected statuscode %d without open reports, got %d", http.StatusNotFound, statusCode)
+		}
+
+		reports, _ := repository.CurrentReportRepository.GetOpenReports()
+		if len(reports) != 0 {
+			t.Errorf("expected no open reports, got %#v", reports)
+		}
+	})
+
+	t.Run("Export own posts", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+"/current-user/export"), nil)
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.ExportCurrentUser(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var export model.UserExport
+		json.Unmarshal(w.Body.Bytes(), &export)
+		if len(export.Posts) != 1 || export.Posts[0].EntityId == nil || *export.Posts[0].EntityId != entityIds[0] {
+			t.Fatalf("expected own post with its entity, got %s", w.Body.String())
+		}
+		if *export.Posts[0].Content != "Interesting comment." {
+			t.Errorf("expected content of own post, got %s", *export.Posts[0].Content)
+		}
+	})
+
+	t.Run("Export user as moderator", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+"/users/"+emails[0]+"/export?format=csv"), nil)
+		audience := []string{"fdk-feedback-service"}
+		authorities := "system:root:admin"
+		jwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.ExportUser(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.Code)
+		}
+
+		rows := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(rows) != 2 || !strings.HasPrefix(rows[1], entityIds[0]+",1,2,1,") {
+			t.Fatalf("expected header and one post, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Erase own posts", func(t *testing.T) {
+		_, emails, threadMap, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+"/current-user/erasure"), nil)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.EraseCurrentUser(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, w.Code)
+		}
+		location := w.Header().Get("Location")
+
+		w = httptest.NewRecorder()
+		r, _ = http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+location), nil)
+		r.Header.Set("Authorization", *jwt)
+		controller.CurrentController.GetErasure(w, r)
+		var tombstone model.ErasureTombstone
+		json.Unmarshal(w.Body.Bytes(), &tombstone)
+
+		if tombstone.Status != model.ErasureCompleted || tombstone.Total != 1 || tombstone.Processed != 1 {
+			t.Fatalf("expected completed erasure of one post, got %s", w.Body.String())
+		}
+		if post, _ := threadMap["1"].FindThreadPostById(&tombstone.PostIds[0]); post == nil || *post.UserId != "0" {
+			t.Errorf("expected post to be reassigned to the anonymous user")
+		}
+	})
+
+	t.Run("Premoderated first post", func(t *testing.T) {
+		entityIds, emails, threadMap, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+		service.CurrentThreadService.(*service.ThreadServiceImpl).Premoderation = true
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		authorities := "system:root:admin"
+		authorJwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[1], &audience)
+		moderatorJwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+		postCount := len(threadMap["1"].Posts)
+
+		createPost := func(content string) *tests.MockResponseWriter {
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(
+				http.MethodPost,
+				fmt.Sprint(endpointUrl+routePath+"/"+currentEntity),
+				strings.NewReader(`{"content":"`+content+`"}`),
+			)
+			r.Header.Set("Authorization", *authorJwt)
+			controller.CurrentController.CreateComment(&w, r)
+			return &w
+		}
+		getThread := func(jwt *string) *model.Thread {
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(http.MethodGet, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), nil)
+			if jwt != nil {
+				r.Header.Set("Authorization", *jwt)
+			}
+			controller.CurrentController.GetComments(&w, r)
+			var thread model.Thread
+			json.Unmarshal(w.CurrentWriteOutput, &thread)
+			return &thread
+		}
+
+		w := createPost("first!")
+		if w.CurrentStatusCode != http.StatusAccepted {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusAccepted, w.CurrentStatusCode)
+		}
+		var pending model.Post
+		json.Unmarshal(w.CurrentWriteOutput, &pending)
+		if pending.PendingId == nil || len(threadMap["1"].Posts) != postCount {
+			t.Fatalf("expected post to be held for moderation, got %s", w.CurrentWriteOutput)
+		}
+
+		if thread := getThread(authorJwt); len(thread.PendingPosts) != 1 || len(thread.Posts) != postCount {
+			t.Fatalf("expected author to see own pending post, got %#v", thread)
+		}
+		if thread := getThread(nil); len(thread.PendingPosts) != 0 {
+			t.Fatalf("expected pending post to be hidden from others, got %#v", thread.PendingPosts)
+		}
+
+		w = &tests.MockResponseWriter{}
+		r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+"/pending/"+*pending.PendingId+"/approve"), nil)
+		r.Header.Set("Authorization", *authorJwt)
+		controller.CurrentController.ApproveComment(w, r)
+		if w.CurrentStatusCode != http.StatusForbidden {
+			t.Fatalf("expected author approval statuscode %d, got %d", http.StatusForbidden, w.CurrentStatusCode)
+		}
+
+		w = &tests.MockResponseWriter{}
+		r.Header.Set("Authorization", *moderatorJwt)
+		controller.CurrentController.ApproveComment(w, r)
+		if w.CurrentStatusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, w.CurrentStatusCode)
+		}
+		if thread := getThread(authorJwt); len(thread.PendingPosts) != 0 || len(thread.Posts) != postCount+1 {
+			t.Fatalf("expected approved post in thread, got %#v", thread)
+		}
+
+		if w := createPost("second!"); w.CurrentStatusCode != http.StatusCreated {
+			t.Fatalf("expected approved user to post directly, got statuscode %d", w.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Reject pending post", func(t *testing.T) {
+		_, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		pending, _ := repository.CurrentPendingPostRepository.CreatePendingPost(model.PendingPost{ThreadId: "1", UserId: "2", Content: "spam"})
+		audience := []string{"fdk-feedback-service"}
+		authorities := "system:root:admin"
+		jwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &emails[2], &audience, &authorities)
+
+		reject := func() int {
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+"/pending/"+pending.PendingId+"/reject"), nil)
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.RejectComment(&w, r)
+			return w.CurrentStatusCode
+		}
+
+		if statusCode := reject(); statusCode != http.StatusOK {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusOK, statusCode)
+		}
+		if statusCode := reject(); statusCode != http.StatusNotFound {
+			t.Fatalf("expected statuscode %d for rejected post, got %d", http.StatusNotFound, statusCode)
+		}
+		if approved, _ := repository.CurrentPendingPostRepository.IsApprovedUser("2"); approved {
+			t.Fatal("expected rejection not to approve user")
+		}
+	})
+
+	t.Run("Create post rejected by screening", func(t *testing.T) {
+		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
+		defer mockJwkStore.Close()
+
+		currentEntity := entityIds[0]
+		audience := []string{"fdk-feedback-service"}
+		jwt := tests.CreateMockJwt(time.Now().Add(time.Hour).Unix(), &emails[0], &audience)
+		createPost := func(content string) *tests.MockResponseWriter {
+			requestBody, _ := util.ProcessRequestBody(&map[string]string{"content": content})
+			w := tests.MockResponseWriter{}
+			r, _ := http.NewRequest(http.MethodPost, fmt.Sprint(endpointUrl+routePath+"/"+currentEntity), requestBody)
+			r.Header.Set("Authorization", *jwt)
+			controller.CurrentController.CreateComment(&w, r)
+			return &w
+		}
+
+		w := createPost("For en dust lenke: https://tilbud.spam.example/kjop")
+		if w.CurrentStatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusUnprocessableEntity, w.CurrentStatusCode)
+		}
+		var rejected model.ContentViolationResponse
+		json.Unmarshal(w.CurrentWriteOutput, &rejected)
+		if len(rejected.Errors) != 2 || rejected.Errors[0].Rule != model.RuleBlockedDomain || rejected.Errors[1].Rule != model.RuleBannedWord {
+			t.Fatalf("expected blocked domain and banned word errors, got %s", w.CurrentWriteOutput)
+		}
+
+		if w := createPost("Lisensen mangler."); w.CurrentStatusCode != http.StatusCreated {
+			t.Fatalf("expected statuscode %d, got %d", http.StatusCreated, w.CurrentStatusCode)
+		}
+		w = createPost("lisensen   MANGLER.")
+		json.Unmarshal(w.CurrentWriteOutput, &rejected)
+		if w.CurrentStatusCode != http.StatusUnprocessableEntity || rejected.Errors[0].Rule != model.RuleRepeatedContent {
+			t.Fatalf("expected repeated content to be rejected, got %d %s", w.CurrentStatusCode, w.CurrentWriteOutput)
+		}
+	})
+
 	t.Run("Create post expired token", func(t *testing.T) {
 		entityIds, emails, _, mockJwkStore := ConfigureIntegrationTests()
 		defer mockJwkStore.Close()
@@ -3,9 +3,15 @@ package integration_tests
 import (
 	"errors"
 	"math/rand"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 )
 
 type MockEntityRepository struct {
@@ -20,6 +26,16 @@ func (m *MockEntityRepository) GetEntityById(entityID string) (*model.Entity, er
 	return &entity, nil
 }
 
+func (m *MockEntityRepository) GetOrganizationEntityIds(organization string) ([]string, error) {
+	entityIds := []string{}
+	for entityId, entity := range m.EntityMap {
+		if entity.PublisherId == organization {
+			entityIds = append(entityIds, entityId)
+		}
+	}
+	return entityIds, nil
+}
+
 type MockThreadIdRepository struct {
 	ThreadIdMap map[string]string
 }
@@ -32,17 +48,44 @@ func (m *MockThreadIdRepository) GetThreadId(id string) (*string, error) {
 	return &threadId, nil
 }
 
+func (m *MockThreadIdRepository) GetThreadIds(ids []string) (map[string]string, error) {
+	threadIds := map[string]string{}
+	for _, id := range ids {
+		if threadId, present := m.ThreadIdMap[id]; present {
+			threadIds[id] = threadId
+		}
+	}
+	return threadIds, nil
+}
+
 func (m *MockThreadIdRepository) CreateThreadId(id string, threadId string) error {
 	m.ThreadIdMap[id] = threadId
 	return nil
 }
 
+func (m *MockThreadIdRepository) DeleteThreadId(id string) error {
+	delete(m.ThreadIdMap, id)
+	return nil
+}
+
+func (m *MockThreadIdRepository) GetEntityId(threadId string) (*string, error) {
+	for entityId, mappedThreadId := range m.ThreadIdMap {
+		if mappedThreadId == threadId {
+			return &entityId, nil
+		}
+	}
+	return nil, nil
+}
+
 type MockThreadRepository struct {
 	ThreadMap map[string]*model.Thread
+	// createdPosts numbers created posts from 100, as NodeBB assigns post ids.
+	createdPosts int
 }
 
-func (m *MockThreadRepository) GetThread(threadId string, page *string, postIndex *string) (*model.Thread, error) {
+func (m *MockThreadRepository) GetThread(threadId string, query model.ThreadQuery) (*model.Thread, error) {
 	var pagedId string
+	page := query.Page
 	if page == nil || *page == "1" || *page == "2" {
 		pagedId = threadId
 	} else {
@@ -55,6 +98,15 @@ func (m *MockThreadRepository) GetThread(threadId string, page *string, postInde
 	}
 	return thread, nil
 }
+func (m *MockThreadRepository) GetThreadPost(postId string, viewerUid *string) (*model.Post, error) {
+	for _, thread := range m.ThreadMap {
+		post, err := thread.FindThreadPostById(&postId)
+		if err == nil {
+			return post, nil
+		}
+	}
+	return nil, errors.New("no post found")
+}
 func (m *MockThreadRepository) CreateThread(thread model.Thread) (*model.Thread, error) {
 	randId := rand.Int()
 	threadId := strconv.Itoa(randId)
@@ -66,10 +118,13 @@ func (m *MockThreadRepository) CreateThread(thread model.Thread) (*model.Thread,
 	return &savedThread, nil
 }
 func (m *MockThreadRepository) CreateThreadPost(post model.Post) (*model.Post, error) {
-	thread, err := m.GetThread(*post.ThreadId, nil, nil)
+	thread, err := m.GetThread(*post.ThreadId, model.ThreadQuery{})
 	if err != nil {
 		return nil, err
 	}
+	postId := strconv.Itoa(100 + m.createdPosts)
+	m.createdPosts++
+	post.PostId = &postId
 	thread.Posts = append(thread.Posts, &post)
 	return &post, nil
 }
@@ -77,8 +132,479 @@ func (m *MockThreadRepository) UpdateThreadPost(post model.Post) error {
 	return nil
 }
 func (m *MockThreadRepository) DeleteThreadPost(post model.Post) error {
+	deletedPost, err := m.GetThreadPost(*post.PostId, nil)
+	if err != nil {
+		return err
+	}
+	deleted := true
+	deletedPost.Deleted = &deleted
+	return nil
+}
+func (m *MockThreadRepository) VoteThreadPost(post model.Post) error {
+	return m.addVote(post, 1)
+}
+func (m *MockThreadRepository) UnvoteThreadPost(post model.Post) error {
+	return m.addVote(post, -1)
+}
+func (m *MockThreadRepository) GetUserPosts(userslug string, page int) ([]*model.Post, int, error) {
+	var posts []*model.Post
+	for _, thread := range m.ThreadMap {
+		for _, post := range thread.Posts {
+			if post.UserId != nil && *post.UserId == userslug {
+				posts = append(posts, post)
+			}
+		}
+	}
+	return posts, 1, nil
+}
+func (m *MockThreadRepository) PurgeThreadPost(post model.Post) error {
+	for _, thread := range m.ThreadMap {
+		for i, threadPost := range thread.Posts {
+			if *threadPost.PostId == *post.PostId {
+				thread.Posts = append(thread.Posts[:i], thread.Posts[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return errors.New("no post found")
+}
+func (m *MockThreadRepository) ChangePostOwner(post model.Post, userId string) error {
+	ownedPost, err := m.GetThreadPost(*post.PostId, nil)
+	if err != nil {
+		return err
+	}
+	ownedPost.UserId = &userId
+	return nil
+}
+func (m *MockThreadRepository) addVote(post model.Post, delta int) error {
+	votedPost, err := m.GetThreadPost(*post.PostId, nil)
+	if err != nil {
+		return err
+	}
+	votes := delta
+	if votedPost.Votes != nil {
+		votes += *votedPost.Votes
+	}
+	votedPost.Votes = &votes
+	return nil
+}
+
+type MockRevisionRepository struct {
+	RevisionMap map[string][]model.PostRevision
+}
+
+// CreateRevision names the revision by its position among the revisions of
+// the post.
+func (m *MockRevisionRepository) CreateRevision(revision model.PostRevision) (string, error) {
+	m.RevisionMap[revision.PostId] = append(m.RevisionMap[revision.PostId], revision)
+	return strconv.Itoa(len(m.RevisionMap[revision.PostId]) - 1), nil
+}
+func (m *MockRevisionRepository) DeleteRevision(postId string, revisionId string) error {
+	index, err := strconv.Atoi(revisionId)
+	if err != nil || index >= len(m.RevisionMap[postId]) {
+		return errors.New("no revision found")
+	}
+	m.RevisionMap[postId] = slices.Delete(m.RevisionMap[postId], index, index+1)
+	return nil
+}
+func (m *MockRevisionRepository) GetRevisions(postId string) ([]model.PostRevision, error) {
+	return append([]model.PostRevision{}, m.RevisionMap[postId]...), nil
+}
+func (m *MockRevisionRepository) DeleteRevisions(postId string) error {
+	delete(m.RevisionMap, postId)
+	return nil
+}
+
+type MockReportRepository struct {
+	Reports []model.PostReport
+}
+
+func (m *MockReportRepository) CreateReport(report model.PostReport) error {
+	for _, existing := range m.Reports {
+		if existing.PostId == report.PostId && existing.ReporterUid == report.ReporterUid {
+			return model.ErrDuplicateReport
+		}
+	}
+	m.Reports = append(m.Reports, report)
+	return nil
+}
+func (m *MockReportRepository) CountReports(postId string) (int, error) {
+	count := 0
+	for _, report := range m.Reports {
+		if report.PostId == postId && report.Status == model.ReportOpen {
+			count++
+		}
+	}
+	return count, nil
+}
+func (m *MockReportRepository) GetOpenReports() ([]model.PostReport, error) {
+	reports := []model.PostReport{}
+	for _, report := range m.Reports {
+		if report.Status == model.ReportOpen {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+func (m *MockReportRepository) DeleteUserReports(reporterUid string) error {
+	reports := []model.PostReport{}
+	for _, report := range m.Reports {
+		if report.ReporterUid != reporterUid {
+			reports = append(reports, report)
+		}
+	}
+	m.Reports = reports
+	return nil
+}
+func (m *MockReportRepository) ResolveReports(postId string, resolverUid string, resolvedAt int64) ([]model.PostReport, error) {
+	resolved := []model.PostReport{}
+	for i, report := range m.Reports {
+		if report.PostId == postId && report.Status == model.ReportOpen {
+			m.Reports[i].Status = model.ReportResolved
+			m.Reports[i].ResolverUid = resolverUid
+			m.Reports[i].ResolvedAt = resolvedAt
+			resolved = append(resolved, m.Reports[i])
+		}
+	}
+	return resolved, nil
+}
+
+type MockPendingPostRepository struct {
+	PendingPosts  map[string]model.PendingPost
+	ApprovedUsers map[string]bool
+}
+
+func (m *MockPendingPostRepository) CreatePendingPost(post model.PendingPost) (*model.PendingPost, error) {
+	post.PendingId = "pending-" + strconv.Itoa(len(m.PendingPosts)+1)
+	m.PendingPosts[post.PendingId] = post
+	return &post, nil
+}
+func (m *MockPendingPostRepository) GetPendingPost(pendingId string) (*model.PendingPost, error) {
+	post, present := m.PendingPosts[pendingId]
+	if !present {
+		return nil, nil
+	}
+	return &post, nil
+}
+func (m *MockPendingPostRepository) GetPendingPosts() ([]model.PendingPost, error) {
+	posts := []model.PendingPost{}
+	for _, post := range m.PendingPosts {
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+func (m *MockPendingPostRepository) GetPendingPostsByUser(threadId string, userId string) ([]model.PendingPost, error) {
+	posts := []model.PendingPost{}
+	for _, post := range m.PendingPosts {
+		if post.ThreadId == threadId && post.UserId == userId {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+func (m *MockPendingPostRepository) DeletePendingPost(pendingId string) error {
+	delete(m.PendingPosts, pendingId)
+	return nil
+}
+func (m *MockPendingPostRepository) IsApprovedUser(userId string) (bool, error) {
+	return m.ApprovedUsers[userId], nil
+}
+func (m *MockPendingPostRepository) ApproveUser(userId string) error {
+	m.ApprovedUsers[userId] = true
+	return nil
+}
+func (m *MockPendingPostRepository) DeleteUser(userId string) error {
+	for pendingId, post := range m.PendingPosts {
+		if post.UserId == userId {
+			delete(m.PendingPosts, pendingId)
+		}
+	}
+	delete(m.ApprovedUsers, userId)
+	return nil
+}
+
+type MockContentHistoryRepository struct {
+	Fingerprints map[string]model.ContentFingerprint
+}
+
+func (m *MockContentHistoryRepository) GetFingerprint(userId string, hash string) (*model.ContentFingerprint, error) {
+	fingerprint, present := m.Fingerprints[userId+"/"+hash]
+	if !present {
+		return nil, nil
+	}
+	return &fingerprint, nil
+}
+func (m *MockContentHistoryRepository) SaveFingerprint(userId string, hash string, fingerprint model.ContentFingerprint) error {
+	m.Fingerprints[userId+"/"+hash] = fingerprint
+	return nil
+}
+func (m *MockContentHistoryRepository) DeleteHistory(userId string) error {
+	for key := range m.Fingerprints {
+		if strings.HasPrefix(key, userId+"/") {
+			delete(m.Fingerprints, key)
+		}
+	}
+	return nil
+}
+
+type MockRatingRepository struct {
+	Ratings map[string]model.Rating
+}
+
+func (m *MockRatingRepository) SaveRating(rating model.Rating) error {
+	m.Ratings[rating.EntityId+"_"+rating.UserId] = rating
 	return nil
 }
+func (m *MockRatingRepository) DeleteRating(entityId string, userId string) error {
+	delete(m.Ratings, entityId+"_"+userId)
+	return nil
+}
+func (m *MockRatingRepository) GetRatings(entityIds []string) ([]model.Rating, error) {
+	ratings := []model.Rating{}
+	for _, rating := range m.Ratings {
+		if slices.Contains(entityIds, rating.EntityId) {
+			ratings = append(ratings, rating)
+		}
+	}
+	return ratings, nil
+}
+func (m *MockRatingRepository) DeleteUserRatings(userId string) error {
+	for key, rating := range m.Ratings {
+		if rating.UserId == userId {
+			delete(m.Ratings, key)
+		}
+	}
+	return nil
+}
+
+type MockUsefulnessRepository struct {
+	Votes map[string]model.UsefulnessVote
+}
+
+func (m *MockUsefulnessRepository) CreateVote(vote model.UsefulnessVote) error {
+	if _, present := m.Votes[vote.VoteId]; present {
+		return model.ErrUsedNonce
+	}
+	m.Votes[vote.VoteId] = vote
+	return nil
+}
+func (m *MockUsefulnessRepository) GetIpVotes(ipWindow string) ([]model.UsefulnessVote, error) {
+	votes := []model.UsefulnessVote{}
+	for _, vote := range m.Votes {
+		if vote.IpWindow == ipWindow {
+			votes = append(votes, vote)
+		}
+	}
+	return votes, nil
+}
+func (m *MockUsefulnessRepository) CountVotes(field string, value string) (int, int, error) {
+	helpful, notHelpful := 0, 0
+	for _, vote := range m.Votes {
+		if (field == "entityId" && vote.EntityId == value) || (field == "entityType" && vote.EntityType == value) {
+			if vote.Helpful {
+				helpful++
+			} else {
+				notHelpful++
+			}
+		}
+	}
+	return helpful, notHelpful, nil
+}
+
+type MockPostIndexRepository struct {
+	Posts map[string]model.IndexedPost
+}
+
+func (m *MockPostIndexRepository) SavePost(post model.IndexedPost) error {
+	m.Posts[post.PostId] = post
+	return nil
+}
+func (m *MockPostIndexRepository) DeletePost(postId string) error {
+	delete(m.Posts, postId)
+	return nil
+}
+func (m *MockPostIndexRepository) GetPostsSince(timestamp int64, organization string, entityType string) ([]model.IndexedPost, error) {
+	posts := []model.IndexedPost{}
+	for _, post := range m.Posts {
+		if post.Timestamp >= timestamp && (organization == "" || post.Organization == organization) &&
+			(entityType == "" || post.EntityType == entityType) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+func (m *MockPostIndexRepository) CountEntityPosts(entityIds []string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, post := range m.Posts {
+		if slices.Contains(entityIds, post.EntityId) {
+			counts[post.EntityId]++
+		}
+	}
+	return counts, nil
+}
+func (m *MockPostIndexRepository) GetOrganizationEntityIds(organization string) ([]string, error) {
+	var entityIds []string
+	for _, post := range m.Posts {
+		if post.Organization == organization && !slices.Contains(entityIds, post.EntityId) {
+			entityIds = append(entityIds, post.EntityId)
+		}
+	}
+	return entityIds, nil
+}
+
+type MockNotificationRepository struct {
+	Claims   map[string]time.Time
+	Settings map[string]model.NotificationSettings
+}
+
+func (m *MockNotificationRepository) ClaimNotification(key string, now time.Time, window time.Duration) (bool, error) {
+	if claimedAt, found := m.Claims[key]; found && now.Sub(claimedAt) < window {
+		return false, nil
+	}
+	m.Claims[key] = now
+	return true, nil
+}
+func (m *MockNotificationRepository) ReleaseNotification(key string, claimedAt time.Time) error {
+	if m.Claims[key].Equal(claimedAt) {
+		delete(m.Claims, key)
+	}
+	return nil
+}
+func (m *MockNotificationRepository) GetSettings(organization string) (*model.NotificationSettings, error) {
+	settings, found := m.Settings[organization]
+	if !found {
+		return nil, nil
+	}
+	return &settings, nil
+}
+func (m *MockNotificationRepository) SaveSettings(settings model.NotificationSettings) error {
+	m.Settings[settings.Organization] = settings
+	return nil
+}
+
+type MockSubscriptionRepository struct {
+	Subscriptions map[string]model.ThreadSubscription
+}
+
+func (m *MockSubscriptionRepository) SaveSubscription(subscription model.ThreadSubscription) error {
+	m.Subscriptions[subscription.EntityId+"_"+subscription.UserId] = subscription
+	return nil
+}
+func (m *MockSubscriptionRepository) DeleteSubscription(entityId string, userId string) error {
+	delete(m.Subscriptions, entityId+"_"+userId)
+	return nil
+}
+func (m *MockSubscriptionRepository) GetSubscription(entityId string, userId string) (*model.ThreadSubscription, error) {
+	subscription, found := m.Subscriptions[entityId+"_"+userId]
+	if !found {
+		return nil, nil
+	}
+	return &subscription, nil
+}
+func (m *MockSubscriptionRepository) GetEntitySubscriptions(entityId string) ([]model.ThreadSubscription, error) {
+	subscriptions := []model.ThreadSubscription{}
+	for _, subscription := range m.Subscriptions {
+		if subscription.EntityId == entityId {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+func (m *MockSubscriptionRepository) DeleteUserSubscriptions(userId string) error {
+	for key, subscription := range m.Subscriptions {
+		if subscription.UserId == userId {
+			delete(m.Subscriptions, key)
+		}
+	}
+	return nil
+}
+
+type MockSecretProvider struct {
+	Secrets map[string]string
+}
+
+func (m *MockSecretProvider) GetSecret(key string) (string, error) {
+	value, present := m.Secrets[key]
+	if !present {
+		return "", secret.ErrSecretNotFound
+	}
+	return value, nil
+}
+
+// MockErasureRepository is locked, as erasures may be processed by
+// concurrent requests.
+type MockErasureRepository struct {
+	mutex    sync.Mutex
+	Erasures map[string]model.ErasureTombstone
+}
+
+func (m *MockErasureRepository) CreateErasure(tombstone model.ErasureTombstone) (*model.ErasureTombstone, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tombstone.ErasureId = "erasure-" + strconv.Itoa(len(m.Erasures)+1)
+	m.Erasures[tombstone.ErasureId] = tombstone
+	return &tombstone, nil
+}
+func (m *MockErasureRepository) UpdateErasure(tombstone model.ErasureTombstone) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tombstone.PostIds = append([]string{}, tombstone.PostIds...)
+	tombstone.FailedPostIds = append([]string{}, tombstone.FailedPostIds...)
+	m.Erasures[tombstone.ErasureId] = tombstone
+	return nil
+}
+func (m *MockErasureRepository) GetErasure(erasureId string) (*model.ErasureTombstone, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tombstone, present := m.Erasures[erasureId]
+	if !present {
+		return nil, nil
+	}
+	return &tombstone, nil
+}
+func (m *MockErasureRepository) LeaseErasure(erasureId string, now time.Time, lease time.Duration) (*model.ErasureTombstone, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	tombstone, present := m.Erasures[erasureId]
+	if !present {
+		return nil, false, nil
+	}
+	if tombstone.Status != model.ErasureRunning || tombstone.LeaseUntil > now.UnixMilli() {
+		return &tombstone, false, nil
+	}
+	tombstone.LeaseUntil = now.Add(lease).UnixMilli()
+	m.Erasures[erasureId] = tombstone
+	return &tombstone, true, nil
+}
+
+type MockIssueRepository struct {
+	Issues map[string]model.DataQualityIssue
+}
+
+func (m *MockIssueRepository) SaveIssue(issue model.DataQualityIssue) error {
+	m.Issues[issue.PostId] = issue
+	return nil
+}
+func (m *MockIssueRepository) DeleteIssue(postId string) error {
+	delete(m.Issues, postId)
+	return nil
+}
+func (m *MockIssueRepository) GetIssue(postId string) (*model.DataQualityIssue, error) {
+	issue, present := m.Issues[postId]
+	if !present {
+		return nil, nil
+	}
+	return &issue, nil
+}
+func (m *MockIssueRepository) GetIssues(threadId string) ([]model.DataQualityIssue, error) {
+	issues := []model.DataQualityIssue{}
+	for _, issue := range m.Issues {
+		if issue.ThreadId == threadId {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
 
 type MockUserRepository struct {
 	UserIdMap map[string]string
@@ -89,5 +615,15 @@ func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
 	if !present {
 		return nil, errors.New("user not found")
 	}
-	return &model.User{UserId: &userId}, nil
+	return &model.User{UserId: &userId, Userslug: &userId}, nil
+}
+
+// MockNotificationQueue runs notification tasks right away, so tests see the
+// mails as soon as the request is done.
+type MockNotificationQueue struct {
+	service.Notifier
+}
+
+func (m *MockNotificationQueue) Run(task func(notifier service.Notifier) error) error {
+	return task(m.Notifier)
 }
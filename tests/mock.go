@@ -63,6 +63,11 @@ func MockJwkStore() *httptest.Server {
 }
 
 func CreateMockJwt(expiresAt int64, email *string, audience *[]string) *string {
+	return CreateMockJwtWithAuthorities(expiresAt, email, audience, nil)
+}
+
+// CreateMockJwtWithAuthorities also sets the comma separated authorities claim of FDK tokens.
+func CreateMockJwtWithAuthorities(expiresAt int64, email *string, audience *[]string, authorities *string) *string {
 	t := jwt.New()
 	t.Set(jwt.SubjectKey, `https://github.com/lestrrat-go/jwx/jwt`)
 	t.Set(jwt.IssuedAtKey, time.Now().Unix())
@@ -71,6 +76,9 @@ func CreateMockJwt(expiresAt int64, email *string, audience *[]string) *string {
 	if audience != nil {
 		t.Set(jwt.AudienceKey, *audience)
 	}
+	if authorities != nil {
+		t.Set(`authorities`, *authorities)
+	}
 
 	jwk_key, _ := jwk.New(MockRsaKey)
 
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+type MockMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// MockSmtpServer is an SMTP sink on a local port, keeping the mail it
+// receives. It offers neither STARTTLS nor AUTH.
+type MockSmtpServer struct {
+	Host     string
+	Port     string
+	listener net.Listener
+	mutex    sync.Mutex
+	mails    []MockMail
+}
+
+func NewMockSmtpServer() *MockSmtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	server := &MockSmtpServer{Host: host, Port: port, listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *MockSmtpServer) Mails() []MockMail {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]MockMail{}, server.mails...)
+}
+
+func (server *MockSmtpServer) Close() {
+	server.listener.Close()
+}
+
+func (server *MockSmtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	writer := textproto.NewWriter(bufio.NewWriter(conn))
+	reply := func(line string) {
+		writer.PrintfLine("%s", line)
+	}
+
+	reply("220 localhost ESMTP")
+	var mail MockMail
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "MAIL":
+			mail = MockMail{From: addressArgument(line)}
+			reply("250 OK")
+		case "RCPT":
+			mail.To = append(mail.To, addressArgument(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := reader.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = string(data)
+			server.mutex.Lock()
+			server.mails = append(server.mails, mail)
+			server.mutex.Unlock()
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func addressArgument(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
@@ -0,0 +1,123 @@
+package unit_tests
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func TestGetAnnotations(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	communityBaseUrl := "https://community.example.com/"
+	entityUri := "https://data.example.com/datasets/1"
+	botUid, userId, replierId := "1", "3", "4"
+	botPostId, issuePostId, replyPostId := "10", "11", "12"
+	content, replyContent := "Lenken er død", "Takk, vi ser på det"
+	created, edited := 1700000000000, 1700000060000
+	pageCount := 2
+
+	setUp := func() *service.AnnotationServiceImpl {
+		return &service.AnnotationServiceImpl{
+			ThreadService: &MockThreadService{
+				MockThreadPages: []*model.Thread{
+					{
+						Posts: []*model.Post{
+							{PostId: &botPostId, UserId: &botUid},
+							{
+								PostId: &issuePostId, UserId: &userId, Content: &content, Timestamp: &created, EditedAt: &edited,
+								Issue: &model.DataQualityIssue{Category: model.BrokenLinkIssue},
+							},
+						},
+						Pagination: &model.Pagination{PageCount: &pageCount},
+					},
+					{
+						Posts:      []*model.Post{{PostId: &replyPostId, UserId: &replierId, Content: &replyContent, ToPostId: &issuePostId}},
+						Pagination: &model.Pagination{PageCount: &pageCount},
+					},
+				},
+				MockStatusCode: http.StatusOK,
+			},
+			EntityService:    &MockEntityService{MockEntity: &model.Entity{EntityId: "1", Uri: entityUri, Type: model.Dataset}},
+			CommunityBaseUrl: communityBaseUrl,
+			ThreadBotUid:     botUid,
+			PageSize:         100,
+		}
+	}
+
+	t.Run("Unknown entity", func(t *testing.T) {
+		annotationService := setUp()
+		annotationService.EntityService = &MockEntityService{}
+
+		_, statusCode := annotationService.GetAnnotations("1")
+
+		if statusCode != http.StatusNotFound {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusNotFound, statusCode)
+		}
+	})
+
+	t.Run("Thread not found", func(t *testing.T) {
+		annotationService := setUp()
+		annotationService.ThreadService = &MockThreadService{MockStatusCode: http.StatusNotFound}
+
+		_, statusCode := annotationService.GetAnnotations("1")
+
+		if statusCode != http.StatusNotFound {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusNotFound, statusCode)
+		}
+	})
+
+	t.Run("Describes posts of every page as annotations of the entity", func(t *testing.T) {
+		triples, statusCode := setUp().GetAnnotations("1")
+
+		if statusCode != http.StatusOK {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusOK, statusCode)
+		}
+
+		issue := model.Iri(communityBaseUrl + "post/" + issuePostId)
+		reply := model.Iri(communityBaseUrl + "post/" + replyPostId)
+		expectedTriples := []model.RdfTriple{
+			{Subject: issue, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "Annotation")},
+			{Subject: issue, Predicate: model.RdfType, Object: model.Iri(model.DqvNamespace + "UserQualityFeedback")},
+			{Subject: issue, Predicate: model.OaNamespace + "hasTarget", Object: model.Iri(entityUri)},
+			{Subject: issue, Predicate: model.OaNamespace + "motivatedBy", Object: model.Iri(model.DqvNamespace + "qualityAssessment")},
+			{Subject: model.BlankNode("body" + issuePostId), Predicate: model.RdfNamespace + "value", Object: model.Literal(content)},
+			{Subject: model.BlankNode("category" + issuePostId), Predicate: model.RdfNamespace + "value", Object: model.Literal("broken_link")},
+			{Subject: issue, Predicate: model.DctNamespace + "creator", Object: model.Iri(communityBaseUrl + "uid/" + userId)},
+			{Subject: issue, Predicate: model.DctNamespace + "created", Object: model.TypedLiteral("2023-11-14T22:13:20Z", model.XsdDateTime)},
+			{Subject: issue, Predicate: model.DctNamespace + "modified", Object: model.TypedLiteral("2023-11-14T22:14:20Z", model.XsdDateTime)},
+			{Subject: reply, Predicate: model.OaNamespace + "motivatedBy", Object: model.Iri(model.OaNamespace + "replying")},
+			{Subject: reply, Predicate: model.AsNamespace + "inReplyTo", Object: issue},
+			{Subject: reply, Predicate: model.OaNamespace + "hasTarget", Object: model.Iri(entityUri)},
+		}
+		for _, expected := range expectedTriples {
+			if !slices.Contains(triples, expected) {
+				t.Errorf("expected triple %v", expected)
+			}
+		}
+
+		for _, triple := range triples {
+			if triple.Subject == model.Iri(communityBaseUrl+"post/"+botPostId) {
+				t.Errorf("expected no annotation of the thread bot post. Got %v", triple)
+			}
+		}
+	})
+
+	t.Run("Targets the entity page without a URI", func(t *testing.T) {
+		annotationService := setUp()
+		annotationService.EntityService = &MockEntityService{MockEntity: &model.Entity{EntityId: "1", Type: model.Dataset}}
+
+		triples, _ := annotationService.GetAnnotations("1")
+
+		if !slices.ContainsFunc(triples, func(triple model.RdfTriple) bool {
+			return triple.Predicate == model.OaNamespace+"hasTarget" && triple.Object.Value != "" && triple.Object.Value != entityUri
+		}) {
+			t.Errorf("expected annotations to target the entity page. Got %v", triples)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
@@ -120,3 +121,54 @@ func TestAuthenticateJwt(t *testing.T) {
 
 	})
 }
+
+func TestAuthenticateModerator(t *testing.T) {
+	mockJwkStore := tests.MockJwkStore()
+	defer mockJwkStore.Close()
+
+	userId := "1"
+	testMail := "test@test.com"
+	testValidAud := []string{"fdk-feedback-service"}
+
+	var authorityTests = []struct {
+		testName              string
+		authorities           *string
+		expectedModerator     bool
+		expectedOrganizations []string
+		expectedAdmin         []string
+	}{
+		{"No authorities", nil, false, nil, nil},
+		{"Organization authority", stringPointer("organization:123456789:admin"), false, []string{"123456789"}, []string{"123456789"}},
+		{"Moderator authority", stringPointer("organization:123456789:admin, system:root:admin"), true, []string{"123456789"}, []string{"123456789"}},
+		{"Organization reader", stringPointer("organization:123456789:read,organization:987654321:write"), false, []string{"987654321"}, nil},
+	}
+
+	for _, test := range authorityTests {
+		t.Run(test.testName, func(t *testing.T) {
+			authService := service.AuthServiceImpl{
+				UserRepository:     &MockUserRepository{MockUser: &model.User{UserId: &userId}},
+				KeycloakHost:       mockJwkStore.URL,
+				ModeratorAuthority: "system:root:admin",
+			}
+			jwt := tests.CreateMockJwtWithAuthorities(time.Now().Add(time.Hour).Unix(), &testMail, &testValidAud, test.authorities)
+
+			user, statusCode := authService.AuthenticateAndGetUser(*jwt)
+			if statusCode != http.StatusOK || user.Moderator != test.expectedModerator {
+				t.Fatalf("Expected moderator %t. Got %#v, %d", test.expectedModerator, user, statusCode)
+			}
+			if !reflect.DeepEqual(user.Organizations, test.expectedOrganizations) {
+				t.Errorf("Expected organizations %v. Got %v", test.expectedOrganizations, user.Organizations)
+			}
+			if !reflect.DeepEqual(user.AdminOrganizations, test.expectedAdmin) {
+				t.Errorf("Expected admin organizations %v. Got %v", test.expectedAdmin, user.AdminOrganizations)
+			}
+			if user.Email != testMail {
+				t.Errorf("Expected email %s. Got %s", testMail, user.Email)
+			}
+		})
+	}
+}
+
+func stringPointer(value string) *string {
+	return &value
+}
@@ -0,0 +1,147 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func screeningServiceMocks() (*MockContentHistoryRepository, *service.ContentScreeningServiceImpl) {
+	mockContentHistoryRepository := MockContentHistoryRepository{}
+
+	screeningService := service.ContentScreeningServiceImpl{
+		ContentHistoryRepository: &mockContentHistoryRepository,
+		MaxLength:                60,
+		MaxRepeatedCharacters:    5,
+		MaxLinks:                 2,
+		BlockedDomains:           []string{"spam.example"},
+		BannedWords: map[string][]string{
+			"nb": {"dust", "kjøp nå"},
+			"en": {"buy now"},
+		},
+		RepeatWindow: time.Hour,
+	}
+
+	return &mockContentHistoryRepository, &screeningService
+}
+
+func violatedRules(violations []model.ContentViolation) []model.ScreeningRule {
+	var rules []model.ScreeningRule
+	for _, violation := range violations {
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func TestScreenPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "1"
+	var screeningTests = []struct {
+		testName      string
+		content       string
+		expectedRules []model.ScreeningRule
+	}{
+		{"Acceptable content", "Datasettet mangler lisens, se https://data.norge.no", nil},
+		{"Empty content", "  \n ", []model.ScreeningRule{model.RuleEmptyContent}},
+		{"Too long", strings.Repeat("lang ", 13), []model.ScreeningRule{model.RuleMaxLength}},
+		{"Repeated characters", "Hallo!!!!!!", []model.ScreeningRule{model.RuleRepeatedCharacters}},
+		{"Too many links", "http://a.no www.b.no https://c.no", []model.ScreeningRule{model.RuleMaxLinks}},
+		{"Blocked subdomain", "Se https://www.spam.example/tilbud", []model.ScreeningRule{model.RuleBlockedDomain}},
+		{"Banned word ignores case", "For en DUST beskrivelse.", []model.ScreeningRule{model.RuleBannedWord}},
+		{"Banned phrase", "Kjøp nå, buy now!", []model.ScreeningRule{model.RuleBannedWord, model.RuleBannedWord}},
+		{"Banned word only as whole word", "Bare litt dustete.", nil},
+	}
+
+	for _, test := range screeningTests {
+		t.Run(test.testName, func(t *testing.T) {
+			_, screeningService := screeningServiceMocks()
+			content := test.content
+
+			actualRules := violatedRules(screeningService.ScreenPost(model.Post{UserId: &userId, Content: &content}))
+
+			if strings.Join(toStrings(actualRules), ",") != strings.Join(toStrings(test.expectedRules), ",") {
+				t.Fatalf("expected violated rules %v. Got %v", test.expectedRules, actualRules)
+			}
+		})
+	}
+
+	t.Run("Violation explains limit", func(t *testing.T) {
+		_, screeningService := screeningServiceMocks()
+		content := "http://a.no http://b.no http://c.no"
+
+		violations := screeningService.ScreenPost(model.Post{UserId: &userId, Content: &content})
+
+		if len(violations) != 1 || *violations[0].Limit != 2 || *violations[0].Actual != 3 {
+			t.Fatalf("expected max_links violation with limit 2 and 3 links. Got %#v", violations)
+		}
+	})
+}
+
+func TestScreenRepeatedContent(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId, postId, content := "1", "2", "Samme innhold igjen"
+
+	t.Run("Recently posted by user", func(t *testing.T) {
+		mockContentHistoryRepository, screeningService := screeningServiceMocks()
+		mockContentHistoryRepository.MockFingerprint = &model.ContentFingerprint{PostId: "1", Timestamp: time.Now().Add(-time.Minute).UnixMilli()}
+
+		actualRules := violatedRules(screeningService.ScreenPost(model.Post{UserId: &userId, Content: &content}))
+
+		if len(actualRules) != 1 || actualRules[0] != model.RuleRepeatedContent {
+			t.Fatalf("expected %s violation. Got %v", model.RuleRepeatedContent, actualRules)
+		}
+	})
+
+	t.Run("Posted before repeat window", func(t *testing.T) {
+		mockContentHistoryRepository, screeningService := screeningServiceMocks()
+		mockContentHistoryRepository.MockFingerprint = &model.ContentFingerprint{PostId: "1", Timestamp: time.Now().Add(-2 * time.Hour).UnixMilli()}
+
+		if violations := screeningService.ScreenPost(model.Post{UserId: &userId, Content: &content}); len(violations) != 0 {
+			t.Fatalf("expected no violations. Got %#v", violations)
+		}
+	})
+
+	t.Run("Unchanged edit of same post", func(t *testing.T) {
+		mockContentHistoryRepository, screeningService := screeningServiceMocks()
+		mockContentHistoryRepository.MockFingerprint = &model.ContentFingerprint{PostId: postId, Timestamp: time.Now().UnixMilli()}
+
+		if violations := screeningService.ScreenPost(model.Post{PostId: &postId, UserId: &userId, Content: &content}); len(violations) != 0 {
+			t.Fatalf("expected no violations. Got %#v", violations)
+		}
+	})
+
+	t.Run("History unavailable", func(t *testing.T) {
+		mockContentHistoryRepository, screeningService := screeningServiceMocks()
+		mockContentHistoryRepository.MockError = errors.New("testerror")
+
+		if violations := screeningService.ScreenPost(model.Post{UserId: &userId, Content: &content}); len(violations) != 0 {
+			t.Fatalf("expected no violations. Got %#v", violations)
+		}
+	})
+
+	t.Run("Records published content", func(t *testing.T) {
+		mockContentHistoryRepository, screeningService := screeningServiceMocks()
+
+		screeningService.RecordPost(model.Post{PostId: &postId, UserId: &userId, Content: &content})
+
+		if len(mockContentHistoryRepository.SavedFingerprints) != 1 || mockContentHistoryRepository.SavedFingerprints[0].PostId != postId {
+			t.Fatalf("expected fingerprint for post %s. Got %#v", postId, mockContentHistoryRepository.SavedFingerprints)
+		}
+	})
+}
+
+func toStrings(rules []model.ScreeningRule) []string {
+	var values []string
+	for _, rule := range rules {
+		values = append(values, string(rule))
+	}
+	return values
+}
@@ -2,14 +2,22 @@ package unit_tests
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/controller"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
 
 func setUpControllerMocks() (*tests.MockResponseWriter, *MockAuthService, *MockThreadIdService, *MockThreadService, controller.Controller) {
@@ -26,6 +34,18 @@ func setUpControllerMocks() (*tests.MockResponseWriter, *MockAuthService, *MockT
 	return &mockResponseWriter, &mockAuthService, &mockThreadIdService, &mockThreadService, &controller
 }
 
+func setUpReportControllerMocks() (*tests.MockResponseWriter, *MockAuthService, *MockReportService, controller.Controller) {
+	mockResponseWriter := tests.MockResponseWriter{}
+	mockAuthService := MockAuthService{}
+	mockReportService := MockReportService{}
+	controller := controller.ControllerImpl{
+		AuthService:   &mockAuthService,
+		ReportService: &mockReportService,
+	}
+
+	return &mockResponseWriter, &mockAuthService, &mockReportService, &controller
+}
+
 func TestCreateComment(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
@@ -138,6 +158,140 @@ func TestCreateComment(t *testing.T) {
 			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
 		}
 	})
+
+	t.Run("Test content rejected by screening", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, _ := setUpControllerMocks()
+		userId := "1"
+		limit, actual := 3, 4
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockScreeningService := MockContentScreeningService{
+			MockViolations: []model.ContentViolation{{Rule: model.RuleMaxLinks, Message: "too many links", Limit: &limit, Actual: &actual}},
+		}
+		controller := controller.ControllerImpl{
+			AuthService:      mockAuthService,
+			ThreadService:    mockThreadService,
+			ScreeningService: &mockScreeningService,
+		}
+		expectedStatusCode := http.StatusUnprocessableEntity
+
+		request, _ := http.NewRequest(http.MethodPost, "/route/entityId", bytes.NewBuffer([]byte(`{"content": "links"}`)))
+		controller.CreateComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		expectedBody := `{"errors":[{"rule":"max_links","message":"too many links","limit":3,"actual":4}]}`
+		if strings.TrimSpace(string(mockResponseWriter.CurrentWriteOutput)) != expectedBody {
+			t.Errorf("expected %s. Got %s", expectedBody, mockResponseWriter.CurrentWriteOutput)
+		}
+		if len(mockScreeningService.RecordedPosts) != 0 {
+			t.Errorf("expected rejected content not to be recorded")
+		}
+	})
+
+	t.Run("Test screened content is recorded", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, _ := setUpControllerMocks()
+		userId, postId := "1", "2"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusCreated
+		mockThreadService.MockPost = &model.Post{PostId: &postId}
+		mockScreeningService := MockContentScreeningService{}
+		controller := controller.ControllerImpl{
+			AuthService:      mockAuthService,
+			ThreadService:    mockThreadService,
+			ScreeningService: &mockScreeningService,
+		}
+
+		request, _ := http.NewRequest(http.MethodPost, "/route/entityId", bytes.NewBuffer([]byte(`{"content": "fine"}`)))
+		controller.CreateComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != http.StatusCreated {
+			t.Fatalf("expected %d. Got %d", http.StatusCreated, mockResponseWriter.CurrentStatusCode)
+		}
+		if len(mockScreeningService.RecordedPosts) != 1 || *mockScreeningService.RecordedPosts[0].PostId != postId {
+			t.Errorf("expected content of post %s to be recorded. Got %#v", postId, mockScreeningService.RecordedPosts)
+		}
+	})
+}
+
+func TestOfficialAnswer(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	for _, test := range []struct {
+		testName           string
+		publisher          bool
+		expectedStatusCode int
+	}{
+		{"Forbidden for other users", false, http.StatusForbidden},
+		{"Created by publisher", true, http.StatusCreated},
+	} {
+		t.Run(test.testName, func(t *testing.T) {
+			mockResponseWriter, mockAuthService, _, mockThreadService, _ := setUpControllerMocks()
+			userId, postId := "1", "2"
+			mockAuthService.MockStatusCode = http.StatusOK
+			mockAuthService.MockUser = &model.User{UserId: &userId}
+			mockThreadService.MockStatusCode = http.StatusCreated
+			mockThreadService.MockPost = &model.Post{PostId: &postId, OfficialAnswer: true}
+			controller := controller.ControllerImpl{
+				AuthService:      mockAuthService,
+				ThreadService:    mockThreadService,
+				ScreeningService: &MockContentScreeningService{},
+				IssueService:     &MockIssueService{MockPublisher: test.publisher, MockStatusCode: http.StatusOK},
+			}
+
+			request, _ := http.NewRequest(http.MethodPost, "/thread/entityId", strings.NewReader(`{"content": "Rettet", "toPid": "9", "officialAnswer": true}`))
+			controller.CreateComment(mockResponseWriter, request)
+
+			if mockResponseWriter.CurrentStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+			}
+		})
+	}
+}
+
+func TestUpdateCommentIssueStatus(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Test invalid body", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, _ := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		controller := controller.ControllerImpl{AuthService: mockAuthService, IssueService: &MockIssueService{}}
+
+		request, _ := http.NewRequest(http.MethodPut, "/thread/entityId/2/status", strings.NewReader("not json"))
+		controller.UpdateIssueStatus(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != http.StatusBadRequest {
+			t.Fatalf("expected %d. Got %d", http.StatusBadRequest, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully changes status", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, _ := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockIssueService := MockIssueService{
+			MockIssue:      &model.DataQualityIssue{Category: model.BrokenLinkIssue, Status: model.IssueResolved},
+			MockStatusCode: http.StatusOK,
+		}
+		controller := controller.ControllerImpl{AuthService: mockAuthService, IssueService: &mockIssueService}
+
+		request, _ := http.NewRequest(http.MethodPut, "/thread/entityId/2/status", strings.NewReader(`{"status": "resolved", "comment": "Rettet"}`))
+		controller.UpdateIssueStatus(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, mockResponseWriter.CurrentStatusCode)
+		}
+		transitions := mockIssueService.Transitions
+		if len(transitions) != 1 || transitions[0].Status != model.IssueResolved || transitions[0].Comment != "Rettet" {
+			t.Errorf("expected transition to resolved. Got %#v", transitions)
+		}
+	})
 }
 
 func TestGetComments(t *testing.T) {
@@ -160,6 +314,24 @@ func TestGetComments(t *testing.T) {
 		}
 	})
 
+	t.Run("Rejects unknown sort", func(t *testing.T) {
+		mockResponseWriter, _, _, mockThreadService, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusBadRequest
+		mockThreadService.MockStatusCode = http.StatusOK
+
+		request, _ := http.NewRequest(
+			http.MethodGet,
+			"/route/entityId?sort=random",
+			nil,
+		)
+
+		controller.GetComments(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
 	t.Run("Handles get thread id error", func(t *testing.T) {
 		mockResponseWriter, _, _, mockThreadService, controller := setUpControllerMocks()
 		expectedStatusCode := http.StatusNotFound
@@ -220,6 +392,89 @@ func TestGetComments(t *testing.T) {
 	})
 }
 
+func TestGetComment(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Missing post id", func(t *testing.T) {
+		mockResponseWriter, _, _, _, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusNotFound
+
+		request, _ := http.NewRequest(
+			http.MethodGet,
+			"/route/entityId",
+			nil,
+		)
+
+		controller.GetComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Handles post not found", func(t *testing.T) {
+		mockResponseWriter, _, _, mockThreadService, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusNotFound
+		mockThreadService.MockStatusCode = http.StatusNotFound
+
+		request, _ := http.NewRequest(
+			http.MethodGet,
+			"/route/entityId/postId",
+			nil,
+		)
+
+		controller.GetComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully gets comment", func(t *testing.T) {
+		mockResponseWriter, _, _, mockThreadService, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusOK
+		mockThreadService.MockPost = &model.Post{}
+		mockThreadService.MockStatusCode = http.StatusOK
+
+		request, _ := http.NewRequest(
+			http.MethodGet,
+			"/route/entityId/postId",
+			nil,
+		)
+
+		controller.GetComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		if mockThreadService.ViewerUid != nil {
+			t.Fatalf("expected anonymous read. Got viewer %s", *mockThreadService.ViewerUid)
+		}
+	})
+
+	t.Run("Reads comment as signed in user", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, controller := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockPost = &model.Post{}
+		mockThreadService.MockStatusCode = http.StatusOK
+
+		request, _ := http.NewRequest(
+			http.MethodGet,
+			"/route/entityId/postId",
+			nil,
+		)
+		request.Header.Set("Authorization", "Bearer token")
+
+		controller.GetComment(mockResponseWriter, request)
+
+		if mockThreadService.ViewerUid == nil || *mockThreadService.ViewerUid != userId {
+			t.Fatalf("expected viewer %s. Got %v", userId, mockThreadService.ViewerUid)
+		}
+	})
+}
+
 func TestUpdateComment(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
@@ -408,7 +663,7 @@ func TestDeleteComment(t *testing.T) {
 	})
 }
 
-func TestCurrentUser(t *testing.T) {
+func TestGetCommentRevisions(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
 	t.Run("Test unauthorized call", func(t *testing.T) {
@@ -416,37 +671,1161 @@ func TestCurrentUser(t *testing.T) {
 		expectedStatusCode := http.StatusUnauthorized
 		mockAuthService.MockStatusCode = expectedStatusCode
 
-		controller.CurrentUser(mockResponseWriter, &http.Request{})
+		controller.GetCommentRevisions(mockResponseWriter, &http.Request{})
 
 		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
 			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
 		}
 	})
 
-	t.Run("Test user not found", func(t *testing.T) {
-		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
-		expectedStatusCode := http.StatusUnauthorized
+	t.Run("Handles forbidden revisions", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, controller := setUpControllerMocks()
+		userId := "1"
 		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusForbidden
+		expectedStatusCode := http.StatusForbidden
 
-		controller.CurrentUser(mockResponseWriter, &http.Request{})
+		request, _ := http.NewRequest(http.MethodGet, "/thread/entityId/2/revisions", nil)
+		controller.GetCommentRevisions(mockResponseWriter, request)
 
 		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
 			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
 		}
 	})
 
-	t.Run("Successfully gets current user", func(t *testing.T) {
-		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
-		testUser := "testUser"
+	t.Run("Successfully gets revisions", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, controller := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockRevisions = []model.PostRevision{{PostId: "2", Content: "first version"}}
 		expectedStatusCode := http.StatusOK
-		expectedUser := model.User{UserId: &testUser}
+
+		request, _ := http.NewRequest(http.MethodGet, "/thread/entityId/2/revisions", nil)
+		controller.GetCommentRevisions(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		if !strings.Contains(string(mockResponseWriter.CurrentWriteOutput), `"content":"first version"`) {
+			t.Errorf("expected revisions in response, got %s", mockResponseWriter.CurrentWriteOutput)
+		}
+	})
+}
+
+func TestReportComment(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Test unauthorized call", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, controller := setUpReportControllerMocks()
+		expectedStatusCode := http.StatusUnauthorized
 		mockAuthService.MockStatusCode = expectedStatusCode
-		mockAuthService.MockUser = &expectedUser
 
-		controller.CurrentUser(mockResponseWriter, &http.Request{})
+		controller.ReportComment(mockResponseWriter, &http.Request{})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Test invalid body", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, controller := setUpReportControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		expectedStatusCode := http.StatusBadRequest
+
+		request, _ := http.NewRequest(http.MethodPost, "/thread/entityId/2/report", strings.NewReader("not json"))
+		controller.ReportComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Handles duplicate report", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, mockReportService, controller := setUpReportControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockReportService.MockStatusCode = http.StatusConflict
+		expectedStatusCode := http.StatusConflict
+
+		request, _ := http.NewRequest(http.MethodPost, "/thread/entityId/2/report", strings.NewReader(`{"reason":"spam"}`))
+		controller.ReportComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully reports", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, mockReportService, controller := setUpReportControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockReportService.MockStatusCode = http.StatusCreated
+		mockReportService.MockReport = &model.PostReport{PostId: "2", Reason: model.ReportSpam}
+		expectedStatusCode := http.StatusCreated
+
+		request, _ := http.NewRequest(http.MethodPost, "/thread/entityId/2/report", strings.NewReader(`{"reason":"spam"}`))
+		controller.ReportComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+}
+
+func TestGetReportedComments(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Test non-moderator", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, controller := setUpReportControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		expectedStatusCode := http.StatusForbidden
+
+		controller.GetReportedComments(mockResponseWriter, &http.Request{Header: http.Header{}})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully lists reports for moderator", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, mockReportService, controller := setUpReportControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId, Moderator: true}
+		mockReportService.MockStatusCode = http.StatusOK
+		mockReportService.MockReportedPosts = []model.ReportedPost{{EntityId: "entityId"}}
+		expectedStatusCode := http.StatusOK
+
+		controller.GetReportedComments(mockResponseWriter, &http.Request{Header: http.Header{}})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		if !strings.Contains(string(mockResponseWriter.CurrentWriteOutput), `"entityId":"entityId"`) {
+			t.Errorf("expected reported posts in response, got %s", mockResponseWriter.CurrentWriteOutput)
+		}
+	})
+}
+
+func TestResolveReportedComments(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "1"
+	var resolveTests = []struct {
+		testName           string
+		user               *model.User
+		serviceStatusCode  int
+		expectedStatusCode int
+	}{
+		{"Unknown user", nil, http.StatusOK, http.StatusUnauthorized},
+		{"Non-moderator", &model.User{UserId: &userId}, http.StatusOK, http.StatusForbidden},
+		{"No open reports", &model.User{UserId: &userId, Moderator: true}, http.StatusNotFound, http.StatusNotFound},
+		{"Resolves reports", &model.User{UserId: &userId, Moderator: true}, http.StatusOK, http.StatusOK},
+	}
+
+	for _, test := range resolveTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockResponseWriter, mockAuthService, mockReportService, controller := setUpReportControllerMocks()
+			mockAuthService.MockStatusCode = http.StatusOK
+			mockAuthService.MockUser = test.user
+			mockReportService.MockStatusCode = test.serviceStatusCode
+			mockReportService.MockReport = &model.PostReport{PostId: "2", Status: model.ReportResolved}
+
+			request, _ := http.NewRequest(http.MethodPost, "/reports/2/resolve", nil)
+			controller.ResolveReports(mockResponseWriter, request)
+
+			if mockResponseWriter.CurrentStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+			}
+			if test.expectedStatusCode == http.StatusOK && !strings.Contains(string(mockResponseWriter.CurrentWriteOutput), `"status":"resolved"`) {
+				t.Errorf("expected resolved reports in response, got %s", mockResponseWriter.CurrentWriteOutput)
+			}
+		})
+	}
+}
+
+func TestModerateComments(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	setUp := func(moderator bool) (*tests.MockResponseWriter, *MockModerationService, controller.Controller) {
+		userId := "1"
+		mockResponseWriter := tests.MockResponseWriter{}
+		mockModerationService := MockModerationService{}
+		controller := controller.ControllerImpl{
+			AuthService:       &MockAuthService{MockStatusCode: http.StatusOK, MockUser: &model.User{UserId: &userId, Moderator: moderator}},
+			ModerationService: &mockModerationService,
+		}
+		return &mockResponseWriter, &mockModerationService, &controller
+	}
+
+	t.Run("Test non-moderator", func(t *testing.T) {
+		mockResponseWriter, _, controller := setUp(false)
+		expectedStatusCode := http.StatusForbidden
+
+		request, _ := http.NewRequest(http.MethodPost, "/pending/pendingId/approve", nil)
+		controller.ApproveComment(mockResponseWriter, request)
 
 		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
 			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
 		}
 	})
+
+	t.Run("Successfully lists pending posts", func(t *testing.T) {
+		mockResponseWriter, mockModerationService, controller := setUp(true)
+		mockModerationService.MockStatusCode = http.StatusOK
+		mockModerationService.MockPendingPosts = []model.PendingPost{{PendingId: "pendingId"}}
+		expectedStatusCode := http.StatusOK
+
+		controller.GetPendingComments(mockResponseWriter, &http.Request{Header: http.Header{}})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		if !strings.Contains(string(mockResponseWriter.CurrentWriteOutput), `"id":"pendingId"`) {
+			t.Errorf("expected pending posts in response, got %s", mockResponseWriter.CurrentWriteOutput)
+		}
+	})
+
+	t.Run("Successfully approves post", func(t *testing.T) {
+		mockResponseWriter, mockModerationService, controller := setUp(true)
+		postId := "2"
+		mockModerationService.MockStatusCode = http.StatusCreated
+		mockModerationService.MockPost = &model.Post{PostId: &postId}
+		expectedStatusCode := http.StatusCreated
+
+		request, _ := http.NewRequest(http.MethodPost, "/pending/pendingId/approve", nil)
+		controller.ApproveComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Reject unknown pending post", func(t *testing.T) {
+		mockResponseWriter, mockModerationService, controller := setUp(true)
+		mockModerationService.MockStatusCode = http.StatusNotFound
+		expectedStatusCode := http.StatusNotFound
+
+		request, _ := http.NewRequest(http.MethodPost, "/pending/pendingId/reject", nil)
+		controller.RejectComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+}
+
+func TestUpvoteComment(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Test unauthorized call", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusUnauthorized
+		mockAuthService.MockStatusCode = expectedStatusCode
+
+		controller.UpvoteComment(mockResponseWriter, &http.Request{})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Test missing post id", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		expectedStatusCode := http.StatusNotFound
+
+		request, _ := http.NewRequest(http.MethodPut, "/votes/entityId", nil)
+		controller.UpvoteComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Handles vote on own post", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, controller := setUpControllerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusForbidden
+		expectedStatusCode := http.StatusForbidden
+
+		request, _ := http.NewRequest(http.MethodDelete, "/votes/entityId/1", nil)
+		controller.UnvoteComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully upvotes", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, mockThreadService, controller := setUpControllerMocks()
+		userId, postId, votes, upvoted := "1", "2", 4, true
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId, Votes: &votes, Upvoted: &upvoted}
+		expectedStatusCode := http.StatusOK
+
+		request, _ := http.NewRequest(http.MethodPut, "/votes/entityId/2", nil)
+		controller.UpvoteComment(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+		if !strings.Contains(string(mockResponseWriter.CurrentWriteOutput), `"upvoted":true`) {
+			t.Errorf("expected own vote in response, got %s", mockResponseWriter.CurrentWriteOutput)
+		}
+	})
+}
+
+func TestCurrentUser(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Test unauthorized call", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusUnauthorized
+		mockAuthService.MockStatusCode = expectedStatusCode
+
+		controller.CurrentUser(mockResponseWriter, &http.Request{})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Test user not found", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
+		expectedStatusCode := http.StatusUnauthorized
+		mockAuthService.MockStatusCode = http.StatusOK
+
+		controller.CurrentUser(mockResponseWriter, &http.Request{})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Successfully gets current user", func(t *testing.T) {
+		mockResponseWriter, mockAuthService, _, _, controller := setUpControllerMocks()
+		testUser := "testUser"
+		expectedStatusCode := http.StatusOK
+		expectedUser := model.User{UserId: &testUser}
+		mockAuthService.MockStatusCode = expectedStatusCode
+		mockAuthService.MockUser = &expectedUser
+
+		controller.CurrentUser(mockResponseWriter, &http.Request{})
+
+		if mockResponseWriter.CurrentStatusCode != expectedStatusCode {
+			t.Fatalf("expected %d. Got %d", expectedStatusCode, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+}
+
+func TestExportUser(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId, entityId, threadId, postId, content := "3", "entity", "1", "2", "Hei, \"alle\""
+	timestamp := 1700000000000
+	setUp := func(moderator bool) (*MockAuthService, *MockExportService, controller.Controller) {
+		mockAuthService := MockAuthService{MockStatusCode: http.StatusOK, MockUser: &model.User{UserId: &userId, Moderator: moderator}}
+		mockExportService := MockExportService{
+			MockStatusCode: http.StatusOK,
+			MockExport: &model.UserExport{
+				User:  &model.User{UserId: &userId},
+				Posts: []model.ExportedPost{{EntityId: &entityId, ThreadId: &threadId, PostId: &postId, Content: &content, Timestamp: &timestamp}},
+			},
+		}
+		controller := controller.ControllerImpl{
+			AuthService:   &mockAuthService,
+			ExportService: &mockExportService,
+		}
+		return &mockAuthService, &mockExportService, &controller
+	}
+
+	t.Run("Exports current user as JSON", func(t *testing.T) {
+		_, _, controller := setUp(false)
+		recorder := httptest.NewRecorder()
+
+		controller.ExportCurrentUser(recorder, httptest.NewRequest(http.MethodGet, "/current-user/export", nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+		if !strings.Contains(recorder.Body.String(), `"entityId":"entity"`) {
+			t.Errorf("expected posts in export, got %s", recorder.Body.String())
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Disposition"), "feedback-export-3.json") {
+			t.Errorf("expected JSON attachment, got %s", recorder.Header().Get("Content-Disposition"))
+		}
+	})
+
+	t.Run("Exports current user as CSV", func(t *testing.T) {
+		_, _, controller := setUp(false)
+		recorder := httptest.NewRecorder()
+
+		controller.ExportCurrentUser(recorder, httptest.NewRequest(http.MethodGet, "/current-user/export?format=csv", nil))
+
+		expectedCsv := "entityId,tid,pid,toPid,timestamp,editedTimestamp,content\n" +
+			"entity,1,2,,2023-11-14T22:13:20Z,,\"Hei, \"\"alle\"\"\"\n"
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+		if recorder.Body.String() != expectedCsv {
+			t.Errorf("expected %q. Got %q", expectedCsv, recorder.Body.String())
+		}
+		if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/csv") {
+			t.Errorf("expected CSV content type, got %s", recorder.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("Test non-moderator exporting other user", func(t *testing.T) {
+		_, mockExportService, controller := setUp(false)
+		recorder := httptest.NewRecorder()
+
+		controller.ExportUser(recorder, httptest.NewRequest(http.MethodGet, "/users/a@test.com/export", nil))
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected %d. Got %d", http.StatusForbidden, recorder.Code)
+		}
+		if len(mockExportService.ExportedUsers) != 0 {
+			t.Errorf("expected no export")
+		}
+	})
+
+	t.Run("Test moderator exporting unknown user", func(t *testing.T) {
+		mockAuthService, _, controller := setUp(true)
+		mockAuthService.MockError = errors.New("user not found")
+		recorder := httptest.NewRecorder()
+
+		controller.ExportUser(recorder, httptest.NewRequest(http.MethodGet, "/users/unknown@test.com/export", nil))
+
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected %d. Got %d", http.StatusNotFound, recorder.Code)
+		}
+	})
+
+	t.Run("Moderator exports user by email", func(t *testing.T) {
+		_, mockExportService, controller := setUp(true)
+		recorder := httptest.NewRecorder()
+
+		controller.ExportUser(recorder, httptest.NewRequest(http.MethodGet, "/users/a@test.com/export", nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+		if len(mockExportService.ExportedUsers) != 1 {
+			t.Errorf("expected user to be exported")
+		}
+	})
+}
+
+func TestExportOrganization(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	timestamp := 1700000000000
+	setUp := func() (*MockAuthService, *MockExportService, controller.Controller) {
+		mockAuthService := MockAuthService{MockStatusCode: http.StatusOK, MockUser: &model.User{UserId: &userId}}
+		mockExportService := MockExportService{
+			MockStatusCode: http.StatusOK,
+			MockEntities:   []model.Entity{{EntityId: "a"}, {EntityId: "b"}},
+			MockRows: []model.FeedbackExportRow{
+				{EntityId: "b", PostId: "11", Timestamp: &timestamp, Content: "second"},
+				{EntityId: "a", PostId: "10", Author: "Ola", Content: "first", Status: model.IssueOpen},
+			},
+		}
+		controller := controller.ControllerImpl{
+			AuthService:   &mockAuthService,
+			ExportService: &mockExportService,
+		}
+		return &mockAuthService, &mockExportService, &controller
+	}
+
+	t.Run("Exports entities in order as CSV", func(t *testing.T) {
+		_, mockExportService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.ExportOrganization(recorder, httptest.NewRequest(http.MethodGet, "/organizations/910244132/export", nil))
+
+		expectedCsv := "entityId,entityTitle,entityType,entityLink,tid,pid,toPid,author,timestamp,content,status\n" +
+			"a,,,,,10,,Ola,,first,open\n" +
+			"b,,,,,11,,,2023-11-14T22:13:20Z,second,\n"
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+		if recorder.Body.String() != expectedCsv {
+			t.Errorf("expected %q. Got %q", expectedCsv, recorder.Body.String())
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Disposition"), "feedback-910244132.csv") {
+			t.Errorf("expected CSV attachment, got %s", recorder.Header().Get("Content-Disposition"))
+		}
+		if len(mockExportService.ExportedUsers) != 1 || *mockExportService.ExportedUsers[0].UserId != userId {
+			t.Errorf("expected export for the user. Got %#v", mockExportService.ExportedUsers)
+		}
+	})
+
+	t.Run("Exports as XLSX", func(t *testing.T) {
+		_, _, controller := setUp()
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/organizations/910244132/export", nil)
+		request.Header.Set("Accept", util.XlsxContentType)
+
+		controller.ExportOrganization(recorder, request)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+		if recorder.Header().Get("Content-Type") != util.XlsxContentType {
+			t.Errorf("expected XLSX content type, got %s", recorder.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Disposition"), "feedback-910244132.xlsx") {
+			t.Errorf("expected XLSX attachment, got %s", recorder.Header().Get("Content-Disposition"))
+		}
+		if !bytes.HasPrefix(recorder.Body.Bytes(), []byte("PK")) {
+			t.Errorf("expected zip archive, got %q", recorder.Body.String())
+		}
+	})
+
+	t.Run("Not allowed to export organization", func(t *testing.T) {
+		_, mockExportService, controller := setUp()
+		mockExportService.MockStatusCode = http.StatusForbidden
+		recorder := httptest.NewRecorder()
+
+		controller.ExportOrganization(recorder, httptest.NewRequest(http.MethodGet, "/organizations/910244132/export", nil))
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected %d. Got %d", http.StatusForbidden, recorder.Code)
+		}
+		if recorder.Body.Len() != 0 {
+			t.Errorf("expected no export, got %q", recorder.Body.String())
+		}
+	})
+
+	t.Run("Not logged in", func(t *testing.T) {
+		mockAuthService, mockExportService, controller := setUp()
+		mockAuthService.MockUser = nil
+		recorder := httptest.NewRecorder()
+
+		controller.ExportOrganization(recorder, httptest.NewRequest(http.MethodGet, "/organizations/910244132/export", nil))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %d. Got %d", http.StatusUnauthorized, recorder.Code)
+		}
+		if len(mockExportService.ExportedUsers) != 0 {
+			t.Errorf("expected no export")
+		}
+	})
+}
+
+func TestEraseUser(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	setUp := func(moderator bool) (*MockAuthService, *MockErasureService, controller.Controller) {
+		mockAuthService := MockAuthService{MockStatusCode: http.StatusOK, MockUser: &model.User{UserId: &userId, Moderator: moderator}}
+		mockErasureService := MockErasureService{
+			MockStatusCode:        http.StatusAccepted,
+			MockProcessStatusCode: http.StatusAccepted,
+			MockTombstone:         &model.ErasureTombstone{ErasureId: "erasure", UserId: userId, Status: model.ErasureRunning},
+		}
+		controller := controller.ControllerImpl{
+			AuthService:    &mockAuthService,
+			ErasureService: &mockErasureService,
+		}
+		return &mockAuthService, &mockErasureService, &controller
+	}
+
+	t.Run("Erases first chunk of current user", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(false)
+		recorder := httptest.NewRecorder()
+
+		controller.EraseCurrentUser(recorder, httptest.NewRequest(http.MethodPost, "/current-user/erasure", nil))
+
+		if recorder.Code != http.StatusAccepted {
+			t.Fatalf("expected %d. Got %d", http.StatusAccepted, recorder.Code)
+		}
+		if recorder.Header().Get("Location") != "/erasures/erasure" {
+			t.Errorf("expected location of erasure, got %s", recorder.Header().Get("Location"))
+		}
+		if !reflect.DeepEqual(mockErasureService.ProcessedIds, []string{"erasure"}) {
+			t.Errorf("expected erasure to be processed, got %v", mockErasureService.ProcessedIds)
+		}
+	})
+
+	t.Run("Erased user resumes own erasure", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(false)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockProcessStatusCode = http.StatusOK
+		recorder := httptest.NewRecorder()
+
+		controller.ResumeErasure(recorder, httptest.NewRequest(http.MethodPost, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusOK || !reflect.DeepEqual(mockErasureService.ProcessedIds, []string{"erasure"}) {
+			t.Fatalf("expected erasure to be processed. Got %d %v", recorder.Code, mockErasureService.ProcessedIds)
+		}
+	})
+
+	t.Run("Test resuming erasure processed elsewhere", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(true)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockProcessStatusCode = http.StatusConflict
+		recorder := httptest.NewRecorder()
+
+		controller.ResumeErasure(recorder, httptest.NewRequest(http.MethodPost, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusConflict {
+			t.Fatalf("expected %d. Got %d", http.StatusConflict, recorder.Code)
+		}
+	})
+
+	t.Run("Test other user resuming erasure", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(false)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockTombstone.UserId = "4"
+		recorder := httptest.NewRecorder()
+
+		controller.ResumeErasure(recorder, httptest.NewRequest(http.MethodPost, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusForbidden || len(mockErasureService.ProcessedIds) != 0 {
+			t.Fatalf("expected %d without processing. Got %d %v", http.StatusForbidden, recorder.Code, mockErasureService.ProcessedIds)
+		}
+	})
+
+	t.Run("Test non-moderator erasing other user", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(false)
+		recorder := httptest.NewRecorder()
+
+		controller.EraseUser(recorder, httptest.NewRequest(http.MethodPost, "/users/a@test.com/erasure", nil))
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected %d. Got %d", http.StatusForbidden, recorder.Code)
+		}
+		if len(mockErasureService.StartedUsers) != 0 {
+			t.Errorf("expected no erasure")
+		}
+	})
+
+	t.Run("Test other user reading erasure", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(false)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockTombstone.UserId = "4"
+		recorder := httptest.NewRecorder()
+
+		controller.GetErasure(recorder, httptest.NewRequest(http.MethodGet, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected %d. Got %d", http.StatusForbidden, recorder.Code)
+		}
+	})
+
+	t.Run("Moderator reads erasure of other user", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(true)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockTombstone.UserId = "4"
+		recorder := httptest.NewRecorder()
+
+		controller.GetErasure(recorder, httptest.NewRequest(http.MethodGet, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"status":"running"`) {
+			t.Fatalf("expected erasure progress. Got %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("Test resuming finished erasure", func(t *testing.T) {
+		_, mockErasureService, controller := setUp(true)
+		mockErasureService.MockStatusCode = http.StatusOK
+		mockErasureService.MockTombstone.Status = model.ErasureCompleted
+		recorder := httptest.NewRecorder()
+
+		controller.ResumeErasure(recorder, httptest.NewRequest(http.MethodPost, "/erasures/erasure", nil))
+
+		if recorder.Code != http.StatusConflict {
+			t.Fatalf("expected %d. Got %d", http.StatusConflict, recorder.Code)
+		}
+	})
+}
+
+func TestStreamThreadEvents(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Missing entity id", func(t *testing.T) {
+		mockResponseWriter, _, _, _, controller := setUpControllerMocks()
+		request, _ := http.NewRequest(http.MethodGet, "/events", nil)
+
+		controller.StreamThreadEvents(mockResponseWriter, request)
+
+		if mockResponseWriter.CurrentStatusCode != http.StatusNotFound {
+			t.Fatalf("expected %d. Got %d", http.StatusNotFound, mockResponseWriter.CurrentStatusCode)
+		}
+	})
+
+	t.Run("Invalid last event id", func(t *testing.T) {
+		threadId := "1"
+		controller := controller.ControllerImpl{
+			ThreadIdService: &MockThreadIdService{MockThreadId: &threadId},
+			EventBus:        eventbus.NewInMemoryEventBus(10),
+		}
+		request, _ := http.NewRequest(http.MethodGet, "/events/entityId", nil)
+		request.Header.Set("Last-Event-ID", "not-an-event")
+		recorder := httptest.NewRecorder()
+
+		controller.StreamThreadEvents(recorder, request)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d. Got %d", http.StatusBadRequest, recorder.Code)
+		}
+	})
+
+	t.Run("Resumes after last event id", func(t *testing.T) {
+		threadId, postId := "1", "10"
+		bus := eventbus.NewInMemoryEventBus(10)
+		controller := controller.ControllerImpl{
+			ThreadIdService:   &MockThreadIdService{MockThreadId: &threadId},
+			EventBus:          bus,
+			HeartbeatInterval: time.Hour,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		seen, _ := bus.Subscribe(ctx, threadId, nil)
+		bus.Publish(threadId, model.PostEvent{Type: model.PostCreated, Post: &model.Post{PostId: &postId}})
+		bus.Publish(threadId, model.PostEvent{Type: model.PostDeleted, Post: &model.Post{PostId: &postId}})
+		lastEvent := <-seen
+
+		requestCtx, requestCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer requestCancel()
+		request, _ := http.NewRequestWithContext(requestCtx, http.MethodGet, "/events/entityId", nil)
+		request.Header.Set("Last-Event-ID", lastEvent.EventId)
+		recorder := httptest.NewRecorder()
+
+		controller.StreamThreadEvents(recorder, request)
+
+		body := recorder.Body.String()
+		if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected event stream with status %d. Got %d, %s", http.StatusOK, recorder.Code, recorder.Header().Get("Content-Type"))
+		}
+		if strings.Contains(body, "event: post_created") || !strings.Contains(body, "event: post_deleted") {
+			t.Fatalf("expected only the event after %s. Got %s", lastEvent.EventId, body)
+		}
+	})
+}
+
+func TestGetCommentAnnotations(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	annotation := model.Iri("https://community.example.com/post/1")
+	setUp := func(statusCode int) *controller.ControllerImpl {
+		return &controller.ControllerImpl{
+			AnnotationService: &MockAnnotationService{
+				MockTriples: []model.RdfTriple{
+					{Subject: annotation, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "Annotation")},
+				},
+				MockStatusCode: statusCode,
+			},
+		}
+	}
+
+	var tests = []struct {
+		testName            string
+		url                 string
+		accept              string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"Turtle by default", "/annotations/entityId", "", http.StatusOK, "text/turtle", "a oa:Annotation ."},
+		{"JSON-LD by Accept", "/annotations/entityId", "application/ld+json", http.StatusOK, "application/ld+json", `"@type":"oa:Annotation"`},
+		{"RDF/XML by format", "/annotations/entityId?format=rdfxml", "text/turtle", http.StatusOK, "application/rdf+xml", `rdf:about="https://community.example.com/post/1"`},
+		{"Not acceptable", "/annotations/entityId", "text/html", http.StatusNotAcceptable, "", ""},
+		{"Missing entity id", "/annotations", "", http.StatusNotFound, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, test.url, nil)
+			if test.accept != "" {
+				request.Header.Set("Accept", test.accept)
+			}
+
+			setUp(http.StatusOK).GetAnnotations(recorder, request)
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if !strings.HasPrefix(recorder.Header().Get("Content-Type"), test.expectedContentType) {
+				t.Errorf("expected content type %s, got %s", test.expectedContentType, recorder.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(recorder.Body.String(), test.expectedBody) {
+				t.Errorf("expected %s in %s", test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+
+	t.Run("Thread not found", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		setUp(http.StatusNotFound).GetAnnotations(recorder, httptest.NewRequest(http.MethodGet, "/annotations/entityId", nil))
+
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected %d. Got %d", http.StatusNotFound, recorder.Code)
+		}
+	})
+}
+
+func TestRatings(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	setUp := func(user *model.User, authStatusCode int) (*MockRatingService, *controller.ControllerImpl) {
+		mockRatingService := MockRatingService{
+			MockSummary:    &model.RatingSummary{EntityId: "entityId", Count: 1, Mean: 4},
+			MockSummaries:  map[string]model.RatingSummary{"a": {EntityId: "a"}, "b": {EntityId: "b"}},
+			MockStatusCode: http.StatusOK,
+		}
+		return &mockRatingService, &controller.ControllerImpl{
+			AuthService:   &MockAuthService{MockUser: user, MockStatusCode: authStatusCode},
+			RatingService: &mockRatingService,
+		}
+	}
+
+	var getTests = []struct {
+		testName           string
+		url                string
+		authorization      string
+		expectedStatusCode int
+		expectedBody       string
+		expectedUserId     *string
+	}{
+		{"Summary of entity", "/ratings/entityId", "", http.StatusOK, `"mean":4`, nil},
+		{"Summary with score of user", "/ratings/entityId", "Bearer token", http.StatusOK, `"count":1`, &userId},
+		{"Batch lookup", "/ratings?entityId=a,b", "", http.StatusOK, `"b":{"entityId":"b"`, nil},
+		{"Batch lookup without entities", "/ratings", "", http.StatusBadRequest, "", nil},
+	}
+
+	for _, test := range getTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRatingService, controller := setUp(&model.User{UserId: &userId}, http.StatusOK)
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, test.url, nil)
+			if test.authorization != "" {
+				request.Header.Set("Authorization", test.authorization)
+			}
+
+			controller.GetRatings(recorder, request)
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if !strings.Contains(recorder.Body.String(), test.expectedBody) {
+				t.Errorf("expected %s in %s", test.expectedBody, recorder.Body.String())
+			}
+			if len(mockRatingService.UserIds) > 0 && (mockRatingService.UserIds[0] == nil) != (test.expectedUserId == nil) {
+				t.Errorf("expected user %v. Got %v", test.expectedUserId, mockRatingService.UserIds[0])
+			}
+		})
+	}
+
+	var rateTests = []struct {
+		testName           string
+		url                string
+		body               string
+		authStatusCode     int
+		expectedStatusCode int
+	}{
+		{"Rates entity", "/ratings/entityId", `{"score":4}`, http.StatusOK, http.StatusOK},
+		{"Not signed in", "/ratings/entityId", `{"score":4}`, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"Missing entity id", "/ratings", `{"score":4}`, http.StatusOK, http.StatusNotFound},
+		{"Malformed body", "/ratings/entityId", `{"score":"four"}`, http.StatusOK, http.StatusBadRequest},
+	}
+
+	for _, test := range rateTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRatingService, controller := setUp(&model.User{UserId: &userId}, test.authStatusCode)
+			recorder := httptest.NewRecorder()
+
+			controller.RateEntity(recorder, httptest.NewRequest(http.MethodPut, test.url, strings.NewReader(test.body)))
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if test.expectedStatusCode == http.StatusOK && (len(mockRatingService.Scores) != 1 || mockRatingService.Scores[0] != 4) {
+				t.Errorf("expected score 4 to be set. Got %v", mockRatingService.Scores)
+			}
+		})
+	}
+
+	t.Run("Withdraws rating", func(t *testing.T) {
+		_, controller := setUp(&model.User{UserId: &userId}, http.StatusOK)
+		recorder := httptest.NewRecorder()
+
+		controller.WithdrawRating(recorder, httptest.NewRequest(http.MethodDelete, "/ratings/entityId", nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected %d. Got %d", http.StatusOK, recorder.Code)
+		}
+	})
+}
+
+func TestUsefulness(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	setUp := func(statusCode int) (*MockUsefulnessService, *controller.ControllerImpl) {
+		mockUsefulnessService := MockUsefulnessService{
+			MockSummary:    &model.UsefulnessSummary{EntityId: "entityId", Helpful: 2, Nonce: "nonce"},
+			MockStatusCode: statusCode,
+		}
+		return &mockUsefulnessService, &controller.ControllerImpl{UsefulnessService: &mockUsefulnessService}
+	}
+
+	var getTests = []struct {
+		testName             string
+		url                  string
+		expectedStatusCode   int
+		expectedCacheControl string
+	}{
+		{"Summary of entity", "/usefulness/entityId", http.StatusOK, "no-store"},
+		{"Summary of entity type", "/usefulness?entityType=dataset", http.StatusOK, ""},
+		{"Missing entity", "/usefulness", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range getTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUsefulnessService, controller := setUp(http.StatusOK)
+			recorder := httptest.NewRecorder()
+
+			controller.GetUsefulness(recorder, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if recorder.Header().Get("Cache-Control") != test.expectedCacheControl {
+				t.Errorf("expected Cache-Control %q. Got %q", test.expectedCacheControl, recorder.Header().Get("Cache-Control"))
+			}
+			if len(mockUsefulnessService.EntityTypes) > 0 && mockUsefulnessService.EntityTypes[0] != model.Dataset {
+				t.Errorf("expected dataset. Got %v", mockUsefulnessService.EntityTypes)
+			}
+		})
+	}
+
+	var voteTests = []struct {
+		testName           string
+		url                string
+		body               string
+		statusCode         int
+		expectedStatusCode int
+	}{
+		{"Vote", "/usefulness/entityId", `{"helpful":true,"nonce":"nonce"}`, http.StatusCreated, http.StatusCreated},
+		{"Rate limited", "/usefulness/entityId", `{"helpful":false,"nonce":"nonce"}`, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		{"Malformed body", "/usefulness/entityId", `{"helpful":"yes"}`, http.StatusCreated, http.StatusBadRequest},
+		{"Missing entity", "/usefulness", `{"helpful":true,"nonce":"nonce"}`, http.StatusCreated, http.StatusNotFound},
+	}
+
+	for _, test := range voteTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUsefulnessService, controller := setUp(test.statusCode)
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, test.url, strings.NewReader(test.body))
+			request.Header.Set("X-Forwarded-For", "203.0.113.9")
+
+			controller.VoteUsefulness(recorder, request)
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if len(mockUsefulnessService.ClientIps) > 0 && mockUsefulnessService.ClientIps[0] != "203.0.113.9" {
+				t.Errorf("expected client IP to be passed on. Got %v", mockUsefulnessService.ClientIps)
+			}
+		})
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	var tests = []struct {
+		testName           string
+		url                string
+		user               *model.User
+		authStatusCode     int
+		expectedStatusCode int
+	}{
+		{"Statistics", "/statistics?organization=910244132&period=week", &model.User{}, http.StatusOK, http.StatusOK},
+		{"Not logged in", "/statistics", &model.User{}, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"Unknown user", "/statistics", nil, http.StatusOK, http.StatusUnauthorized},
+		{"Invalid query", "/statistics?period=day", &model.User{}, http.StatusOK, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockStatisticsService := MockStatisticsService{
+				MockStatistics: []model.FeedbackStatistics{{Organization: "910244132", Period: "2026-W42", Posts: 2}},
+				MockStatusCode: http.StatusOK,
+			}
+			controller := controller.ControllerImpl{
+				AuthService:       &MockAuthService{MockUser: test.user, MockStatusCode: test.authStatusCode},
+				StatisticsService: &mockStatisticsService,
+			}
+			recorder := httptest.NewRecorder()
+
+			controller.GetStatistics(recorder, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if test.expectedStatusCode == http.StatusOK {
+				if query := mockStatisticsService.Queries[0]; query.Organization != "910244132" || query.Period != model.WeekPeriod {
+					t.Errorf("unexpected query %+v", query)
+				}
+				if !strings.Contains(recorder.Body.String(), `"period":"2026-W42"`) {
+					t.Errorf("expected statistics, got %s", recorder.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestNotificationSettingsEndpoints(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	setUp := func() (*MockNotificationService, controller.Controller) {
+		mockNotificationService := MockNotificationService{
+			MockSettings:   &model.NotificationSettings{Organization: "910244132", Enabled: true},
+			MockStatusCode: http.StatusOK,
+		}
+		controller := controller.ControllerImpl{
+			AuthService:         &MockAuthService{MockUser: &model.User{UserId: &userId}, MockStatusCode: http.StatusOK},
+			NotificationService: &mockNotificationService,
+		}
+		return &mockNotificationService, &controller
+	}
+
+	t.Run("Get settings", func(t *testing.T) {
+		_, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.GetNotificationSettings(recorder, httptest.NewRequest(http.MethodGet, "/organizations/910244132/notifications", nil))
+
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"enabled":true`) {
+			t.Errorf("expected settings, got %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	var updateTests = []struct {
+		testName           string
+		body               string
+		expectedStatusCode int
+	}{
+		{"Turn off", `{"enabled": false}`, http.StatusOK},
+		{"Missing enabled", `{}`, http.StatusBadRequest},
+		{"Invalid body", `enabled`, http.StatusBadRequest},
+	}
+
+	for _, test := range updateTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockNotificationService, controller := setUp()
+			recorder := httptest.NewRecorder()
+
+			controller.UpdateNotificationSettings(recorder, httptest.NewRequest(http.MethodPut, "/organizations/910244132/notifications", strings.NewReader(test.body)))
+
+			if recorder.Code != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, recorder.Code)
+			}
+			if test.expectedStatusCode == http.StatusOK {
+				updated := mockNotificationService.UpdatedSettings
+				if len(updated) != 1 || updated[0].Organization != "910244132" || updated[0].Enabled {
+					t.Errorf("expected notifications of organization turned off. Got %#v", updated)
+				}
+			} else if len(mockNotificationService.UpdatedSettings) != 0 {
+				t.Errorf("expected no update. Got %#v", mockNotificationService.UpdatedSettings)
+			}
+		})
+	}
+}
+
+func TestSubscriptionEndpoints(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	setUp := func() (*MockSubscriptionService, controller.Controller) {
+		mockSubscriptionService := MockSubscriptionService{MockStatusCode: http.StatusOK}
+		controller := controller.ControllerImpl{
+			AuthService:         &MockAuthService{MockUser: &model.User{UserId: &userId, Email: "a@test.com"}, MockStatusCode: http.StatusOK},
+			ThreadService:       &MockThreadService{MockPost: &model.Post{UserId: &userId}, MockStatusCode: http.StatusCreated},
+			SubscriptionService: &mockSubscriptionService,
+		}
+		return &mockSubscriptionService, &controller
+	}
+
+	t.Run("Follows thread", func(t *testing.T) {
+		mockSubscriptionService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.FollowThread(recorder, httptest.NewRequest(http.MethodPut, "/subscriptions/entity", nil))
+
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"following":true`) {
+			t.Fatalf("expected thread to be followed, got %d %s", recorder.Code, recorder.Body.String())
+		}
+		if len(mockSubscriptionService.FollowedBy) != 1 || mockSubscriptionService.FollowedBy[0].Email != "a@test.com" {
+			t.Errorf("expected user to follow thread. Got %#v", mockSubscriptionService.FollowedBy)
+		}
+	})
+
+	t.Run("Follows thread of new post", func(t *testing.T) {
+		mockSubscriptionService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.CreateComment(recorder, httptest.NewRequest(http.MethodPost, "/thread/entity", strings.NewReader(`{"content": "Nyttig"}`)))
+
+		if recorder.Code != http.StatusCreated || len(mockSubscriptionService.FollowedBy) != 1 {
+			t.Errorf("expected author to follow thread. Got %d %#v", recorder.Code, mockSubscriptionService.FollowedBy)
+		}
+	})
+
+	t.Run("Unsubscribes with token", func(t *testing.T) {
+		mockSubscriptionService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.Unsubscribe(recorder, httptest.NewRequest(http.MethodPost, "/unsubscribe?token=abc.def", nil))
+
+		if recorder.Code != http.StatusOK || len(mockSubscriptionService.Tokens) != 1 || mockSubscriptionService.Tokens[0] != "abc.def" {
+			t.Errorf("expected token to be used. Got %d %v", recorder.Code, mockSubscriptionService.Tokens)
+		}
+	})
+
+	t.Run("Shows subscription of token without unsubscribing", func(t *testing.T) {
+		mockSubscriptionService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.GetUnsubscribe(recorder, httptest.NewRequest(http.MethodGet, "/unsubscribe?token=abc.def", nil))
+
+		if recorder.Code != http.StatusOK || len(mockSubscriptionService.Tokens) != 0 {
+			t.Errorf("expected subscription without unsubscribing. Got %d %v", recorder.Code, mockSubscriptionService.Tokens)
+		}
+	})
+
+	t.Run("Unsubscribes without token", func(t *testing.T) {
+		mockSubscriptionService, controller := setUp()
+		recorder := httptest.NewRecorder()
+
+		controller.Unsubscribe(recorder, httptest.NewRequest(http.MethodPost, "/unsubscribe", nil))
+
+		if recorder.Code != http.StatusBadRequest || len(mockSubscriptionService.Tokens) != 0 {
+			t.Errorf("expected bad request. Got %d %v", recorder.Code, mockSubscriptionService.Tokens)
+		}
+	})
 }
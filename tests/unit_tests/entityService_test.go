@@ -4,9 +4,14 @@ import (
 	"errors"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 )
 
@@ -56,3 +61,57 @@ func TestGetEntity(t *testing.T) {
 		}
 	})
 }
+
+func TestGetEntityById(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	var queries []string
+	sparqlService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		w.Write([]byte(`{"results": {"bindings": [{
+			"entity": {"value": "https://data.example.com/datasets/1"},
+			"type": {"value": "http://www.w3.org/ns/dcat#Dataset"},
+			"title": {"value": "Stort testdatasett"},
+			"titleLanguage": {"value": "nb"},
+			"responsibleOrganizationId": {"value": "910244132"},
+			"contactEmail": {"value": "mailto:post@example.com"}
+		}]}}`))
+	}))
+	defer sparqlService.Close()
+
+	entityRepository := repository.EntityRepositoryImpl{SparqlServiceUrl: sparqlService.URL}
+
+	entity, err := entityRepository.GetEntityById("entity")
+
+	if err != nil || entity == nil {
+		t.Fatalf("expected entity. Got %v", err)
+	}
+	if entity.ContactEmail != "post@example.com" || entity.PublisherId != "910244132" || entity.Type != model.Dataset {
+		t.Errorf("unexpected entity %#v", entity)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], "dcat:contactPoint") || !strings.Contains(queries[0], `dct:identifier "entity"`) {
+		t.Errorf("unexpected queries %v", queries)
+	}
+}
+
+func TestGetOrganizationEntityIds(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	var queries []string
+	sparqlService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		w.Write([]byte(`{"results": {"bindings": [{"entityId": {"value": "a"}}, {"entityId": {"value": "b"}}]}}`))
+	}))
+	defer sparqlService.Close()
+
+	entityRepository := repository.EntityRepositoryImpl{SparqlServiceUrl: sparqlService.URL}
+
+	entityIds, err := entityRepository.GetOrganizationEntityIds(`910244132" } #`)
+
+	if err != nil || !reflect.DeepEqual(entityIds, []string{"a", "b"}) {
+		t.Fatalf("expected entity ids. Got %v, %v", entityIds, err)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], `?publisher dct:identifier "910244132\" } #"`) {
+		t.Errorf("expected organization to be quoted in query. Got %v", queries)
+	}
+}
@@ -0,0 +1,266 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+type erasureMocks struct {
+	threadRepository         *MockThreadRepository
+	revisionRepository       *MockRevisionRepository
+	pendingPostRepository    *MockPendingPostRepository
+	contentHistoryRepository *MockContentHistoryRepository
+	erasureRepository        *MockErasureRepository
+	ratingRepository         *MockRatingRepository
+	subscriptionRepository   *MockSubscriptionRepository
+	issueRepository          *MockIssueRepository
+	reportRepository         *MockReportRepository
+	graphStoreService        *MockGraphStoreService
+	statisticsService        *MockStatisticsService
+}
+
+func erasureServiceMocks(policy model.ErasurePolicy) (*erasureMocks, *service.ErasureServiceImpl) {
+	mocks := erasureMocks{
+		threadRepository:         &MockThreadRepository{},
+		revisionRepository:       &MockRevisionRepository{},
+		pendingPostRepository:    &MockPendingPostRepository{},
+		contentHistoryRepository: &MockContentHistoryRepository{},
+		erasureRepository:        &MockErasureRepository{},
+		ratingRepository:         &MockRatingRepository{},
+		subscriptionRepository:   &MockSubscriptionRepository{},
+		issueRepository:          &MockIssueRepository{},
+		reportRepository:         &MockReportRepository{},
+		graphStoreService:        &MockGraphStoreService{},
+		statisticsService:        &MockStatisticsService{},
+	}
+
+	erasureService := service.ErasureServiceImpl{
+		ThreadRepository:         mocks.threadRepository,
+		RevisionRepository:       mocks.revisionRepository,
+		PendingPostRepository:    mocks.pendingPostRepository,
+		ContentHistoryRepository: mocks.contentHistoryRepository,
+		ErasureRepository:        mocks.erasureRepository,
+		RatingRepository:         mocks.ratingRepository,
+		SubscriptionRepository:   mocks.subscriptionRepository,
+		IssueRepository:          mocks.issueRepository,
+		ReportRepository:         mocks.reportRepository,
+		GraphStoreService:        mocks.graphStoreService,
+		StatisticsService:        mocks.statisticsService,
+		Policy:                   policy,
+		AnonymousUid:             "99",
+		ProgressInterval:         2,
+	}
+
+	return &mocks, &erasureService
+}
+
+func TestStartErasure(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId, userslug := "3", "user"
+	user := model.User{UserId: &userId, Userslug: &userslug}
+
+	t.Run("Anonymizing the anonymous user", func(t *testing.T) {
+		_, erasureService := erasureServiceMocks(model.AnonymizeErasure)
+		anonymousUid := "99"
+		expectedStatusCode := http.StatusConflict
+
+		_, actualStatusCode := erasureService.StartErasure(model.User{UserId: &anonymousUid, Userslug: &userslug}, anonymousUid)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Anonymizing without anonymous user", func(t *testing.T) {
+		_, erasureService := erasureServiceMocks(model.AnonymizeErasure)
+		erasureService.AnonymousUid = ""
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := erasureService.StartErasure(user, userId)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Records tombstone of every post", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		postIds := []string{"10", "11", "12"}
+		mocks.threadRepository.MockUserPostPages = [][]*model.Post{
+			{{PostId: &postIds[0]}, {PostId: &postIds[1]}},
+			{{PostId: &postIds[2]}},
+		}
+
+		tombstone, actualStatusCode := erasureService.StartErasure(user, "1")
+
+		if actualStatusCode != http.StatusAccepted {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusAccepted, actualStatusCode)
+		}
+		if tombstone.ErasureId == "" || tombstone.Status != model.ErasureRunning || tombstone.RequestedBy != "1" {
+			t.Errorf("expected running erasure requested by 1. Got %#v", tombstone)
+		}
+		if !reflect.DeepEqual(tombstone.PostIds, postIds) || tombstone.Total != len(postIds) {
+			t.Errorf("expected posts %v. Got %v", postIds, tombstone.PostIds)
+		}
+		if len(mocks.threadRepository.PurgedPosts) != 0 {
+			t.Errorf("expected no posts to be erased before processing")
+		}
+	})
+}
+
+func TestProcessErasure(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	runningErasure := func(policy model.ErasurePolicy, processed int) *model.ErasureTombstone {
+		return &model.ErasureTombstone{
+			ErasureId: "erasure",
+			UserId:    "3",
+			Policy:    policy,
+			Status:    model.ErasureRunning,
+			PostIds:   []string{"10", "11", "12"},
+			Total:     3,
+			Processed: processed,
+		}
+	}
+
+	t.Run("Erasure not found", func(t *testing.T) {
+		_, erasureService := erasureServiceMocks(model.PurgeErasure)
+		expectedStatusCode := http.StatusNotFound
+
+		_, actualStatusCode := erasureService.ProcessErasure("erasure")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Finished erasure is not processed again", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		mocks.erasureRepository.MockTombstone = &model.ErasureTombstone{ErasureId: "erasure", Status: model.ErasureCompleted, PostIds: []string{"10"}}
+
+		erasureService.ProcessErasure("erasure")
+
+		if len(mocks.threadRepository.PurgedPosts) != 0 || len(mocks.erasureRepository.UpdatedTombstones) != 0 {
+			t.Errorf("expected finished erasure to be left alone")
+		}
+	})
+
+	t.Run("Purges posts and data of user", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		mocks.erasureRepository.MockTombstone = runningErasure(model.PurgeErasure, 0)
+
+		tombstone, actualStatusCode := erasureService.ProcessErasure("erasure")
+
+		if actualStatusCode != http.StatusOK || tombstone.Status != model.ErasureCompleted || tombstone.Processed != 3 || tombstone.FinishedAt == 0 {
+			t.Fatalf("expected completed erasure. Got %#v, %d", tombstone, actualStatusCode)
+		}
+		if len(mocks.threadRepository.PurgedPosts) != 3 || *mocks.threadRepository.PurgedPosts[0].UserId != "3" {
+			t.Errorf("expected 3 posts of user 3 to be purged. Got %#v", mocks.threadRepository.PurgedPosts)
+		}
+		if !reflect.DeepEqual(mocks.revisionRepository.DeletedPostIds, []string{"10", "11", "12"}) {
+			t.Errorf("expected revisions of every post to be deleted. Got %v", mocks.revisionRepository.DeletedPostIds)
+		}
+		if !reflect.DeepEqual(mocks.graphStoreService.DeletedPostIds, []string{"10", "11", "12"}) {
+			t.Errorf("expected annotations of every post to be deleted. Got %v", mocks.graphStoreService.DeletedPostIds)
+		}
+		if !reflect.DeepEqual(mocks.statisticsService.RemovedPostIds, []string{"10", "11", "12"}) {
+			t.Errorf("expected every post to be removed from the statistics. Got %v", mocks.statisticsService.RemovedPostIds)
+		}
+		if !reflect.DeepEqual(mocks.issueRepository.DeletedPostIds, []string{"10", "11", "12"}) {
+			t.Errorf("expected issues of every post to be deleted. Got %v", mocks.issueRepository.DeletedPostIds)
+		}
+		if len(mocks.pendingPostRepository.DeletedUsers) != 1 || len(mocks.contentHistoryRepository.DeletedUsers) != 1 || len(mocks.ratingRepository.DeletedUsers) != 1 ||
+			len(mocks.subscriptionRepository.DeletedUsers) != 1 || !reflect.DeepEqual(mocks.reportRepository.DeletedReporters, []string{"3"}) {
+			t.Errorf("expected pending posts, content history, ratings, subscriptions and reports of user to be deleted")
+		}
+		// Progress after the second post, then the finished erasure.
+		if len(mocks.erasureRepository.UpdatedTombstones) != 2 || mocks.erasureRepository.UpdatedTombstones[0].Processed != 2 {
+			t.Errorf("expected progress to be saved. Got %#v", mocks.erasureRepository.UpdatedTombstones)
+		}
+	})
+
+	t.Run("Resumes from saved progress by the policy of the erasure", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		mocks.erasureRepository.MockTombstone = runningErasure(model.AnonymizeErasure, 2)
+
+		erasureService.ProcessErasure("erasure")
+
+		if len(mocks.threadRepository.ReassignedPosts) != 1 || *mocks.threadRepository.ReassignedPosts[0].PostId != "12" {
+			t.Errorf("expected only the last post to be reassigned. Got %#v", mocks.threadRepository.ReassignedPosts)
+		}
+		if len(mocks.threadRepository.PurgedPosts) != 0 {
+			t.Errorf("expected no posts to be purged")
+		}
+		if !reflect.DeepEqual(mocks.graphStoreService.ChangedOwners, map[string]string{"12": "99"}) {
+			t.Errorf("expected annotation of the last post to be reassigned. Got %v", mocks.graphStoreService.ChangedOwners)
+		}
+		if len(mocks.statisticsService.RemovedPostIds) != 0 || len(mocks.issueRepository.DeletedPostIds) != 0 {
+			t.Errorf("expected anonymized posts to be kept in the statistics with their issues")
+		}
+	})
+
+	t.Run("Erases one chunk at a time", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		erasureService.ChunkSize = 2
+		mocks.erasureRepository.MockTombstone = runningErasure(model.PurgeErasure, 0)
+
+		tombstone, actualStatusCode := erasureService.ProcessErasure("erasure")
+
+		if actualStatusCode != http.StatusAccepted || tombstone.Status != model.ErasureRunning || tombstone.Processed != 2 {
+			t.Fatalf("expected running erasure after the first chunk. Got %#v, %d", tombstone, actualStatusCode)
+		}
+		if len(mocks.threadRepository.PurgedPosts) != 2 || len(mocks.pendingPostRepository.DeletedUsers) != 0 {
+			t.Errorf("expected only the posts of the chunk to be erased")
+		}
+		updated := mocks.erasureRepository.UpdatedTombstones
+		if len(updated) != 1 || updated[0].Processed != 2 || updated[0].LeaseUntil != 0 {
+			t.Errorf("expected progress to be saved without the lease. Got %#v", updated)
+		}
+	})
+
+	t.Run("Erasure leased by another worker", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		mocks.erasureRepository.MockTombstone = runningErasure(model.PurgeErasure, 0)
+		mocks.erasureRepository.MockLeased = true
+
+		_, actualStatusCode := erasureService.ProcessErasure("erasure")
+
+		if actualStatusCode != http.StatusConflict || len(mocks.threadRepository.PurgedPosts) != 0 {
+			t.Errorf("expected %d without erasing. Got %d, %#v", http.StatusConflict, actualStatusCode, mocks.threadRepository.PurgedPosts)
+		}
+	})
+
+	t.Run("Soft-deletes posts", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.SoftDeleteErasure)
+		mocks.erasureRepository.MockTombstone = runningErasure(model.SoftDeleteErasure, 0)
+
+		erasureService.ProcessErasure("erasure")
+
+		if len(mocks.threadRepository.DeletedPosts) != 3 {
+			t.Errorf("expected 3 posts to be deleted. Got %d", len(mocks.threadRepository.DeletedPosts))
+		}
+	})
+
+	t.Run("Failed posts fail the erasure", func(t *testing.T) {
+		mocks, erasureService := erasureServiceMocks(model.PurgeErasure)
+		mocks.erasureRepository.MockTombstone = runningErasure(model.PurgeErasure, 0)
+		mocks.threadRepository.MockError = errors.New("error")
+
+		tombstone, _ := erasureService.ProcessErasure("erasure")
+
+		if tombstone.Status != model.ErasureFailed || len(tombstone.FailedPostIds) != 3 || tombstone.Processed != 3 {
+			t.Errorf("expected failed erasure listing every post. Got %#v", tombstone)
+		}
+		if len(mocks.revisionRepository.DeletedPostIds) != 0 || len(mocks.graphStoreService.DeletedPostIds) != 0 {
+			t.Errorf("expected revisions and annotations of posts that were not erased to be kept")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package unit_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+func receiveEvent(t *testing.T, events <-chan model.PostEvent) model.PostEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("expected event, got none")
+	}
+	return model.PostEvent{}
+}
+
+func TestInMemoryEventBus(t *testing.T) {
+	t.Run("Delivers published events to thread subscribers", func(t *testing.T) {
+		bus := eventbus.NewInMemoryEventBus(10)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, _ := bus.Subscribe(ctx, "1", nil)
+		otherEvents, _ := bus.Subscribe(ctx, "2", nil)
+
+		postId := "10"
+		bus.Publish("1", model.PostEvent{Type: model.PostCreated, Post: &model.Post{PostId: &postId}})
+
+		actual := receiveEvent(t, events)
+		if actual.Type != model.PostCreated || *actual.Post.PostId != postId || actual.EventId == "" {
+			t.Fatalf("expected %s event for post %s. Got %#v", model.PostCreated, postId, actual)
+		}
+
+		select {
+		case event := <-otherEvents:
+			t.Fatalf("expected no event for other thread. Got %#v", event)
+		default:
+		}
+	})
+
+	t.Run("Replays events after last event id", func(t *testing.T) {
+		bus := eventbus.NewInMemoryEventBus(10)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first, _ := bus.Subscribe(ctx, "1", nil)
+		bus.Publish("1", model.PostEvent{Type: model.PostCreated})
+		bus.Publish("1", model.PostEvent{Type: model.PostUpdated})
+		firstEvent := receiveEvent(t, first)
+
+		resumed, _ := bus.Subscribe(ctx, "1", &firstEvent.EventId)
+
+		actual := receiveEvent(t, resumed)
+		if actual.Type != model.PostUpdated {
+			t.Fatalf("expected %s event. Got %#v", model.PostUpdated, actual)
+		}
+	})
+
+	t.Run("Keeps only buffered events", func(t *testing.T) {
+		bus := eventbus.NewInMemoryEventBus(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		bus.Publish("1", model.PostEvent{Type: model.PostCreated})
+		bus.Publish("1", model.PostEvent{Type: model.PostDeleted})
+
+		replayAll := ""
+		events, _ := bus.Subscribe(ctx, "1", &replayAll)
+
+		actual := receiveEvent(t, events)
+		if actual.Type != model.PostDeleted || len(events) != 0 {
+			t.Fatalf("expected only %s event. Got %#v and %d more", model.PostDeleted, actual, len(events))
+		}
+	})
+
+	t.Run("Closes subscription when context is done", func(t *testing.T) {
+		bus := eventbus.NewInMemoryEventBus(1)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, _ := bus.Subscribe(ctx, "1", nil)
+		cancel()
+
+		select {
+		case _, open := <-events:
+			if open {
+				t.Fatal("expected closed subscription")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected closed subscription")
+		}
+	})
+}
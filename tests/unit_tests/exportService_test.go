@@ -0,0 +1,310 @@
+package unit_tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestExportUserPosts(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId, userslug := "3", "user"
+	user := model.User{UserId: &userId, Userslug: &userslug}
+
+	t.Run("User without userslug", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{ThreadRepository: &MockThreadRepository{}, ThreadIdService: &MockThreadIdService{}}
+		expectedStatusCode := http.StatusNotFound
+
+		_, actualStatusCode := exportService.ExportUserPosts(model.User{UserId: &userId})
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Could not get posts", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{
+			ThreadRepository: &MockThreadRepository{MockGetError: errors.New("error")},
+			ThreadIdService:  &MockThreadIdService{},
+		}
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := exportService.ExportUserPosts(user)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Exports posts of every page with their entities", func(t *testing.T) {
+		threadA, threadB, orphanThread := "1", "2", "9"
+		postIds := []string{"10", "11", "12", "13"}
+		exportService := service.ExportServiceImpl{
+			ThreadRepository: &MockThreadRepository{MockUserPostPages: [][]*model.Post{
+				{{PostId: &postIds[0], ThreadId: &threadA}, {PostId: &postIds[1], ThreadId: &threadB}},
+				{{PostId: &postIds[2], ThreadId: &threadA}, {PostId: &postIds[3], ThreadId: &orphanThread}},
+			}},
+			ThreadIdService: &MockThreadIdService{MockEntityIds: map[string]string{threadA: "entity-a", threadB: "entity-b"}},
+		}
+
+		export, actualStatusCode := exportService.ExportUserPosts(user)
+
+		if actualStatusCode != http.StatusOK {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusOK, actualStatusCode)
+		}
+		if export.User == nil || *export.User.UserId != userId || export.ExportedAt == 0 {
+			t.Errorf("expected export of user %s. Got %#v", userId, export)
+		}
+
+		expectedEntityIds := []string{"entity-a", "entity-b", "entity-a", ""}
+		if len(export.Posts) != len(expectedEntityIds) {
+			t.Fatalf("expected %d posts. Got %d", len(expectedEntityIds), len(export.Posts))
+		}
+		for i, post := range export.Posts {
+			if *post.PostId != postIds[i] {
+				t.Errorf("expected post %s. Got %s", postIds[i], *post.PostId)
+			}
+			entityId := ""
+			if post.EntityId != nil {
+				entityId = *post.EntityId
+			}
+			if entityId != expectedEntityIds[i] {
+				t.Errorf("expected entity %q for post %s. Got %q", expectedEntityIds[i], postIds[i], entityId)
+			}
+		}
+	})
+}
+
+func TestGetExportedEntities(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	admin := model.User{AdminOrganizations: []string{"910244132"}}
+
+	var exportedEntitiesTests = []struct {
+		testName           string
+		user               model.User
+		catalogError       error
+		expectedStatusCode int
+	}{
+		{"Admin of organization", admin, nil, http.StatusOK},
+		{"Moderator", model.User{Moderator: true}, nil, http.StatusOK},
+		{"Writer for organization", model.User{Organizations: []string{"910244132"}}, nil, http.StatusForbidden},
+		{"Admin of other organization", model.User{AdminOrganizations: []string{"123456789"}}, nil, http.StatusForbidden},
+		{"Could not get entities", admin, errors.New("error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range exportedEntitiesTests {
+		t.Run(test.testName, func(t *testing.T) {
+			exportService := service.ExportServiceImpl{
+				ThreadIdService: &MockThreadIdService{MockThreadIds: map[string]string{"a": "1", "b": "2"}},
+				EntityService:   &MockEntityService{MockEntity: &model.Entity{EntityId: "a", Title: "Title"}, MockEntityIds: []string{"b", "a"}, MockIdsError: test.catalogError},
+			}
+
+			entities, actualStatusCode := exportService.GetExportedEntities("910244132", test.user)
+
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if actualStatusCode == http.StatusOK && len(entities) != 2 {
+				t.Errorf("expected 2 entities. Got %#v", entities)
+			}
+		})
+	}
+
+	t.Run("Lists entities with a thread from the catalog and the index", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{
+			ThreadIdService:     &MockThreadIdService{MockThreadIds: map[string]string{"a": "1", "b": "2", "removed": "3"}},
+			EntityService:       &MockEntityService{MockError: errors.New("entity not found"), MockEntityIds: []string{"b", "a", "without-thread"}},
+			PostIndexRepository: &MockPostIndexRepository{MockEntityIds: []string{"removed", "a"}},
+		}
+
+		entities, _ := exportService.GetExportedEntities("910244132", admin)
+
+		expected := []model.Entity{{EntityId: "a"}, {EntityId: "b"}, {EntityId: "removed"}}
+		if !reflect.DeepEqual(entities, expected) {
+			t.Errorf("expected entities %#v. Got %#v", expected, entities)
+		}
+	})
+}
+
+func TestExportEntityFeedback(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	entity := model.Entity{EntityId: "entity", Title: "Title", Type: model.Dataset}
+	botUid, userUid, threadId := "22", "3", "1"
+	postIds := []string{"10", "11", "12"}
+	content, displayname, timestamp := "content", "Ola Nordmann", 1700000000000
+
+	t.Run("Writes posts of every page except the opening post", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{
+			ThreadService: &MockThreadService{MockStatusCode: http.StatusOK, MockThreadPages: []*model.Thread{
+				{Posts: []*model.Post{{PostId: &postIds[0], UserId: &botUid}, {
+					PostId:    &postIds[1],
+					UserId:    &userUid,
+					ThreadId:  &threadId,
+					Content:   &content,
+					Timestamp: &timestamp,
+					UserInfo:  &model.User{Displayname: &displayname},
+					Issue:     &model.DataQualityIssue{Status: model.IssueAcknowledged},
+				}}, Pagination: &model.Pagination{PageCount: intPointer(2)}},
+				{Posts: []*model.Post{{PostId: &postIds[2], UserId: &userUid, ToPostId: &postIds[1]}}, Pagination: &model.Pagination{PageCount: intPointer(2)}},
+			}},
+			ThreadBotUid: botUid,
+			PageSize:     100,
+		}
+
+		var rows []model.FeedbackExportRow
+		err := exportService.ExportEntityFeedback(entity, func(row model.FeedbackExportRow) error {
+			rows = append(rows, row)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error. Got %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows. Got %#v", rows)
+		}
+		first := rows[0]
+		if first.PostId != postIds[1] || first.ThreadId != threadId || first.Author != displayname || first.Content != content ||
+			first.Status != model.IssueAcknowledged || *first.Timestamp != timestamp {
+			t.Errorf("unexpected row %#v", first)
+		}
+		if first.EntityId != entity.EntityId || first.EntityTitle != entity.Title || first.EntityType != "dataset" || !strings.HasSuffix(first.EntityLink, entity.EntityId) {
+			t.Errorf("expected entity %#v in row. Got %#v", entity, first)
+		}
+		if rows[1].PostId != postIds[2] || rows[1].ToPostId != postIds[1] || rows[1].Status != "" {
+			t.Errorf("unexpected row %#v", rows[1])
+		}
+	})
+
+	t.Run("Entity without thread", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{ThreadService: &MockThreadService{MockStatusCode: http.StatusNotFound}}
+
+		err := exportService.ExportEntityFeedback(entity, func(row model.FeedbackExportRow) error {
+			t.Errorf("expected no rows. Got %#v", row)
+			return nil
+		})
+
+		if err != nil {
+			t.Errorf("expected no error. Got %v", err)
+		}
+	})
+
+	t.Run("Could not write row", func(t *testing.T) {
+		exportService := service.ExportServiceImpl{
+			ThreadService: &MockThreadService{MockStatusCode: http.StatusOK, MockThread: &model.Thread{Posts: []*model.Post{{PostId: &postIds[1]}}}},
+		}
+		expectedError := errors.New("closed")
+
+		err := exportService.ExportEntityFeedback(entity, func(row model.FeedbackExportRow) error { return expectedError })
+
+		if err != expectedError {
+			t.Errorf("expected error %v. Got %v", expectedError, err)
+		}
+	})
+}
+
+func TestFeedbackExportWriters(t *testing.T) {
+	timestamp := 1700000000000
+	row := model.FeedbackExportRow{
+		EntityId:   "entity",
+		PostId:     "10",
+		Author:     "Ola <Nordmann>",
+		Timestamp:  &timestamp,
+		Content:    "=HYPERLINK(\"x\") & more",
+		Status:     model.IssueOpen,
+		EntityType: "dataset",
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		var buffer bytes.Buffer
+		writer, err := util.NewFeedbackCsvWriter(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Write(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := csv.NewReader(&buffer).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 || records[0][0] != "entityId" || records[0][10] != "status" {
+			t.Fatalf("expected header and one row. Got %v", records)
+		}
+		expected := []string{"entity", "", "dataset", "", "", "10", "", "Ola <Nordmann>", "2023-11-14T22:13:20Z", "'=HYPERLINK(\"x\") & more", "open"}
+		if !reflect.DeepEqual(records[1], expected) {
+			t.Errorf("expected row %q. Got %q", expected, records[1])
+		}
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		var buffer bytes.Buffer
+		writer, err := util.NewFeedbackXlsxWriter(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Write(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := map[string]*zip.File{}
+		for _, file := range zipReader.File {
+			parts[file.Name] = file
+		}
+		for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+			if parts[name] == nil {
+				t.Fatalf("expected part %s. Got %v", name, parts)
+			}
+		}
+
+		sheetFile, err := parts["xl/worksheets/sheet1.xml"].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sheetFile.Close()
+		var sheet struct {
+			Rows []struct {
+				Cells []struct {
+					Reference string `xml:"r,attr"`
+					Text      string `xml:"is>t"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xml.NewDecoder(sheetFile).Decode(&sheet); err != nil {
+			t.Fatal(err)
+		}
+		if len(sheet.Rows) != 2 || len(sheet.Rows[1].Cells) != 11 {
+			t.Fatalf("expected header and one row of 11 cells. Got %#v", sheet.Rows)
+		}
+		cells := sheet.Rows[1].Cells
+		if cells[0].Reference != "A2" || cells[7].Text != row.Author || cells[9].Text != row.Content || cells[10].Reference != "K2" {
+			t.Errorf("unexpected cells %#v", cells)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package unit_tests
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/feedbackpb"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/grpcserver"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func setUpFeedbackServerMocks() (*MockAuthService, *MockThreadIdService, *MockThreadService, feedbackpb.FeedbackServiceServer) {
+	mockAuthService := MockAuthService{}
+	mockThreadIdService := MockThreadIdService{}
+	mockThreadService := MockThreadService{}
+	server := grpcserver.FeedbackServerImpl{
+		AuthService:     &mockAuthService,
+		ThreadIdService: &mockThreadIdService,
+		ThreadService:   &mockThreadService,
+	}
+
+	return &mockAuthService, &mockThreadIdService, &mockThreadService, &server
+}
+
+func authorizedContext() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+}
+
+func TestFeedbackServerGetThread(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Missing entity id", func(t *testing.T) {
+		_, _, _, server := setUpFeedbackServerMocks()
+
+		_, err := server.GetThread(context.Background(), &feedbackpb.GetThreadRequest{})
+
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected %v. Got %v", codes.InvalidArgument, status.Code(err))
+		}
+	})
+
+	t.Run("Thread not found", func(t *testing.T) {
+		_, _, mockThreadService, server := setUpFeedbackServerMocks()
+		mockThreadService.MockStatusCode = http.StatusNotFound
+
+		_, err := server.GetThread(context.Background(), &feedbackpb.GetThreadRequest{EntityId: "entity"})
+
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected %v. Got %v", codes.NotFound, status.Code(err))
+		}
+	})
+
+	t.Run("Successfully gets thread", func(t *testing.T) {
+		_, _, mockThreadService, server := setUpFeedbackServerMocks()
+		threadId, postId, content := "1", "2", "content"
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockThread = &model.Thread{
+			ThreadId: &threadId,
+			Posts:    []*model.Post{{PostId: &postId, Content: &content}},
+		}
+
+		actual, err := server.GetThread(context.Background(), &feedbackpb.GetThreadRequest{EntityId: "entity"})
+
+		if err != nil || actual.GetThreadId() != threadId || len(actual.GetPosts()) != 1 || actual.GetPosts()[0].GetContent() != content {
+			t.Fatalf("expected thread %s with one post. Got %v, %v", threadId, actual, err)
+		}
+	})
+}
+
+func TestFeedbackServerGetPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Anonymous reader", func(t *testing.T) {
+		_, _, mockThreadService, server := setUpFeedbackServerMocks()
+		postId := "2"
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId}
+
+		actual, err := server.GetPost(context.Background(), &feedbackpb.GetPostRequest{EntityId: "entity", PostId: postId})
+
+		if err != nil || actual.GetPostId() != postId || mockThreadService.ViewerUid != nil {
+			t.Fatalf("expected post %s read anonymously. Got %v, %v, %v", postId, actual, err, mockThreadService.ViewerUid)
+		}
+	})
+
+	t.Run("Reads post as signed in user", func(t *testing.T) {
+		mockAuthService, _, mockThreadService, server := setUpFeedbackServerMocks()
+		userId, postId := "1", "2"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId}
+
+		_, err := server.GetPost(authorizedContext(), &feedbackpb.GetPostRequest{EntityId: "entity", PostId: postId})
+
+		if err != nil || mockThreadService.ViewerUid == nil || *mockThreadService.ViewerUid != userId {
+			t.Fatalf("expected post read by viewer %s. Got %v, %v", userId, mockThreadService.ViewerUid, err)
+		}
+	})
+}
+
+func TestFeedbackServerCreatePost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Missing authorization metadata", func(t *testing.T) {
+		_, _, _, server := setUpFeedbackServerMocks()
+
+		_, err := server.CreatePost(context.Background(), &feedbackpb.CreatePostRequest{EntityId: "entity"})
+
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected %v. Got %v", codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("Forbidden token", func(t *testing.T) {
+		mockAuthService, _, _, server := setUpFeedbackServerMocks()
+		mockAuthService.MockStatusCode = http.StatusForbidden
+
+		_, err := server.CreatePost(authorizedContext(), &feedbackpb.CreatePostRequest{EntityId: "entity"})
+
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected %v. Got %v", codes.PermissionDenied, status.Code(err))
+		}
+	})
+
+	t.Run("Successfully creates post", func(t *testing.T) {
+		mockAuthService, _, mockThreadService, server := setUpFeedbackServerMocks()
+		userId, postId, content := "1", "2", "content"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusCreated
+		mockThreadService.MockPost = &model.Post{PostId: &postId, UserId: &userId, Content: &content}
+
+		actual, err := server.CreatePost(authorizedContext(), &feedbackpb.CreatePostRequest{EntityId: "entity", Content: content})
+
+		if err != nil || actual.GetPostId() != postId || actual.GetUserId() != userId {
+			t.Fatalf("expected post %s by user %s. Got %v, %v", postId, userId, actual, err)
+		}
+	})
+
+	t.Run("Author follows thread", func(t *testing.T) {
+		mockAuthService, mockThreadIdService, mockThreadService, _ := setUpFeedbackServerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadService.MockStatusCode = http.StatusCreated
+		mockSubscriptionService := MockSubscriptionService{MockStatusCode: http.StatusOK}
+		server := grpcserver.FeedbackServerImpl{
+			AuthService:         mockAuthService,
+			ThreadIdService:     mockThreadIdService,
+			ThreadService:       mockThreadService,
+			SubscriptionService: &mockSubscriptionService,
+		}
+
+		_, err := server.CreatePost(authorizedContext(), &feedbackpb.CreatePostRequest{EntityId: "entity", Content: "content"})
+
+		if err != nil || len(mockSubscriptionService.FollowedBy) != 1 || *mockSubscriptionService.FollowedBy[0].UserId != userId {
+			t.Errorf("expected author to follow thread. Got %#v, %v", mockSubscriptionService.FollowedBy, err)
+		}
+	})
+
+	t.Run("Content rejected by screening", func(t *testing.T) {
+		mockAuthService, mockThreadIdService, mockThreadService, _ := setUpFeedbackServerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		server := grpcserver.FeedbackServerImpl{
+			AuthService:     mockAuthService,
+			ThreadIdService: mockThreadIdService,
+			ThreadService:   mockThreadService,
+			ScreeningService: &MockContentScreeningService{
+				MockViolations: []model.ContentViolation{{Rule: model.RuleBlockedDomain, Message: "links to spam.example are not allowed"}},
+			},
+		}
+
+		_, err := server.CreatePost(authorizedContext(), &feedbackpb.CreatePostRequest{EntityId: "entity", Content: "spam"})
+
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected %v. Got %v", codes.FailedPrecondition, status.Code(err))
+		}
+		details := status.Convert(err).Details()
+		badRequest, ok := details[0].(*errdetails.BadRequest)
+		if len(details) != 1 || !ok || badRequest.GetFieldViolations()[0].GetDescription() != "blocked_domain: links to spam.example are not allowed" {
+			t.Fatalf("expected bad request detail for blocked domain. Got %v", details)
+		}
+	})
+}
+
+func TestFeedbackServerDeletePost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("No thread for entity", func(t *testing.T) {
+		mockAuthService, _, _, server := setUpFeedbackServerMocks()
+		userId := "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+
+		_, err := server.DeletePost(authorizedContext(), &feedbackpb.DeletePostRequest{EntityId: "entity", PostId: "2"})
+
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected %v. Got %v", codes.NotFound, status.Code(err))
+		}
+	})
+
+	t.Run("Post attributed to different user", func(t *testing.T) {
+		mockAuthService, mockThreadIdService, mockThreadService, server := setUpFeedbackServerMocks()
+		userId, threadId := "1", "1"
+		mockAuthService.MockStatusCode = http.StatusOK
+		mockAuthService.MockUser = &model.User{UserId: &userId}
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadService.MockStatusCode = http.StatusUnauthorized
+
+		_, err := server.DeletePost(authorizedContext(), &feedbackpb.DeletePostRequest{EntityId: "entity", PostId: "2"})
+
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected %v. Got %v", codes.Unauthenticated, status.Code(err))
+		}
+	})
+}
+
+func TestFeedbackServerBatchCountPosts(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	_, _, mockThreadService, server := setUpFeedbackServerMocks()
+	mockThreadService.MockStatusCode = http.StatusOK
+	mockThreadService.MockCounts = map[string]int{"a": 3, "b": 0}
+
+	actual, err := server.BatchCountPosts(context.Background(), &feedbackpb.BatchCountPostsRequest{EntityIds: []string{"a", "b"}})
+
+	if err != nil || actual.GetCounts()["a"] != 3 || actual.GetCounts()["b"] != 0 {
+		t.Fatalf("expected counts %v. Got %v, %v", mockThreadService.MockCounts, actual, err)
+	}
+
+	for _, entityIds := range [][]string{nil, make([]string, 101)} {
+		_, err = server.BatchCountPosts(context.Background(), &feedbackpb.BatchCountPostsRequest{EntityIds: entityIds})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected %v for %d entity ids. Got %v", codes.InvalidArgument, len(entityIds), status.Code(err))
+		}
+	}
+}
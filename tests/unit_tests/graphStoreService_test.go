@@ -0,0 +1,117 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func TestGraphStoreService(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, botUid := "1", "10", "3", "22"
+	content := "Lenken er død"
+	post := model.Post{PostId: &postId, UserId: &userId, ThreadId: &threadId, Content: &content}
+
+	setUp := func(maxAttempts int, mockErrors ...error) (*MockGraphStoreRepository, *service.GraphStoreServiceImpl) {
+		repository := &MockGraphStoreRepository{MockErrors: mockErrors, Updates: make(chan string, 10)}
+		return repository, &service.GraphStoreServiceImpl{
+			GraphStoreRepository: repository,
+			ThreadIdService:      &MockThreadIdService{MockEntityIds: map[string]string{threadId: "entity"}},
+			EntityService:        &MockEntityService{MockEntity: &model.Entity{EntityId: "entity", Uri: "https://data.example.com/datasets/1"}},
+			CommunityBaseUrl:     "https://community.example.com/",
+			ThreadBotUid:         botUid,
+			Graph:                "https://data.example.com/graphs/feedback",
+			MaxAttempts:          maxAttempts,
+			RetryDelay:           time.Millisecond,
+			QueueSize:            10,
+		}
+	}
+
+	receive := func(t *testing.T, updates chan string) string {
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("expected an update of the graph store")
+			return ""
+		}
+	}
+
+	t.Run("Replaces annotation of pushed post", func(t *testing.T) {
+		repository, graphStoreService := setUp(1)
+
+		graphStoreService.PushPost(threadId, post)
+
+		update := receive(t, repository.Updates)
+		for _, expected := range []string{
+			"DELETE WHERE { GRAPH <https://data.example.com/graphs/feedback> { <https://community.example.com/post/10> ?predicate ?object } }",
+			"INSERT DATA {\n  GRAPH <https://data.example.com/graphs/feedback> {",
+			"<https://community.example.com/post/10> oa:hasTarget <https://data.example.com/datasets/1> .",
+			`rdf:value "Lenken er død" .`,
+		} {
+			if !strings.Contains(update, expected) {
+				t.Errorf("expected %q in %s", expected, update)
+			}
+		}
+	})
+
+	t.Run("Retries failed updates with the same update", func(t *testing.T) {
+		repository, graphStoreService := setUp(3, errors.New("503 Service Unavailable"), errors.New("503 Service Unavailable"))
+
+		graphStoreService.DeletePost(postId)
+
+		first := receive(t, repository.Updates)
+		for attempt := 2; attempt <= 3; attempt++ {
+			if update := receive(t, repository.Updates); update != first {
+				t.Fatalf("expected attempt %d to send %s. Got %s", attempt, first, update)
+			}
+		}
+		if !strings.Contains(first, "DELETE WHERE") || strings.Contains(first, "INSERT") {
+			t.Errorf("expected only deletes, got %s", first)
+		}
+	})
+
+	t.Run("Gives up after max attempts and sends later updates", func(t *testing.T) {
+		repository, graphStoreService := setUp(2, errors.New("error"), errors.New("error"))
+
+		graphStoreService.DeletePost(postId)
+		graphStoreService.ChangePostOwner(postId, "0")
+
+		receive(t, repository.Updates)
+		receive(t, repository.Updates)
+		update := receive(t, repository.Updates)
+		if !strings.Contains(update, "dct:creator <https://community.example.com/uid/0>") {
+			t.Errorf("expected new creator, got %s", update)
+		}
+	})
+
+	t.Run("Retries when the entity cannot be found", func(t *testing.T) {
+		repository, graphStoreService := setUp(2)
+		graphStoreService.EntityService = &MockEntityService{MockError: errors.New("error")}
+
+		graphStoreService.PushPost(threadId, post)
+		graphStoreService.DeletePost(postId)
+
+		if update := receive(t, repository.Updates); strings.Contains(update, "INSERT") {
+			t.Errorf("expected no annotation without entity, got %s", update)
+		}
+	})
+
+	t.Run("Posts of the thread bot are not pushed", func(t *testing.T) {
+		repository, graphStoreService := setUp(1)
+
+		graphStoreService.PushPost(threadId, model.Post{PostId: &postId, UserId: &botUid})
+		graphStoreService.DeletePost(postId)
+
+		if update := receive(t, repository.Updates); strings.Contains(update, "INSERT") {
+			t.Errorf("expected thread bot post to be skipped, got %s", update)
+		}
+	})
+}
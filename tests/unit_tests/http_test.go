@@ -1,8 +1,16 @@
 package unit_tests
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
 )
 
@@ -128,3 +136,254 @@ func TestParseRequestUrlPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGetThreadQueryParams(t *testing.T) {
+	oldestCursor := (&model.ThreadCursor{Sort: model.OldestFirst, Index: 4, Timestamp: 1000, PostId: 12}).String()
+
+	var tests = []struct {
+		testName         string
+		rawQuery         string
+		expectedSort     model.ThreadSort
+		expectedPageSize *int
+		expectedError    error
+	}{
+		{"Defaults", "", model.NewestFirst, nil, nil},
+		{"Oldest", "sort=oldest", model.OldestFirst, nil, nil},
+		{"Most votes", "sort=most_votes&pageSize=10", model.MostVotes, intPointer(10), nil},
+		{"Unknown sort", "sort=random", model.NewestFirst, nil, model.ErrInvalidSort},
+		{"Page size not a number", "pageSize=ten", model.NewestFirst, nil, model.ErrInvalidPageSize},
+		{"Page size too small", "pageSize=0", model.NewestFirst, nil, model.ErrInvalidPageSize},
+		{"Page size too large", "pageSize=1000", model.NewestFirst, nil, model.ErrInvalidPageSize},
+		{"Cursor sets sort", "cursor=" + oldestCursor, model.OldestFirst, nil, nil},
+		{"Cursor with matching sort", "sort=oldest&cursor=" + oldestCursor, model.OldestFirst, nil, nil},
+		{"Cursor with other sort", "sort=newest&cursor=" + oldestCursor, model.NewestFirst, nil, model.ErrInvalidCursor},
+		{"Malformed cursor", "cursor=not-a-cursor", model.NewestFirst, nil, model.ErrInvalidCursor},
+		{"Category", "category=broken_link", model.NewestFirst, nil, nil},
+		{"Unknown category", "category=typo", model.NewestFirst, nil, model.ErrInvalidCategory},
+		{"Category with cursor", "category=other&cursor=" + oldestCursor, model.OldestFirst, nil, model.ErrInvalidCursor},
+		{"Open issues", "status=open&category=broken_link", model.NewestFirst, nil, nil},
+		{"Unknown status", "status=closed", model.NewestFirst, nil, model.ErrInvalidIssueStatus},
+		{"Status with cursor", "status=open&cursor=" + oldestCursor, model.OldestFirst, nil, model.ErrInvalidCursor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			queryParams, _ := url.ParseQuery(test.rawQuery)
+			actual, err := util.GetThreadQueryParams(queryParams)
+			if err != test.expectedError {
+				t.Fatalf("expected error %v, got %v", test.expectedError, err)
+			}
+			if err == nil && (actual.Sort != test.expectedSort || !reflect.DeepEqual(actual.PageSize, test.expectedPageSize)) {
+				t.Errorf("expected %v %v, got %v %v", test.expectedSort, test.expectedPageSize, actual.Sort, actual.PageSize)
+			}
+		})
+	}
+}
+
+func TestGetEntityIdsQueryParam(t *testing.T) {
+	var tests = []struct {
+		testName      string
+		rawQuery      string
+		expected      []string
+		expectedError error
+	}{
+		{"Repeated", "entityId=a&entityId=b", []string{"a", "b"}, nil},
+		{"Comma-separated", "entityId=a,%20b,,c", []string{"a", "b", "c"}, nil},
+		{"Duplicates", "entityId=a,b&entityId=a", []string{"a", "b"}, nil},
+		{"Missing", "", nil, model.ErrInvalidEntityIds},
+		{"Empty", "entityId=,", nil, model.ErrInvalidEntityIds},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			queryParams, _ := url.ParseQuery(test.rawQuery)
+			actual, err := util.GetEntityIdsQueryParam(queryParams)
+			if err != test.expectedError || !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v %v, got %v %v", test.expected, test.expectedError, actual, err)
+			}
+		})
+	}
+
+	t.Run("Too many", func(t *testing.T) {
+		entityIds := make([]string, env.ConstantValues.MaxPageSize+1)
+		for i := range entityIds {
+			entityIds[i] = strconv.Itoa(i)
+		}
+
+		_, err := util.GetEntityIdsQueryParam(url.Values{"entityId": entityIds})
+		if err != model.ErrInvalidEntityIds {
+			t.Errorf("expected %v, got %v", model.ErrInvalidEntityIds, err)
+		}
+	})
+}
+
+func TestGetStatisticsQueryParams(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	endOfDay := func(year int, month time.Month, day int) time.Time {
+		return date(year, month, day+1).Add(-time.Millisecond)
+	}
+
+	var tests = []struct {
+		testName      string
+		rawQuery      string
+		expected      *model.StatisticsQuery
+		expectedError error
+	}{
+		{"Defaults", "", &model.StatisticsQuery{Period: model.MonthPeriod, From: now.AddDate(-1, 0, 0), To: now}, nil},
+		{"Filters and dates", "organization=910244132&entityType=dataset&period=week&from=2026-01-01&to=2026-07-01", &model.StatisticsQuery{Organization: "910244132", EntityType: "dataset", Period: model.WeekPeriod, From: date(2026, 1, 1), To: endOfDay(2026, 7, 1)}, nil},
+		{"Year before end", "to=2026-07-01", &model.StatisticsQuery{Period: model.MonthPeriod, From: date(2025, 7, 1), To: endOfDay(2026, 7, 1)}, nil},
+		{"Single day", "from=2026-07-01&to=2026-07-01", &model.StatisticsQuery{Period: model.MonthPeriod, From: date(2026, 7, 1), To: endOfDay(2026, 7, 1)}, nil},
+		{"Unknown period", "period=day", nil, model.ErrInvalidStatisticsQuery},
+		{"Unknown entity type", "entityType=car", nil, model.ErrInvalidStatisticsQuery},
+		{"Malformed date", "from=01.01.2026", nil, model.ErrInvalidStatisticsQuery},
+		{"Start after end", "from=2026-07-01&to=2026-01-01", nil, model.ErrInvalidStatisticsQuery},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			queryParams, _ := url.ParseQuery(test.rawQuery)
+			actual, err := util.GetStatisticsQueryParams(queryParams, now)
+			if err != test.expectedError || !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %+v %v, got %+v %v", test.expected, test.expectedError, actual, err)
+			}
+		})
+	}
+}
+
+func TestClientIp(t *testing.T) {
+	var tests = []struct {
+		testName     string
+		remoteAddr   string
+		forwardedFor string
+		expectedIp   string
+	}{
+		{"Remote address", "192.0.2.1:1234", "", "192.0.2.1"},
+		{"Added by load balancer", "10.0.0.1:1234", "203.0.113.9", "203.0.113.9"},
+		{"Set by client", "10.0.0.1:1234", "198.51.100.7, 203.0.113.9", "203.0.113.9"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/usefulness/a", nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+
+			if actual := util.ClientIp(request); actual != test.expectedIp {
+				t.Errorf("expected %s, got %s", test.expectedIp, actual)
+			}
+		})
+	}
+}
+
+func TestPaginationLinks(t *testing.T) {
+	requestUrl, _ := url.Parse("https://example.com/thread/entity?sort=oldest&page=2")
+
+	var tests = []struct {
+		testName    string
+		currentPage int
+		pageCount   int
+		expected    string
+	}{
+		{"Single page", 1, 1, `</thread/entity?page=1&sort=oldest>; rel="first", </thread/entity?page=1&sort=oldest>; rel="last"`},
+		{"First page", 1, 3, `</thread/entity?page=1&sort=oldest>; rel="first", </thread/entity?page=2&sort=oldest>; rel="next", </thread/entity?page=3&sort=oldest>; rel="last"`},
+		{"Middle page", 2, 3, `</thread/entity?page=1&sort=oldest>; rel="first", </thread/entity?page=1&sort=oldest>; rel="prev", </thread/entity?page=3&sort=oldest>; rel="next", </thread/entity?page=3&sort=oldest>; rel="last"`},
+		{"No pages", 1, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			actual := util.PaginationLinks(requestUrl, &model.Pagination{
+				CurrentPage: &test.currentPage,
+				PageCount:   &test.pageCount,
+			})
+			if actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCursorPaginationLinks(t *testing.T) {
+	requestUrl, _ := url.Parse("https://example.com/thread/entity?cursor=abc&page=2")
+	prev, next := "prev-cursor", "next-cursor"
+
+	expected := `</thread/entity?cursor=prev-cursor>; rel="prev", </thread/entity?cursor=next-cursor>; rel="next"`
+	actual := util.PaginationLinks(requestUrl, &model.Pagination{PrevCursor: &prev, NextCursor: &next})
+	if actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestParseThreadCursor(t *testing.T) {
+	cursor := model.ThreadCursor{Sort: model.NewestFirst, Backward: true, Index: 3, Timestamp: 1000, PostId: 7}
+
+	parsed, err := model.ParseThreadCursor(cursor.String())
+	if err != nil || !reflect.DeepEqual(*parsed, cursor) {
+		t.Fatalf("expected %#v, got %#v, %v", cursor, parsed, err)
+	}
+
+	mostVotes := model.ThreadCursor{Sort: model.MostVotes, PostId: 7}
+	if _, err := model.ParseThreadCursor(mostVotes.String()); err != model.ErrInvalidCursor {
+		t.Errorf("expected %v for most_votes cursor, got %v", model.ErrInvalidCursor, err)
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestUnmarshalUserPosts(t *testing.T) {
+	response := []byte(`{
+		"posts": [
+			{"pid": 1, "tid": 10, "content": "feedback", "category": {"cid": 25}},
+			{"pid": 2, "tid": 20, "content": "elsewhere", "category": {"cid": 3}}
+		],
+		"pagination": {"currentPage": 1, "pageCount": 2}
+	}`)
+
+	userPosts, err := util.UnmarshalUserPosts(&response)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	posts := userPosts.ToPosts("25")
+	if len(posts) != 1 || *posts[0].PostId != "1" || *posts[0].ThreadId != "10" {
+		t.Errorf("expected only the post in category 25. Got %#v", posts)
+	}
+	if userPosts.PageCount() != 2 {
+		t.Errorf("expected 2 pages. Got %d", userPosts.PageCount())
+	}
+}
+
+func TestUnmarshalPostResponse(t *testing.T) {
+	var responseTests = []struct {
+		testName       string
+		response       string
+		expectedPostId string
+		expectedError  error
+	}{
+		{"Created or looked up post", `{"status": {"code": "ok"}, "response": {"pid": 5, "tid": 1}}`, "5", nil},
+		{"Error from NodeBB", `{"status": {"code": "not-found"}}`, "", model.ErrBadResponse},
+		{"Without status", `{"response": {"pid": 5}}`, "", model.ErrBadResponse},
+	}
+
+	for _, test := range responseTests {
+		t.Run(test.testName, func(t *testing.T) {
+			response := []byte(test.response)
+
+			post, err := util.UnmarshalPostResponse(&response)
+
+			if err != test.expectedError {
+				t.Fatalf("expected error %v. Got %v", test.expectedError, err)
+			}
+			if test.expectedPostId != "" && (post == nil || *post.PostId != test.expectedPostId) {
+				t.Errorf("expected post %s. Got %#v", test.expectedPostId, post)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func TestIsPublisher(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	entity := &model.Entity{EntityId: "entity", PublisherId: "910244132"}
+	var publisherTests = []struct {
+		testName           string
+		user               model.User
+		entityError        error
+		expectedPublisher  bool
+		expectedStatusCode int
+	}{
+		{"Moderator", model.User{Moderator: true}, nil, true, http.StatusOK},
+		{"Publisher", model.User{Organizations: []string{"123456789", "910244132"}}, nil, true, http.StatusOK},
+		{"Other organization", model.User{Organizations: []string{"123456789"}}, nil, false, http.StatusOK},
+		{"No organizations", model.User{}, errors.New("not looked up"), false, http.StatusOK},
+		{"Unknown entity", model.User{Organizations: []string{"910244132"}}, errors.New("entity not found"), false, http.StatusNotFound},
+	}
+
+	for _, test := range publisherTests {
+		t.Run(test.testName, func(t *testing.T) {
+			issueService := service.IssueServiceImpl{
+				EntityService: &MockEntityService{MockEntity: entity, MockError: test.entityError},
+			}
+
+			publisher, statusCode := issueService.IsPublisher("entity", test.user)
+			if publisher != test.expectedPublisher || statusCode != test.expectedStatusCode {
+				t.Fatalf("expected %t, %d. Got %t, %d", test.expectedPublisher, test.expectedStatusCode, publisher, statusCode)
+			}
+		})
+	}
+}
+
+func TestUpdateIssueStatus(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId := "1", "2", "3"
+	publisher := model.User{UserId: &userId, Organizations: []string{"910244132"}}
+	issueServiceMocks := func() (*MockIssueRepository, service.IssueService) {
+		mockIssueRepository := MockIssueRepository{
+			MockIssue: &model.DataQualityIssue{PostId: postId, ThreadId: threadId, Category: model.BrokenLinkIssue, Status: model.IssueOpen},
+		}
+		issueService := service.IssueServiceImpl{
+			IssueRepository:  &mockIssueRepository,
+			ThreadIdService:  &MockThreadIdService{MockThreadId: &threadId},
+			EntityService:    &MockEntityService{MockEntity: &model.Entity{PublisherId: "910244132"}},
+			MaxCommentLength: 10,
+		}
+		return &mockIssueRepository, &issueService
+	}
+
+	t.Run("Rejects unknown status", func(t *testing.T) {
+		_, issueService := issueServiceMocks()
+
+		_, statusCode := issueService.UpdateIssueStatus("entity", postId, model.IssueTransition{Status: "closed"}, publisher)
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected %d. Got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+
+	t.Run("Rejects long comment", func(t *testing.T) {
+		_, issueService := issueServiceMocks()
+
+		transition := model.IssueTransition{Status: model.IssueResolved, Comment: "Fikset i dag, takk!"}
+		_, statusCode := issueService.UpdateIssueStatus("entity", postId, transition, publisher)
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected %d. Got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+
+	t.Run("Post without issue", func(t *testing.T) {
+		mockIssueRepository, issueService := issueServiceMocks()
+		mockIssueRepository.MockIssue = nil
+
+		_, statusCode := issueService.UpdateIssueStatus("entity", postId, model.IssueTransition{Status: model.IssueResolved}, publisher)
+		if statusCode != http.StatusNotFound {
+			t.Fatalf("expected %d. Got %d", http.StatusNotFound, statusCode)
+		}
+	})
+
+	t.Run("Forbidden for other users", func(t *testing.T) {
+		mockIssueRepository, issueService := issueServiceMocks()
+
+		_, statusCode := issueService.UpdateIssueStatus("entity", postId, model.IssueTransition{Status: model.IssueResolved}, model.User{UserId: &userId})
+		if statusCode != http.StatusForbidden || len(mockIssueRepository.SavedIssues) != 0 {
+			t.Fatalf("expected %d. Got %d", http.StatusForbidden, statusCode)
+		}
+	})
+
+	t.Run("Records transition", func(t *testing.T) {
+		mockIssueRepository, issueService := issueServiceMocks()
+
+		transition := model.IssueTransition{Status: model.IssueAcknowledged, Comment: "Ser på det"}
+		issue, statusCode := issueService.UpdateIssueStatus("entity", postId, transition, publisher)
+		if statusCode != http.StatusOK || issue.Status != model.IssueAcknowledged {
+			t.Fatalf("expected acknowledged issue. Got %#v, %d", issue, statusCode)
+		}
+
+		saved := mockIssueRepository.SavedIssues
+		if len(saved) != 1 || len(saved[0].Transitions) != 1 {
+			t.Fatalf("expected issue with one transition to be saved. Got %#v", saved)
+		}
+		recorded := saved[0].Transitions[0]
+		if recorded.From != model.IssueOpen || recorded.Status != model.IssueAcknowledged || recorded.ChangedBy != userId || recorded.Comment != "Ser på det" {
+			t.Errorf("expected transition from open to acknowledged. Got %#v", recorded)
+		}
+	})
+
+	t.Run("Same status changes nothing", func(t *testing.T) {
+		mockIssueRepository, issueService := issueServiceMocks()
+
+		_, statusCode := issueService.UpdateIssueStatus("entity", postId, model.IssueTransition{Status: model.IssueOpen}, publisher)
+		if statusCode != http.StatusOK || len(mockIssueRepository.SavedIssues) != 0 {
+			t.Fatalf("expected unchanged issue. Got %d, %#v", statusCode, mockIssueRepository.SavedIssues)
+		}
+	})
+}
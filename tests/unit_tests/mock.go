@@ -1,21 +1,33 @@
 package unit_tests
 
 import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 type MockEntityRepository struct {
-	MockEntity *model.Entity
-	MockError  error
+	MockEntity    *model.Entity
+	MockEntityIds []string
+	MockError     error
 }
 
 func (m *MockEntityRepository) GetEntityById(entityID string) (*model.Entity, error) {
 	return m.MockEntity, m.MockError
 }
+func (m *MockEntityRepository) GetOrganizationEntityIds(organization string) ([]string, error) {
+	return m.MockEntityIds, m.MockError
+}
 
 type MockThreadIdRepository struct {
 	MockThreadId *string
+	MockEntityId *string
 	MockError    error
 }
 
@@ -23,33 +35,477 @@ func (m *MockThreadIdRepository) GetThreadId(id string) (*string, error) {
 	return m.MockThreadId, m.MockError
 }
 
+func (m *MockThreadIdRepository) GetThreadIds(ids []string) (map[string]string, error) {
+	threadIds := map[string]string{}
+	if m.MockThreadId != nil {
+		for _, id := range ids {
+			threadIds[id] = *m.MockThreadId
+		}
+	}
+	return threadIds, m.MockError
+}
+
 func (m *MockThreadIdRepository) CreateThreadId(id string, threadId string) error {
 	return m.MockError
 }
 
+func (m *MockThreadIdRepository) DeleteThreadId(id string) error {
+	return m.MockError
+}
+
+func (m *MockThreadIdRepository) GetEntityId(threadId string) (*string, error) {
+	return m.MockEntityId, m.MockError
+}
+
 type MockThreadRepository struct {
 	MockError     error
 	MockThread    *model.Thread
 	MockPost      *model.Post
 	MockGetThread *model.Thread
+	MockGetPost   *model.Post
 	MockGetError  error
+	// MockGetThreadPages, when set, is served by page number instead of MockGetThread.
+	MockGetThreadPages map[string]*model.Thread
+	// MockGetPosts, when set, is served by post id instead of MockGetPost.
+	MockGetPosts    map[string]*model.Post
+	CreatedPosts    []model.Post
+	UpdatedPosts    []model.Post
+	DeletedPosts    []model.Post
+	PurgedPosts     []model.Post
+	ReassignedPosts []model.Post
+	// MockUserPostPages holds the posts of a user by page number.
+	MockUserPostPages [][]*model.Post
+	// PostViewerUid is the viewer of the last post read.
+	PostViewerUid *string
 }
 
-func (m *MockThreadRepository) GetThread(threadId string, page *string, postIndex *string) (*model.Thread, error) {
+func (m *MockThreadRepository) GetThread(threadId string, query model.ThreadQuery) (*model.Thread, error) {
+	if m.MockGetThreadPages != nil && query.Page != nil {
+		return m.MockGetThreadPages[*query.Page], m.MockGetError
+	}
 	return m.MockGetThread, m.MockGetError
 }
+func (m *MockThreadRepository) GetThreadPost(postId string, viewerUid *string) (*model.Post, error) {
+	m.PostViewerUid = viewerUid
+	if m.MockGetPosts != nil {
+		return m.MockGetPosts[postId], m.MockGetError
+	}
+	return m.MockGetPost, m.MockGetError
+}
 func (m *MockThreadRepository) CreateThread(thread model.Thread) (*model.Thread, error) {
 	return m.MockThread, m.MockError
 }
 func (m *MockThreadRepository) CreateThreadPost(post model.Post) (*model.Post, error) {
+	m.CreatedPosts = append(m.CreatedPosts, post)
 	return m.MockPost, m.MockError
 }
 func (m *MockThreadRepository) UpdateThreadPost(post model.Post) error {
+	m.UpdatedPosts = append(m.UpdatedPosts, post)
 	return m.MockError
 }
 func (m *MockThreadRepository) DeleteThreadPost(post model.Post) error {
+	m.DeletedPosts = append(m.DeletedPosts, post)
+	return m.MockError
+}
+func (m *MockThreadRepository) VoteThreadPost(post model.Post) error {
+	return m.MockError
+}
+func (m *MockThreadRepository) UnvoteThreadPost(post model.Post) error {
+	return m.MockError
+}
+func (m *MockThreadRepository) PurgeThreadPost(post model.Post) error {
+	m.PurgedPosts = append(m.PurgedPosts, post)
+	return m.MockError
+}
+func (m *MockThreadRepository) ChangePostOwner(post model.Post, userId string) error {
+	m.ReassignedPosts = append(m.ReassignedPosts, post)
+	return m.MockError
+}
+func (m *MockThreadRepository) GetUserPosts(userslug string, page int) ([]*model.Post, int, error) {
+	if page < 1 || page > len(m.MockUserPostPages) {
+		return nil, len(m.MockUserPostPages), m.MockGetError
+	}
+	return m.MockUserPostPages[page-1], len(m.MockUserPostPages), m.MockGetError
+}
+
+type MockRevisionRepository struct {
+	MockRevisions    []model.PostRevision
+	MockError        error
+	CreatedRevisions []model.PostRevision
+	DeletedRevisions []string
+	DeletedPostIds   []string
+}
+
+func (m *MockRevisionRepository) CreateRevision(revision model.PostRevision) (string, error) {
+	m.CreatedRevisions = append(m.CreatedRevisions, revision)
+	return strconv.Itoa(len(m.CreatedRevisions)), m.MockError
+}
+func (m *MockRevisionRepository) DeleteRevision(postId string, revisionId string) error {
+	m.DeletedRevisions = append(m.DeletedRevisions, revisionId)
+	return m.MockError
+}
+func (m *MockRevisionRepository) GetRevisions(postId string) ([]model.PostRevision, error) {
+	return m.MockRevisions, m.MockError
+}
+func (m *MockRevisionRepository) DeleteRevisions(postId string) error {
+	m.DeletedPostIds = append(m.DeletedPostIds, postId)
+	return m.MockError
+}
+
+type MockReportRepository struct {
+	MockCount        int
+	MockReports      []model.PostReport
+	MockError        error
+	MockCountError   error
+	CreatedReports   []model.PostReport
+	ResolvedPosts    []string
+	DeletedReporters []string
+}
+
+func (m *MockReportRepository) CreateReport(report model.PostReport) error {
+	m.CreatedReports = append(m.CreatedReports, report)
+	return m.MockError
+}
+func (m *MockReportRepository) CountReports(postId string) (int, error) {
+	return m.MockCount, m.MockCountError
+}
+func (m *MockReportRepository) GetOpenReports() ([]model.PostReport, error) {
+	return m.MockReports, m.MockError
+}
+func (m *MockReportRepository) DeleteUserReports(reporterUid string) error {
+	m.DeletedReporters = append(m.DeletedReporters, reporterUid)
+	return m.MockError
+}
+func (m *MockReportRepository) ResolveReports(postId string, resolverUid string, resolvedAt int64) ([]model.PostReport, error) {
+	m.ResolvedPosts = append(m.ResolvedPosts, postId+"_"+resolverUid)
+	return m.MockReports, m.MockError
+}
+
+type MockPendingPostRepository struct {
+	MockPendingPost  *model.PendingPost
+	MockPendingPosts []model.PendingPost
+	MockApproved     bool
+	MockError        error
+	CreatedPosts     []model.PendingPost
+	DeletedIds       []string
+	ApprovedUsers    []string
+	DeletedUsers     []string
+}
+
+func (m *MockPendingPostRepository) CreatePendingPost(post model.PendingPost) (*model.PendingPost, error) {
+	m.CreatedPosts = append(m.CreatedPosts, post)
+	post.PendingId = "pending"
+	return &post, m.MockError
+}
+func (m *MockPendingPostRepository) GetPendingPost(pendingId string) (*model.PendingPost, error) {
+	return m.MockPendingPost, m.MockError
+}
+func (m *MockPendingPostRepository) GetPendingPosts() ([]model.PendingPost, error) {
+	return m.MockPendingPosts, m.MockError
+}
+func (m *MockPendingPostRepository) GetPendingPostsByUser(threadId string, userId string) ([]model.PendingPost, error) {
+	return m.MockPendingPosts, m.MockError
+}
+func (m *MockPendingPostRepository) DeletePendingPost(pendingId string) error {
+	m.DeletedIds = append(m.DeletedIds, pendingId)
+	return m.MockError
+}
+func (m *MockPendingPostRepository) IsApprovedUser(userId string) (bool, error) {
+	return m.MockApproved, m.MockError
+}
+func (m *MockPendingPostRepository) ApproveUser(userId string) error {
+	m.ApprovedUsers = append(m.ApprovedUsers, userId)
+	return m.MockError
+}
+func (m *MockPendingPostRepository) DeleteUser(userId string) error {
+	m.DeletedUsers = append(m.DeletedUsers, userId)
+	return m.MockError
+}
+
+type MockIssueRepository struct {
+	MockIssue      *model.DataQualityIssue
+	MockIssues     []model.DataQualityIssue
+	MockError      error
+	SavedIssues    []model.DataQualityIssue
+	DeletedPostIds []string
+}
+
+func (m *MockIssueRepository) SaveIssue(issue model.DataQualityIssue) error {
+	m.SavedIssues = append(m.SavedIssues, issue)
+	return m.MockError
+}
+func (m *MockIssueRepository) GetIssue(postId string) (*model.DataQualityIssue, error) {
+	return m.MockIssue, m.MockError
+}
+func (m *MockIssueRepository) GetIssues(threadId string) ([]model.DataQualityIssue, error) {
+	return m.MockIssues, m.MockError
+}
+func (m *MockIssueRepository) DeleteIssue(postId string) error {
+	m.DeletedPostIds = append(m.DeletedPostIds, postId)
+	return m.MockError
+}
+
+// MockGraphStoreRepository fails the first updates with MockErrors, and
+// sends every update it receives to Updates.
+type MockGraphStoreRepository struct {
+	MockErrors []error
+	Updates    chan string
+}
+
+func (m *MockGraphStoreRepository) Update(update string) error {
+	m.Updates <- update
+	if len(m.MockErrors) > 0 {
+		err := m.MockErrors[0]
+		m.MockErrors = m.MockErrors[1:]
+		return err
+	}
+	return nil
+}
+
+type MockContentHistoryRepository struct {
+	MockFingerprint   *model.ContentFingerprint
+	MockError         error
+	SavedFingerprints []model.ContentFingerprint
+	DeletedUsers      []string
+}
+
+func (m *MockContentHistoryRepository) GetFingerprint(userId string, hash string) (*model.ContentFingerprint, error) {
+	return m.MockFingerprint, m.MockError
+}
+func (m *MockContentHistoryRepository) SaveFingerprint(userId string, hash string, fingerprint model.ContentFingerprint) error {
+	m.SavedFingerprints = append(m.SavedFingerprints, fingerprint)
+	return m.MockError
+}
+func (m *MockContentHistoryRepository) DeleteHistory(userId string) error {
+	m.DeletedUsers = append(m.DeletedUsers, userId)
+	return m.MockError
+}
+
+type MockErasureRepository struct {
+	MockTombstone     *model.ErasureTombstone
+	MockLeased        bool
+	MockError         error
+	CreatedTombstones []model.ErasureTombstone
+	UpdatedTombstones []model.ErasureTombstone
+}
+
+func (m *MockErasureRepository) CreateErasure(tombstone model.ErasureTombstone) (*model.ErasureTombstone, error) {
+	m.CreatedTombstones = append(m.CreatedTombstones, tombstone)
+	tombstone.ErasureId = "erasure"
+	return &tombstone, m.MockError
+}
+func (m *MockErasureRepository) UpdateErasure(tombstone model.ErasureTombstone) error {
+	m.UpdatedTombstones = append(m.UpdatedTombstones, tombstone)
+	return m.MockError
+}
+func (m *MockErasureRepository) GetErasure(erasureId string) (*model.ErasureTombstone, error) {
+	return m.MockTombstone, m.MockError
+}
+func (m *MockErasureRepository) LeaseErasure(erasureId string, now time.Time, lease time.Duration) (*model.ErasureTombstone, bool, error) {
+	if m.MockTombstone == nil {
+		return nil, false, m.MockError
+	}
+	tombstone := *m.MockTombstone
+	return &tombstone, !m.MockLeased, m.MockError
+}
+
+type MockRatingRepository struct {
+	MockRatings   []model.Rating
+	MockError     error
+	SavedRatings  []model.Rating
+	DeletedUsers  []string
+	EntityIdsRead [][]string
+}
+
+func (m *MockRatingRepository) SaveRating(rating model.Rating) error {
+	m.SavedRatings = append(m.SavedRatings, rating)
+	return m.MockError
+}
+func (m *MockRatingRepository) DeleteRating(entityId string, userId string) error {
+	m.DeletedUsers = append(m.DeletedUsers, userId)
+	return m.MockError
+}
+func (m *MockRatingRepository) GetRatings(entityIds []string) ([]model.Rating, error) {
+	m.EntityIdsRead = append(m.EntityIdsRead, entityIds)
+	return m.MockRatings, m.MockError
+}
+func (m *MockRatingRepository) DeleteUserRatings(userId string) error {
+	m.DeletedUsers = append(m.DeletedUsers, userId)
+	return m.MockError
+}
+
+type MockUsefulnessRepository struct {
+	MockIpVotes    []model.UsefulnessVote
+	MockHelpful    int
+	MockNotHelpful int
+	MockError      error
+	CreatedVotes   []model.UsefulnessVote
+	CountedBy      []string
+}
+
+func (m *MockUsefulnessRepository) CreateVote(vote model.UsefulnessVote) error {
+	m.CreatedVotes = append(m.CreatedVotes, vote)
+	return m.MockError
+}
+func (m *MockUsefulnessRepository) GetIpVotes(ipWindow string) ([]model.UsefulnessVote, error) {
+	return m.MockIpVotes, nil
+}
+func (m *MockUsefulnessRepository) CountVotes(field string, value string) (int, int, error) {
+	m.CountedBy = append(m.CountedBy, field+"="+value)
+	return m.MockHelpful, m.MockNotHelpful, nil
+}
+
+type MockPostIndexRepository struct {
+	MockPosts     []model.IndexedPost
+	MockEntityIds []string
+	MockError     error
+	// MockFailedCount fails the count of the batch holding this entity id.
+	MockFailedCount string
+	SavedPosts      []model.IndexedPost
+	DeletedPostIds  []string
+	CountedBatches  [][]string
+}
+
+func (m *MockPostIndexRepository) SavePost(post model.IndexedPost) error {
+	m.SavedPosts = append(m.SavedPosts, post)
+	return m.MockError
+}
+func (m *MockPostIndexRepository) DeletePost(postId string) error {
+	m.DeletedPostIds = append(m.DeletedPostIds, postId)
+	return m.MockError
+}
+func (m *MockPostIndexRepository) GetPostsSince(timestamp int64, organization string, entityType string) ([]model.IndexedPost, error) {
+	posts := []model.IndexedPost{}
+	for _, post := range m.MockPosts {
+		if post.Timestamp >= timestamp && (organization == "" || post.Organization == organization) &&
+			(entityType == "" || post.EntityType == entityType) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, m.MockError
+}
+func (m *MockPostIndexRepository) GetOrganizationEntityIds(organization string) ([]string, error) {
+	return m.MockEntityIds, m.MockError
+}
+func (m *MockPostIndexRepository) CountEntityPosts(entityIds []string) (map[string]int, error) {
+	m.CountedBatches = append(m.CountedBatches, entityIds)
+	if slices.Contains(entityIds, m.MockFailedCount) {
+		return nil, errors.New("count failed")
+	}
+	counts := map[string]int{}
+	for _, post := range m.MockPosts {
+		if slices.Contains(entityIds, post.EntityId) {
+			counts[post.EntityId]++
+		}
+	}
+	return counts, m.MockError
+}
+
+// MockNotificationRepository claims every notification not claimed within
+// the window, by its own clock.
+type MockNotificationRepository struct {
+	MockSettings  *model.NotificationSettings
+	MockError     error
+	Claims        map[string]time.Time
+	SavedSettings []model.NotificationSettings
+}
+
+func (m *MockNotificationRepository) ClaimNotification(key string, now time.Time, window time.Duration) (bool, error) {
+	if m.MockError != nil {
+		return false, m.MockError
+	}
+	if claimedAt, found := m.Claims[key]; found && now.Sub(claimedAt) < window {
+		return false, nil
+	}
+	if m.Claims == nil {
+		m.Claims = map[string]time.Time{}
+	}
+	m.Claims[key] = now
+	return true, nil
+}
+func (m *MockNotificationRepository) ReleaseNotification(key string, claimedAt time.Time) error {
+	if m.Claims[key].Equal(claimedAt) {
+		delete(m.Claims, key)
+	}
+	return nil
+}
+func (m *MockNotificationRepository) GetSettings(organization string) (*model.NotificationSettings, error) {
+	return m.MockSettings, m.MockError
+}
+func (m *MockNotificationRepository) SaveSettings(settings model.NotificationSettings) error {
+	m.SavedSettings = append(m.SavedSettings, settings)
+	return m.MockError
+}
+
+type MockMailRepository struct {
+	MockError error
+	SentMails []model.MailMessage
+}
+
+func (m *MockMailRepository) SendMail(message model.MailMessage) error {
+	m.SentMails = append(m.SentMails, message)
+	return m.MockError
+}
+
+type MockSubscriptionRepository struct {
+	MockSubscription     *model.ThreadSubscription
+	MockSubscriptions    []model.ThreadSubscription
+	MockError            error
+	SavedSubscriptions   []model.ThreadSubscription
+	DeletedSubscriptions []string
+	DeletedUsers         []string
+}
+
+func (m *MockSubscriptionRepository) SaveSubscription(subscription model.ThreadSubscription) error {
+	m.SavedSubscriptions = append(m.SavedSubscriptions, subscription)
+	return m.MockError
+}
+func (m *MockSubscriptionRepository) DeleteSubscription(entityId string, userId string) error {
+	m.DeletedSubscriptions = append(m.DeletedSubscriptions, entityId+"_"+userId)
 	return m.MockError
 }
+func (m *MockSubscriptionRepository) GetSubscription(entityId string, userId string) (*model.ThreadSubscription, error) {
+	return m.MockSubscription, m.MockError
+}
+func (m *MockSubscriptionRepository) GetEntitySubscriptions(entityId string) ([]model.ThreadSubscription, error) {
+	return m.MockSubscriptions, m.MockError
+}
+func (m *MockSubscriptionRepository) DeleteUserSubscriptions(userId string) error {
+	m.DeletedUsers = append(m.DeletedUsers, userId)
+	return m.MockError
+}
+
+type MockNotifier struct {
+	MockError     error
+	Notifications []model.UserNotification
+	Delivered     chan model.UserNotification
+}
+
+func (m *MockNotifier) Notify(notification model.UserNotification) error {
+	if m.Delivered != nil {
+		m.Delivered <- notification
+		return m.MockError
+	}
+	m.Notifications = append(m.Notifications, notification)
+	return m.MockError
+}
+
+// Run runs the task right away, delivering through the mock.
+func (m *MockNotifier) Run(task func(notifier service.Notifier) error) error {
+	return task(m)
+}
+
+type MockSecretProvider struct {
+	Secrets map[string]string
+}
+
+func (m *MockSecretProvider) GetSecret(key string) (string, error) {
+	value, present := m.Secrets[key]
+	if !present {
+		return "", secret.ErrSecretNotFound
+	}
+	return value, nil
+}
 
 type MockUserRepository struct {
 	MockUser  *model.User
@@ -61,25 +517,49 @@ func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
 }
 
 type MockThreadIdService struct {
-	MockThreadId *string
-	MockError    error
+	MockThreadId     *string
+	MockThreadIds    map[string]string
+	MockEntityIds    map[string]string
+	MockError        error
+	CreatedThreadIds []string
+	DeletedIds       []string
 }
 
 func (m *MockThreadIdService) GetThreadId(id string) (*string, error) {
 	return m.MockThreadId, m.MockError
 }
+func (m *MockThreadIdService) GetThreadIds(ids []string) (map[string]string, error) {
+	return m.MockThreadIds, m.MockError
+}
 func (m *MockThreadIdService) CreateThreadId(id string, threadId string) error {
+	m.CreatedThreadIds = append(m.CreatedThreadIds, id+"_"+threadId)
+	return m.MockError
+}
+func (m *MockThreadIdService) DeleteThreadId(id string) error {
+	m.DeletedIds = append(m.DeletedIds, id)
 	return m.MockError
 }
+func (m *MockThreadIdService) GetEntityId(threadId string) (*string, error) {
+	entityId, present := m.MockEntityIds[threadId]
+	if !present {
+		return nil, m.MockError
+	}
+	return &entityId, m.MockError
+}
 
 type MockEntityService struct {
-	MockEntity *model.Entity
-	MockError  error
+	MockEntity    *model.Entity
+	MockEntityIds []string
+	MockError     error
+	MockIdsError  error
 }
 
 func (m *MockEntityService) GetEntity(id string) (*model.Entity, error) {
 	return m.MockEntity, m.MockError
 }
+func (m *MockEntityService) GetOrganizationEntityIds(organization string) ([]string, error) {
+	return m.MockEntityIds, m.MockIdsError
+}
 
 type MockAuthService struct {
 	MockUser       *model.User
@@ -98,25 +578,39 @@ func (m *MockAuthService) GetUser(email string) (*model.User, error) {
 	return m.MockUser, m.MockError
 }
 
+// MockThreadService returns the page of MockThreadPages asked for, when
+// set, instead of MockThread.
 type MockThreadService struct {
-	MockPost       *model.Post
-	MockThread     *model.Thread
-	MockStatusCode int
+	MockPost        *model.Post
+	MockThread      *model.Thread
+	MockThreadPages []*model.Thread
+	MockCounts      map[string]int
+	MockRevisions   []model.PostRevision
+	MockStatusCode  int
+	HiddenPosts     []string
+	ViewerUid       *string
 }
 
-func (m *MockThreadService) CreateThreadPost(postRequest model.Post) (*model.Post, int) {
+func (m *MockThreadService) CreateThreadPost(postRequest model.Post, entityId string) (*model.Post, int) {
 	return m.MockPost, m.MockStatusCode
 }
 func (m *MockThreadService) CreateThread(forEntityId string) (*model.Thread, int) {
 	return m.MockThread, m.MockStatusCode
 }
-func (m *MockThreadService) GetThread(id string, page *string, postIndex *string) (*model.Thread, int) {
+func (m *MockThreadService) GetThread(id string, query model.ThreadQuery) (*model.Thread, int) {
 	return m.MockThread, m.MockStatusCode
 }
-func (m *MockThreadService) UpdateThreadPost(updatedPost model.Post, postIndex *string) (*model.Post, int) {
+func (m *MockThreadService) GetThreadPost(threadId string, postId string) (*model.Post, int) {
+	return m.MockPost, m.MockStatusCode
+}
+func (m *MockThreadService) UpdateThreadPost(updatedPost model.Post) (*model.Post, int) {
 	return m.MockPost, m.MockStatusCode
 }
-func (m *MockThreadService) DeleteThreadPost(postToDelete model.Post, postIndex *string) int {
+func (m *MockThreadService) DeleteThreadPost(postToDelete model.Post) int {
+	return m.MockStatusCode
+}
+func (m *MockThreadService) HideThreadPost(threadId string, postId string, moderatorUid string) int {
+	m.HiddenPosts = append(m.HiddenPosts, postId+"_"+moderatorUid)
 	return m.MockStatusCode
 }
 
@@ -124,6 +618,281 @@ func (m *MockThreadService) CreatePostForEntityId(postRequest model.Post, entity
 	return m.MockPost, m.MockStatusCode
 }
 
-func (m *MockThreadService) GetThreadByEntityId(entityId string, page *string) (*model.Thread, int) {
+func (m *MockThreadService) GetThreadByEntityId(entityId string, query model.ThreadQuery) (*model.Thread, int) {
+	if m.MockThreadPages != nil && query.Page != nil {
+		page, _ := strconv.Atoi(*query.Page)
+		return m.MockThreadPages[page-1], m.MockStatusCode
+	}
 	return m.MockThread, m.MockStatusCode
 }
+
+func (m *MockThreadService) GetThreadPostByEntityId(entityId string, postId string, viewerUid *string) (*model.Post, int) {
+	m.ViewerUid = viewerUid
+	return m.MockPost, m.MockStatusCode
+}
+
+func (m *MockThreadService) CountPostsByEntityIds(entityIds []string) (map[string]int, int) {
+	return m.MockCounts, m.MockStatusCode
+}
+
+func (m *MockThreadService) GetThreadPostRevisions(entityId string, postId string, user model.User) ([]model.PostRevision, int) {
+	return m.MockRevisions, m.MockStatusCode
+}
+
+func (m *MockThreadService) VotePostForEntityId(entityId string, postId string, userId string, upvote bool) (*model.Post, int) {
+	return m.MockPost, m.MockStatusCode
+}
+
+func (m *MockThreadService) PublishPendingPost(pending model.PendingPost) (*model.Post, int) {
+	return m.MockPost, m.MockStatusCode
+}
+
+type MockReportService struct {
+	MockReport        *model.PostReport
+	MockReportedPosts []model.ReportedPost
+	MockStatusCode    int
+}
+
+func (m *MockReportService) ReportPost(entityId string, postId string, report model.PostReport) (*model.PostReport, int) {
+	return m.MockReport, m.MockStatusCode
+}
+func (m *MockReportService) GetReportedPosts() ([]model.ReportedPost, int) {
+	return m.MockReportedPosts, m.MockStatusCode
+}
+func (m *MockReportService) ResolveReports(postId string, moderatorUid string) ([]model.PostReport, int) {
+	if m.MockReport == nil {
+		return nil, m.MockStatusCode
+	}
+	return []model.PostReport{*m.MockReport}, m.MockStatusCode
+}
+
+type MockModerationService struct {
+	MockPost         *model.Post
+	MockPendingPosts []model.PendingPost
+	MockStatusCode   int
+}
+
+func (m *MockModerationService) GetPendingPosts() ([]model.PendingPost, int) {
+	return m.MockPendingPosts, m.MockStatusCode
+}
+func (m *MockModerationService) ApprovePendingPost(pendingId string) (*model.Post, int) {
+	return m.MockPost, m.MockStatusCode
+}
+func (m *MockModerationService) RejectPendingPost(pendingId string) int {
+	return m.MockStatusCode
+}
+
+type MockExportService struct {
+	MockExport     *model.UserExport
+	MockEntities   []model.Entity
+	MockRows       []model.FeedbackExportRow
+	MockStatusCode int
+	ExportedUsers  []model.User
+}
+
+func (m *MockExportService) ExportUserPosts(user model.User) (*model.UserExport, int) {
+	m.ExportedUsers = append(m.ExportedUsers, user)
+	return m.MockExport, m.MockStatusCode
+}
+func (m *MockExportService) GetExportedEntities(organization string, user model.User) ([]model.Entity, int) {
+	m.ExportedUsers = append(m.ExportedUsers, user)
+	return m.MockEntities, m.MockStatusCode
+}
+func (m *MockExportService) ExportEntityFeedback(entity model.Entity, write func(model.FeedbackExportRow) error) error {
+	for _, row := range m.MockRows {
+		if row.EntityId == entity.EntityId {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type MockNotificationService struct {
+	MockSettings    *model.NotificationSettings
+	MockStatusCode  int
+	NotifiedPosts   []model.Post
+	UpdatedSettings []model.NotificationSettings
+}
+
+func (m *MockNotificationService) NotifyPost(entityId string, post model.Post) {
+	m.NotifiedPosts = append(m.NotifiedPosts, post)
+}
+func (m *MockNotificationService) GetSettings(organization string, user model.User) (*model.NotificationSettings, int) {
+	return m.MockSettings, m.MockStatusCode
+}
+func (m *MockNotificationService) UpdateSettings(settings model.NotificationSettings, user model.User) (*model.NotificationSettings, int) {
+	m.UpdatedSettings = append(m.UpdatedSettings, settings)
+	return &settings, m.MockStatusCode
+}
+
+type MockSubscriptionService struct {
+	MockStatusCode int
+	FollowedBy     []model.User
+	NotifiedPosts  []model.Post
+	NotifiedIds    []string
+	Tokens         []string
+}
+
+func (m *MockSubscriptionService) GetSubscription(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	return &model.SubscriptionStatus{EntityId: entityId, Following: len(m.FollowedBy) > 0}, m.MockStatusCode
+}
+func (m *MockSubscriptionService) Follow(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	m.FollowedBy = append(m.FollowedBy, user)
+	return &model.SubscriptionStatus{EntityId: entityId, Following: true}, m.MockStatusCode
+}
+func (m *MockSubscriptionService) Unfollow(entityId string, user model.User) (*model.SubscriptionStatus, int) {
+	return &model.SubscriptionStatus{EntityId: entityId}, m.MockStatusCode
+}
+func (m *MockSubscriptionService) GetTokenSubscription(token string) (*model.SubscriptionStatus, int) {
+	return &model.SubscriptionStatus{Following: true}, m.MockStatusCode
+}
+func (m *MockSubscriptionService) Unsubscribe(token string) (*model.SubscriptionStatus, int) {
+	m.Tokens = append(m.Tokens, token)
+	return &model.SubscriptionStatus{}, m.MockStatusCode
+}
+func (m *MockSubscriptionService) NotifyPost(entityId string, post model.Post) {
+	m.NotifiedPosts = append(m.NotifiedPosts, post)
+	m.NotifiedIds = append(m.NotifiedIds, entityId)
+}
+
+type MockIssueService struct {
+	MockPublisher  bool
+	MockIssue      *model.DataQualityIssue
+	MockStatusCode int
+	Transitions    []model.IssueTransition
+}
+
+func (m *MockIssueService) IsPublisher(entityId string, user model.User) (bool, int) {
+	return m.MockPublisher, m.MockStatusCode
+}
+func (m *MockIssueService) UpdateIssueStatus(entityId string, postId string, transition model.IssueTransition, user model.User) (*model.DataQualityIssue, int) {
+	m.Transitions = append(m.Transitions, transition)
+	return m.MockIssue, m.MockStatusCode
+}
+
+type MockGraphStoreService struct {
+	PushedPosts    []model.Post
+	DeletedPostIds []string
+	ChangedOwners  map[string]string
+}
+
+func (m *MockGraphStoreService) PushPost(threadId string, post model.Post) {
+	m.PushedPosts = append(m.PushedPosts, post)
+}
+func (m *MockGraphStoreService) DeletePost(postId string) {
+	m.DeletedPostIds = append(m.DeletedPostIds, postId)
+}
+func (m *MockGraphStoreService) ChangePostOwner(postId string, userId string) {
+	if m.ChangedOwners == nil {
+		m.ChangedOwners = map[string]string{}
+	}
+	m.ChangedOwners[postId] = userId
+}
+
+type MockAnnotationService struct {
+	MockTriples    []model.RdfTriple
+	MockStatusCode int
+}
+
+func (m *MockAnnotationService) GetAnnotations(entityId string) ([]model.RdfTriple, int) {
+	return m.MockTriples, m.MockStatusCode
+}
+
+type MockRatingService struct {
+	MockSummary    *model.RatingSummary
+	MockSummaries  map[string]model.RatingSummary
+	MockStatusCode int
+	Scores         []int
+	UserIds        []*string
+}
+
+func (m *MockRatingService) GetRatingSummary(entityId string, userId *string) (*model.RatingSummary, int) {
+	m.UserIds = append(m.UserIds, userId)
+	return m.MockSummary, m.MockStatusCode
+}
+func (m *MockRatingService) GetRatingSummaries(entityIds []string) (map[string]model.RatingSummary, int) {
+	return m.MockSummaries, m.MockStatusCode
+}
+func (m *MockRatingService) RateEntity(entityId string, userId string, score int) (*model.RatingSummary, int) {
+	m.Scores = append(m.Scores, score)
+	return m.MockSummary, m.MockStatusCode
+}
+func (m *MockRatingService) WithdrawRating(entityId string, userId string) (*model.RatingSummary, int) {
+	return m.MockSummary, m.MockStatusCode
+}
+
+type MockUsefulnessService struct {
+	MockSummary    *model.UsefulnessSummary
+	MockStatusCode int
+	EntityTypes    []model.EntityType
+	ClientIps      []string
+	Requests       []model.UsefulnessVoteRequest
+}
+
+func (m *MockUsefulnessService) GetEntitySummary(entityId string) (*model.UsefulnessSummary, int) {
+	return m.MockSummary, m.MockStatusCode
+}
+func (m *MockUsefulnessService) GetTypeSummary(entityType model.EntityType) (*model.UsefulnessSummary, int) {
+	m.EntityTypes = append(m.EntityTypes, entityType)
+	return m.MockSummary, m.MockStatusCode
+}
+func (m *MockUsefulnessService) Vote(entityId string, clientIp string, request model.UsefulnessVoteRequest) (*model.UsefulnessSummary, int) {
+	m.ClientIps = append(m.ClientIps, clientIp)
+	m.Requests = append(m.Requests, request)
+	return m.MockSummary, m.MockStatusCode
+}
+
+type MockStatisticsService struct {
+	MockStatistics []model.FeedbackStatistics
+	MockStatusCode int
+	IndexedPosts   []model.Post
+	RemovedPostIds []string
+	Queries        []model.StatisticsQuery
+}
+
+func (m *MockStatisticsService) IndexPost(entityId string, threadId string, post model.Post) {
+	m.IndexedPosts = append(m.IndexedPosts, post)
+}
+func (m *MockStatisticsService) RemovePost(postId string) {
+	m.RemovedPostIds = append(m.RemovedPostIds, postId)
+}
+func (m *MockStatisticsService) GetStatistics(query model.StatisticsQuery, user model.User) ([]model.FeedbackStatistics, int) {
+	m.Queries = append(m.Queries, query)
+	return m.MockStatistics, m.MockStatusCode
+}
+
+// MockErasureService answers ProcessErasure with MockProcessStatusCode,
+// recording the erasures processed.
+type MockErasureService struct {
+	MockTombstone         *model.ErasureTombstone
+	MockStatusCode        int
+	MockProcessStatusCode int
+	StartedUsers          []model.User
+	ProcessedIds          []string
+}
+
+func (m *MockErasureService) StartErasure(user model.User, requestedBy string) (*model.ErasureTombstone, int) {
+	m.StartedUsers = append(m.StartedUsers, user)
+	return m.MockTombstone, m.MockStatusCode
+}
+func (m *MockErasureService) ProcessErasure(erasureId string) (*model.ErasureTombstone, int) {
+	m.ProcessedIds = append(m.ProcessedIds, erasureId)
+	return m.MockTombstone, m.MockProcessStatusCode
+}
+func (m *MockErasureService) GetErasure(erasureId string) (*model.ErasureTombstone, int) {
+	return m.MockTombstone, m.MockStatusCode
+}
+
+type MockContentScreeningService struct {
+	MockViolations []model.ContentViolation
+	RecordedPosts  []model.Post
+}
+
+func (m *MockContentScreeningService) ScreenPost(post model.Post) []model.ContentViolation {
+	return m.MockViolations
+}
+func (m *MockContentScreeningService) RecordPost(post model.Post) {
+	m.RecordedPosts = append(m.RecordedPosts, post)
+}
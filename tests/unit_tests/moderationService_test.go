@@ -0,0 +1,120 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func moderationServiceMocks() (*MockPendingPostRepository, *MockThreadService, *service.ModerationServiceImpl) {
+	mockPendingPostRepository := MockPendingPostRepository{}
+	mockThreadService := MockThreadService{}
+
+	moderationService := service.ModerationServiceImpl{
+		PendingPostRepository: &mockPendingPostRepository,
+		ThreadService:         &mockThreadService,
+	}
+
+	return &mockPendingPostRepository, &mockThreadService, &moderationService
+}
+
+func TestApprovePendingPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	pending := model.PendingPost{PendingId: "pending", ThreadId: "1", UserId: "3", Content: "content"}
+
+	t.Run("Pending post not found", func(t *testing.T) {
+		_, _, moderationService := moderationServiceMocks()
+		expectedStatusCode := http.StatusNotFound
+
+		_, actualStatusCode := moderationService.ApprovePendingPost("pending")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Could not publish post", func(t *testing.T) {
+		mockPendingPostRepository, mockThreadService, moderationService := moderationServiceMocks()
+		mockPendingPostRepository.MockPendingPost = &pending
+		mockThreadService.MockStatusCode = http.StatusInternalServerError
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := moderationService.ApprovePendingPost("pending")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+		if len(mockPendingPostRepository.DeletedIds) != 0 {
+			t.Errorf("expected pending post to be kept. Got deleted %v", mockPendingPostRepository.DeletedIds)
+		}
+	})
+
+	t.Run("Successfully approves post", func(t *testing.T) {
+		mockPendingPostRepository, mockThreadService, moderationService := moderationServiceMocks()
+		postId := "2"
+		mockPendingPostRepository.MockPendingPost = &pending
+		mockThreadService.MockStatusCode = http.StatusCreated
+		mockThreadService.MockPost = &model.Post{PostId: &postId}
+		expectedStatusCode := http.StatusCreated
+
+		actualPost, actualStatusCode := moderationService.ApprovePendingPost("pending")
+
+		if actualPost != mockThreadService.MockPost || actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", mockThreadService.MockPost, expectedStatusCode, actualPost, actualStatusCode)
+		}
+		if len(mockPendingPostRepository.ApprovedUsers) != 1 || mockPendingPostRepository.ApprovedUsers[0] != pending.UserId {
+			t.Errorf("expected user %s to be approved. Got %v", pending.UserId, mockPendingPostRepository.ApprovedUsers)
+		}
+		if len(mockPendingPostRepository.DeletedIds) != 1 {
+			t.Errorf("expected pending post to be deleted. Got %v", mockPendingPostRepository.DeletedIds)
+		}
+	})
+}
+
+func TestRejectPendingPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Pending post not found", func(t *testing.T) {
+		_, _, moderationService := moderationServiceMocks()
+		expectedStatusCode := http.StatusNotFound
+
+		actualStatusCode := moderationService.RejectPendingPost("pending")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Could not look up pending post", func(t *testing.T) {
+		mockPendingPostRepository, _, moderationService := moderationServiceMocks()
+		mockPendingPostRepository.MockError = errors.New("testerror")
+		expectedStatusCode := http.StatusInternalServerError
+
+		actualStatusCode := moderationService.RejectPendingPost("pending")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Successfully rejects post", func(t *testing.T) {
+		mockPendingPostRepository, _, moderationService := moderationServiceMocks()
+		mockPendingPostRepository.MockPendingPost = &model.PendingPost{PendingId: "pending", UserId: "3"}
+		expectedStatusCode := http.StatusOK
+
+		actualStatusCode := moderationService.RejectPendingPost("pending")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+		if len(mockPendingPostRepository.ApprovedUsers) != 0 || len(mockPendingPostRepository.DeletedIds) != 1 {
+			t.Errorf("expected post deleted without approving user. Got %#v", mockPendingPostRepository)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"mime/quotedprintable"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestNotifyPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, botUid := "1", "2", "3", "22"
+	entity := model.Entity{EntityId: "entity", Title: "Stort testdatasett", PublisherId: "910244132", Type: model.Dataset, ContactEmail: "post@example.com"}
+	setUp := func(entity model.Entity) (*MockNotificationRepository, *MockNotifier, service.NotificationService) {
+		mockNotificationRepository := MockNotificationRepository{}
+		mockNotifier := MockNotifier{}
+		notificationService := service.NotificationServiceImpl{
+			NotificationRepository: &mockNotificationRepository,
+			Notifier:               &mockNotifier,
+			EntityService:          &MockEntityService{MockEntity: &entity},
+			ThreadBotUid:           botUid,
+			ThrottleWindow:         time.Hour,
+		}
+		return &mockNotificationRepository, &mockNotifier, &notificationService
+	}
+	post := model.Post{PostId: &postId, UserId: &userId, ThreadId: &threadId}
+
+	t.Run("Emails contact point once within the window", func(t *testing.T) {
+		mockNotificationRepository, mockNotifier, notificationService := setUp(entity)
+
+		notificationService.NotifyPost(entity.EntityId, post)
+		notificationService.NotifyPost(entity.EntityId, post)
+
+		if len(mockNotifier.Notifications) != 1 {
+			t.Fatalf("expected 1 mail. Got %#v", mockNotifier.Notifications)
+		}
+		mail := mockNotifier.Notifications[0]
+		if mail.Email != entity.ContactEmail || !strings.Contains(mail.Subject, entity.Title) || !strings.Contains(mail.Body, "datasettet Stort testdatasett") {
+			t.Errorf("unexpected mail %#v", mail)
+		}
+		for key := range mockNotificationRepository.Claims {
+			if strings.Contains(key, "@") {
+				t.Errorf("expected contact point not to be stored. Got %s", key)
+			}
+		}
+	})
+
+	t.Run("Releases the claim when the mail fails", func(t *testing.T) {
+		mockNotificationRepository, mockNotifier, notificationService := setUp(entity)
+		mockNotifier.MockError = errors.New("error")
+
+		notificationService.NotifyPost(entity.EntityId, post)
+		if len(mockNotificationRepository.Claims) != 0 {
+			t.Fatalf("expected claim to be released. Got %v", mockNotificationRepository.Claims)
+		}
+
+		mockNotifier.MockError = nil
+		notificationService.NotifyPost(entity.EntityId, post)
+		if len(mockNotifier.Notifications) != 2 || len(mockNotificationRepository.Claims) != 1 {
+			t.Errorf("expected the next post to be notified. Got %#v, %v", mockNotifier.Notifications, mockNotificationRepository.Claims)
+		}
+	})
+
+	var skippedTests = []struct {
+		testName string
+		entity   model.Entity
+		post     model.Post
+		settings *model.NotificationSettings
+	}{
+		{"Entity without contact point", model.Entity{EntityId: "entity", Title: "Tittel", PublisherId: "910244132"}, post, nil},
+		{"Turned off by publisher", entity, post, &model.NotificationSettings{Organization: "910244132", Enabled: false}},
+		{"Post of thread bot", entity, model.Post{PostId: &postId, UserId: &botUid}, nil},
+		{"Official answer", entity, model.Post{PostId: &postId, UserId: &userId, OfficialAnswer: true}, nil},
+	}
+
+	for _, test := range skippedTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockNotificationRepository, mockNotifier, notificationService := setUp(test.entity)
+			mockNotificationRepository.MockSettings = test.settings
+
+			notificationService.NotifyPost(entity.EntityId, test.post)
+
+			if len(mockNotifier.Notifications) != 0 {
+				t.Errorf("expected no mail. Got %#v", mockNotifier.Notifications)
+			}
+		})
+	}
+
+	t.Run("Turned on by publisher", func(t *testing.T) {
+		mockNotificationRepository, mockNotifier, notificationService := setUp(entity)
+		mockNotificationRepository.MockSettings = &model.NotificationSettings{Organization: "910244132", Enabled: true}
+
+		notificationService.NotifyPost(entity.EntityId, post)
+
+		if len(mockNotifier.Notifications) != 1 {
+			t.Errorf("expected 1 mail. Got %#v", mockNotifier.Notifications)
+		}
+	})
+
+	t.Run("Without mail server", func(t *testing.T) {
+		notificationService := service.NotificationServiceImpl{}
+
+		notificationService.NotifyPost(entity.EntityId, post)
+	})
+}
+
+func TestNotificationSettings(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	admin := model.User{UserId: &userId, AdminOrganizations: []string{"910244132"}}
+
+	var settingsTests = []struct {
+		testName           string
+		user               model.User
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{"Admin of organization", admin, nil, http.StatusOK},
+		{"Moderator", model.User{UserId: &userId, Moderator: true}, nil, http.StatusOK},
+		{"Writer for organization", model.User{UserId: &userId, Organizations: []string{"910244132"}}, nil, http.StatusForbidden},
+		{"Repository error", admin, errors.New("error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range settingsTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockNotificationRepository := MockNotificationRepository{MockError: test.repositoryError}
+			notificationService := service.NotificationServiceImpl{NotificationRepository: &mockNotificationRepository}
+
+			settings, actualStatusCode := notificationService.GetSettings("910244132", test.user)
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if actualStatusCode == http.StatusOK && (!settings.Enabled || settings.Organization != "910244132") {
+				t.Errorf("expected notifications to be on by default. Got %#v", settings)
+			}
+
+			updated, actualStatusCode := notificationService.UpdateSettings(model.NotificationSettings{Organization: "910244132"}, test.user)
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if actualStatusCode == http.StatusOK && (updated.Enabled || updated.UpdatedBy != userId || updated.Timestamp == 0) {
+				t.Errorf("expected notifications turned off by user. Got %#v", updated)
+			}
+			if test.expectedStatusCode == http.StatusForbidden && len(mockNotificationRepository.SavedSettings) != 0 {
+				t.Errorf("expected no settings to be saved. Got %#v", mockNotificationRepository.SavedSettings)
+			}
+		})
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	smtpServer := tests.NewMockSmtpServer()
+	defer smtpServer.Close()
+
+	mailRepository := repository.MailRepositoryImpl{
+		SmtpHost: smtpServer.Host,
+		SmtpPort: smtpServer.Port,
+		From:     "noreply@example.com",
+		Timeout:  time.Second,
+	}
+
+	t.Run("Sends mail to the server", func(t *testing.T) {
+		err := mailRepository.SendMail(model.MailMessage{To: "post@example.com", Subject: "Ny tilbakemelding på Ære", Body: "Første linje\nAndre linje"})
+		if err != nil {
+			t.Fatalf("expected no error. Got %v", err)
+		}
+
+		mails := smtpServer.Mails()
+		if len(mails) != 1 || mails[0].From != "noreply@example.com" || len(mails[0].To) != 1 || mails[0].To[0] != "post@example.com" {
+			t.Fatalf("expected 1 mail to contact point. Got %#v", mails)
+		}
+		header, body, _ := strings.Cut(mails[0].Data, "\n\n")
+		if !strings.Contains(header, "Subject: =?utf-8?q?Ny_tilbakemelding_p=C3=A5_=C3=86re?=") || !strings.Contains(header, "Auto-Submitted: auto-generated") {
+			t.Errorf("unexpected header %q", header)
+		}
+		decoded, _ := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+		if strings.TrimSuffix(string(decoded), "\n") != "Første linje\nAndre linje" {
+			t.Errorf("unexpected body %q", decoded)
+		}
+	})
+
+	t.Run("Invalid address", func(t *testing.T) {
+		for _, to := range []string{"", "Ola <post@example.com>", "post@example.com\r\nBcc: other@example.com"} {
+			if err := mailRepository.SendMail(model.MailMessage{To: to}); err != util.ErrInvalidMailAddress {
+				t.Errorf("expected invalid address for %q. Got %v", to, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package unit_tests
+
+import (
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestFindPersonalData(t *testing.T) {
+	var personalDataTests = []struct {
+		testName      string
+		content       string
+		expectedKinds []model.PersonalDataKind
+		expectedMask  string
+	}{
+		{"No personal data", "Datasettet ble oppdatert 2020-01-01 med 1234 rader.", nil, "Datasettet ble oppdatert 2020-01-01 med 1234 rader."},
+		{"Fødselsnummer", "Mitt fnr er 31129998787.", []model.PersonalDataKind{model.BirthNumber}, "Mitt fnr er [fjernet]."},
+		{"Fødselsnummer with space", "fnr 311299 98787", []model.PersonalDataKind{model.BirthNumber}, "fnr [fjernet]"},
+		{"D-number", "D-nummer: 41019012393", []model.PersonalDataKind{model.DNumber}, "D-nummer: [fjernet]"},
+		{"Invalid control digits", "Nummer 31129998788 er ikke gyldig.", nil, "Nummer 31129998788 er ikke gyldig."},
+		{"Bank account", "Betal til 1503.12.34562 snarest", []model.PersonalDataKind{model.BankAccount}, "Betal til [fjernet] snarest"},
+		{"Email", "Kontakt ola.nordmann@example.no for tilgang", []model.PersonalDataKind{model.EmailAddress}, "Kontakt [fjernet] for tilgang"},
+		{"Phone numbers", "Ring 412 34 567 eller +47 22 33 44 55", []model.PersonalDataKind{model.PhoneNumber, model.PhoneNumber}, "Ring [fjernet] eller [fjernet]"},
+		{"Phone number with country code", "Tlf: +4741234567", []model.PersonalDataKind{model.PhoneNumber}, "Tlf: [fjernet]"},
+		{"Phone number with international prefix", "Tlf: 0047 41234567", []model.PersonalDataKind{model.PhoneNumber}, "Tlf: [fjernet]"},
+		{"Longer numbers are not phone numbers", "Org.nr 987654321", nil, "Org.nr 987654321"},
+		{"Ungrouped eight digit numbers are not phone numbers", "Saksnummer 20231234 gjelder 45000000 kroner", nil, "Saksnummer 20231234 gjelder 45000000 kroner"},
+	}
+
+	for _, test := range personalDataTests {
+		t.Run(test.testName, func(t *testing.T) {
+			matches := util.FindPersonalData(test.content)
+
+			var actualKinds []model.PersonalDataKind
+			for _, match := range matches {
+				actualKinds = append(actualKinds, match.Kind)
+			}
+			if len(actualKinds) != len(test.expectedKinds) {
+				t.Fatalf("expected %v. Got %v", test.expectedKinds, actualKinds)
+			}
+			for i := range actualKinds {
+				if actualKinds[i] != test.expectedKinds[i] {
+					t.Fatalf("expected %v. Got %v", test.expectedKinds, actualKinds)
+				}
+			}
+
+			if actualMask := util.MaskPersonalData(test.content, matches); actualMask != test.expectedMask {
+				t.Errorf("expected %q. Got %q", test.expectedMask, actualMask)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func TestRatingSummary(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	ratings := []model.Rating{
+		{EntityId: "a", UserId: "1", Score: 5},
+		{EntityId: "a", UserId: "2", Score: 4},
+		{EntityId: "a", UserId: userId, Score: 4},
+		{EntityId: "b", UserId: userId, Score: 1},
+	}
+	setUp := func(err error) (*MockRatingRepository, service.RatingService) {
+		mockRatingRepository := MockRatingRepository{MockRatings: ratings, MockError: err}
+		return &mockRatingRepository, &service.RatingServiceImpl{RatingRepository: &mockRatingRepository}
+	}
+
+	t.Run("Aggregates ratings with the score of the user", func(t *testing.T) {
+		_, ratingService := setUp(nil)
+
+		summary, statusCode := ratingService.GetRatingSummary("a", &userId)
+
+		if statusCode != http.StatusOK || summary.Count != 3 || summary.Mean != 4.33 {
+			t.Fatalf("expected 3 ratings with mean 4.33. Got %#v, %d", summary, statusCode)
+		}
+		if !reflect.DeepEqual(summary.Distribution, map[int]int{1: 0, 2: 0, 3: 0, 4: 2, 5: 1}) {
+			t.Errorf("unexpected distribution %v", summary.Distribution)
+		}
+		if summary.UserScore == nil || *summary.UserScore != 4 {
+			t.Errorf("expected user score 4. Got %v", summary.UserScore)
+		}
+	})
+
+	t.Run("Anonymous readers have no score", func(t *testing.T) {
+		_, ratingService := setUp(nil)
+
+		summary, _ := ratingService.GetRatingSummary("a", nil)
+
+		if summary.UserScore != nil {
+			t.Errorf("expected no user score. Got %d", *summary.UserScore)
+		}
+	})
+
+	t.Run("Summarizes every entity in one lookup", func(t *testing.T) {
+		mockRatingRepository, ratingService := setUp(nil)
+
+		summaries, statusCode := ratingService.GetRatingSummaries([]string{"a", "b", "c"})
+
+		if statusCode != http.StatusOK || len(mockRatingRepository.EntityIdsRead) != 1 {
+			t.Fatalf("expected a single lookup. Got %d lookups, %d", len(mockRatingRepository.EntityIdsRead), statusCode)
+		}
+		if summaries["a"].Count != 3 || summaries["b"].Mean != 1 || summaries["c"].Count != 0 || len(summaries["c"].Distribution) != 5 {
+			t.Errorf("unexpected summaries %#v", summaries)
+		}
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		_, ratingService := setUp(errors.New("error"))
+
+		_, statusCode := ratingService.GetRatingSummaries([]string{"a"})
+
+		if statusCode != http.StatusInternalServerError {
+			t.Fatalf("expected %d. Got %d", http.StatusInternalServerError, statusCode)
+		}
+	})
+}
+
+func TestRateEntity(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	var rateTests = []struct {
+		testName           string
+		score              int
+		entityError        error
+		expectedStatusCode int
+		expectedSaved      int
+	}{
+		{"Rates entity", 4, nil, http.StatusOK, 1},
+		{"Score too low", 0, nil, http.StatusBadRequest, 0},
+		{"Score too high", 6, nil, http.StatusBadRequest, 0},
+		{"Unknown entity", 3, errors.New("entity not found"), http.StatusNotFound, 0},
+	}
+
+	for _, test := range rateTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRatingRepository := MockRatingRepository{}
+			ratingService := service.RatingServiceImpl{
+				RatingRepository: &mockRatingRepository,
+				EntityService:    &MockEntityService{MockEntity: &model.Entity{EntityId: "a"}, MockError: test.entityError},
+			}
+
+			_, statusCode := ratingService.RateEntity("a", userId, test.score)
+
+			if statusCode != test.expectedStatusCode || len(mockRatingRepository.SavedRatings) != test.expectedSaved {
+				t.Fatalf("expected %d, %d saved. Got %d, %d saved", test.expectedStatusCode, test.expectedSaved, statusCode, len(mockRatingRepository.SavedRatings))
+			}
+			if test.expectedSaved > 0 {
+				saved := mockRatingRepository.SavedRatings[0]
+				if saved.EntityId != "a" || saved.UserId != userId || saved.Score != test.score || saved.Timestamp == 0 {
+					t.Errorf("unexpected rating %#v", saved)
+				}
+			}
+		})
+	}
+
+	t.Run("Withdraws rating", func(t *testing.T) {
+		mockRatingRepository := MockRatingRepository{}
+		ratingService := service.RatingServiceImpl{RatingRepository: &mockRatingRepository}
+
+		summary, statusCode := ratingService.WithdrawRating("a", userId)
+
+		if statusCode != http.StatusOK || summary.UserScore != nil || !reflect.DeepEqual(mockRatingRepository.DeletedUsers, []string{userId}) {
+			t.Fatalf("expected rating of user to be deleted. Got %#v, %d", summary, statusCode)
+		}
+	})
+}
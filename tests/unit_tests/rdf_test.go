@@ -0,0 +1,130 @@
+package unit_tests
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestNegotiateRdfFormat(t *testing.T) {
+	var tests = []struct {
+		testName       string
+		format         string
+		accept         string
+		expectedFormat model.RdfFormat
+		expectedOk     bool
+	}{
+		{"Defaults to Turtle", "", "", model.Turtle, true},
+		{"Any media type", "", "*/*", model.Turtle, true},
+		{"JSON-LD", "", "application/ld+json", model.JsonLd, true},
+		{"RDF/XML", "", "application/rdf+xml", model.RdfXml, true},
+		{"Highest quality", "", "text/turtle;q=0.5, application/rdf+xml;q=0.9, text/html", model.RdfXml, true},
+		{"Zero quality", "", "application/ld+json;q=0", "", false},
+		{"Unsupported media type", "", "text/html", "", false},
+		{"Format parameter overrides Accept", "jsonld", "text/turtle", model.JsonLd, true},
+		{"Unknown format parameter", "n3", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			actualFormat, actualOk := util.NegotiateRdfFormat(test.format, test.accept)
+			if actualFormat != test.expectedFormat || actualOk != test.expectedOk {
+				t.Errorf("expected %q %v, got %q %v", test.expectedFormat, test.expectedOk, actualFormat, actualOk)
+			}
+		})
+	}
+}
+
+func TestWriteRdf(t *testing.T) {
+	annotation := model.Iri("https://community.example.com/post/1")
+	body := model.BlankNode("body1")
+	triples := []model.RdfTriple{
+		{Subject: annotation, Predicate: model.RdfType, Object: model.Iri(model.OaNamespace + "Annotation")},
+		{Subject: annotation, Predicate: model.OaNamespace + "hasTarget", Object: model.Iri("https://data.example.com/datasets/<1>")},
+		{Subject: annotation, Predicate: model.OaNamespace + "hasBody", Object: body},
+		{Subject: annotation, Predicate: model.DctNamespace + "created", Object: model.TypedLiteral("2023-11-14T22:13:20Z", model.XsdDateTime)},
+		{Subject: body, Predicate: model.RdfNamespace + "value", Object: model.Literal("Si \"hei\" & <b>ha det</b>\n")},
+	}
+
+	t.Run("Turtle", func(t *testing.T) {
+		var builder strings.Builder
+		if err := util.WriteRdf(&builder, model.Turtle, triples); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "\n<https://community.example.com/post/1>" +
+			"\n    a oa:Annotation ;" +
+			"\n    oa:hasTarget <https://data.example.com/datasets/\\u003C1\\u003E> ;" +
+			"\n    oa:hasBody _:body1 ;" +
+			"\n    dct:created \"2023-11-14T22:13:20Z\"^^xsd:dateTime ." +
+			"\n" +
+			"\n_:body1" +
+			"\n    rdf:value \"Si \\\"hei\\\" & <b>ha det</b>\\n\" .\n"
+		if !strings.HasPrefix(builder.String(), "@prefix rdf: <"+model.RdfNamespace+"> .\n") {
+			t.Errorf("expected prefixes first, got %s", builder.String())
+		}
+		if !strings.HasSuffix(builder.String(), expected) {
+			t.Errorf("expected %q, got %q", expected, builder.String())
+		}
+	})
+
+	t.Run("JSON-LD", func(t *testing.T) {
+		var builder strings.Builder
+		if err := util.WriteRdf(&builder, model.JsonLd, triples); err != nil {
+			t.Fatal(err)
+		}
+
+		var document struct {
+			Context map[string]string `json:"@context"`
+			Graph   []map[string]any  `json:"@graph"`
+		}
+		if err := json.Unmarshal([]byte(builder.String()), &document); err != nil {
+			t.Fatal(err)
+		}
+		if document.Context["oa"] != model.OaNamespace || len(document.Graph) != 2 {
+			t.Fatalf("expected context and two nodes, got %s", builder.String())
+		}
+		node := document.Graph[0]
+		if node["@id"] != annotation.Value || node["@type"] != "oa:Annotation" {
+			t.Errorf("expected annotation node, got %v", node)
+		}
+		if body, ok := node["oa:hasBody"].(map[string]any); !ok || body["@id"] != "_:body1" {
+			t.Errorf("expected body reference, got %v", node["oa:hasBody"])
+		}
+		if created, ok := node["dct:created"].(map[string]any); !ok || created["@type"] != "xsd:dateTime" {
+			t.Errorf("expected typed creation time, got %v", node["dct:created"])
+		}
+	})
+
+	t.Run("RDF/XML", func(t *testing.T) {
+		var builder strings.Builder
+		if err := util.WriteRdf(&builder, model.RdfXml, triples); err != nil {
+			t.Fatal(err)
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(builder.String()))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					t.Fatalf("expected well-formed XML, got %v in %s", err, builder.String())
+				}
+				break
+			}
+		}
+		for _, expected := range []string{
+			`<rdf:Description rdf:about="https://community.example.com/post/1">`,
+			`<oa:hasBody rdf:nodeID="body1"/>`,
+			`<dct:created rdf:datatype="` + model.XsdDateTime + `">2023-11-14T22:13:20Z</dct:created>`,
+			`<rdf:Description rdf:nodeID="body1">`,
+		} {
+			if !strings.Contains(builder.String(), expected) {
+				t.Errorf("expected %s in %s", expected, builder.String())
+			}
+		}
+	})
+}
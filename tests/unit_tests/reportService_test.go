@@ -0,0 +1,196 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func reportServiceMocks() (*MockReportRepository, *MockThreadRepository, *MockThreadService, *MockEntityService, *service.ReportServiceImpl) {
+	mockReportRepository := MockReportRepository{}
+	mockThreadRepository := MockThreadRepository{}
+	mockThreadService := MockThreadService{}
+	mockEntityService := MockEntityService{}
+
+	reportService := service.ReportServiceImpl{
+		ReportRepository: &mockReportRepository,
+		ThreadRepository: &mockThreadRepository,
+		ThreadService:    &mockThreadService,
+		EntityService:    &mockEntityService,
+		HideThreshold:    3,
+		ModeratorUid:     "bot",
+		MaxTextLength:    10,
+	}
+
+	return &mockReportRepository, &mockThreadRepository, &mockThreadService, &mockEntityService, &reportService
+}
+
+func TestReportPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId := "1", "2"
+	report := model.PostReport{ReporterUid: "3", Reason: model.ReportSpam, Text: "buy now"}
+
+	var validationTests = []struct {
+		testName string
+		report   model.PostReport
+	}{
+		{"Unknown reason", model.PostReport{ReporterUid: "3", Reason: "boring"}},
+		{"Missing reporter", model.PostReport{Reason: model.ReportSpam}},
+		{"Text too long", model.PostReport{ReporterUid: "3", Reason: model.ReportOther, Text: strings.Repeat("a", 11)}},
+	}
+
+	for _, test := range validationTests {
+		t.Run(test.testName, func(t *testing.T) {
+			_, _, _, _, reportService := reportServiceMocks()
+			expectedStatusCode := http.StatusBadRequest
+
+			_, actualStatusCode := reportService.ReportPost("entity", postId, test.report)
+
+			if actualStatusCode != expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+			}
+		})
+	}
+
+	t.Run("Post not found", func(t *testing.T) {
+		_, _, mockThreadService, _, reportService := reportServiceMocks()
+		mockThreadService.MockStatusCode = http.StatusNotFound
+		expectedStatusCode := http.StatusNotFound
+
+		_, actualStatusCode := reportService.ReportPost("entity", postId, report)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Duplicate report", func(t *testing.T) {
+		mockReportRepository, _, mockThreadService, _, reportService := reportServiceMocks()
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId, ThreadId: &threadId}
+		mockReportRepository.MockError = model.ErrDuplicateReport
+		expectedStatusCode := http.StatusConflict
+
+		_, actualStatusCode := reportService.ReportPost("entity", postId, report)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Reports below threshold keep post", func(t *testing.T) {
+		mockReportRepository, mockThreadRepository, mockThreadService, _, reportService := reportServiceMocks()
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId, ThreadId: &threadId}
+		mockReportRepository.MockCount = 2
+		expectedStatusCode := http.StatusCreated
+
+		actualReport, actualStatusCode := reportService.ReportPost("entity", postId, report)
+
+		if actualStatusCode != expectedStatusCode || actualReport.Status != model.ReportOpen || actualReport.ThreadId != threadId {
+			t.Fatalf("expected open report and status code: %d. Got: %#v, %d", expectedStatusCode, actualReport, actualStatusCode)
+		}
+		if len(mockThreadRepository.DeletedPosts) != 0 {
+			t.Errorf("expected post to be kept, got deletions %#v", mockThreadRepository.DeletedPosts)
+		}
+	})
+
+	t.Run("Reports at threshold hide post", func(t *testing.T) {
+		mockReportRepository, mockThreadRepository, mockThreadService, _, reportService := reportServiceMocks()
+		mockThreadService.MockStatusCode = http.StatusOK
+		mockThreadService.MockPost = &model.Post{PostId: &postId, ThreadId: &threadId}
+		mockReportRepository.MockCount = 3
+
+		_, actualStatusCode := reportService.ReportPost("entity", postId, report)
+
+		if actualStatusCode != http.StatusCreated {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusCreated, actualStatusCode)
+		}
+		if len(mockThreadService.HiddenPosts) != 1 || mockThreadService.HiddenPosts[0] != postId+"_bot" {
+			t.Errorf("expected post to be hidden by moderator uid, got %v", mockThreadService.HiddenPosts)
+		}
+		if len(mockThreadRepository.DeletedPosts) != 0 {
+			t.Errorf("expected post to be hidden through the thread service, got deletions %#v", mockThreadRepository.DeletedPosts)
+		}
+	})
+}
+
+func TestResolveReports(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	var resolveTests = []struct {
+		testName           string
+		reports            []model.PostReport
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{"Resolves open reports", []model.PostReport{{PostId: "2", ReporterUid: "3", Status: model.ReportResolved}}, nil, http.StatusOK},
+		{"No open reports", []model.PostReport{}, nil, http.StatusNotFound},
+		{"Repository error", nil, errors.New("test error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range resolveTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockReportRepository, _, _, _, reportService := reportServiceMocks()
+			mockReportRepository.MockReports = test.reports
+			mockReportRepository.MockError = test.repositoryError
+
+			reports, actualStatusCode := reportService.ResolveReports("2", "moderator")
+
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if len(mockReportRepository.ResolvedPosts) != 1 || mockReportRepository.ResolvedPosts[0] != "2_moderator" {
+				t.Errorf("expected reports of post to be resolved by moderator. Got %v", mockReportRepository.ResolvedPosts)
+			}
+			if actualStatusCode == http.StatusOK && len(reports) != 1 {
+				t.Errorf("expected resolved reports. Got %#v", reports)
+			}
+		})
+	}
+}
+
+func TestGetReportedPosts(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Handles report repository error", func(t *testing.T) {
+		mockReportRepository, _, _, _, reportService := reportServiceMocks()
+		mockReportRepository.MockError = errors.New("test error")
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := reportService.GetReportedPosts()
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Groups reports by post, most reported first", func(t *testing.T) {
+		mockReportRepository, mockThreadRepository, _, mockEntityService, reportService := reportServiceMocks()
+		postId := "2"
+		mockReportRepository.MockReports = []model.PostReport{
+			{PostId: "1", EntityId: "a", ReporterUid: "3"},
+			{PostId: "2", EntityId: "b", ReporterUid: "3"},
+			{PostId: "2", EntityId: "b", ReporterUid: "4"},
+		}
+		mockThreadRepository.MockGetPost = &model.Post{PostId: &postId}
+		mockEntityService.MockEntity = &model.Entity{EntityId: "b", Title: "Datasett"}
+
+		actualReportedPosts, actualStatusCode := reportService.GetReportedPosts()
+
+		if actualStatusCode != http.StatusOK || len(actualReportedPosts) != 2 {
+			t.Fatalf("expected two reported posts. Got: %#v, %d", actualReportedPosts, actualStatusCode)
+		}
+		first := actualReportedPosts[0]
+		if first.EntityId != "b" || len(first.Reports) != 2 || first.Post == nil || first.Entity == nil {
+			t.Errorf("expected post 2 with both reports, post and entity first. Got: %#v", first)
+		}
+	})
+}
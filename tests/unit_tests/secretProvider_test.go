@@ -0,0 +1,66 @@
+package unit_tests
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+)
+
+func TestFileSecretProvider(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Reads secret from file", func(t *testing.T) {
+		directory := t.TempDir()
+		os.WriteFile(filepath.Join(directory, secret.ReadApiToken), []byte("read-token\n"), 0600)
+
+		provider := secret.NewFileSecretProvider(directory, nil)
+
+		actual, err := provider.GetSecret(secret.ReadApiToken)
+		if err != nil || actual != "read-token" {
+			t.Fatalf("expected secret %s. Got %s, %v", "read-token", actual, err)
+		}
+	})
+
+	t.Run("Reloads rotated secret", func(t *testing.T) {
+		directory := t.TempDir()
+		path := filepath.Join(directory, secret.WriteApiToken)
+		os.WriteFile(path, []byte("first"), 0600)
+
+		provider := secret.NewFileSecretProvider(directory, nil)
+		provider.GetSecret(secret.WriteApiToken)
+
+		os.WriteFile(path, []byte("second-token"), 0600)
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(path, later, later)
+
+		actual, err := provider.GetSecret(secret.WriteApiToken)
+		if err != nil || actual != "second-token" {
+			t.Fatalf("expected secret %s. Got %s, %v", "second-token", actual, err)
+		}
+	})
+
+	t.Run("Falls back to environment", func(t *testing.T) {
+		t.Setenv(secret.ReadApiToken, "env-token")
+
+		provider := secret.NewFileSecretProvider(t.TempDir(), &secret.EnvSecretProvider{})
+
+		actual, err := provider.GetSecret(secret.ReadApiToken)
+		if err != nil || actual != "env-token" {
+			t.Fatalf("expected secret %s. Got %s, %v", "env-token", actual, err)
+		}
+	})
+
+	t.Run("Missing secret", func(t *testing.T) {
+		provider := secret.NewFileSecretProvider("", nil)
+
+		_, err := provider.GetSecret(secret.ReadApiToken)
+		if err != secret.ErrSecretNotFound {
+			t.Fatalf("expected error %v. Got %v", secret.ErrSecretNotFound, err)
+		}
+	})
+}
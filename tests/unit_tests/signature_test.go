@@ -0,0 +1,44 @@
+package unit_tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestSignToken(t *testing.T) {
+	key := []byte("key")
+	token := util.SignToken(key, "purpose", "a", "b")
+
+	var tests = []struct {
+		testName       string
+		key            []byte
+		token          string
+		expectedFields []string
+		expectedOk     bool
+	}{
+		{"Signed token", key, token, []string{"purpose", "a", "b"}, true},
+		{"Other key", []byte("other"), token, nil, false},
+		{"No key", nil, token, nil, false},
+		{"Changed payload", key, strings.Split(util.SignToken(key, "purpose", "a", "c"), ".")[0] + "." + strings.Split(token, ".")[1], nil, false},
+		{"Missing signature", key, strings.Split(token, ".")[0], nil, false},
+		{"Not base64", key, "not base64!." + strings.Split(token, ".")[1], nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			fields, ok := util.VerifyToken(test.key, test.token)
+			if ok != test.expectedOk || !reflect.DeepEqual(fields, test.expectedFields) {
+				t.Errorf("expected %v %t, got %v %t", test.expectedFields, test.expectedOk, fields, ok)
+			}
+		})
+	}
+
+	t.Run("Pseudonyms depend on key", func(t *testing.T) {
+		if util.Pseudonym(key, "192.0.2.1") != util.Pseudonym(key, "192.0.2.1") || util.Pseudonym(key, "192.0.2.1") == util.Pseudonym([]byte("other"), "192.0.2.1") {
+			t.Errorf("expected the same pseudonym for the same key only")
+		}
+	})
+}
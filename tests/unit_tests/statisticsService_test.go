@@ -0,0 +1,170 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+)
+
+func TestIndexPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, botUid, toPostId := "1", "10", "3", "22", "9"
+	created := 1700000000000
+	setUp := func(entityError error) (*MockPostIndexRepository, *service.StatisticsServiceImpl) {
+		mockPostIndexRepository := MockPostIndexRepository{}
+		return &mockPostIndexRepository, &service.StatisticsServiceImpl{
+			PostIndexRepository: &mockPostIndexRepository,
+			EntityService:       &MockEntityService{MockEntity: &model.Entity{EntityId: "entity", PublisherId: "910244132", Type: model.Dataset}, MockError: entityError},
+			ThreadBotUid:        botUid,
+		}
+	}
+
+	t.Run("Indexes post with organization and type of entity", func(t *testing.T) {
+		mockPostIndexRepository, statisticsService := setUp(nil)
+
+		statisticsService.IndexPost("entity", threadId, model.Post{PostId: &postId, UserId: &userId, ToPostId: &toPostId, Timestamp: &created, OfficialAnswer: true})
+
+		expected := []model.IndexedPost{{
+			PostId:         postId,
+			ThreadId:       threadId,
+			EntityId:       "entity",
+			Organization:   "910244132",
+			EntityType:     "dataset",
+			ToPostId:       toPostId,
+			OfficialAnswer: true,
+			Timestamp:      int64(created),
+		}}
+		if !reflect.DeepEqual(mockPostIndexRepository.SavedPosts, expected) {
+			t.Fatalf("expected %#v. Got %#v", expected, mockPostIndexRepository.SavedPosts)
+		}
+	})
+
+	t.Run("Skips posts of the thread bot", func(t *testing.T) {
+		mockPostIndexRepository, statisticsService := setUp(nil)
+
+		statisticsService.IndexPost("entity", threadId, model.Post{PostId: &postId, UserId: &botUid})
+
+		if len(mockPostIndexRepository.SavedPosts) != 0 {
+			t.Errorf("expected no posts to be indexed")
+		}
+	})
+
+	t.Run("Skips posts of unknown entities", func(t *testing.T) {
+		mockPostIndexRepository, statisticsService := setUp(errors.New("entity not found"))
+
+		statisticsService.IndexPost("entity", threadId, model.Post{PostId: &postId, UserId: &userId})
+
+		if len(mockPostIndexRepository.SavedPosts) != 0 {
+			t.Errorf("expected no posts to be indexed")
+		}
+	})
+}
+
+func TestGetStatistics(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	millis := func(date string, hours int) int64 {
+		day, _ := time.Parse(time.DateOnly, date)
+		return day.Add(time.Duration(hours) * time.Hour).UnixMilli()
+	}
+	posts := []model.IndexedPost{
+		{PostId: "1", ThreadId: "a", Organization: "1", EntityType: "dataset", Timestamp: millis("2026-08-30", 0)},
+		{PostId: "2", ThreadId: "a", Organization: "1", EntityType: "dataset", Timestamp: millis("2026-09-01", 0)},
+		{PostId: "3", ThreadId: "a", Organization: "1", EntityType: "dataset", ToPostId: "2", OfficialAnswer: true, Timestamp: millis("2026-09-01", 4)},
+		{PostId: "4", ThreadId: "a", Organization: "1", EntityType: "dataset", ToPostId: "2", OfficialAnswer: true, Timestamp: millis("2026-09-02", 0)},
+		{PostId: "5", ThreadId: "b", Organization: "1", EntityType: "dataset", Timestamp: millis("2026-09-10", 0)},
+		{PostId: "6", ThreadId: "b", Organization: "1", EntityType: "dataset", ToPostId: "5", OfficialAnswer: true, Timestamp: millis("2026-10-01", 2)},
+		{PostId: "7", ThreadId: "c", Organization: "1", EntityType: "concept", Timestamp: millis("2026-09-15", 0)},
+		{PostId: "8", ThreadId: "d", Organization: "2", EntityType: "dataset", Timestamp: millis("2026-09-15", 0)},
+	}
+	from, _ := time.Parse(time.DateOnly, "2026-09-01")
+	to, _ := time.Parse(time.DateOnly, "2026-10-01")
+	moderator := model.User{Moderator: true}
+	hours := func(h int64) *int64 {
+		millis := h * time.Hour.Milliseconds()
+		return &millis
+	}
+
+	var statisticsTests = []struct {
+		testName           string
+		query              model.StatisticsQuery
+		user               model.User
+		expectedStatusCode int
+		expected           []model.FeedbackStatistics
+	}{
+		{
+			"Per organization, type and month",
+			model.StatisticsQuery{Period: model.MonthPeriod, From: from, To: to},
+			moderator,
+			http.StatusOK,
+			[]model.FeedbackStatistics{
+				{Organization: "1", EntityType: "concept", Period: "2026-09", Posts: 1, Threads: 1},
+				{Organization: "1", EntityType: "dataset", Period: "2026-09", Posts: 2, Threads: 2, OfficialAnswers: 2, Answered: 2, MeanResponseTime: hours(255), MedianResponseTime: hours(255)},
+				{Organization: "2", EntityType: "dataset", Period: "2026-09", Posts: 1, Threads: 1},
+			},
+		},
+		{
+			"Per week for an organization and type",
+			model.StatisticsQuery{Organization: "1", EntityType: "dataset", Period: model.WeekPeriod, From: from, To: to},
+			model.User{Organizations: []string{"1"}},
+			http.StatusOK,
+			[]model.FeedbackStatistics{
+				{Organization: "1", EntityType: "dataset", Period: "2026-W36", Posts: 1, Threads: 1, OfficialAnswers: 2, Answered: 1, MeanResponseTime: hours(4), MedianResponseTime: hours(4)},
+				{Organization: "1", EntityType: "dataset", Period: "2026-W37", Posts: 1, Threads: 1, Answered: 1, MeanResponseTime: hours(506), MedianResponseTime: hours(506)},
+			},
+		},
+		{"Publisher of other organization", model.StatisticsQuery{Organization: "2", From: from, To: to}, model.User{Organizations: []string{"1"}}, http.StatusForbidden, nil},
+		{"Publisher without organization", model.StatisticsQuery{From: from, To: to}, model.User{Organizations: []string{"1"}}, http.StatusForbidden, nil},
+	}
+
+	for _, test := range statisticsTests {
+		t.Run(test.testName, func(t *testing.T) {
+			statisticsService := service.StatisticsServiceImpl{
+				PostIndexRepository: &MockPostIndexRepository{MockPosts: posts},
+			}
+
+			statistics, statusCode := statisticsService.GetStatistics(test.query, test.user)
+
+			if statusCode != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, statusCode)
+			}
+			if statusCode == http.StatusOK && !reflect.DeepEqual(statistics, test.expected) {
+				t.Errorf("expected %+v. Got %+v", test.expected, statistics)
+			}
+		})
+	}
+
+	t.Run("Includes posts at the end of the query", func(t *testing.T) {
+		statisticsService := service.StatisticsServiceImpl{
+			PostIndexRepository: &MockPostIndexRepository{MockPosts: []model.IndexedPost{
+				{PostId: "1", ThreadId: "a", Organization: "1", EntityType: "dataset", Timestamp: to.UnixMilli()},
+			}},
+		}
+
+		statistics, statusCode := statisticsService.GetStatistics(model.StatisticsQuery{Period: model.MonthPeriod, From: from, To: to}, moderator)
+
+		if statusCode != http.StatusOK || len(statistics) != 1 || statistics[0].Posts != 1 {
+			t.Errorf("expected post at the end to be counted. Got %+v, %d", statistics, statusCode)
+		}
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		statisticsService := service.StatisticsServiceImpl{
+			PostIndexRepository: &MockPostIndexRepository{MockError: errors.New("error")},
+		}
+
+		_, statusCode := statisticsService.GetStatistics(model.StatisticsQuery{From: from, To: to}, moderator)
+
+		if statusCode != http.StatusInternalServerError {
+			t.Fatalf("expected %d. Got %d", http.StatusInternalServerError, statusCode)
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package unit_tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestFollowThread(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	userId := "3"
+	entity := model.Entity{EntityId: "entity", Title: "Stort testdatasett"}
+	user := model.User{UserId: &userId, Email: "a@test.com"}
+
+	var followTests = []struct {
+		testName           string
+		user               model.User
+		entity             *model.Entity
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{"Follows thread", user, &entity, nil, http.StatusOK},
+		{"Without email", model.User{UserId: &userId}, &entity, nil, http.StatusBadRequest},
+		{"Without user id", model.User{Email: "a@test.com"}, &entity, nil, http.StatusUnauthorized},
+		{"Unknown entity", user, nil, nil, http.StatusNotFound},
+		{"Repository error", user, &entity, errors.New("error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range followTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockSubscriptionRepository := MockSubscriptionRepository{MockError: test.repositoryError}
+			subscriptionService := service.SubscriptionServiceImpl{
+				SubscriptionRepository: &mockSubscriptionRepository,
+				EntityService:          &MockEntityService{MockEntity: test.entity},
+			}
+
+			status, actualStatusCode := subscriptionService.Follow("entity", test.user)
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if actualStatusCode != http.StatusOK {
+				return
+			}
+			if !status.Following || status.EntityId != "entity" {
+				t.Errorf("expected entity to be followed. Got %#v", status)
+			}
+			saved := mockSubscriptionRepository.SavedSubscriptions
+			if len(saved) != 1 || saved[0].UserId != userId || saved[0].Email != "a@test.com" || saved[0].Timestamp == 0 {
+				t.Errorf("expected subscription with address of user. Got %#v", saved)
+			}
+		})
+	}
+
+	t.Run("Unfollows thread", func(t *testing.T) {
+		mockSubscriptionRepository := MockSubscriptionRepository{}
+		subscriptionService := service.SubscriptionServiceImpl{SubscriptionRepository: &mockSubscriptionRepository}
+
+		status, statusCode := subscriptionService.Unfollow("entity", user)
+		if statusCode != http.StatusOK || status.Following {
+			t.Fatalf("expected entity not to be followed. Got %#v, %d", status, statusCode)
+		}
+		if len(mockSubscriptionRepository.DeletedSubscriptions) != 1 || mockSubscriptionRepository.DeletedSubscriptions[0] != "entity_3" {
+			t.Errorf("expected subscription to be deleted. Got %v", mockSubscriptionRepository.DeletedSubscriptions)
+		}
+	})
+
+	t.Run("Reads subscription", func(t *testing.T) {
+		mockSubscriptionRepository := MockSubscriptionRepository{MockSubscription: &model.ThreadSubscription{EntityId: "entity", UserId: userId}}
+		subscriptionService := service.SubscriptionServiceImpl{SubscriptionRepository: &mockSubscriptionRepository}
+
+		status, statusCode := subscriptionService.GetSubscription("entity", user)
+		if statusCode != http.StatusOK || !status.Following {
+			t.Errorf("expected entity to be followed. Got %#v, %d", status, statusCode)
+		}
+	})
+}
+
+func TestUnsubscribe(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	key := "subscription-key"
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	expired := strconv.FormatInt(time.Now().Add(-25*time.Hour).UnixMilli(), 10)
+	var unsubscribeTests = []struct {
+		testName           string
+		secrets            map[string]string
+		token              string
+		expectedStatusCode int
+	}{
+		{"Valid token", map[string]string{secret.SubscriptionTokenKey: key}, util.SignToken([]byte(key), "unsubscribe", "entity", "3", now), http.StatusOK},
+		{"Expired token", map[string]string{secret.SubscriptionTokenKey: key}, util.SignToken([]byte(key), "unsubscribe", "entity", "3", expired), http.StatusBadRequest},
+		{"Token without issue time", map[string]string{secret.SubscriptionTokenKey: key}, util.SignToken([]byte(key), "unsubscribe", "entity", "3"), http.StatusBadRequest},
+		{"Token signed with other key", map[string]string{secret.SubscriptionTokenKey: key}, util.SignToken([]byte("other"), "unsubscribe", "entity", "3", now), http.StatusBadRequest},
+		{"Token for other purpose", map[string]string{secret.SubscriptionTokenKey: key}, util.SignToken([]byte(key), "usefulness", "entity", "3", now), http.StatusBadRequest},
+		{"Without key", map[string]string{}, util.SignToken([]byte(key), "unsubscribe", "entity", "3", now), http.StatusServiceUnavailable},
+	}
+
+	for _, test := range unsubscribeTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockSubscriptionRepository := MockSubscriptionRepository{}
+			subscriptionService := service.SubscriptionServiceImpl{
+				SubscriptionRepository: &mockSubscriptionRepository,
+				SecretProvider:         &MockSecretProvider{Secrets: test.secrets},
+				TokenTtl:               24 * time.Hour,
+			}
+
+			_, getStatusCode := subscriptionService.GetTokenSubscription(test.token)
+			if getStatusCode != test.expectedStatusCode || len(mockSubscriptionRepository.DeletedSubscriptions) != 0 {
+				t.Fatalf("expected status code: %d without unsubscribing. Got: %d, %v", test.expectedStatusCode, getStatusCode, mockSubscriptionRepository.DeletedSubscriptions)
+			}
+
+			_, actualStatusCode := subscriptionService.Unsubscribe(test.token)
+			if actualStatusCode != test.expectedStatusCode {
+				t.Fatalf("expected status code: %d. Got: %d", test.expectedStatusCode, actualStatusCode)
+			}
+			if test.expectedStatusCode == http.StatusOK && (len(mockSubscriptionRepository.DeletedSubscriptions) != 1 || mockSubscriptionRepository.DeletedSubscriptions[0] != "entity_3") {
+				t.Errorf("expected subscription to be deleted. Got %v", mockSubscriptionRepository.DeletedSubscriptions)
+			}
+			if test.expectedStatusCode != http.StatusOK && len(mockSubscriptionRepository.DeletedSubscriptions) != 0 {
+				t.Errorf("expected no subscription to be deleted. Got %v", mockSubscriptionRepository.DeletedSubscriptions)
+			}
+		})
+	}
+}
+
+func TestNotifyFollowers(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, toPostId, botUid := "1", "10", "9", "22"
+	author, follower, repliedTo := "3", "4", "5"
+	entity := model.Entity{EntityId: "entity", Title: "Stort testdatasett", Type: model.Dataset}
+	subscriptions := []model.ThreadSubscription{
+		{EntityId: "entity", UserId: author, Email: "author@test.com"},
+		{EntityId: "entity", UserId: follower, Email: "follower@test.com"},
+		{EntityId: "entity", UserId: repliedTo, Email: "replied@test.com"},
+	}
+	setUp := func() (*MockNotifier, service.SubscriptionService) {
+		mockNotifier := MockNotifier{}
+		subscriptionService := service.SubscriptionServiceImpl{
+			SubscriptionRepository: &MockSubscriptionRepository{MockSubscriptions: subscriptions},
+			NotificationRepository: &MockNotificationRepository{},
+			ThreadRepository:       &MockThreadRepository{MockGetPosts: map[string]*model.Post{toPostId: {PostId: &toPostId, UserId: &repliedTo}}},
+			EntityService:          &MockEntityService{MockEntity: &entity},
+			SecretProvider:         &MockSecretProvider{Secrets: map[string]string{secret.SubscriptionTokenKey: "subscription-key"}},
+			Notifier:               &mockNotifier,
+			ThreadBotUid:           botUid,
+			ThrottleWindow:         time.Hour,
+			UnsubscribeUrl:         "https://feedback.example.com/unsubscribe",
+		}
+		return &mockNotifier, &subscriptionService
+	}
+	notified := func(notifications []model.UserNotification) map[string]model.UserNotification {
+		byUserId := map[string]model.UserNotification{}
+		for _, notification := range notifications {
+			byUserId[notification.UserId] = notification
+		}
+		return byUserId
+	}
+
+	t.Run("Notifies followers other than the author once within the window", func(t *testing.T) {
+		mockNotifier, subscriptionService := setUp()
+		post := model.Post{PostId: &postId, UserId: &author, ThreadId: &threadId}
+
+		subscriptionService.NotifyPost(entity.EntityId, post)
+		subscriptionService.NotifyPost(entity.EntityId, post)
+
+		byUserId := notified(mockNotifier.Notifications)
+		if len(mockNotifier.Notifications) != 2 || len(byUserId) != 2 || byUserId[author].UserId != "" {
+			t.Fatalf("expected followers other than author to be notified once. Got %#v", mockNotifier.Notifications)
+		}
+		notification := byUserId[follower]
+		if notification.Email != "follower@test.com" || !strings.HasPrefix(notification.Subject, "Nytt innlegg") ||
+			!strings.Contains(notification.Body, entity.PortalLink()) || !strings.Contains(notification.Body, notification.UnsubscribeUrl) {
+			t.Errorf("unexpected notification %#v", notification)
+		}
+
+		unsubscribeUrl, err := url.Parse(notification.UnsubscribeUrl)
+		if err != nil || !strings.HasPrefix(notification.UnsubscribeUrl, "https://feedback.example.com/unsubscribe?") {
+			t.Fatalf("unexpected unsubscribe url %s", notification.UnsubscribeUrl)
+		}
+		fields, ok := util.VerifyToken([]byte("subscription-key"), unsubscribeUrl.Query().Get("token"))
+		if !ok || len(fields) != 4 || strings.Join(fields[:3], ",") != "unsubscribe,entity,"+follower {
+			t.Errorf("expected token to unsubscribe follower. Got %v", fields)
+		}
+	})
+
+	t.Run("Notifies about replies within the window", func(t *testing.T) {
+		mockNotifier, subscriptionService := setUp()
+		subscriptionService.NotifyPost(entity.EntityId, model.Post{PostId: &postId, UserId: &author, ThreadId: &threadId})
+		mockNotifier.Notifications = nil
+
+		subscriptionService.NotifyPost(entity.EntityId, model.Post{PostId: &postId, UserId: &author, ThreadId: &threadId, ToPostId: &toPostId})
+
+		if len(mockNotifier.Notifications) != 1 || mockNotifier.Notifications[0].UserId != repliedTo ||
+			!strings.HasPrefix(mockNotifier.Notifications[0].Subject, "Nytt svar") {
+			t.Errorf("expected reply to be notified to author of replied post. Got %#v", mockNotifier.Notifications)
+		}
+	})
+
+	t.Run("Post of thread bot", func(t *testing.T) {
+		mockNotifier, subscriptionService := setUp()
+
+		subscriptionService.NotifyPost(entity.EntityId, model.Post{PostId: &postId, UserId: &botUid, ThreadId: &threadId})
+
+		if len(mockNotifier.Notifications) != 0 {
+			t.Errorf("expected no notification. Got %#v", mockNotifier.Notifications)
+		}
+	})
+
+	t.Run("Without notifier", func(t *testing.T) {
+		subscriptionService := service.SubscriptionServiceImpl{}
+
+		subscriptionService.NotifyPost(entity.EntityId, model.Post{PostId: &postId, UserId: &author})
+	})
+}
+
+func TestQueuedNotifier(t *testing.T) {
+	delivered := make(chan model.UserNotification)
+	notifier := service.QueuedNotifier{Notifier: &MockNotifier{Delivered: delivered}, QueueSize: 1}
+
+	if err := notifier.Notify(model.UserNotification{UserId: "1"}); err != nil {
+		t.Fatalf("expected no error. Got %v", err)
+	}
+	select {
+	case notification := <-delivered:
+		if notification.UserId != "1" {
+			t.Errorf("unexpected notification %#v", notification)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the notification to be delivered")
+	}
+
+	// The first notification blocks delivery, the second waits in the queue.
+	notifier.Notify(model.UserNotification{UserId: "2"})
+	var err error
+	for attempt := 0; attempt < 100 && err == nil; attempt++ {
+		err = notifier.Notify(model.UserNotification{UserId: "3"})
+		time.Sleep(time.Millisecond)
+	}
+	if err != service.ErrNotificationQueueFull {
+		t.Errorf("expected a full queue. Got %v", err)
+	}
+	<-delivered
+	<-delivered
+}
+
+func TestQueuedNotifierRun(t *testing.T) {
+	delivered := make(chan model.UserNotification)
+	mockNotifier := MockNotifier{Delivered: delivered}
+	notifier := service.QueuedNotifier{Notifier: &mockNotifier, QueueSize: 1}
+
+	// The task runs in the background and delivers through the notifier it
+	// wraps, right away.
+	err := notifier.Run(func(delivery service.Notifier) error {
+		if delivery != &mockNotifier {
+			t.Errorf("expected delivery through the wrapped notifier. Got %#v", delivery)
+		}
+		return delivery.Notify(model.UserNotification{UserId: "1"})
+	})
+	if err != nil {
+		t.Fatalf("expected no error. Got %v", err)
+	}
+	select {
+	case notification := <-delivered:
+		if notification.UserId != "1" {
+			t.Errorf("unexpected notification %#v", notification)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the task to run")
+	}
+}
+
+func TestMailNotifier(t *testing.T) {
+	mockMailRepository := MockMailRepository{}
+	notifier := service.MailNotifier{MailRepository: &mockMailRepository}
+	unsubscribeUrl := "https://feedback.example.com/unsubscribe?token=abc.def"
+
+	err := notifier.Notify(model.UserNotification{UserId: "3", Email: "a@test.com", Subject: "Emne", Body: "Tekst", UnsubscribeUrl: unsubscribeUrl})
+	if err != nil || len(mockMailRepository.SentMails) != 1 {
+		t.Fatalf("expected 1 mail. Got %#v, %v", mockMailRepository.SentMails, err)
+	}
+	mail := mockMailRepository.SentMails[0]
+	if mail.To != "a@test.com" || mail.UnsubscribeUrl != unsubscribeUrl {
+		t.Errorf("unexpected mail %#v", mail)
+	}
+
+	content, err := util.FormatMail("noreply@example.com", mail, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error. Got %v", err)
+	}
+	if !strings.Contains(string(content), "List-Unsubscribe: <"+unsubscribeUrl+">\r\n") ||
+		!strings.Contains(string(content), "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n") {
+		t.Errorf("expected unsubscribe headers. Got %q", content)
+	}
+}
@@ -83,3 +83,33 @@ func TestCreateThreadId(t *testing.T) {
 		}
 	})
 }
+
+func TestDeleteThreadId(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Handles repository error", func(t *testing.T) {
+		mockThreadIdRepository, threadIdService := threadIdServiceMocks()
+
+		expectedError := errors.New("test error")
+
+		mockThreadIdRepository.MockError = expectedError
+
+		actualError := threadIdService.DeleteThreadId("testId")
+
+		if actualError != expectedError {
+			t.Fatalf("expected: %s. Got: %s", expectedError, actualError)
+		}
+	})
+
+	t.Run("Successful delete", func(t *testing.T) {
+		_, threadIdService := threadIdServiceMocks()
+
+		var expectedError error
+
+		actualError := threadIdService.DeleteThreadId("testId")
+
+		if actualError != expectedError {
+			t.Fatalf("expected: %s. Got: %s", expectedError, actualError)
+		}
+	})
+}
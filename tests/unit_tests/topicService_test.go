@@ -1,15 +1,24 @@
 package unit_tests
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/eventbus"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/repository"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/tests"
 )
 
 func threadServiceMocks() (*MockEntityService, *MockThreadIdService, *MockThreadRepository, service.ThreadService) {
@@ -21,9 +30,10 @@ func threadServiceMocks() (*MockEntityService, *MockThreadIdService, *MockThread
 	mockEntityService := MockEntityService{}
 
 	threadService := service.ThreadServiceImpl{
-		ThreadRepository: &mockThreadRepository,
-		ThreadIdService:  &mockThreadIdService,
-		EntityService:    &mockEntityService,
+		ThreadRepository:   &mockThreadRepository,
+		ThreadIdService:    &mockThreadIdService,
+		EntityService:      &mockEntityService,
+		RevisionRepository: &MockRevisionRepository{},
 	}
 
 	return &mockEntityService, &mockThreadIdService, &mockThreadRepository, &threadService
@@ -38,7 +48,7 @@ func TestCreateThreadPost(t *testing.T) {
 		var expectedPost *model.Post
 		expectedStatusCode := http.StatusBadRequest
 
-		actualPost, actualStatusCode := threadService.CreateThreadPost(model.Post{})
+		actualPost, actualStatusCode := threadService.CreateThreadPost(model.Post{}, "entity")
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
@@ -58,7 +68,7 @@ func TestCreateThreadPost(t *testing.T) {
 			PostId:   &postId,
 			UserId:   &userId,
 			Content:  &content,
-		})
+		}, "entity")
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
@@ -78,13 +88,562 @@ func TestCreateThreadPost(t *testing.T) {
 		expectedStatusCode := http.StatusCreated
 		mockThreadRepository.MockPost = &expectedPost
 		mockThreadRepository.MockError = nil
-		actualPost, actualStatusCode := threadService.CreateThreadPost(expectedPost)
+		actualPost, actualStatusCode := threadService.CreateThreadPost(expectedPost, "entity")
 		if actualPost != &expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
 		}
 	})
 }
 
+func TestHideThreadPost(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, moderatorUid := "1", "2", "bot"
+	bus := eventbus.NewInMemoryEventBus(10)
+	mockStatisticsService := MockStatisticsService{}
+	mockThreadRepository := MockThreadRepository{}
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository:  &mockThreadRepository,
+		EventBus:          bus,
+		StatisticsService: &mockStatisticsService,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _ := bus.Subscribe(ctx, threadId, nil)
+
+	statusCode := threadService.HideThreadPost(threadId, postId, moderatorUid)
+
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status code: %d. Got: %d", http.StatusOK, statusCode)
+	}
+	if len(mockThreadRepository.DeletedPosts) != 1 || *mockThreadRepository.DeletedPosts[0].UserId != moderatorUid {
+		t.Errorf("expected post to be deleted as moderator. Got %#v", mockThreadRepository.DeletedPosts)
+	}
+	if actual := receiveEvent(t, events); actual.Type != model.PostDeleted || *actual.Post.PostId != postId {
+		t.Errorf("expected %s event for hidden post. Got %#v", model.PostDeleted, actual)
+	}
+	if !reflect.DeepEqual(mockStatisticsService.RemovedPostIds, []string{postId}) {
+		t.Errorf("expected hidden post to be removed from statistics. Got %v", mockStatisticsService.RemovedPostIds)
+	}
+}
+
+func TestCreateThreadPostPublishesEvent(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	bus := eventbus.NewInMemoryEventBus(10)
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository: &MockThreadRepository{},
+		EventBus:         bus,
+	}
+	threadId, postId, userId, content := "1", "1", "1", "content"
+	createdPost := model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content}
+	threadService.ThreadRepository.(*MockThreadRepository).MockPost = &createdPost
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _ := bus.Subscribe(ctx, threadId, nil)
+
+	threadService.CreateThreadPost(createdPost, "entity")
+
+	actual := receiveEvent(t, events)
+	if actual.Type != model.PostCreated || actual.Post != &createdPost {
+		t.Fatalf("expected %s event for created post. Got %#v", model.PostCreated, actual)
+	}
+}
+
+func TestThreadPostsPushedToGraphStore(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, toPostId := "1", "2", "3", "1"
+	content, previousContent := "content", "previous content"
+	created := 1700000000000
+	mockGraphStoreService := MockGraphStoreService{}
+	mockThreadRepository := MockThreadRepository{}
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository:   &mockThreadRepository,
+		RevisionRepository: &MockRevisionRepository{},
+		GraphStoreService:  &mockGraphStoreService,
+	}
+	post := model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content}
+	mockThreadRepository.MockPost = &post
+	mockThreadRepository.MockGetPost = &model.Post{
+		ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &previousContent, ToPostId: &toPostId, Timestamp: &created,
+	}
+
+	threadService.CreateThreadPost(post, "entity")
+	threadService.UpdateThreadPost(post)
+	threadService.DeleteThreadPost(post)
+
+	if len(mockGraphStoreService.PushedPosts) != 2 || *mockGraphStoreService.PushedPosts[0].PostId != postId {
+		t.Fatalf("expected created and updated post to be pushed. Got %#v", mockGraphStoreService.PushedPosts)
+	}
+	updated := mockGraphStoreService.PushedPosts[1]
+	if updated.EditedAt == nil || updated.Timestamp == nil || *updated.Timestamp != created || updated.ToPostId == nil {
+		t.Errorf("expected updated post with creation time and parent of the post. Got %#v", updated)
+	}
+	if !reflect.DeepEqual(mockGraphStoreService.DeletedPostIds, []string{postId}) {
+		t.Errorf("expected deleted post to be removed. Got %v", mockGraphStoreService.DeletedPostIds)
+	}
+}
+
+func TestThreadPostsIndexedForStatistics(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, content := "1", "2", "3", "content"
+	mockStatisticsService := MockStatisticsService{}
+	mockThreadRepository := MockThreadRepository{}
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository:  &mockThreadRepository,
+		StatisticsService: &mockStatisticsService,
+	}
+	post := model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content}
+	mockThreadRepository.MockPost = &post
+	mockThreadRepository.MockGetPost = &post
+
+	threadService.CreateThreadPost(post, "entity")
+	threadService.DeleteThreadPost(post)
+
+	if len(mockStatisticsService.IndexedPosts) != 1 || *mockStatisticsService.IndexedPosts[0].PostId != postId {
+		t.Fatalf("expected created post to be indexed. Got %#v", mockStatisticsService.IndexedPosts)
+	}
+	if !reflect.DeepEqual(mockStatisticsService.RemovedPostIds, []string{postId}) {
+		t.Errorf("expected deleted post to be removed. Got %v", mockStatisticsService.RemovedPostIds)
+	}
+}
+
+func TestThreadPostsNotifyContactPointAndFollowers(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, content := "1", "2", "3", "content"
+	mockNotificationService := MockNotificationService{}
+	mockSubscriptionService := MockSubscriptionService{}
+	mockThreadRepository := MockThreadRepository{}
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository:    &mockThreadRepository,
+		NotificationService: &mockNotificationService,
+		SubscriptionService: &mockSubscriptionService,
+	}
+	post := model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content}
+	mockThreadRepository.MockPost = &post
+
+	threadService.CreateThreadPost(post, "entity")
+
+	if len(mockNotificationService.NotifiedPosts) != 1 || *mockNotificationService.NotifiedPosts[0].PostId != postId {
+		t.Errorf("expected created post to be notified. Got %#v", mockNotificationService.NotifiedPosts)
+	}
+	if len(mockSubscriptionService.NotifiedPosts) != 1 || *mockSubscriptionService.NotifiedPosts[0].PostId != postId ||
+		!reflect.DeepEqual(mockSubscriptionService.NotifiedIds, []string{"entity"}) {
+		t.Errorf("expected followers of the entity to be notified of created post. Got %#v, %v", mockSubscriptionService.NotifiedPosts, mockSubscriptionService.NotifiedIds)
+	}
+}
+
+func TestCreatePostWithPremoderation(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId, content := "1", "2", "3", "content"
+	premoderatedService := func() (*MockThreadRepository, *MockPendingPostRepository, service.ThreadService) {
+		mockThreadRepository := MockThreadRepository{}
+		mockPendingPostRepository := MockPendingPostRepository{}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:      &mockThreadRepository,
+			ThreadIdService:       &MockThreadIdService{MockThreadId: &threadId},
+			PendingPostRepository: &mockPendingPostRepository,
+			Premoderation:         true,
+		}
+		return &mockThreadRepository, &mockPendingPostRepository, &threadService
+	}
+
+	t.Run("Holds post from user without approved posts", func(t *testing.T) {
+		_, mockPendingPostRepository, threadService := premoderatedService()
+		expectedStatusCode := http.StatusAccepted
+
+		actualPost, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content}, "entity")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+		if actualPost == nil || actualPost.PendingId == nil || actualPost.PostId != nil {
+			t.Fatalf("expected pending post without post id. Got %#v", actualPost)
+		}
+		if len(mockPendingPostRepository.CreatedPosts) != 1 || mockPendingPostRepository.CreatedPosts[0].ThreadId != threadId {
+			t.Errorf("expected pending post in thread %s. Got %#v", threadId, mockPendingPostRepository.CreatedPosts)
+		}
+	})
+
+	t.Run("Publishes post from approved user", func(t *testing.T) {
+		mockThreadRepository, mockPendingPostRepository, threadService := premoderatedService()
+		mockPendingPostRepository.MockApproved = true
+		mockThreadRepository.MockPost = &model.Post{PostId: &postId, ThreadId: &threadId}
+		expectedStatusCode := http.StatusCreated
+
+		actualPost, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content}, "entity")
+
+		if actualPost != mockThreadRepository.MockPost || actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", mockThreadRepository.MockPost, expectedStatusCode, actualPost, actualStatusCode)
+		}
+		if len(mockPendingPostRepository.CreatedPosts) != 0 {
+			t.Errorf("expected no pending posts. Got %#v", mockPendingPostRepository.CreatedPosts)
+		}
+	})
+
+	t.Run("Holds post with post id as new post", func(t *testing.T) {
+		_, mockPendingPostRepository, threadService := premoderatedService()
+		otherPostId := "9"
+
+		_, actualStatusCode := threadService.CreatePostForEntityId(model.Post{PostId: &otherPostId, UserId: &userId, Content: &content}, "entity")
+
+		if actualStatusCode != http.StatusAccepted || len(mockPendingPostRepository.CreatedPosts) != 1 || mockPendingPostRepository.CreatedPosts[0].PostId != "" {
+			t.Fatalf("expected pending new post, not an edit. Got %#v, %d", mockPendingPostRepository.CreatedPosts, actualStatusCode)
+		}
+	})
+
+	t.Run("Does not publish pending edit of post by other user", func(t *testing.T) {
+		mockThreadRepository, _, threadService := premoderatedService()
+		otherUserId := "4"
+		mockThreadRepository.MockGetPost = &model.Post{PostId: &postId, ThreadId: &threadId, UserId: &otherUserId}
+
+		_, actualStatusCode := threadService.PublishPendingPost(model.PendingPost{PostId: postId, ThreadId: threadId, UserId: userId, Content: content})
+
+		if actualStatusCode != http.StatusUnauthorized || len(mockThreadRepository.UpdatedPosts) != 0 {
+			t.Fatalf("expected status code: %d without update. Got: %d, %#v", http.StatusUnauthorized, actualStatusCode, mockThreadRepository.UpdatedPosts)
+		}
+	})
+
+	t.Run("Could not look up approved user", func(t *testing.T) {
+		_, mockPendingPostRepository, threadService := premoderatedService()
+		mockPendingPostRepository.MockError = errors.New("testerror")
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content}, "entity")
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+}
+
+func TestPersonalDataPolicy(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId := "1", "2", "3"
+	content := "Ring meg på 412 34 567"
+	policyService := func(action model.PersonalDataAction) (*MockThreadRepository, *MockPendingPostRepository, *MockRevisionRepository, service.ThreadService) {
+		mockThreadRepository := MockThreadRepository{
+			MockGetPost: &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId},
+		}
+		mockPendingPostRepository := MockPendingPostRepository{}
+		mockRevisionRepository := MockRevisionRepository{}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:      &mockThreadRepository,
+			ThreadIdService:       &MockThreadIdService{MockThreadId: &threadId, MockEntityIds: map[string]string{threadId: "entity"}},
+			RevisionRepository:    &mockRevisionRepository,
+			PendingPostRepository: &mockPendingPostRepository,
+			PersonalDataAction:    action,
+		}
+		return &mockThreadRepository, &mockPendingPostRepository, &mockRevisionRepository, &threadService
+	}
+
+	t.Run("Masks personal data in new post", func(t *testing.T) {
+		mockThreadRepository, _, _, threadService := policyService(model.MaskPersonalData)
+		mockThreadRepository.MockPost = &model.Post{PostId: &postId}
+
+		_, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content}, "entity")
+
+		if actualStatusCode != http.StatusCreated {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusCreated, actualStatusCode)
+		}
+	})
+
+	t.Run("Masks personal data in edit", func(t *testing.T) {
+		_, _, _, threadService := policyService(model.MaskPersonalData)
+
+		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+
+		if actualStatusCode != http.StatusOK || *actualPost.Content != "Ring meg på [fjernet]" {
+			t.Fatalf("expected masked post. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+	})
+
+	t.Run("Rejects personal data", func(t *testing.T) {
+		_, _, _, threadService := policyService(model.RejectPersonalData)
+
+		_, createStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content}, "entity")
+		_, updateStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+
+		if createStatusCode != http.StatusUnprocessableEntity || updateStatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status code: %d. Got: %d, %d", http.StatusUnprocessableEntity, createStatusCode, updateStatusCode)
+		}
+	})
+
+	t.Run("Holds edit with personal data for moderation", func(t *testing.T) {
+		_, mockPendingPostRepository, mockRevisionRepository, threadService := policyService(model.ModeratePersonalData)
+
+		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+
+		if actualStatusCode != http.StatusAccepted || actualPost.PendingId == nil {
+			t.Fatalf("expected pending edit. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+		if len(mockPendingPostRepository.CreatedPosts) != 1 || mockPendingPostRepository.CreatedPosts[0].PostId != postId ||
+			mockPendingPostRepository.CreatedPosts[0].EntityId != "entity" {
+			t.Errorf("expected pending edit of post %s on the entity. Got %#v", postId, mockPendingPostRepository.CreatedPosts)
+		}
+		if len(mockRevisionRepository.CreatedRevisions) != 0 {
+			t.Errorf("expected post to be left unchanged")
+		}
+	})
+
+	t.Run("Publishes approved edit unmasked", func(t *testing.T) {
+		_, _, mockRevisionRepository, threadService := policyService(model.ModeratePersonalData)
+
+		actualPost, actualStatusCode := threadService.PublishPendingPost(model.PendingPost{PostId: postId, ThreadId: threadId, UserId: userId, Content: content})
+
+		if actualStatusCode != http.StatusOK || *actualPost.Content != content {
+			t.Fatalf("expected approved edit to be applied. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+		if len(mockRevisionRepository.CreatedRevisions) != 1 {
+			t.Errorf("expected revision of edited post")
+		}
+	})
+}
+
+func TestDataQualityIssues(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId := "1", "2", "3"
+	content := "Lenken til distribusjonen gir 404"
+	issueService := func() (*MockThreadRepository, *MockIssueRepository, service.ThreadService) {
+		mockThreadRepository := MockThreadRepository{
+			MockPost:    &model.Post{PostId: &postId},
+			MockGetPost: &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId},
+		}
+		mockIssueRepository := MockIssueRepository{}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:   &mockThreadRepository,
+			ThreadIdService:    &MockThreadIdService{MockThreadId: &threadId},
+			RevisionRepository: &MockRevisionRepository{},
+			IssueRepository:    &mockIssueRepository,
+		}
+		return &mockThreadRepository, &mockIssueRepository, &threadService
+	}
+
+	t.Run("Rejects invalid issue", func(t *testing.T) {
+		_, mockIssueRepository, threadService := issueService()
+
+		for _, issue := range []model.DataQualityIssue{
+			{Category: "unknown"},
+			{Category: model.BrokenLinkIssue, Severity: "critical"},
+			{Category: model.BrokenLinkIssue, Field: "accessURL`\n"},
+		} {
+			_, createStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content, Issue: &issue}, "entity")
+			_, updateStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content, Issue: &issue})
+
+			if createStatusCode != http.StatusBadRequest || updateStatusCode != http.StatusBadRequest {
+				t.Errorf("expected status code: %d for %#v. Got: %d, %d", http.StatusBadRequest, issue, createStatusCode, updateStatusCode)
+			}
+		}
+		if len(mockIssueRepository.SavedIssues) != 0 {
+			t.Errorf("expected no issues to be saved. Got %#v", mockIssueRepository.SavedIssues)
+		}
+	})
+
+	t.Run("Renders and saves issue of new post", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+
+		issue := model.DataQualityIssue{Category: model.BrokenLinkIssue, Field: " accessURL "}
+		actualPost, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content, Issue: &issue}, "entity")
+
+		if actualStatusCode != http.StatusCreated || actualPost.Issue == nil || actualPost.Issue.Severity != model.MediumSeverity {
+			t.Fatalf("expected post with issue of default severity. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+		expectedContent := "> **Datakvalitet:** Lenke virker ikke · **Felt:** `accessURL` · **Alvorlighetsgrad:** Middels\n\n" + content
+		if len(mockThreadRepository.CreatedPosts) != 1 || *mockThreadRepository.CreatedPosts[0].Content != expectedContent {
+			t.Errorf("expected content: %q. Got %#v", expectedContent, mockThreadRepository.CreatedPosts)
+		}
+		saved := mockIssueRepository.SavedIssues
+		if len(saved) != 1 || saved[0].PostId != postId || saved[0].ThreadId != threadId || saved[0].Field != "accessURL" {
+			t.Errorf("expected issue of post %s to be saved. Got %#v", postId, saved)
+		}
+	})
+
+	t.Run("Keeps issue summary on edit", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		mockIssueRepository.MockIssue = &model.DataQualityIssue{PostId: postId, Category: model.WrongLicenseIssue, Severity: model.HighSeverity}
+
+		edited := "> **Datakvalitet:** Feil lisens · **Alvorlighetsgrad:** Høy\n\nLisensen er CC BY 4.0"
+		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &edited})
+
+		if actualStatusCode != http.StatusOK || actualPost.Issue == nil || *actualPost.Content != edited {
+			t.Fatalf("expected edited post with one summary. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+		if len(mockThreadRepository.UpdatedPosts) != 1 || *mockThreadRepository.UpdatedPosts[0].Content != edited {
+			t.Errorf("expected content: %q. Got %#v", edited, mockThreadRepository.UpdatedPosts)
+		}
+		if len(mockIssueRepository.SavedIssues) != 0 {
+			t.Errorf("expected unchanged issue not to be saved again")
+		}
+	})
+
+	t.Run("Publishes issue of approved post", func(t *testing.T) {
+		_, mockIssueRepository, threadService := issueService()
+
+		issue := model.DataQualityIssue{Category: model.OutdatedDataIssue, Severity: model.LowSeverity}
+		actualPost, actualStatusCode := threadService.PublishPendingPost(model.PendingPost{ThreadId: threadId, UserId: userId, Content: content, Issue: &issue})
+
+		if actualStatusCode != http.StatusCreated || actualPost.Issue == nil || len(mockIssueRepository.SavedIssues) != 1 {
+			t.Fatalf("expected published post with issue. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+	})
+
+	t.Run("Attaches issues to thread posts", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		otherPostId := "4"
+		mockThreadRepository.MockGetThread = &model.Thread{
+			ThreadId: &threadId,
+			Posts:    []*model.Post{{PostId: &postId}, {PostId: &otherPostId}},
+		}
+		mockIssueRepository.MockIssues = []model.DataQualityIssue{{PostId: postId, ThreadId: threadId, Category: model.MissingDataIssue}}
+
+		thread, statusCode := threadService.GetThreadByEntityId("entity", model.ThreadQuery{})
+
+		if statusCode != http.StatusOK || thread.Posts[0].Issue == nil || thread.Posts[1].Issue != nil {
+			t.Fatalf("expected issue on first post only. Got: %#v, %d", thread, statusCode)
+		}
+	})
+
+	t.Run("Filters thread by category", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		postIds := []string{"10", "11", "12", "13"}
+		timestamps := []int{100, 300, 200, 400}
+		pageCount := 2
+		mockThreadRepository.MockGetThreadPages = map[string]*model.Thread{
+			"1": {ThreadId: &threadId, Pagination: &model.Pagination{PageCount: &pageCount}},
+			"2": {ThreadId: &threadId, Pagination: &model.Pagination{PageCount: &pageCount}},
+		}
+		for i := range postIds {
+			// The posts of the issues are read from the pages of the thread.
+			threadPage := mockThreadRepository.MockGetThreadPages[strconv.Itoa(i/2+1)]
+			threadPage.Posts = append(threadPage.Posts, &model.Post{ThreadId: &threadId, PostId: &postIds[i], Timestamp: &timestamps[i]})
+			category := model.BrokenLinkIssue
+			if i == 3 {
+				category = model.OtherIssue
+			}
+			mockIssueRepository.MockIssues = append(mockIssueRepository.MockIssues, model.DataQualityIssue{PostId: postIds[i], ThreadId: threadId, Category: category})
+		}
+
+		category, page, pageSize := model.BrokenLinkIssue, "2", 2
+		thread, statusCode := threadService.GetThreadByEntityId("entity", model.ThreadQuery{Category: &category, Page: &page, PageSize: &pageSize})
+
+		if statusCode != http.StatusOK || len(thread.Posts) != 1 || *thread.Posts[0].PostId != "10" || thread.Posts[0].Issue == nil {
+			t.Fatalf("expected oldest broken link on second page. Got: %#v, %d", thread, statusCode)
+		}
+		if *thread.Pagination.TotalPosts != 3 || *thread.Pagination.PageCount != 2 || *thread.Pagination.CurrentPage != 2 {
+			t.Errorf("expected pagination over 3 posts. Got %#v", thread.Pagination)
+		}
+	})
+
+	t.Run("Keeps status of issue on edit", func(t *testing.T) {
+		_, mockIssueRepository, threadService := issueService()
+		mockIssueRepository.MockIssue = &model.DataQualityIssue{PostId: postId, ThreadId: threadId, Category: model.BrokenLinkIssue, Status: model.IssueResolved, Timestamp: 100}
+
+		issue := model.DataQualityIssue{Category: model.MissingDataIssue, Status: model.IssueOpen}
+		_, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content, Issue: &issue})
+
+		saved := mockIssueRepository.SavedIssues
+		if actualStatusCode != http.StatusOK || len(saved) != 1 || saved[0].Status != model.IssueResolved || saved[0].Category != model.MissingDataIssue {
+			t.Fatalf("expected new category with status kept. Got %#v, %d", saved, actualStatusCode)
+		}
+	})
+
+	t.Run("Rejects official answer to post without issue", func(t *testing.T) {
+		_, _, threadService := issueService()
+
+		otherPostId := "9"
+		_, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &content, ToPostId: &otherPostId, OfficialAnswer: true}, "entity")
+
+		if actualStatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusBadRequest, actualStatusCode)
+		}
+	})
+
+	t.Run("Publishes official answer without premoderation", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, _ := issueService()
+		issuePostId := "9"
+		mockIssueRepository.MockIssue = &model.DataQualityIssue{PostId: issuePostId, ThreadId: threadId, Category: model.BrokenLinkIssue, Status: model.IssueOpen}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:      mockThreadRepository,
+			ThreadIdService:       &MockThreadIdService{MockThreadId: &threadId},
+			PendingPostRepository: &MockPendingPostRepository{},
+			IssueRepository:       mockIssueRepository,
+			Premoderation:         true,
+		}
+
+		answer := "Lenken er rettet"
+		actualPost, actualStatusCode := threadService.CreatePostForEntityId(model.Post{UserId: &userId, Content: &answer, ToPostId: &issuePostId, OfficialAnswer: true}, "entity")
+
+		if actualStatusCode != http.StatusCreated || !actualPost.OfficialAnswer {
+			t.Fatalf("expected official answer. Got: %#v, %d", actualPost, actualStatusCode)
+		}
+		expectedContent := "> **Offisielt svar fra utgiver**\n\n" + answer
+		if len(mockThreadRepository.CreatedPosts) != 1 || *mockThreadRepository.CreatedPosts[0].Content != expectedContent {
+			t.Errorf("expected content: %q. Got %#v", expectedContent, mockThreadRepository.CreatedPosts)
+		}
+		saved := mockIssueRepository.SavedIssues
+		if len(saved) != 1 || !reflect.DeepEqual(saved[0].AnswerPostIds, []string{postId}) {
+			t.Errorf("expected answer to be recorded on issue. Got %#v", saved)
+		}
+	})
+
+	t.Run("Marks official answers in thread", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		answerPostId := "4"
+		mockThreadRepository.MockGetThread = &model.Thread{
+			ThreadId: &threadId,
+			Posts:    []*model.Post{{PostId: &postId}, {PostId: &answerPostId}},
+		}
+		mockIssueRepository.MockIssues = []model.DataQualityIssue{{PostId: postId, ThreadId: threadId, AnswerPostIds: []string{answerPostId}}}
+
+		thread, statusCode := threadService.GetThreadByEntityId("entity", model.ThreadQuery{})
+
+		if statusCode != http.StatusOK || thread.Posts[0].OfficialAnswer || !thread.Posts[1].OfficialAnswer {
+			t.Fatalf("expected second post to be official answer. Got: %#v, %d", thread, statusCode)
+		}
+	})
+
+	t.Run("Filters thread by open issues", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		resolvedPostId := "4"
+		mockThreadRepository.MockGetThread = &model.Thread{
+			ThreadId: &threadId,
+			Posts:    []*model.Post{{ThreadId: &threadId, PostId: &postId}, {ThreadId: &threadId, PostId: &resolvedPostId}},
+		}
+		mockIssueRepository.MockIssues = []model.DataQualityIssue{
+			{PostId: postId, ThreadId: threadId, Category: model.BrokenLinkIssue, Status: model.IssueOpen},
+			{PostId: resolvedPostId, ThreadId: threadId, Category: model.BrokenLinkIssue, Status: model.IssueResolved},
+		}
+
+		status := model.IssueOpen
+		thread, statusCode := threadService.GetThreadByEntityId("entity", model.ThreadQuery{IssueStatus: &status})
+
+		if statusCode != http.StatusOK || len(thread.Posts) != 1 || *thread.Posts[0].PostId != postId {
+			t.Fatalf("expected open issue only. Got: %#v, %d", thread, statusCode)
+		}
+	})
+
+	t.Run("Handles issue repository error", func(t *testing.T) {
+		mockThreadRepository, mockIssueRepository, threadService := issueService()
+		mockThreadRepository.MockGetThread = &model.Thread{ThreadId: &threadId}
+		mockIssueRepository.MockError = errors.New("testerror")
+
+		category := model.BrokenLinkIssue
+		_, statusCode := threadService.GetThreadByEntityId("entity", model.ThreadQuery{Category: &category})
+
+		if statusCode != http.StatusInternalServerError {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusInternalServerError, statusCode)
+		}
+	})
+}
+
 func TestCreateThread(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
@@ -183,7 +742,7 @@ func TestGetThread(t *testing.T) {
 		mockThreadRepository.MockGetError = errors.New("testerror")
 		expectedStatusCode := http.StatusNotFound
 
-		actualThread, actualStatusCode := threadService.GetThread("", nil, nil)
+		actualThread, actualStatusCode := threadService.GetThread("", model.ThreadQuery{})
 
 		if actualThread != expectedThread || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedThread, expectedStatusCode, actualThread, actualStatusCode)
@@ -196,7 +755,7 @@ func TestGetThread(t *testing.T) {
 		var expectedThread *model.Thread
 		expectedStatusCode := http.StatusNotFound
 
-		actualThread, actualStatusCode := threadService.GetThread("", nil, nil)
+		actualThread, actualStatusCode := threadService.GetThread("", model.ThreadQuery{})
 
 		if actualThread != expectedThread || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedThread, expectedStatusCode, actualThread, actualStatusCode)
@@ -205,35 +764,129 @@ func TestGetThread(t *testing.T) {
 
 	t.Run("Successfully gets thread", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
-		threadTitle := "test thread"
+		threadTitle, sort := "test thread", "newest"
 
 		expectedThread := model.Thread{
-			Title: &threadTitle,
+			Title:      &threadTitle,
+			Pagination: &model.Pagination{Sort: &sort},
 		}
 		expectedStatusCode := http.StatusOK
-		mockThreadRepository.MockGetThread = &expectedThread
+		mockThreadRepository.MockGetThread = &model.Thread{
+			Title: &threadTitle,
+		}
 
-		actualThread, actualStatusCode := threadService.GetThread("", nil, nil)
+		actualThread, actualStatusCode := threadService.GetThread("", model.ThreadQuery{})
 
 		if !reflect.DeepEqual(*actualThread, expectedThread) || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedThread, expectedStatusCode, actualThread, actualStatusCode)
 		}
 	})
+
+	t.Run("Echoes sort and page size in pagination", func(t *testing.T) {
+		_, _, mockThreadRepository, threadService := threadServiceMocks()
+		page, currentPage, pageCount, totalPosts, pageSize, sort := "2", 2, 3, 12, 5, "oldest"
+
+		expectedPagination := model.Pagination{
+			CurrentPage: &currentPage,
+			PageCount:   &pageCount,
+			TotalPosts:  &totalPosts,
+			Sort:        &sort,
+			PageSize:    &pageSize,
+		}
+		nodeBBPageCount := 1
+		mockThreadRepository.MockGetThread = &model.Thread{
+			Pagination: &model.Pagination{CurrentPage: &currentPage, PageCount: &nodeBBPageCount, TotalPosts: &totalPosts},
+		}
+
+		actualThread, _ := threadService.GetThread("", model.ThreadQuery{Sort: model.OldestFirst, Page: &page, PageSize: &pageSize})
+
+		if !reflect.DeepEqual(*actualThread.Pagination, expectedPagination) {
+			t.Fatalf("expected pagination: %#v. Got: %#v", expectedPagination, *actualThread.Pagination)
+		}
+	})
+}
+
+func TestGetThreadFromCursor(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	post := func(postId int) *model.Post {
+		pid, index, timestamp := strconv.Itoa(postId), strconv.Itoa(postId-1), postId*100
+		return &model.Post{PostId: &pid, Index: &index, Timestamp: &timestamp}
+	}
+	pageSize, pageCount, totalPosts := 2, 3, 6
+	pagination := &model.Pagination{PageCount: &pageCount, TotalPosts: &totalPosts}
+
+	// Post 6 arrived after the client read posts 5 and 4 from the first page.
+	pages := map[string]*model.Thread{
+		"1": {Posts: []*model.Post{post(6), post(5)}, Pagination: pagination},
+		"2": {Posts: []*model.Post{post(4), post(3)}, Pagination: pagination},
+		"3": {Posts: []*model.Post{post(2), post(1)}, Pagination: pagination},
+	}
+
+	postIds := func(thread *model.Thread) []string {
+		var ids []string
+		for _, post := range thread.Posts {
+			ids = append(ids, *post.PostId)
+		}
+		return ids
+	}
+
+	t.Run("Reads on from next cursor without repeating posts", func(t *testing.T) {
+		_, _, mockThreadRepository, threadService := threadServiceMocks()
+		mockThreadRepository.MockGetThreadPages = pages
+
+		cursor := model.CursorForPost(post(4), model.NewestFirst, false)
+		thread, statusCode := threadService.GetThread("", model.ThreadQuery{Sort: model.NewestFirst, PageSize: &pageSize, Cursor: cursor})
+
+		expected := []string{"3", "2"}
+		if statusCode != http.StatusOK || !reflect.DeepEqual(postIds(thread), expected) {
+			t.Fatalf("expected posts %v, got %v, %d", expected, postIds(thread), statusCode)
+		}
+		if thread.Pagination.NextCursor == nil || thread.Pagination.PrevCursor == nil {
+			t.Errorf("expected next and prev cursors, got %#v", thread.Pagination)
+		}
+	})
+
+	t.Run("Reads back from prev cursor to new posts", func(t *testing.T) {
+		_, _, mockThreadRepository, threadService := threadServiceMocks()
+		mockThreadRepository.MockGetThreadPages = pages
+
+		cursor := model.CursorForPost(post(5), model.NewestFirst, true)
+		thread, statusCode := threadService.GetThread("", model.ThreadQuery{Sort: model.NewestFirst, PageSize: &pageSize, Cursor: cursor})
+
+		expected := []string{"6"}
+		if statusCode != http.StatusOK || !reflect.DeepEqual(postIds(thread), expected) {
+			t.Fatalf("expected posts %v, got %v, %d", expected, postIds(thread), statusCode)
+		}
+	})
+
+	t.Run("Hands cursor back when there are no more posts", func(t *testing.T) {
+		_, _, mockThreadRepository, threadService := threadServiceMocks()
+		mockThreadRepository.MockGetThreadPages = pages
+
+		cursor := model.CursorForPost(post(1), model.NewestFirst, false)
+		thread, _ := threadService.GetThread("", model.ThreadQuery{Sort: model.NewestFirst, PageSize: &pageSize, Cursor: cursor})
+
+		if len(thread.Posts) != 0 || thread.Pagination.NextCursor == nil || *thread.Pagination.NextCursor != cursor.String() {
+			t.Fatalf("expected no posts and the same next cursor, got %v, %#v", postIds(thread), thread.Pagination)
+		}
+	})
 }
 
 func TestUpdateThreadPost(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
-	t.Run("Could not get thread", func(t *testing.T) {
+	t.Run("Could not get post", func(t *testing.T) {
 		_, _, _, threadService := threadServiceMocks()
-		threadId := "1"
+		threadId, postId := "1", "1"
 
 		var expectedPost *model.Post
 		expectedStatusCode := http.StatusNotFound
 
 		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{
 			ThreadId: &threadId,
-		}, nil)
+			PostId:   &postId,
+		})
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
@@ -242,16 +895,18 @@ func TestUpdateThreadPost(t *testing.T) {
 
 	t.Run("Thread does not contain post", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
-		threadId := "1"
+		threadId, otherThreadId, postId, userId := "1", "2", "1", "1"
 
 		var expectedPost *model.Post
 		expectedStatusCode := http.StatusNotFound
 
-		mockThreadRepository.MockGetThread = &model.Thread{}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &otherThreadId, PostId: &postId, UserId: &userId}
 
 		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{
 			ThreadId: &threadId,
-		}, nil)
+			PostId:   &postId,
+			UserId:   &userId,
+		})
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
@@ -261,21 +916,17 @@ func TestUpdateThreadPost(t *testing.T) {
 	t.Run("Post to update attributed to different user", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
 		threadId, postId, userId, otherUserId := "1", "1", "1", "2"
-		post := model.Post{PostId: &postId, UserId: &userId}
 
 		var expectedPost *model.Post
 		expectedStatusCode := http.StatusUnauthorized
 
-		posts := []*model.Post{&post}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
 
 		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{
 			ThreadId: &threadId,
 			PostId:   &postId,
 			UserId:   &otherUserId,
-		}, nil)
+		})
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
@@ -285,32 +936,73 @@ func TestUpdateThreadPost(t *testing.T) {
 	t.Run("Could not update post", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
 		threadId, postId, userId := "1", "1", "1"
-		post := model.Post{PostId: &postId, UserId: &userId}
 
 		var expectedPost *model.Post
 		expectedStatusCode := http.StatusInternalServerError
 
-		posts := []*model.Post{&post}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
 		mockThreadRepository.MockError = errors.New("test error")
 
 		actualPost, actualStatusCode := threadService.UpdateThreadPost(model.Post{
 			ThreadId: &threadId,
 			PostId:   &postId,
 			UserId:   &userId,
-		}, nil)
+		})
 
 		if actualPost != expectedPost || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
 		}
 	})
 
-	t.Run("Successful post update", func(t *testing.T) {
-		_, _, mockThreadRepository, threadService := threadServiceMocks()
+	t.Run("Removes revision of failed update", func(t *testing.T) {
+		mockRevisionRepository := MockRevisionRepository{}
+		mockThreadRepository := MockThreadRepository{MockError: errors.New("test error")}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:   &mockThreadRepository,
+			RevisionRepository: &mockRevisionRepository,
+		}
+		threadId, postId, userId, content := "1", "1", "1", "content"
+
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
+
+		_, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+
+		if actualStatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected status code: %d. Got: %d", http.StatusInternalServerError, actualStatusCode)
+		}
+		if len(mockRevisionRepository.CreatedRevisions) != 1 || !reflect.DeepEqual(mockRevisionRepository.DeletedRevisions, []string{"1"}) {
+			t.Errorf("expected the recorded revision to be removed. Got: %v", mockRevisionRepository.DeletedRevisions)
+		}
+	})
+
+	t.Run("Could not record revision", func(t *testing.T) {
+		mockRevisionRepository := MockRevisionRepository{MockError: errors.New("test error")}
+		mockThreadRepository := MockThreadRepository{}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:   &mockThreadRepository,
+			RevisionRepository: &mockRevisionRepository,
+		}
 		threadId, postId, userId, content := "1", "1", "1", "content"
-		post := model.Post{PostId: &postId, UserId: &userId}
+
+		expectedStatusCode := http.StatusInternalServerError
+
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
+
+		_, actualStatusCode := threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Successful post update", func(t *testing.T) {
+		mockRevisionRepository := MockRevisionRepository{}
+		mockThreadRepository := MockThreadRepository{}
+		threadService := service.ThreadServiceImpl{
+			ThreadRepository:   &mockThreadRepository,
+			RevisionRepository: &mockRevisionRepository,
+		}
+		threadId, postId, userId, content, previousContent := "1", "1", "1", "content", "previous content"
 
 		expectedPost := model.Post{
 			ThreadId: &threadId,
@@ -320,48 +1012,92 @@ func TestUpdateThreadPost(t *testing.T) {
 		}
 		expectedStatusCode := http.StatusOK
 
-		posts := []*model.Post{&post}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &previousContent}
 
-		actualPost, actualStatusCode := threadService.UpdateThreadPost(expectedPost, nil)
+		actualPost, actualStatusCode := threadService.UpdateThreadPost(expectedPost)
 
-		if *actualPost != expectedPost || actualStatusCode != expectedStatusCode {
+		if !tests.DeepEqualsPost(actualPost, &expectedPost) || actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
 		}
+		if actualPost.Edited == nil || !*actualPost.Edited || actualPost.EditedAt == nil {
+			t.Errorf("expected post to be marked as edited. Got: %#v", actualPost)
+		}
+		if len(mockRevisionRepository.CreatedRevisions) != 1 || mockRevisionRepository.CreatedRevisions[0].Content != previousContent {
+			t.Errorf("expected revision with previous content. Got: %#v", mockRevisionRepository.CreatedRevisions)
+		}
 	})
 }
 
+func TestForgedHeadersStripped(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, userId := "1", "1", "1"
+	var forgedTests = []struct {
+		testName        string
+		content         string
+		expectedContent string
+	}{
+		{"Official answer mark", "> **Offisielt svar fra utgiver**\n\nDatasettet er slettet", "Datasettet er slettet"},
+		{"Issue summary", "> **Datakvalitet:** Feil lisens · **Alvorlighetsgrad:** Høy\n\nLisensen er feil", "Lisensen er feil"},
+		{"Stacked and indented marks", "  >**offisielt svar fra utgiver**\n> **Datakvalitet:** Annet\n\nTekst\n> **Offisielt svar fra utgiver**", "Tekst"},
+		{"Quotes without marks", "> Sitat\n\nTekst", "> Sitat\n\nTekst"},
+	}
+
+	for _, test := range forgedTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockThreadRepository := MockThreadRepository{}
+			threadService := service.ThreadServiceImpl{
+				ThreadRepository:   &mockThreadRepository,
+				RevisionRepository: &MockRevisionRepository{},
+			}
+			content := test.content
+
+			threadService.CreateThreadPost(model.Post{ThreadId: &threadId, UserId: &userId, Content: &content}, "entity")
+			if len(mockThreadRepository.CreatedPosts) != 1 || *mockThreadRepository.CreatedPosts[0].Content != test.expectedContent {
+				t.Errorf("expected created content: %q. Got %#v", test.expectedContent, mockThreadRepository.CreatedPosts)
+			}
+
+			mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
+			threadService.UpdateThreadPost(model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Content: &content})
+			if len(mockThreadRepository.UpdatedPosts) != 1 || *mockThreadRepository.UpdatedPosts[0].Content != test.expectedContent {
+				t.Errorf("expected updated content: %q. Got %#v", test.expectedContent, mockThreadRepository.UpdatedPosts)
+			}
+		})
+	}
+}
+
 func TestDeleteThreadPost(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
-	t.Run("Could not get thread", func(t *testing.T) {
+	t.Run("Could not get post", func(t *testing.T) {
 		_, _, _, threadService := threadServiceMocks()
-		threadID := "1"
+		threadID, postId := "1", "1"
 
 		expectedStatusCode := http.StatusNotFound
 
 		actualStatusCode := threadService.DeleteThreadPost(model.Post{
 			ThreadId: &threadID,
-		}, nil)
+			PostId:   &postId,
+		})
 
 		if actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
 		}
 	})
 
-	t.Run("Thread does not contain post", func(t *testing.T) {
+	t.Run("Post already deleted", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
-		threadID := "1"
+		threadId, postId, userId, deleted := "1", "1", "1", true
 
 		expectedStatusCode := http.StatusNotFound
 
-		mockThreadRepository.MockGetThread = &model.Thread{}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId, Deleted: &deleted}
 
 		actualStatusCode := threadService.DeleteThreadPost(model.Post{
-			ThreadId: &threadID,
-		}, nil)
+			ThreadId: &threadId,
+			PostId:   &postId,
+			UserId:   &userId,
+		})
 
 		if actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
@@ -371,20 +1107,16 @@ func TestDeleteThreadPost(t *testing.T) {
 	t.Run("Post to update attributed to different user", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
 		threadId, postId, userId, otherUserId := "1", "1", "1", "2"
-		post := model.Post{PostId: &postId, UserId: &userId}
 
 		expectedStatusCode := http.StatusUnauthorized
 
-		posts := []*model.Post{&post}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
 
 		actualStatusCode := threadService.DeleteThreadPost(model.Post{
 			ThreadId: &threadId,
 			PostId:   &postId,
 			UserId:   &otherUserId,
-		}, nil)
+		})
 
 		if actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
@@ -394,21 +1126,17 @@ func TestDeleteThreadPost(t *testing.T) {
 	t.Run("Could not delete post", func(t *testing.T) {
 		_, _, mockThreadRepository, threadService := threadServiceMocks()
 		threadId, postId, userId := "1", "1", "1"
-		post := model.Post{PostId: &postId, UserId: &userId}
 
 		expectedStatusCode := http.StatusInternalServerError
 
-		posts := []*model.Post{&post}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
 		mockThreadRepository.MockError = errors.New("test error")
 
 		actualStatusCode := threadService.DeleteThreadPost(model.Post{
 			ThreadId: &threadId,
 			PostId:   &postId,
 			UserId:   &userId,
-		}, nil)
+		})
 
 		if actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
@@ -427,15 +1155,354 @@ func TestDeleteThreadPost(t *testing.T) {
 		}
 		expectedStatusCode := http.StatusOK
 
-		posts := []*model.Post{&expectedPost}
-		mockThreadRepository.MockGetThread = &model.Thread{
-			Posts: posts,
-		}
+		mockThreadRepository.MockGetPost = &expectedPost
 
-		actualStatusCode := threadService.DeleteThreadPost(expectedPost, nil)
+		actualStatusCode := threadService.DeleteThreadPost(expectedPost)
 
 		if actualStatusCode != expectedStatusCode {
 			t.Fatalf("expected post response and status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
 		}
 	})
 }
+
+func TestGetThreadPostByEntityId(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("No thread for entity", func(t *testing.T) {
+		_, _, _, threadService := threadServiceMocks()
+
+		actualPost, actualStatusCode := threadService.GetThreadPostByEntityId("entity", "1", nil)
+
+		if actualPost != nil || actualStatusCode != http.StatusNotFound {
+			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", nil, http.StatusNotFound, actualPost, actualStatusCode)
+		}
+	})
+
+	t.Run("Successfully gets post", func(t *testing.T) {
+		_, mockThreadIdService, mockThreadRepository, threadService := threadServiceMocks()
+		threadId, postId := "1", "2"
+		expectedPost := model.Post{ThreadId: &threadId, PostId: &postId}
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadRepository.MockGetPost = &expectedPost
+
+		actualPost, actualStatusCode := threadService.GetThreadPostByEntityId("entity", postId, nil)
+
+		if actualPost != &expectedPost || actualStatusCode != http.StatusOK {
+			t.Fatalf("expected post response and status code: %#v, %d. Got: %#v, %d", expectedPost, http.StatusOK, actualPost, actualStatusCode)
+		}
+	})
+
+	t.Run("Reads post as viewer", func(t *testing.T) {
+		_, mockThreadIdService, mockThreadRepository, threadService := threadServiceMocks()
+		threadId, postId, userId := "1", "2", "3"
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId}
+
+		threadService.GetThreadPostByEntityId("entity", postId, &userId)
+
+		if mockThreadRepository.PostViewerUid == nil || *mockThreadRepository.PostViewerUid != userId {
+			t.Fatalf("expected post read by viewer %s. Got %v", userId, mockThreadRepository.PostViewerUid)
+		}
+	})
+}
+
+func TestGetThreadByEntityIdPendingPosts(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, userId := "1", "3"
+	pendingPostRepository := MockPendingPostRepository{
+		MockPendingPosts: []model.PendingPost{{PendingId: "pending", ThreadId: threadId, UserId: userId, Content: "content"}},
+	}
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository:      &MockThreadRepository{MockGetThread: &model.Thread{ThreadId: &threadId}},
+		ThreadIdService:       &MockThreadIdService{MockThreadId: &threadId},
+		PendingPostRepository: &pendingPostRepository,
+	}
+
+	t.Run("Anonymous reader", func(t *testing.T) {
+		thread, _ := threadService.GetThreadByEntityId("entity", model.ThreadQuery{})
+
+		if thread == nil || thread.PendingPosts != nil {
+			t.Fatalf("expected thread without pending posts. Got %#v", thread)
+		}
+	})
+
+	t.Run("Author of pending post", func(t *testing.T) {
+		thread, _ := threadService.GetThreadByEntityId("entity", model.ThreadQuery{ViewerUid: &userId})
+
+		if thread == nil || len(thread.PendingPosts) != 1 || *thread.PendingPosts[0].PendingId != "pending" {
+			t.Fatalf("expected own pending post in thread. Got %#v", thread)
+		}
+	})
+}
+
+func TestCountPostsByEntityIds(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	setUp := func() (*MockPostIndexRepository, service.ThreadService) {
+		mockPostIndexRepository := MockPostIndexRepository{MockPosts: []model.IndexedPost{
+			{PostId: "1", EntityId: "a"},
+			{PostId: "2", EntityId: "a"},
+			{PostId: "3", EntityId: "b"},
+		}}
+		return &mockPostIndexRepository, &service.ThreadServiceImpl{PostIndexRepository: &mockPostIndexRepository}
+	}
+
+	t.Run("Counts posts from the post index", func(t *testing.T) {
+		_, threadService := setUp()
+		expectedCounts := map[string]int{"a": 2, "b": 1, "c": 0}
+
+		actualCounts, actualStatusCode := threadService.CountPostsByEntityIds([]string{"a", "b", "c"})
+
+		if !reflect.DeepEqual(actualCounts, expectedCounts) || actualStatusCode != http.StatusOK {
+			t.Fatalf("expected counts and status code: %v, %d. Got: %v, %d", expectedCounts, http.StatusOK, actualCounts, actualStatusCode)
+		}
+	})
+
+	t.Run("Counts in batches and leaves out failed batches", func(t *testing.T) {
+		mockPostIndexRepository, threadService := setUp()
+		mockPostIndexRepository.MockFailedCount = "b"
+		entityIds := []string{"a"}
+		for i := 0; i < 40; i++ {
+			entityIds = append(entityIds, "entity"+strconv.Itoa(i))
+		}
+		entityIds = append(entityIds, "b")
+
+		actualCounts, actualStatusCode := threadService.CountPostsByEntityIds(entityIds)
+
+		if actualStatusCode != http.StatusOK || len(mockPostIndexRepository.CountedBatches) != 2 {
+			t.Fatalf("expected 2 batches. Got %d, %v", actualStatusCode, mockPostIndexRepository.CountedBatches)
+		}
+		if actualCounts["a"] != 2 || len(actualCounts) != 30 {
+			t.Errorf("expected counts of the first batch only. Got %v", actualCounts)
+		}
+		if _, ok := actualCounts["b"]; ok {
+			t.Errorf("expected no count of a failed batch. Got %v", actualCounts)
+		}
+	})
+
+	t.Run("Too many entities", func(t *testing.T) {
+		mockPostIndexRepository, threadService := setUp()
+		entityIds := make([]string, 101)
+		for i := range entityIds {
+			entityIds[i] = strconv.Itoa(i)
+		}
+
+		actualCounts, actualStatusCode := threadService.CountPostsByEntityIds(entityIds)
+
+		if actualCounts != nil || actualStatusCode != http.StatusBadRequest || len(mockPostIndexRepository.CountedBatches) != 0 {
+			t.Fatalf("expected status code: %d. Got: %v, %d", http.StatusBadRequest, actualCounts, actualStatusCode)
+		}
+	})
+}
+
+func TestVotePostForEntityId(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	t.Run("Entity without thread", func(t *testing.T) {
+		_, _, _, threadService := threadServiceMocks()
+		expectedStatusCode := http.StatusNotFound
+
+		_, actualStatusCode := threadService.VotePostForEntityId("entity", "1", "2", true)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Cannot vote on own post", func(t *testing.T) {
+		_, mockThreadIdService, mockThreadRepository, threadService := threadServiceMocks()
+		threadId, postId, userId := "1", "1", "2"
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &userId}
+		expectedStatusCode := http.StatusForbidden
+
+		_, actualStatusCode := threadService.VotePostForEntityId("entity", postId, userId, true)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Could not vote on post", func(t *testing.T) {
+		_, mockThreadIdService, mockThreadRepository, threadService := threadServiceMocks()
+		threadId, postId, authorId := "1", "1", "1"
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &authorId}
+		mockThreadRepository.MockError = errors.New("testerror")
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := threadService.VotePostForEntityId("entity", postId, "2", true)
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Successfully withdraws vote", func(t *testing.T) {
+		_, mockThreadIdService, mockThreadRepository, threadService := threadServiceMocks()
+		threadId, postId, authorId, votes, upvoted := "1", "1", "1", 3, false
+		mockThreadIdService.MockThreadId = &threadId
+		mockThreadRepository.MockGetPost = &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &authorId, Votes: &votes}
+		expectedPost := model.Post{ThreadId: &threadId, PostId: &postId, UserId: &authorId, Votes: &votes, Upvoted: &upvoted}
+		expectedStatusCode := http.StatusOK
+
+		actualPost, actualStatusCode := threadService.VotePostForEntityId("entity", postId, "2", false)
+
+		if actualStatusCode != expectedStatusCode || !reflect.DeepEqual(*actualPost, expectedPost) {
+			t.Fatalf("expected post and status code: %#v, %d. Got: %#v, %d", expectedPost, expectedStatusCode, actualPost, actualStatusCode)
+		}
+	})
+}
+
+func TestGetThreadPostRevisions(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	threadId, postId, authorId, otherUserId := "1", "1", "1", "2"
+	revisions := []model.PostRevision{{PostId: postId, ThreadId: threadId, Content: "first version", EditorUid: authorId, Timestamp: 1000}}
+
+	setUp := func() (*MockRevisionRepository, service.ThreadService) {
+		mockThreadIdService := MockThreadIdService{MockThreadId: &threadId}
+		mockThreadRepository := MockThreadRepository{MockGetPost: &model.Post{ThreadId: &threadId, PostId: &postId, UserId: &authorId}}
+		mockRevisionRepository := MockRevisionRepository{MockRevisions: revisions}
+		return &mockRevisionRepository, &service.ThreadServiceImpl{
+			ThreadRepository:   &mockThreadRepository,
+			ThreadIdService:    &mockThreadIdService,
+			RevisionRepository: &mockRevisionRepository,
+		}
+	}
+
+	t.Run("Other users cannot see revisions", func(t *testing.T) {
+		_, threadService := setUp()
+		expectedStatusCode := http.StatusForbidden
+
+		_, actualStatusCode := threadService.GetThreadPostRevisions("entity", postId, model.User{UserId: &otherUserId})
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Handles revision repository error", func(t *testing.T) {
+		mockRevisionRepository, threadService := setUp()
+		mockRevisionRepository.MockError = errors.New("test error")
+		expectedStatusCode := http.StatusInternalServerError
+
+		_, actualStatusCode := threadService.GetThreadPostRevisions("entity", postId, model.User{UserId: &authorId})
+
+		if actualStatusCode != expectedStatusCode {
+			t.Fatalf("expected status code: %d. Got: %d", expectedStatusCode, actualStatusCode)
+		}
+	})
+
+	t.Run("Author sees revisions", func(t *testing.T) {
+		_, threadService := setUp()
+
+		actualRevisions, actualStatusCode := threadService.GetThreadPostRevisions("entity", postId, model.User{UserId: &authorId})
+
+		if actualStatusCode != http.StatusOK || !reflect.DeepEqual(actualRevisions, revisions) {
+			t.Fatalf("expected revisions and status code: %#v, %d. Got: %#v, %d", revisions, http.StatusOK, actualRevisions, actualStatusCode)
+		}
+	})
+
+	t.Run("Moderator sees revisions", func(t *testing.T) {
+		_, threadService := setUp()
+
+		actualRevisions, actualStatusCode := threadService.GetThreadPostRevisions("entity", postId, model.User{UserId: &otherUserId, Moderator: true})
+
+		if actualStatusCode != http.StatusOK || !reflect.DeepEqual(actualRevisions, revisions) {
+			t.Fatalf("expected revisions and status code: %#v, %d. Got: %#v, %d", revisions, http.StatusOK, actualRevisions, actualStatusCode)
+		}
+	})
+}
+
+func TestGetThreadPagesNodeBBPages(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	// NodeBB pages the thread by the postsPerPage setting of the forum, three
+	// posts here, whatever postsPerPage the request asks for.
+	nodeBBPageSize, totalPosts := 3, 7
+	var requests []string
+	nodeBB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		pageCount := (totalPosts + nodeBBPageSize - 1) / nodeBBPageSize
+
+		var posts []string
+		for index := (page - 1) * nodeBBPageSize; index < min(page*nodeBBPageSize, totalPosts); index++ {
+			posts = append(posts, fmt.Sprintf(`{"pid": %d, "tid": 1, "index": %d, "timestamp": %d, "deleted": 0}`, index+1, index, (index+1)*100))
+		}
+		fmt.Fprintf(w, `{"tid": 1, "title": "Thread", "postcount": %d, "posts": [%s], "pagination": {"currentPage": %d, "pageCount": %d}}`,
+			totalPosts, strings.Join(posts, ","), page, pageCount)
+	}))
+	defer nodeBB.Close()
+
+	threadService := service.ThreadServiceImpl{
+		ThreadRepository: &repository.ThreadRepositoryImpl{
+			SecretProvider:  &MockSecretProvider{Secrets: map[string]string{secret.ReadApiToken: "token"}},
+			CommunityApiUrl: nodeBB.URL,
+			TopicPath:       "/topic/",
+		},
+	}
+
+	postIds := func(thread *model.Thread) []string {
+		var ids []string
+		for _, post := range thread.Posts {
+			ids = append(ids, *post.PostId)
+		}
+		return ids
+	}
+
+	t.Run("Cuts pages of the asked size out of NodeBB pages", func(t *testing.T) {
+		page, pageSize := "2", 2
+
+		thread, statusCode := threadService.GetThread("1", model.ThreadQuery{Sort: model.OldestFirst, Page: &page, PageSize: &pageSize})
+
+		if statusCode != http.StatusOK || !reflect.DeepEqual(postIds(thread), []string{"3", "4"}) {
+			t.Fatalf("expected posts 3 and 4. Got %v, %d", postIds(thread), statusCode)
+		}
+		if *thread.Pagination.CurrentPage != 2 || *thread.Pagination.PageCount != 4 || *thread.Pagination.PageSize != 2 {
+			t.Errorf("unexpected pagination %#v", *thread.Pagination)
+		}
+	})
+
+	t.Run("Reads the last page", func(t *testing.T) {
+		page, pageSize := "2", 5
+
+		thread, statusCode := threadService.GetThread("1", model.ThreadQuery{Sort: model.OldestFirst, Page: &page, PageSize: &pageSize})
+
+		if statusCode != http.StatusOK || !reflect.DeepEqual(postIds(thread), []string{"6", "7"}) || *thread.Pagination.PageCount != 2 {
+			t.Fatalf("expected posts 6 and 7 on the last of 2 pages. Got %v, %d", postIds(thread), statusCode)
+		}
+	})
+
+	t.Run("Follows a cursor by the NodeBB page size", func(t *testing.T) {
+		pageSize := 2
+		first, _ := threadService.GetThread("1", model.ThreadQuery{Sort: model.OldestFirst, PageSize: &pageSize})
+		cursor, err := model.ParseThreadCursor(*first.Pagination.NextCursor)
+		if err != nil {
+			t.Fatalf("expected a next cursor. Got %v", err)
+		}
+
+		var seen []string
+		thread := first
+		for thread.Pagination.NextCursor != nil && len(thread.Posts) > 0 {
+			seen = append(seen, postIds(thread)...)
+			cursor, _ = model.ParseThreadCursor(*thread.Pagination.NextCursor)
+			thread, _ = threadService.GetThread("1", model.ThreadQuery{Sort: model.OldestFirst, PageSize: &pageSize, Cursor: cursor})
+		}
+
+		if !reflect.DeepEqual(seen, []string{"1", "2", "3", "4", "5", "6", "7"}) {
+			t.Errorf("expected every post once. Got %v", seen)
+		}
+	})
+
+	for _, request := range requests {
+		if strings.Contains(request, "postsPerPage") {
+			t.Errorf("expected no postsPerPage in %s", request)
+		}
+	}
+}
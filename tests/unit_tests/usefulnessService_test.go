@@ -0,0 +1,138 @@
+package unit_tests
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/secret"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/service"
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/util"
+)
+
+func TestUsefulnessSummary(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	setUp := func(secrets map[string]string) (*MockUsefulnessRepository, service.UsefulnessService) {
+		mockUsefulnessRepository := MockUsefulnessRepository{MockHelpful: 3, MockNotHelpful: 1}
+		return &mockUsefulnessRepository, &service.UsefulnessServiceImpl{
+			UsefulnessRepository: &mockUsefulnessRepository,
+			EntityService:        &MockEntityService{MockEntity: &model.Entity{EntityId: "a", Type: model.Dataset}},
+			SecretProvider:       &MockSecretProvider{Secrets: secrets},
+			NonceTtl:             time.Hour,
+		}
+	}
+
+	t.Run("Counts votes of entity with a nonce", func(t *testing.T) {
+		mockUsefulnessRepository, usefulnessService := setUp(map[string]string{secret.UsefulnessNonceKey: "key"})
+
+		summary, statusCode := usefulnessService.GetEntitySummary("a")
+
+		if statusCode != http.StatusOK || summary.Helpful != 3 || summary.NotHelpful != 1 || summary.EntityType != "dataset" {
+			t.Fatalf("expected counted votes of dataset. Got %#v, %d", summary, statusCode)
+		}
+		if fields, ok := util.VerifyToken([]byte("key"), summary.Nonce); !ok || fields[1] != "a" {
+			t.Errorf("expected nonce for entity a. Got %v", fields)
+		}
+		if mockUsefulnessRepository.CountedBy[0] != "entityId=a" {
+			t.Errorf("expected votes counted by entity. Got %v", mockUsefulnessRepository.CountedBy)
+		}
+	})
+
+	t.Run("No nonce key", func(t *testing.T) {
+		_, usefulnessService := setUp(nil)
+
+		_, statusCode := usefulnessService.GetEntitySummary("a")
+
+		if statusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected %d. Got %d", http.StatusServiceUnavailable, statusCode)
+		}
+	})
+
+	t.Run("Counts votes of entity type", func(t *testing.T) {
+		mockUsefulnessRepository, usefulnessService := setUp(nil)
+
+		summary, statusCode := usefulnessService.GetTypeSummary(model.Concept)
+
+		if statusCode != http.StatusOK || summary.EntityType != "concept" || summary.Nonce != "" || mockUsefulnessRepository.CountedBy[0] != "entityType=concept" {
+			t.Fatalf("expected counted votes of concepts. Got %#v, %d", summary, statusCode)
+		}
+	})
+
+	t.Run("Unknown entity type", func(t *testing.T) {
+		_, usefulnessService := setUp(nil)
+
+		_, statusCode := usefulnessService.GetTypeSummary(model.Undefined)
+
+		if statusCode != http.StatusBadRequest {
+			t.Fatalf("expected %d. Got %d", http.StatusBadRequest, statusCode)
+		}
+	})
+}
+
+func TestUsefulnessVote(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	key := []byte("key")
+	helpful := true
+	nonce := func(entityId string, issuedAt time.Time) string {
+		return util.SignToken(key, "usefulness", entityId, "nonce-id", strconv.FormatInt(issuedAt.UnixMilli(), 10))
+	}
+
+	var voteTests = []struct {
+		testName           string
+		request            model.UsefulnessVoteRequest
+		ipVotes            []model.UsefulnessVote
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{"Vote", model.UsefulnessVoteRequest{Helpful: &helpful, Reason: " Fant det jeg lette etter ", Nonce: nonce("a", time.Now())}, nil, nil, http.StatusCreated},
+		{"Missing answer", model.UsefulnessVoteRequest{Nonce: nonce("a", time.Now())}, nil, nil, http.StatusBadRequest},
+		{"Reason too long", model.UsefulnessVoteRequest{Helpful: &helpful, Reason: "Fant ikke det jeg lette etter", Nonce: nonce("a", time.Now())}, nil, nil, http.StatusBadRequest},
+		{"Missing nonce", model.UsefulnessVoteRequest{Helpful: &helpful}, nil, nil, http.StatusBadRequest},
+		{"Nonce of other entity", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: nonce("b", time.Now())}, nil, nil, http.StatusBadRequest},
+		{"Expired nonce", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: nonce("a", time.Now().Add(-2*time.Hour))}, nil, nil, http.StatusBadRequest},
+		{"Forged nonce", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: util.SignToken([]byte("other"), "usefulness", "a", "nonce-id", strconv.FormatInt(time.Now().UnixMilli(), 10))}, nil, nil, http.StatusBadRequest},
+		{"Used nonce", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: nonce("a", time.Now())}, nil, model.ErrUsedNonce, http.StatusConflict},
+		{"IP voted on entity", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: nonce("a", time.Now())}, []model.UsefulnessVote{{EntityId: "a"}}, nil, http.StatusTooManyRequests},
+		{"IP out of votes", model.UsefulnessVoteRequest{Helpful: &helpful, Nonce: nonce("a", time.Now())}, []model.UsefulnessVote{{EntityId: "b"}, {EntityId: "c"}}, nil, http.StatusTooManyRequests},
+	}
+
+	for _, test := range voteTests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUsefulnessRepository := MockUsefulnessRepository{MockIpVotes: test.ipVotes, MockError: test.repositoryError, MockHelpful: 1}
+			usefulnessService := service.UsefulnessServiceImpl{
+				UsefulnessRepository: &mockUsefulnessRepository,
+				EntityService:        &MockEntityService{MockEntity: &model.Entity{EntityId: "a", Type: model.Dataset}},
+				SecretProvider:       &MockSecretProvider{Secrets: map[string]string{secret.UsefulnessNonceKey: string(key)}},
+				NonceTtl:             time.Hour,
+				IpLimit:              2,
+				IpWindow:             time.Hour,
+				MaxReasonLength:      24,
+			}
+
+			summary, statusCode := usefulnessService.Vote("a", "192.0.2.1", test.request)
+
+			if statusCode != test.expectedStatusCode {
+				t.Fatalf("expected %d. Got %d", test.expectedStatusCode, statusCode)
+			}
+			if statusCode != http.StatusCreated {
+				return
+			}
+			vote := mockUsefulnessRepository.CreatedVotes[0]
+			if vote.VoteId != "nonce-id" || vote.EntityType != "dataset" || !vote.Helpful || vote.Reason != "Fant det jeg lette etter" {
+				t.Errorf("unexpected vote %#v", vote)
+			}
+			if vote.IpWindow == "" || vote.IpWindow[:9] == "192.0.2.1" {
+				t.Errorf("expected pseudonymous IP window. Got %s", vote.IpWindow)
+			}
+			if summary.Helpful != 1 || summary.EntityId != "a" {
+				t.Errorf("unexpected summary %#v", summary)
+			}
+		})
+	}
+}
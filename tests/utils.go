@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"reflect"
+
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 )
 
@@ -30,7 +32,7 @@ func DeepEqualsPost(a *model.Post, b *model.Post) bool {
 	if a.Deleted != b.Deleted && a.Deleted != nil && *a.Deleted != *b.Deleted {
 		return false
 	}
-	if a.UserInfo != b.UserInfo && a.UserInfo != nil && *a.UserInfo != *b.UserInfo {
+	if a.UserInfo != b.UserInfo && a.UserInfo != nil && !reflect.DeepEqual(*a.UserInfo, *b.UserInfo) {
 		return false
 	}
 
@@ -0,0 +1,212 @@
+package util
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+var userExportCsvHeader = []string{"entityId", "tid", "pid", "toPid", "timestamp", "editedTimestamp", "content"}
+
+var feedbackExportHeader = []string{"entityId", "entityTitle", "entityType", "entityLink", "tid", "pid", "toPid", "author", "timestamp", "content", "status"}
+
+const XlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxParts are the parts of a workbook with a single sheet, other than the
+// sheet itself.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Feedback" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+const xlsxSheetName = "xl/worksheets/sheet1.xml"
+const xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+const xlsxSheetEnd = `</sheetData></worksheet>`
+
+// FeedbackExportWriter writes the rows of a feedback export as they are
+// read. Flush hands the rows written so far to the underlying writer, and
+// Close ends the export.
+type FeedbackExportWriter interface {
+	Write(row model.FeedbackExportRow) error
+	Flush() error
+	Close() error
+}
+
+// WriteUserExportCsv writes a row for each post of the export, with times in
+// RFC 3339 and UTC.
+func WriteUserExportCsv(w io.Writer, export model.UserExport) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(userExportCsvHeader); err != nil {
+		return err
+	}
+
+	for _, post := range export.Posts {
+		err := csvWriter.Write([]string{
+			stringOrEmpty(post.EntityId),
+			stringOrEmpty(post.ThreadId),
+			stringOrEmpty(post.PostId),
+			stringOrEmpty(post.ToPostId),
+			formatMillis(post.Timestamp),
+			formatMillis(post.EditedAt),
+			stringOrEmpty(post.Content),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+type feedbackCsvWriter struct {
+	csvWriter *csv.Writer
+}
+
+// NewFeedbackCsvWriter starts a CSV feedback export. Values that spreadsheet
+// applications would read as formulas are prefixed with an apostrophe.
+func NewFeedbackCsvWriter(w io.Writer) (FeedbackExportWriter, error) {
+	writer := &feedbackCsvWriter{csvWriter: csv.NewWriter(w)}
+	if err := writer.csvWriter.Write(feedbackExportHeader); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *feedbackCsvWriter) Write(row model.FeedbackExportRow) error {
+	values := feedbackExportValues(row)
+	for i := range values {
+		if values[i] != "" && strings.ContainsRune("=+-@\t\r", rune(values[i][0])) {
+			values[i] = "'" + values[i]
+		}
+	}
+	return writer.csvWriter.Write(values)
+}
+
+func (writer *feedbackCsvWriter) Flush() error {
+	writer.csvWriter.Flush()
+	return writer.csvWriter.Error()
+}
+
+func (writer *feedbackCsvWriter) Close() error {
+	return writer.Flush()
+}
+
+type feedbackXlsxWriter struct {
+	zipWriter *zip.Writer
+	sheet     io.Writer
+	rows      int
+}
+
+// NewFeedbackXlsxWriter starts an XLSX feedback export, a workbook with a
+// single sheet of inline strings.
+func NewFeedbackXlsxWriter(w io.Writer) (FeedbackExportWriter, error) {
+	zipWriter := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		partWriter, err := zipWriter.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zipWriter.Create(xlsxSheetName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	writer := &feedbackXlsxWriter{zipWriter: zipWriter, sheet: sheet}
+	if err := writer.writeRow(feedbackExportHeader); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *feedbackXlsxWriter) Write(row model.FeedbackExportRow) error {
+	return writer.writeRow(feedbackExportValues(row))
+}
+
+func (writer *feedbackXlsxWriter) writeRow(values []string) error {
+	writer.rows++
+	if _, err := fmt.Fprintf(writer.sheet, `<row r="%d">`, writer.rows); err != nil {
+		return err
+	}
+	for i, value := range values {
+		if _, err := fmt.Fprintf(writer.sheet, `<c r="%c%d" t="inlineStr"><is><t xml:space="preserve">`, 'A'+i, writer.rows); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(writer.sheet, []byte(value)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer.sheet, `</t></is></c>`); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(writer.sheet, `</row>`)
+	return err
+}
+
+func (writer *feedbackXlsxWriter) Flush() error {
+	return writer.zipWriter.Flush()
+}
+
+func (writer *feedbackXlsxWriter) Close() error {
+	if _, err := io.WriteString(writer.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return writer.zipWriter.Close()
+}
+
+func feedbackExportValues(row model.FeedbackExportRow) []string {
+	return []string{
+		row.EntityId,
+		row.EntityTitle,
+		row.EntityType,
+		row.EntityLink,
+		row.ThreadId,
+		row.PostId,
+		row.ToPostId,
+		row.Author,
+		formatMillis(row.Timestamp),
+		row.Content,
+		string(row.Status),
+	}
+}
+
+func formatMillis(millis *int) string {
+	if millis == nil {
+		return ""
+	}
+	return time.UnixMilli(int64(*millis)).UTC().Format(time.RFC3339)
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/env"
 	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
 )
 
@@ -21,6 +25,9 @@ type RequestOptions struct {
 	AccessToken     *string
 	RequestBody     *map[string]string
 	QueryParameters *map[string]string
+	// JsonBody is sent instead of RequestBody for bodies that are not flat
+	// string maps.
+	JsonBody interface{}
 }
 
 func Request(options RequestOptions) (*[]byte, error) {
@@ -29,7 +36,12 @@ func Request(options RequestOptions) (*[]byte, error) {
 		return nil, err
 	}
 
-	processedBody, err := ProcessRequestBody(options.RequestBody)
+	var processedBody *bytes.Buffer
+	if options.JsonBody != nil {
+		processedBody, err = processJsonBody(options.JsonBody)
+	} else {
+		processedBody, err = ProcessRequestBody(options.RequestBody)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +99,16 @@ func ProcessRequestBody(reqBody *map[string]string) (*bytes.Buffer, error) {
 	return bodyBuffer, err
 }
 
+func processJsonBody(body interface{}) (*bytes.Buffer, error) {
+	processedBody, err := json.Marshal(body)
+	if err != nil {
+		log.Println("Error on body marshal.\n[ERROR] -", err)
+		return nil, err
+	}
+
+	return bytes.NewBuffer(processedBody), nil
+}
+
 func buildRequest(method string, endpointUrl string, body *bytes.Buffer) (*http.Request, error) {
 	if body != nil {
 		return http.NewRequest(method, endpointUrl, body)
@@ -153,6 +175,16 @@ func ParseRequestUrlPath(requestPath string) (*string, *string, *string) {
 	return route, entityId, threadId
 }
 
+// ParseRequestUrlSubresource returns the path segment following the post id,
+// as in /thread/{entityId}/{postId}/revisions.
+func ParseRequestUrlSubresource(requestPath string) *string {
+	splitPath := strings.Split(requestPath, "/")
+	if len(splitPath) >= 5 && splitPath[4] != "" {
+		return &splitPath[4]
+	}
+	return nil
+}
+
 func GetPageQueryParam(queryParams url.Values) *string {
 	var page = queryParams.Get("page")
 
@@ -164,15 +196,149 @@ func GetPageQueryParam(queryParams url.Values) *string {
 	return &page
 }
 
-func GetPostIndexQueryParam(queryParams url.Values) *string {
-	var postIndex = queryParams.Get("postIndex")
+// GetThreadQueryParams reads the page, sort, pageSize, cursor, category and
+// status query parameters. Unlike page, which falls back to the first page,
+// an unknown sort, category or status, an out-of-range pageSize or a
+// malformed cursor is reported as an error. A cursor takes precedence over
+// page and carries its own sort, and cannot be combined with issue filters.
+func GetThreadQueryParams(queryParams url.Values) (*model.ThreadQuery, error) {
+	sort, ok := model.ParseThreadSort(queryParams.Get("sort"))
+	if !ok {
+		return nil, model.ErrInvalidSort
+	}
+
+	query := model.ThreadQuery{
+		Page: GetPageQueryParam(queryParams),
+		Sort: sort,
+	}
 
-	indexInt, err := strconv.Atoi(postIndex)
-	if err != nil || indexInt < 1 {
-		return nil
+	if token := queryParams.Get("cursor"); token != "" {
+		cursor, err := model.ParseThreadCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		if queryParams.Get("sort") != "" && cursor.Sort != sort {
+			return nil, model.ErrInvalidCursor
+		}
+		query.Page = nil
+		query.Sort = cursor.Sort
+		query.Cursor = cursor
+	}
+
+	if pageSize := queryParams.Get("pageSize"); pageSize != "" {
+		parsedPageSize, err := strconv.Atoi(pageSize)
+		if err != nil || parsedPageSize < 1 || parsedPageSize > env.ConstantValues.MaxPageSize {
+			return nil, model.ErrInvalidPageSize
+		}
+		query.PageSize = &parsedPageSize
+	}
+
+	if category := queryParams.Get("category"); category != "" {
+		parsedCategory, ok := model.ParseIssueCategory(category)
+		if !ok {
+			return nil, model.ErrInvalidCategory
+		}
+		query.Category = &parsedCategory
+	}
+
+	if status := queryParams.Get("status"); status != "" {
+		parsedStatus, ok := model.ParseIssueStatus(status)
+		if !ok {
+			return nil, model.ErrInvalidIssueStatus
+		}
+		query.IssueStatus = &parsedStatus
+	}
+
+	if query.Cursor != nil && query.FiltersIssues() {
+		return nil, model.ErrInvalidCursor
+	}
+
+	return &query, nil
+}
+
+// GetEntityIdsQueryParam reads the entity ids of a batch lookup, given as
+// repeated or comma-separated entityId query parameters. Duplicates are left
+// out. No ids, or more than MaxPageSize ids, is reported as an error.
+func GetEntityIdsQueryParam(queryParams url.Values) ([]string, error) {
+	var entityIds []string
+	seen := map[string]bool{}
+	for _, value := range queryParams["entityId"] {
+		for _, entityId := range strings.Split(value, ",") {
+			if entityId = strings.TrimSpace(entityId); entityId != "" && !seen[entityId] {
+				seen[entityId] = true
+				entityIds = append(entityIds, entityId)
+			}
+		}
+	}
+
+	if len(entityIds) == 0 || len(entityIds) > env.ConstantValues.MaxPageSize {
+		return nil, model.ErrInvalidEntityIds
+	}
+
+	return entityIds, nil
+}
+
+// PaginationLinks builds an RFC 8288 Link header value with first, prev, next
+// and last relations for the page described by pagination, keeping the other
+// query parameters of requestUrl. Pages read from a cursor get prev and next
+// cursor links instead.
+func PaginationLinks(requestUrl *url.URL, pagination *model.Pagination) string {
+	if requestUrl == nil || pagination == nil {
+		return ""
+	}
+
+	if requestUrl.Query().Get("cursor") != "" {
+		return cursorLinks(requestUrl, pagination)
+	}
+
+	if pagination.CurrentPage == nil || pagination.PageCount == nil {
+		return ""
+	}
+
+	currentPage, pageCount := *pagination.CurrentPage, *pagination.PageCount
+	if pageCount < 1 {
+		return ""
 	}
 
-	return &postIndex
+	pageLink := func(page int, rel string) string {
+		linkUrl := *requestUrl
+		query := linkUrl.Query()
+		query.Set("page", strconv.Itoa(page))
+		linkUrl.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=\"%s\"", linkUrl.RequestURI(), rel)
+	}
+
+	links := []string{pageLink(1, "first")}
+	if currentPage > 1 {
+		links = append(links, pageLink(currentPage-1, "prev"))
+	}
+	if currentPage < pageCount {
+		links = append(links, pageLink(currentPage+1, "next"))
+	}
+	links = append(links, pageLink(pageCount, "last"))
+
+	return strings.Join(links, ", ")
+}
+
+func cursorLinks(requestUrl *url.URL, pagination *model.Pagination) string {
+	cursorLink := func(cursor string, rel string) string {
+		linkUrl := *requestUrl
+		query := linkUrl.Query()
+		query.Del("page")
+		query.Set("cursor", cursor)
+		linkUrl.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=\"%s\"", linkUrl.RequestURI(), rel)
+	}
+
+	var links []string
+	if pagination.PrevCursor != nil {
+		links = append(links, cursorLink(*pagination.PrevCursor, "prev"))
+	}
+	if pagination.NextCursor != nil {
+		links = append(links, cursorLink(*pagination.NextCursor, "next"))
+	}
+
+	return strings.Join(links, ", ")
 }
 
 func DecodePost(body io.ReadCloser) (*model.Post, error) {
@@ -216,13 +382,14 @@ func UnmarshalPostResponse(bytes *[]byte) (*model.Post, error) {
 	err := json.Unmarshal(*bytes, &response)
 	if err != nil {
 		log.Println("Error on Post unmarshal.\n[ERROR] -", err)
+		return nil, err
 	}
 
-	if *response.Status.Code != "ok" {
+	if response.Status == nil || response.Status.Code == nil || *response.Status.Code != "ok" {
 		return nil, model.ErrBadResponse
 	}
 
-	return response.Response.ToPost(), err
+	return response.Response.ToPost(), nil
 }
 
 func UnmarshalThreadResponse(bytes *[]byte) (*model.Thread, error) {
@@ -255,3 +422,78 @@ func UnmarshalUser(bytes *[]byte) (*model.User, error) {
 
 	return dbUser.ToUser(), err
 }
+
+func UnmarshalUserPosts(bytes *[]byte) (*model.UserPostsDTO, error) {
+	var userPosts model.UserPostsDTO
+	if bytes == nil {
+		return nil, model.ErrNoBytes
+	}
+	err := json.Unmarshal(*bytes, &userPosts)
+	if err != nil {
+		log.Println("Error on user posts unmarshal.\n[ERROR] -", err)
+		return nil, err
+	}
+
+	return &userPosts, nil
+}
+
+// ClientIp is the address of the client making the request. Behind the load
+// balancer in front of the function it is the last X-Forwarded-For entry, as
+// earlier entries are set by the client.
+func ClientIp(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		entries := strings.Split(forwardedFor, ",")
+		return strings.TrimSpace(entries[len(entries)-1])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// GetStatisticsQueryParams reads the statistics query, from the start of the
+// from date to the end of the to date, as YYYY-MM-DD in UTC. The query ends
+// now and starts a year before its end by default.
+func GetStatisticsQueryParams(queryParams url.Values, now time.Time) (*model.StatisticsQuery, error) {
+	period, ok := model.ParseStatisticsPeriod(queryParams.Get("period"))
+	if !ok {
+		return nil, model.ErrInvalidStatisticsQuery
+	}
+
+	query := model.StatisticsQuery{
+		Organization: strings.TrimSpace(queryParams.Get("organization")),
+		Period:       period,
+		From:         now.AddDate(-1, 0, 0),
+		To:           now,
+	}
+
+	if entityType := queryParams.Get("entityType"); entityType != "" {
+		if query.EntityType = model.ParseEntityType(&entityType).Key(); query.EntityType == "" {
+			return nil, model.ErrInvalidStatisticsQuery
+		}
+	}
+
+	if to := queryParams.Get("to"); to != "" {
+		date, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return nil, model.ErrInvalidStatisticsQuery
+		}
+		query.From = date.AddDate(-1, 0, 0)
+		query.To = date.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+	if from := queryParams.Get("from"); from != "" {
+		date, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return nil, model.ErrInvalidStatisticsQuery
+		}
+		query.From = date
+	}
+
+	if !query.From.Before(query.To) {
+		return nil, model.ErrInvalidStatisticsQuery
+	}
+
+	return &query, nil
+}
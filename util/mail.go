@@ -0,0 +1,66 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+var ErrInvalidMailAddress = errors.New("invalid mail address")
+
+// ParseMailAddress checks that the address is a single bare email address,
+// as given to SMTP.
+func ParseMailAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != strings.TrimSpace(address) {
+		return "", ErrInvalidMailAddress
+	}
+	return parsed.Address, nil
+}
+
+// FormatMail writes the message as a quoted-printable UTF-8 text email,
+// with CRLF line endings and marked as sent automatically. Messages with an
+// UnsubscribeUrl get one-click List-Unsubscribe headers.
+func FormatMail(from string, message model.MailMessage, date time.Time) ([]byte, error) {
+	var buffer bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", message.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+		{"Auto-Submitted", "auto-generated"},
+	}
+	if message.UnsubscribeUrl != "" {
+		headers = append(headers,
+			[2]string{"List-Unsubscribe", "<" + message.UnsubscribeUrl + ">"},
+			[2]string{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+		)
+	}
+	for _, header := range headers {
+		if strings.ContainsAny(header[1], "\r\n") {
+			return nil, errors.New("line break in mail header " + header[0])
+		}
+		buffer.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	buffer.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buffer)
+	text := strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := body.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
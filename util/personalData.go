@@ -0,0 +1,143 @@
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+const PersonalDataMask = "[fjernet]"
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+var elevenDigitPattern = regexp.MustCompile(`\b\d{4}[. ]?\d{2}[. ]?\d{5}\b|\b\d{6} \d{5}\b`)
+
+// phonePattern matches eight digit numbers grouped the Norwegian way, or
+// after the country code, as other eight digit numbers are often case
+// numbers, dates or amounts.
+var phonePattern = regexp.MustCompile(`(?:(?:\+|\b00)47 ?(?:[2-9]\d{7}|[2-9]\d{2} \d{2} \d{3}|[2-9]\d(?: \d{2}){3})|\b(?:[2-9]\d{2} \d{2} \d{3}|[2-9]\d(?: \d{2}){3}))\b`)
+
+// FindPersonalData finds e-mail addresses, fødselsnummer and D-numbers,
+// bank account numbers and Norwegian phone numbers in content. Eleven digit
+// numbers only count when their control digits are valid. Matches are
+// ordered by position and do not overlap.
+func FindPersonalData(content string) []model.PersonalDataMatch {
+	var matches []model.PersonalDataMatch
+	overlaps := func(start int, end int) bool {
+		for _, match := range matches {
+			if start < match.End && match.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, location := range emailPattern.FindAllStringIndex(content, -1) {
+		matches = append(matches, model.PersonalDataMatch{Kind: model.EmailAddress, Start: location[0], End: location[1]})
+	}
+
+	for _, location := range elevenDigitPattern.FindAllStringIndex(content, -1) {
+		if overlaps(location[0], location[1]) {
+			continue
+		}
+
+		digits := onlyDigits(content[location[0]:location[1]])
+		var kind model.PersonalDataKind
+		if isValidBirthNumber(digits) {
+			kind = model.BirthNumber
+			if digits[0] >= '4' {
+				kind = model.DNumber
+			}
+		} else if isValidAccountNumber(digits) {
+			kind = model.BankAccount
+		} else {
+			continue
+		}
+		matches = append(matches, model.PersonalDataMatch{Kind: kind, Start: location[0], End: location[1]})
+	}
+
+	for _, location := range phonePattern.FindAllStringIndex(content, -1) {
+		if !overlaps(location[0], location[1]) {
+			matches = append(matches, model.PersonalDataMatch{Kind: model.PhoneNumber, Start: location[0], End: location[1]})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+// MaskPersonalData replaces the matches found by FindPersonalData.
+func MaskPersonalData(content string, matches []model.PersonalDataMatch) string {
+	var masked strings.Builder
+	previous := 0
+	for _, match := range matches {
+		masked.WriteString(content[previous:match.Start])
+		masked.WriteString(PersonalDataMask)
+		previous = match.End
+	}
+	masked.WriteString(content[previous:])
+	return masked.String()
+}
+
+// isValidBirthNumber checks the date and both control digits of a
+// fødselsnummer. D-numbers add 4 to the first digit, H-numbers 4 and
+// synthetic test numbers 8 to the third.
+func isValidBirthNumber(digits string) bool {
+	if len(digits) != 11 {
+		return false
+	}
+
+	day := int(digits[0]-'0')*10 + int(digits[1]-'0')
+	month := int(digits[2]-'0')*10 + int(digits[3]-'0')
+	if day > 40 {
+		day -= 40
+	}
+	if month > 80 {
+		month -= 80
+	} else if month > 40 {
+		month -= 40
+	}
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return false
+	}
+
+	first := mod11ControlDigit(digits[:9], []int{3, 7, 6, 1, 8, 9, 4, 5, 2})
+	second := mod11ControlDigit(digits[:10], []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2})
+	return first == int(digits[9]-'0') && second == int(digits[10]-'0')
+}
+
+func isValidAccountNumber(digits string) bool {
+	if len(digits) != 11 {
+		return false
+	}
+
+	return mod11ControlDigit(digits[:10], []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}) == int(digits[10]-'0')
+}
+
+// mod11ControlDigit returns the control digit of digits, or -1 when there
+// is none and the number cannot be valid.
+func mod11ControlDigit(digits string, weights []int) int {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+
+	control := 11 - sum%11
+	if control == 11 {
+		return 0
+	}
+	if control == 10 {
+		return -1
+	}
+	return control
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
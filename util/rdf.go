@@ -0,0 +1,308 @@
+package util
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Informasjonsforvaltning/fdk-user-feedback-service/model"
+)
+
+var rdfMediaTypes = map[string]model.RdfFormat{
+	"text/turtle":          model.Turtle,
+	"application/x-turtle": model.Turtle,
+	"text/*":               model.Turtle,
+	"*/*":                  model.Turtle,
+	"application/ld+json":  model.JsonLd,
+	"application/json":     model.JsonLd,
+	"application/rdf+xml":  model.RdfXml,
+	"application/xml":      model.RdfXml,
+}
+
+// NegotiateRdfFormat picks the format asked for with ?format=, or else the
+// supported media type of the Accept header with the highest quality.
+// Turtle is used when neither is given.
+func NegotiateRdfFormat(format string, accept string) (model.RdfFormat, bool) {
+	if format != "" {
+		return model.ParseRdfFormat(format)
+	}
+	if strings.TrimSpace(accept) == "" {
+		return model.Turtle, true
+	}
+
+	var negotiated model.RdfFormat
+	bestQuality := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		rdfFormat, supported := rdfMediaTypes[strings.ToLower(strings.TrimSpace(params[0]))]
+		if !supported {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				quality, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+		if quality > bestQuality {
+			negotiated, bestQuality = rdfFormat, quality
+		}
+	}
+
+	return negotiated, negotiated != ""
+}
+
+// WriteRdf writes the triples in the format, with the namespaces of
+// model.RdfPrefixes.
+func WriteRdf(w io.Writer, format model.RdfFormat, triples []model.RdfTriple) error {
+	switch format {
+	case model.JsonLd:
+		return WriteJsonLd(w, triples)
+	case model.RdfXml:
+		return WriteRdfXml(w, triples)
+	default:
+		return WriteTurtle(w, triples)
+	}
+}
+
+func WriteTurtle(w io.Writer, triples []model.RdfTriple) error {
+	var builder strings.Builder
+	for _, prefix := range model.RdfPrefixes {
+		fmt.Fprintf(&builder, "@prefix %s: <%s> .\n", prefix.Prefix, prefix.Namespace)
+	}
+
+	for _, subject := range groupBySubject(triples) {
+		builder.WriteString("\n" + turtleTerm(subject.term))
+		for i, triple := range subject.triples {
+			predicate := "a"
+			if triple.Predicate != model.RdfType {
+				predicate = turtleIri(triple.Predicate)
+			}
+			separator := " ;"
+			if i == len(subject.triples)-1 {
+				separator = " ."
+			}
+			builder.WriteString("\n    " + predicate + " " + turtleTerm(triple.Object) + separator)
+		}
+		builder.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func WriteJsonLd(w io.Writer, triples []model.RdfTriple) error {
+	context := map[string]string{}
+	for _, prefix := range model.RdfPrefixes {
+		context[prefix.Prefix] = prefix.Namespace
+	}
+
+	graph := []map[string]any{}
+	for _, subject := range groupBySubject(triples) {
+		node := map[string]any{"@id": jsonLdId(subject.term)}
+		for _, triple := range subject.triples {
+			key, value := "@type", any(compact(triple.Object.Value))
+			if triple.Predicate != model.RdfType {
+				key, value = compact(triple.Predicate), jsonLdValue(triple.Object)
+			}
+			switch existing := node[key].(type) {
+			case nil:
+				node[key] = value
+			case []any:
+				node[key] = append(existing, value)
+			default:
+				node[key] = []any{existing, value}
+			}
+		}
+		graph = append(graph, node)
+	}
+
+	return json.NewEncoder(w).Encode(map[string]any{
+		"@context": context,
+		"@graph":   graph,
+	})
+}
+
+func WriteRdfXml(w io.Writer, triples []model.RdfTriple) error {
+	var builder strings.Builder
+	builder.WriteString(xml.Header + "<rdf:RDF")
+	for _, prefix := range model.RdfPrefixes {
+		fmt.Fprintf(&builder, "\n    xmlns:%s=\"%s\"", prefix.Prefix, xmlEscape(prefix.Namespace))
+	}
+	builder.WriteString(">\n")
+
+	for _, subject := range groupBySubject(triples) {
+		if subject.term.Kind == model.BlankTerm {
+			fmt.Fprintf(&builder, "  <rdf:Description rdf:nodeID=\"%s\">\n", xmlEscape(subject.term.Value))
+		} else {
+			fmt.Fprintf(&builder, "  <rdf:Description rdf:about=\"%s\">\n", xmlEscape(subject.term.Value))
+		}
+
+		for _, triple := range subject.triples {
+			element, ok := model.CompactIri(triple.Predicate)
+			if !ok {
+				return fmt.Errorf("predicate %s has no namespace prefix", triple.Predicate)
+			}
+
+			object := triple.Object
+			switch {
+			case object.Kind == model.IriTerm:
+				fmt.Fprintf(&builder, "    <%s rdf:resource=\"%s\"/>\n", element, xmlEscape(object.Value))
+			case object.Kind == model.BlankTerm:
+				fmt.Fprintf(&builder, "    <%s rdf:nodeID=\"%s\"/>\n", element, xmlEscape(object.Value))
+			case object.Datatype != "":
+				fmt.Fprintf(&builder, "    <%s rdf:datatype=\"%s\">%s</%s>\n", element, xmlEscape(object.Datatype), xmlEscape(object.Value), element)
+			default:
+				fmt.Fprintf(&builder, "    <%s>%s</%s>\n", element, xmlEscape(object.Value), element)
+			}
+		}
+
+		builder.WriteString("  </rdf:Description>\n")
+	}
+	builder.WriteString("</rdf:RDF>\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+type rdfSubject struct {
+	term    model.RdfTerm
+	triples []model.RdfTriple
+}
+
+// groupBySubject groups the triples by subject, in the order the subjects
+// first appear.
+func groupBySubject(triples []model.RdfTriple) []*rdfSubject {
+	var subjects []*rdfSubject
+	bySubject := map[model.RdfTerm]*rdfSubject{}
+	for _, triple := range triples {
+		subject, found := bySubject[triple.Subject]
+		if !found {
+			subject = &rdfSubject{term: triple.Subject}
+			bySubject[triple.Subject] = subject
+			subjects = append(subjects, subject)
+		}
+		subject.triples = append(subject.triples, triple)
+	}
+	return subjects
+}
+
+func turtleTerm(term model.RdfTerm) string {
+	switch term.Kind {
+	case model.BlankTerm:
+		return "_:" + term.Value
+	case model.LiteralTerm:
+		literal := turtleString(term.Value)
+		if term.Datatype != "" {
+			literal += "^^" + turtleIri(term.Datatype)
+		}
+		return literal
+	default:
+		return turtleIri(term.Value)
+	}
+}
+
+func turtleIri(iri string) string {
+	if compacted, ok := model.CompactIri(iri); ok {
+		return compacted
+	}
+
+	var builder strings.Builder
+	builder.WriteString("<")
+	for _, r := range iri {
+		if r <= ' ' || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&builder, "\\u%04X", r)
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteString(">")
+	return builder.String()
+}
+
+func turtleString(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+func jsonLdId(term model.RdfTerm) string {
+	if term.Kind == model.BlankTerm {
+		return "_:" + term.Value
+	}
+	return term.Value
+}
+
+func jsonLdValue(term model.RdfTerm) any {
+	switch {
+	case term.Kind != model.LiteralTerm:
+		return map[string]string{"@id": jsonLdId(term)}
+	case term.Datatype != "":
+		return map[string]string{"@value": term.Value, "@type": compact(term.Datatype)}
+	default:
+		return term.Value
+	}
+}
+
+func compact(iri string) string {
+	compacted, _ := model.CompactIri(iri)
+	return compacted
+}
+
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
+
+// SparqlDeleteAnnotation is a SPARQL Update removing the annotation and its
+// bodies from the graph.
+func SparqlDeleteAnnotation(graph string, annotation string) string {
+	var builder strings.Builder
+	writeSparqlPrefixes(&builder)
+	writeSparqlDelete(&builder, graph, annotation)
+	return builder.String()
+}
+
+// SparqlReplaceAnnotation is a SPARQL Update replacing whatever the graph
+// holds about the annotation and its bodies with the triples.
+func SparqlReplaceAnnotation(graph string, annotation string, triples []model.RdfTriple) string {
+	var builder strings.Builder
+	writeSparqlPrefixes(&builder)
+	writeSparqlDelete(&builder, graph, annotation)
+	builder.WriteString(" ;\nINSERT DATA {\n  GRAPH " + turtleIri(graph) + " {")
+	for _, triple := range triples {
+		builder.WriteString("\n    " + turtleTerm(triple.Subject) + " " + turtleIri(triple.Predicate) + " " + turtleTerm(triple.Object) + " .")
+	}
+	builder.WriteString("\n  }\n}")
+	return builder.String()
+}
+
+func writeSparqlPrefixes(builder *strings.Builder) {
+	for _, prefix := range model.RdfPrefixes {
+		fmt.Fprintf(builder, "PREFIX %s: <%s>\n", prefix.Prefix, prefix.Namespace)
+	}
+}
+
+// writeSparqlDelete deletes the bodies before the annotation, as a DELETE
+// WHERE only matches annotations that have bodies.
+func writeSparqlDelete(builder *strings.Builder, graph string, annotation string) {
+	graphIri, annotationIri := turtleIri(graph), turtleIri(annotation)
+	fmt.Fprintf(builder, "DELETE WHERE { GRAPH %s { %s oa:hasBody ?body . ?body ?bodyPredicate ?bodyObject } } ;\n", graphIri, annotationIri)
+	fmt.Fprintf(builder, "DELETE WHERE { GRAPH %s { %s ?predicate ?object } }", graphIri, annotationIri)
+}
+
+// SparqlReplaceCreator is a SPARQL Update giving the annotation a new
+// creator.
+func SparqlReplaceCreator(graph string, annotation string, creator string) string {
+	var builder strings.Builder
+	writeSparqlPrefixes(&builder)
+	graphIri, annotationIri := turtleIri(graph), turtleIri(annotation)
+	fmt.Fprintf(&builder, "DELETE { GRAPH %s { %s dct:creator ?creator } }\n", graphIri, annotationIri)
+	fmt.Fprintf(&builder, "INSERT { GRAPH %s { %s dct:creator %s } }\n", graphIri, annotationIri, turtleIri(creator))
+	fmt.Fprintf(&builder, "WHERE { GRAPH %s { %s dct:creator ?creator } }", graphIri, annotationIri)
+	return builder.String()
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+const tokenFieldSeparator = "\n"
+
+// SignToken joins the fields into a token signed with HMAC-SHA256, to be
+// handed out and checked with VerifyToken when it comes back. Fields must not
+// contain line breaks.
+func SignToken(key []byte, fields ...string) string {
+	payload := strings.Join(fields, tokenFieldSeparator)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(key, payload))
+}
+
+// VerifyToken returns the fields of a token signed with key by SignToken.
+func VerifyToken(key []byte, token string) ([]string, bool) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found || len(key) == 0 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, sign(key, string(payload))) {
+		return nil, false
+	}
+
+	return strings.Split(string(payload), tokenFieldSeparator), true
+}
+
+// Pseudonym is a keyed hash of value, for values such as IP addresses that
+// are compared but should not be stored.
+func Pseudonym(key []byte, value string) string {
+	return base64.RawURLEncoding.EncodeToString(sign(key, value))
+}
+
+func sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
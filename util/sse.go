@@ -0,0 +1,29 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func WriteServerSentEvent(w io.Writer, eventId string, event string, data []byte) error {
+	var builder strings.Builder
+	if eventId != "" {
+		fmt.Fprintf(&builder, "id: %s\n", eventId)
+	}
+	if event != "" {
+		fmt.Fprintf(&builder, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(&builder, "data: %s\n", line)
+	}
+	builder.WriteString("\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func WriteServerSentComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}